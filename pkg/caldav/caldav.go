@@ -55,6 +55,7 @@ type Todo struct {
 	Organizer    *user.User
 	Priority     int64 // 0-9, 1 is highest
 	RelatedToUID string
+	RepeatRule   string // The value of the RRULE property, without the "RRULE:" prefix
 
 	Start    time.Time
 	End      time.Time
@@ -189,6 +190,11 @@ DURATION:PT` + fmt.Sprintf("%.6f", t.Duration.Hours()) + `H` + fmt.Sprintf("%.6f
 PRIORITY:` + strconv.Itoa(int(t.Priority))
 		}
 
+		if t.RepeatRule != "" {
+			caldavtodos += `
+RRULE:` + t.RepeatRule
+		}
+
 		caldavtodos += `
 LAST-MODIFIED:` + makeCalDavTimeFromTimeStamp(t.Updated)
 
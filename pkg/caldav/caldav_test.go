@@ -249,3 +249,35 @@ END:VCALENDAR`,
 		})
 	}
 }
+
+func TestParseTodos(t *testing.T) {
+	t.Run("repeat rule", func(t *testing.T) {
+		caldavConfig := &Config{
+			Name:   "test",
+			ProdID: "RandomProdID which is not random",
+		}
+		todos := []*Todo{
+			{
+				Summary:    "Todo #1",
+				UID:        "randommduid",
+				Timestamp:  time.Unix(1543626724, 0).In(config.GetTimeZone()),
+				Updated:    time.Unix(1543626724, 0).In(config.GetTimeZone()),
+				RepeatRule: "FREQ=MONTHLY;BYDAY=-1FR",
+			},
+		}
+		assert.Equal(t, `BEGIN:VCALENDAR
+VERSION:2.0
+METHOD:PUBLISH
+X-PUBLISHED-TTL:PT4H
+X-WR-CALNAME:test
+PRODID:-//RandomProdID which is not random//EN
+BEGIN:VTODO
+UID:randommduid
+DTSTAMP:20181201T011204
+SUMMARY:Todo #1
+RRULE:FREQ=MONTHLY;BYDAY=-1FR
+LAST-MODIFIED:20181201T011204
+END:VTODO
+END:VCALENDAR`, ParseTodos(caldavConfig, todos))
+	})
+}
@@ -0,0 +1,73 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"code.vikunja.io/api/pkg/initialize"
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/models"
+	"code.vikunja.io/api/pkg/user"
+	"github.com/spf13/cobra"
+)
+
+var digestFlagUserID int64
+
+func init() {
+	digestSendCmd.Flags().Int64VarP(&digestFlagUserID, "user", "u", 0, "The id of the user to send the digest to.")
+	_ = digestSendCmd.MarkFlagRequired("user")
+
+	digestCmd.AddCommand(digestSendCmd)
+	rootCmd.AddCommand(digestCmd)
+}
+
+var digestCmd = &cobra.Command{
+	Use:   "digest",
+	Short: "Manage digest emails of due and overdue tasks.",
+}
+
+var digestSendCmd = &cobra.Command{
+	Use:   "send",
+	Short: "Send the digest email to a user right away, regardless of their digest settings.",
+	PreRun: func(cmd *cobra.Command, args []string) {
+		initialize.FullInit()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if digestFlagUserID < 1 {
+			log.Fatalf("Invalid user id: %d", digestFlagUserID)
+		}
+
+		u, err := user.GetUserWithEmail(&user.User{ID: digestFlagUserID})
+		if err != nil {
+			log.Fatalf("Could not get user: %s", err)
+		}
+
+		sent, err := models.SendDigestForUser(u, time.Now())
+		if err != nil {
+			log.Fatalf("Could not send digest: %s", err)
+		}
+
+		if !sent {
+			fmt.Println("No digest was sent. Either the user does not have any due tasks, has no email address or the mailer is disabled.")
+			return
+		}
+
+		fmt.Println("Digest sent successfully.")
+	},
+}
@@ -26,6 +26,7 @@ import (
 
 	"code.vikunja.io/api/pkg/initialize"
 	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/models"
 	"code.vikunja.io/api/pkg/user"
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
@@ -40,6 +41,8 @@ var (
 	userFlagResetPasswordDirectly bool
 	userFlagEnableUser            bool
 	userFlagDisableUser           bool
+	userFlagTransferTo            string
+	userFlagDeleteConfirm         bool
 )
 
 func init() {
@@ -64,7 +67,11 @@ func init() {
 	userChangeEnabledCmd.Flags().BoolVarP(&userFlagDisableUser, "disable", "d", false, "Disable the user.")
 	userChangeEnabledCmd.Flags().BoolVarP(&userFlagEnableUser, "enable", "e", false, "Enable the user.")
 
-	userCmd.AddCommand(userListCmd, userCreateCmd, userUpdateCmd, userResetPasswordCmd, userChangeEnabledCmd)
+	// User delete flags
+	userDeleteCmd.Flags().StringVarP(&userFlagTransferTo, "transfer-to", "t", "", "The id of the user who gets all namespaces, lists, teams, labels, saved filters and list templates of the deleted user. If not provided, they are deleted.")
+	userDeleteCmd.Flags().BoolVarP(&userFlagDeleteConfirm, "confirm", "c", false, "Delete the user without asking for confirmation.")
+
+	userCmd.AddCommand(userListCmd, userCreateCmd, userUpdateCmd, userResetPasswordCmd, userChangeEnabledCmd, userDeleteCmd)
 	rootCmd.AddCommand(userCmd)
 }
 
@@ -247,3 +254,44 @@ var userChangeEnabledCmd = &cobra.Command{
 		fmt.Printf("User status successfully changed, user is now active: %t.\n", u.IsActive)
 	},
 }
+
+var userDeleteCmd = &cobra.Command{
+	Use:   "delete [user id]",
+	Short: "Delete a user. Their namespaces, lists, teams, labels, saved filters and list templates are transferred to another user with --transfer-to or deleted.",
+	PreRun: func(cmd *cobra.Command, args []string) {
+		initialize.FullInit()
+	},
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		u := getUserFromArg(args[0])
+
+		var transferTo *user.User
+		if userFlagTransferTo != "" {
+			transferTo = getUserFromArg(userFlagTransferTo)
+			if transferTo.ID == u.ID {
+				log.Fatalf("Cannot transfer the data of user %s to themselves.", u.Username)
+			}
+		}
+
+		if !userFlagDeleteConfirm {
+			if transferTo != nil {
+				fmt.Printf("Delete user %s and transfer all their data to %s? [y/N]: ", u.Username, transferTo.Username)
+			} else {
+				fmt.Printf("Delete user %s and all their data? This cannot be undone. [y/N]: ", u.Username)
+			}
+			var answer string
+			_, _ = fmt.Scanln(&answer)
+			if strings.ToLower(strings.TrimSpace(answer)) != "y" {
+				fmt.Println("Aborted.")
+				return
+			}
+		}
+
+		err := models.DeleteUser(u, transferTo)
+		if err != nil {
+			log.Fatalf("Could not delete the user: %s", err)
+		}
+
+		fmt.Println("User deleted successfully.")
+	},
+}
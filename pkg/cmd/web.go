@@ -25,6 +25,8 @@ import (
 	"code.vikunja.io/api/pkg/config"
 	"code.vikunja.io/api/pkg/initialize"
 	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/models"
+	"code.vikunja.io/api/pkg/modules/realtime"
 	"code.vikunja.io/api/pkg/routes"
 	"code.vikunja.io/api/pkg/swagger"
 	"code.vikunja.io/api/pkg/version"
@@ -49,6 +51,18 @@ var webCmd = &cobra.Command{
 		// Additional swagger information
 		swagger.SwaggerInfo.Version = version.Version
 
+		// Start sending task reminders
+		models.StartReminderDaemon()
+
+		// Start sending digest emails
+		models.StartDigestDaemon()
+
+		// Start deleting expired link shares
+		models.StartLinkShareCleanupDaemon()
+
+		// Start deleting expired user data exports
+		models.StartDataExportCleanupDaemon()
+
 		// Start the webserver
 		e := routes.NewEcho()
 		routes.RegisterRoutes(e)
@@ -67,6 +81,11 @@ var webCmd = &cobra.Command{
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 		log.Infof("Shutting down...")
+		models.StopReminderDaemon()
+		models.StopDigestDaemon()
+		models.StopLinkShareCleanupDaemon()
+		models.StopDataExportCleanupDaemon()
+		realtime.Stop()
 		if err := e.Shutdown(ctx); err != nil {
 			e.Logger.Fatal(err)
 		}
@@ -37,6 +37,8 @@ type Key string
 const (
 	// #nosec
 	ServiceJWTSecret             Key = `service.JWTSecret`
+	ServiceJWTTTL                Key = `service.jwtttl`
+	ServiceSessionTTL            Key = `service.sessionttl`
 	ServiceInterface             Key = `service.interface`
 	ServiceFrontendurl           Key = `service.frontendurl`
 	ServiceEnableCaldav          Key = `service.enablecaldav`
@@ -51,6 +53,9 @@ const (
 	ServiceEnableTaskComments    Key = `service.enabletaskcomments`
 	ServiceEnableTotp            Key = `service.enabletotp`
 	ServiceSentryDsn             Key = `service.sentrydsn`
+	ServiceEnableEmailReminders  Key = `service.enableemailreminders`
+	ServiceEnableEmailDigests    Key = `service.enableemaildigests`
+	ServiceEnableTimeTracking    Key = `service.enabletimetracking`
 
 	LegalImprintURL Key = `legal.imprinturl`
 	LegalPrivacyURL Key = `legal.privacyurl`
@@ -95,11 +100,13 @@ const (
 	LogEcho          Key = `log.echo`
 	LogPath          Key = `log.path`
 
-	RateLimitEnabled Key = `ratelimit.enabled`
-	RateLimitKind    Key = `ratelimit.kind`
-	RateLimitPeriod  Key = `ratelimit.period`
-	RateLimitLimit   Key = `ratelimit.limit`
-	RateLimitStore   Key = `ratelimit.store`
+	RateLimitEnabled                 Key = `ratelimit.enabled`
+	RateLimitKind                    Key = `ratelimit.kind`
+	RateLimitPeriod                  Key = `ratelimit.period`
+	RateLimitLimit                   Key = `ratelimit.limit`
+	RateLimitStore                   Key = `ratelimit.store`
+	RateLimitLinkSharePasswordLimit  Key = `ratelimit.linksharepasswordlimit`
+	RateLimitLinkSharePasswordPeriod Key = `ratelimit.linksharepasswordperiod`
 
 	FilesBasePath Key = `files.basepath`
 	FilesMaxSize  Key = `files.maxsize`
@@ -126,6 +133,34 @@ const (
 	BackgroundsUnsplashApplicationID Key = `backgrounds.providers.unsplash.applicationid`
 
 	KeyvalueType Key = `keyvalue.type`
+
+	WebhooksEnabled               Key = `webhooks.enabled`
+	WebhooksTimeoutSeconds        Key = `webhooks.timeoutseconds`
+	WebhooksMaxRetries            Key = `webhooks.maxretries`
+	WebhooksAllowNonPublicTargets Key = `webhooks.allownonpublictargets`
+
+	AuthOpenIDEnabled     Key = `auth.openid.enabled`
+	AuthOpenIDRedirectURL Key = `auth.openid.redirecturl`
+	AuthOpenIDProviders   Key = `auth.openid.providers`
+
+	AuthLdapEnabled              Key = `auth.ldap.enabled`
+	AuthLdapHost                 Key = `auth.ldap.host`
+	AuthLdapPort                 Key = `auth.ldap.port`
+	AuthLdapUseTLS               Key = `auth.ldap.usetls`
+	AuthLdapVerifyTLS            Key = `auth.ldap.verifytls`
+	AuthLdapBaseDN               Key = `auth.ldap.basedn`
+	AuthLdapBindDN               Key = `auth.ldap.binddn`
+	AuthLdapBindPassword         Key = `auth.ldap.bindpassword`
+	AuthLdapUserFilter           Key = `auth.ldap.userfilter`
+	AuthLdapAttributeUsername    Key = `auth.ldap.attribute.username`
+	AuthLdapAttributeEmail       Key = `auth.ldap.attribute.email`
+	AuthLdapAttributeDisplayname Key = `auth.ldap.attribute.displayname`
+	AuthLdapAttributeID          Key = `auth.ldap.attribute.id`
+
+	AuthWebAuthnEnabled       Key = `auth.webauthn.enabled`
+	AuthWebAuthnRPDisplayName Key = `auth.webauthn.rpdisplayname`
+	AuthWebAuthnRPID          Key = `auth.webauthn.rpid`
+	AuthWebAuthnRPOrigin      Key = `auth.webauthn.rporigin`
 )
 
 // GetString returns a string config value
@@ -153,6 +188,11 @@ func (k Key) GetDuration() time.Duration {
 	return viper.GetDuration(string(k))
 }
 
+// Get returns the raw value of a config option, used for more complex values like lists of maps
+func (k Key) Get() interface{} {
+	return viper.Get(string(k))
+}
+
 // GetStringSlice returns a string slice from a config option
 func (k Key) GetStringSlice() []string {
 	return viper.GetStringSlice(string(k))
@@ -196,6 +236,8 @@ func InitDefaultConfig() {
 
 	// Service
 	ServiceJWTSecret.setDefault(random)
+	ServiceJWTTTL.setDefault(900)
+	ServiceSessionTTL.setDefault(2592000)
 	ServiceInterface.setDefault(":3456")
 	ServiceFrontendurl.setDefault("")
 	ServiceEnableCaldav.setDefault(true)
@@ -215,6 +257,9 @@ func InitDefaultConfig() {
 	ServiceTimeZone.setDefault("GMT")
 	ServiceEnableTaskComments.setDefault(true)
 	ServiceEnableTotp.setDefault(true)
+	ServiceEnableEmailReminders.setDefault(true)
+	ServiceEnableEmailDigests.setDefault(true)
+	ServiceEnableTimeTracking.setDefault(true)
 
 	// Database
 	DatabaseType.setDefault("sqlite")
@@ -263,6 +308,8 @@ func InitDefaultConfig() {
 	RateLimitLimit.setDefault(100)
 	RateLimitPeriod.setDefault(60)
 	RateLimitStore.setDefault("memory")
+	RateLimitLinkSharePasswordLimit.setDefault(10)
+	RateLimitLinkSharePasswordPeriod.setDefault(3600)
 	// Files
 	FilesBasePath.setDefault("files")
 	FilesMaxSize.setDefault("20MB")
@@ -281,6 +328,27 @@ func InitDefaultConfig() {
 	BackgroundsUnsplashEnabled.setDefault(false)
 	// Key Value
 	KeyvalueType.setDefault("memory")
+	// Webhooks
+	WebhooksEnabled.setDefault(true)
+	WebhooksTimeoutSeconds.setDefault(30)
+	WebhooksMaxRetries.setDefault(5)
+	WebhooksAllowNonPublicTargets.setDefault(false)
+	// Auth
+	AuthOpenIDEnabled.setDefault(false)
+	AuthOpenIDRedirectURL.setDefault("")
+	AuthLdapEnabled.setDefault(false)
+	AuthLdapPort.setDefault(389)
+	AuthLdapUseTLS.setDefault(false)
+	AuthLdapVerifyTLS.setDefault(true)
+	AuthLdapUserFilter.setDefault("(&(objectclass=inetOrgPerson)(uid={username}))")
+	AuthLdapAttributeUsername.setDefault("uid")
+	AuthLdapAttributeEmail.setDefault("mail")
+	AuthLdapAttributeDisplayname.setDefault("displayName")
+	AuthLdapAttributeID.setDefault("entryUUID")
+	AuthWebAuthnEnabled.setDefault(true)
+	AuthWebAuthnRPDisplayName.setDefault("Vikunja")
+	AuthWebAuthnRPID.setDefault("")
+	AuthWebAuthnRPOrigin.setDefault("")
 }
 
 // InitConfig initializes the config, sets defaults etc.
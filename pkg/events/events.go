@@ -0,0 +1,70 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package events
+
+import (
+	"sync"
+
+	"code.vikunja.io/api/pkg/log"
+)
+
+// Event represents something which happened in Vikunja, for example a task being created.
+type Event interface {
+	// Name returns the unique name of the event, like "task.created".
+	Name() string
+}
+
+// Listener is something which wants to do something when an event is dispatched.
+type Listener interface {
+	// Handle is called with the dispatched event.
+	Handle(event Event) error
+	// Name returns a name for the listener, used for logging.
+	Name() string
+}
+
+var (
+	listeners     = make(map[string][]Listener)
+	listenersLock sync.RWMutex
+)
+
+// RegisterListener registers a listener for all events with the given name.
+// Listeners should be registered at init time, before any event is dispatched.
+func RegisterListener(eventName string, listener Listener) {
+	listenersLock.Lock()
+	defer listenersLock.Unlock()
+	listeners[eventName] = append(listeners[eventName], listener)
+}
+
+// Dispatch calls all listeners registered for the event.
+// It should only be called after the change which triggered the event was committed to the database.
+// An error in one listener is logged and does not prevent the other listeners from running.
+func Dispatch(event Event) {
+	if isUnderTest {
+		recordDispatched(event)
+		return
+	}
+
+	listenersLock.RLock()
+	ls := listeners[event.Name()]
+	listenersLock.RUnlock()
+
+	for _, l := range ls {
+		if err := l.Handle(event); err != nil {
+			log.Errorf("[Events] Listener %s failed to handle event %s: %s", l.Name(), event.Name(), err)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package events
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testEvent struct{}
+
+func (t *testEvent) Name() string {
+	return "test.event"
+}
+
+type testListener struct {
+	handled int
+	err     error
+}
+
+func (l *testListener) Handle(_ Event) error {
+	l.handled++
+	return l.err
+}
+
+func (l *testListener) Name() string {
+	return "test.listener"
+}
+
+func TestDispatch(t *testing.T) {
+	t.Run("calls all listeners", func(t *testing.T) {
+		first := &testListener{err: errors.New("failed")}
+		second := &testListener{}
+		RegisterListener((&testEvent{}).Name(), first)
+		RegisterListener((&testEvent{}).Name(), second)
+
+		Dispatch(&testEvent{})
+		assert.Equal(t, 1, first.handled)
+		assert.Equal(t, 1, second.handled)
+	})
+	t.Run("fake", func(t *testing.T) {
+		Fake()
+		defer func() {
+			isUnderTest = false
+		}()
+
+		l := &testListener{}
+		RegisterListener((&testEvent{}).Name(), l)
+
+		Dispatch(&testEvent{})
+		assert.Equal(t, 0, l.handled)
+		AssertDispatched(t, &testEvent{})
+	})
+}
@@ -0,0 +1,72 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package events
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	isUnderTest      bool
+	dispatchedEvents []Event
+	dispatchedLock   sync.Mutex
+)
+
+// Fake sets up the event dispatcher for tests: Instead of calling the registered listeners,
+// all dispatched events are recorded so they can be checked with AssertDispatched.
+func Fake() {
+	dispatchedLock.Lock()
+	defer dispatchedLock.Unlock()
+	isUnderTest = true
+	dispatchedEvents = nil
+}
+
+func recordDispatched(event Event) {
+	dispatchedLock.Lock()
+	defer dispatchedLock.Unlock()
+	dispatchedEvents = append(dispatchedEvents, event)
+}
+
+// AssertDispatched asserts an event with the same name as the given one was dispatched since the last call to Fake.
+func AssertDispatched(t *testing.T, event Event) {
+	dispatchedLock.Lock()
+	defer dispatchedLock.Unlock()
+
+	for _, e := range dispatchedEvents {
+		if e.Name() == event.Name() {
+			return
+		}
+	}
+
+	assert.Fail(t, "Event "+event.Name()+" was not dispatched.")
+}
+
+// AssertNotDispatched asserts no event with the same name as the given one was dispatched since the last call to Fake.
+func AssertNotDispatched(t *testing.T, event Event) {
+	dispatchedLock.Lock()
+	defer dispatchedLock.Unlock()
+
+	for _, e := range dispatchedEvents {
+		if e.Name() == event.Name() {
+			assert.Fail(t, "Event "+event.Name()+" was dispatched.")
+			return
+		}
+	}
+}
@@ -74,15 +74,27 @@ func Create(f io.Reader, realname string, realsize uint64, a web.Auth) (file *Fi
 
 // CreateWithMime creates a new file from an FileHeader and sets its mime type
 func CreateWithMime(f io.Reader, realname string, realsize uint64, a web.Auth, mime string) (file *File, err error) {
+	return create(f, realname, realsize, a, mime, true)
+}
 
-	// Get and parse the configured file size
-	var maxSize datasize.ByteSize
-	err = maxSize.UnmarshalText([]byte(config.FilesMaxSize.GetString()))
-	if err != nil {
-		return nil, err
-	}
-	if realsize > maxSize.Bytes() {
-		return nil, ErrFileIsTooLarge{Size: realsize}
+// CreateWithoutSizeLimit creates a new file without checking its size against the configured maximum file size.
+// Only use this for files generated by Vikunja itself, like data exports.
+func CreateWithoutSizeLimit(f io.Reader, realname string, realsize uint64, a web.Auth, mime string) (file *File, err error) {
+	return create(f, realname, realsize, a, mime, false)
+}
+
+func create(f io.Reader, realname string, realsize uint64, a web.Auth, mime string, checkFileSizeLimit bool) (file *File, err error) {
+
+	if checkFileSizeLimit {
+		// Get and parse the configured file size
+		var maxSize datasize.ByteSize
+		err = maxSize.UnmarshalText([]byte(config.FilesMaxSize.GetString()))
+		if err != nil {
+			return nil, err
+		}
+		if realsize > maxSize.Bytes() {
+			return nil, ErrFileIsTooLarge{Size: realsize}
+		}
 	}
 
 	// We first insert the file into the db to get it's ID
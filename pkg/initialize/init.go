@@ -23,8 +23,10 @@ import (
 	"code.vikunja.io/api/pkg/mail"
 	"code.vikunja.io/api/pkg/migration"
 	"code.vikunja.io/api/pkg/models"
+	"code.vikunja.io/api/pkg/modules/auth/ldap"
 	"code.vikunja.io/api/pkg/modules/keyvalue"
 	migrator "code.vikunja.io/api/pkg/modules/migration"
+	"code.vikunja.io/api/pkg/modules/realtime"
 	"code.vikunja.io/api/pkg/red"
 	"code.vikunja.io/api/pkg/user"
 )
@@ -40,8 +42,14 @@ func LightInit() {
 	// Init keyvalue store
 	keyvalue.InitStorage()
 
+	// Init the broker for realtime updates
+	realtime.InitBroker()
+
 	// Set logger
 	log.InitLogger()
+
+	// Authenticate users against ldap if enabled
+	ldap.InitLDAP()
 }
 
 // InitEngines intializes all db connections
@@ -0,0 +1,134 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package integrations
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	apiToken1        = "tk_testtoken010000000000000000000000000000000000000000000000000000"
+	apiTokenExpired2 = "tk_testtoken020000000000000000000000000000000000000000000000000000"
+	apiTokenAdmin3   = "tk_testtoken030000000000000000000000000000000000000000000000000000"
+)
+
+// Sends the request through all routes and middlewares, authenticated with an api token or a jwt
+func newTestRequestWithToken(t *testing.T, method, path, token string) *httptest.ResponseRecorder {
+	e, err := setupTestEnv()
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAPIToken(t *testing.T) {
+	t.Run("read with read scope", func(t *testing.T) {
+		rec := newTestRequestWithToken(t, http.MethodGet, "/api/v1/tasks/1", apiToken1)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"title":"task #1"`)
+	})
+	t.Run("write with read scope", func(t *testing.T) {
+		rec := newTestRequestWithToken(t, http.MethodDelete, "/api/v1/tasks/1", apiToken1)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"code":16005`)
+	})
+	t.Run("read with write scope", func(t *testing.T) {
+		rec := newTestRequestWithToken(t, http.MethodGet, "/api/v1/lists/1", apiToken1)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+	t.Run("admin route without admin scope", func(t *testing.T) {
+		rec := newTestRequestWithToken(t, http.MethodGet, "/api/v1/lists/1/shares", apiToken1)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"code":16005`)
+	})
+	t.Run("update list with write scope", func(t *testing.T) {
+		rec := newTestRequestWithToken(t, http.MethodPost, "/api/v1/lists/1", apiToken1)
+		assert.NotContains(t, rec.Body.String(), `"code":16005`)
+	})
+	t.Run("delete list without admin scope", func(t *testing.T) {
+		rec := newTestRequestWithToken(t, http.MethodDelete, "/api/v1/lists/1", apiToken1)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Contains(t, rec.Body.String(), `lists:admin`)
+	})
+	t.Run("delete list with admin scope", func(t *testing.T) {
+		rec := newTestRequestWithToken(t, http.MethodDelete, "/api/v1/lists/1", apiTokenAdmin3)
+		assert.NotContains(t, rec.Body.String(), `"code":16005`)
+	})
+	t.Run("delete bucket with write scope", func(t *testing.T) {
+		rec := newTestRequestWithToken(t, http.MethodDelete, "/api/v1/lists/1/buckets/1", apiToken1)
+		assert.NotContains(t, rec.Body.String(), `"code":16005`)
+	})
+	t.Run("delete namespace without admin scope", func(t *testing.T) {
+		rec := newTestRequestWithToken(t, http.MethodDelete, "/api/v1/namespaces/1", apiToken1)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Contains(t, rec.Body.String(), `namespaces:admin`)
+	})
+	t.Run("read custom fields with write scope", func(t *testing.T) {
+		rec := newTestRequestWithToken(t, http.MethodGet, "/api/v1/lists/1/custom-fields", apiToken1)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+	t.Run("manage custom fields without admin scope", func(t *testing.T) {
+		rec := newTestRequestWithToken(t, http.MethodPut, "/api/v1/lists/1/custom-fields", apiToken1)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Contains(t, rec.Body.String(), `lists:admin`)
+
+		rec = newTestRequestWithToken(t, http.MethodPost, "/api/v1/lists/1/custom-fields/1", apiToken1)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+
+		rec = newTestRequestWithToken(t, http.MethodDelete, "/api/v1/lists/1/custom-fields/1", apiToken1)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+	t.Run("manage custom fields with admin scope", func(t *testing.T) {
+		rec := newTestRequestWithToken(t, http.MethodPut, "/api/v1/lists/1/custom-fields", apiTokenAdmin3)
+		assert.NotContains(t, rec.Body.String(), `"code":16005`)
+	})
+	t.Run("route without scope", func(t *testing.T) {
+		rec := newTestRequestWithToken(t, http.MethodGet, "/api/v1/labels", apiToken1)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+	t.Run("user settings", func(t *testing.T) {
+		rec := newTestRequestWithToken(t, http.MethodGet, "/api/v1/user/settings/tokens", apiToken1)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+	t.Run("renew token", func(t *testing.T) {
+		rec := newTestRequestWithToken(t, http.MethodPost, "/api/v1/user/token", apiToken1)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+	t.Run("current user", func(t *testing.T) {
+		rec := newTestRequestWithToken(t, http.MethodGet, "/api/v1/user", apiToken1)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"username":"user1"`)
+	})
+	t.Run("expired", func(t *testing.T) {
+		rec := newTestRequestWithToken(t, http.MethodGet, "/api/v1/tasks/1", apiTokenExpired2)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"code":16004`)
+	})
+	t.Run("nonexisting", func(t *testing.T) {
+		rec := newTestRequestWithToken(t, http.MethodGet, "/api/v1/tasks/1", "tk_nonexisting")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+}
@@ -0,0 +1,130 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package integrations
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/models"
+	apiv1 "code.vikunja.io/api/pkg/routes/api/v1"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLinkShareAuth(t *testing.T) {
+	authenticate := func(e *echo.Echo, hash, payload string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/shares/"+hash+"/auth", strings.NewReader(payload))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("without password", func(t *testing.T) {
+		e, err := setupTestEnv()
+		assert.NoError(t, err)
+		rec := authenticate(e, "test", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"token":`)
+	})
+	t.Run("password required", func(t *testing.T) {
+		e, err := setupTestEnv()
+		assert.NoError(t, err)
+		rec := authenticate(e, "testWithPassword", "")
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"code":3009`)
+	})
+	t.Run("wrong password", func(t *testing.T) {
+		e, err := setupTestEnv()
+		assert.NoError(t, err)
+		rec := authenticate(e, "testWithPassword", `{"password":"wrong"}`)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"code":3010`)
+	})
+	t.Run("correct password", func(t *testing.T) {
+		e, err := setupTestEnv()
+		assert.NoError(t, err)
+		rec := authenticate(e, "testWithPassword", `{"password":"1234"}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"token":`)
+		assert.NotContains(t, rec.Body.String(), `"password"`)
+	})
+	t.Run("too many wrong passwords", func(t *testing.T) {
+		config.RateLimitLinkSharePasswordLimit.Set(3)
+		defer config.RateLimitLinkSharePasswordLimit.Set(10)
+
+		e, err := setupTestEnv()
+		assert.NoError(t, err)
+		for i := 0; i < 3; i++ {
+			rec := authenticate(e, "testWithPassword", `{"password":"wrong"}`)
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+		}
+
+		// Even the correct password should not work anymore
+		rec := authenticate(e, "testWithPassword", `{"password":"1234"}`)
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"code":3012`)
+
+		// Other shares are not affected
+		rec = authenticate(e, "test", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+}
+
+func TestLinkShareExpiry(t *testing.T) {
+	t.Run("authenticate with expired share", func(t *testing.T) {
+		e, err := setupTestEnv()
+		assert.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/shares/testExpired/auth", nil)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"code":3013`)
+	})
+	t.Run("renew token of an expired share", func(t *testing.T) {
+		share := &models.LinkSharing{
+			ID:     5,
+			Hash:   "testExpired",
+			ListID: 1,
+			Right:  models.RightRead,
+		}
+		_, err := newTestRequestWithLinkShare(t, http.MethodGet, apiv1.RenewToken, share, "", nil, nil)
+		assert.Error(t, err)
+		assertHandlerErrorCode(t, err, models.ErrCodeLinkShareExpired)
+	})
+}
+
+func TestLinkShareAuthLimit(t *testing.T) {
+	e, err := setupTestEnv()
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/shares/testLimited/auth", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"authentications":2`)
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/shares/testLimited/auth", nil)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"code":3014`)
+}
@@ -33,6 +33,7 @@ func TestLogin(t *testing.T) {
 }`)
 		assert.NoError(t, err)
 		assert.Contains(t, rec.Body.String(), "token")
+		assert.Contains(t, rec.Body.String(), "refresh_token")
 	})
 	t.Run("Empty payload", func(t *testing.T) {
 		_, err := newTestRequest(t, http.MethodPost, apiv1.Login, `{}`)
@@ -55,6 +56,40 @@ func TestLogin(t *testing.T) {
 		assert.Error(t, err)
 		assertHandlerErrorCode(t, err, user.ErrCodeWrongUsernameOrPassword)
 	})
+	t.Run("totp without passcode", func(t *testing.T) {
+		_, err := newTestRequest(t, http.MethodPost, apiv1.Login, `{
+  "username": "user10",
+  "password": "1234"
+}`)
+		assert.Error(t, err)
+		assertHandlerErrorCode(t, err, user.ErrCodeInvalidTOTPPasscode)
+	})
+	t.Run("totp recovery code", func(t *testing.T) {
+		rec, err := newTestRequest(t, http.MethodPost, apiv1.Login, `{
+  "username": "user10",
+  "password": "1234",
+  "totp_passcode": "RECOVERY-CODE0001"
+}`)
+		assert.NoError(t, err)
+		assert.Contains(t, rec.Body.String(), `"totp_recovery_codes_left":1`)
+	})
+	t.Run("security key without second factor", func(t *testing.T) {
+		_, err := newTestRequest(t, http.MethodPost, apiv1.Login, `{
+  "username": "user11",
+  "password": "1234"
+}`)
+		assert.Error(t, err)
+		assertHandlerErrorCode(t, err, user.ErrCodeSecondFactorRequired)
+	})
+	t.Run("security key with invalid response", func(t *testing.T) {
+		_, err := newTestRequest(t, http.MethodPost, apiv1.Login, `{
+  "username": "user11",
+  "password": "1234",
+  "webauthn": {"id": "invalid"}
+}`)
+		assert.Error(t, err)
+		assertHandlerErrorCode(t, err, user.ErrCodeInvalidWebAuthnResponse)
+	})
 	t.Run("user with unconfirmed email", func(t *testing.T) {
 		_, err := newTestRequest(t, http.MethodPost, apiv1.Login, `{
   "username": "user5",
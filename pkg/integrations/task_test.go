@@ -193,6 +193,21 @@ func TestTask(t *testing.T) {
 				assert.Contains(t, rec.Body.String(), `"percent_done":0,`)
 				assert.NotContains(t, rec.Body.String(), `"percent_done":0.1`)
 			})
+			t.Run("Estimate", func(t *testing.T) {
+				rec, err := testHandler.testUpdateWithUser(nil, map[string]string{"listtask": "1"}, `{"estimate":3600}`)
+				assert.NoError(t, err)
+				assert.Contains(t, rec.Body.String(), `"estimate":3600`)
+			})
+			t.Run("Points", func(t *testing.T) {
+				rec, err := testHandler.testUpdateWithUser(nil, map[string]string{"listtask": "1"}, `{"points":2.5}`)
+				assert.NoError(t, err)
+				assert.Contains(t, rec.Body.String(), `"points":2.5`)
+			})
+			t.Run("Negative points", func(t *testing.T) {
+				_, err := testHandler.testUpdateWithUser(nil, map[string]string{"listtask": "1"}, `{"points":-1}`)
+				assert.Error(t, err)
+				assertHandlerErrorCode(t, err, models.ErrCodeInvalidTaskEstimate)
+			})
 		})
 
 		t.Run("Nonexisting", func(t *testing.T) {
@@ -0,0 +1,78 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package integrations
+
+import (
+	"net/http"
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	apiv1 "code.vikunja.io/api/pkg/routes/api/v1"
+	"code.vikunja.io/api/pkg/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserDeletion(t *testing.T) {
+	t.Run("Request deletion", func(t *testing.T) {
+		rec, err := newTestRequestWithUser(t, http.MethodPost, apiv1.UserRequestDeletion, &testuser1, "", nil, nil)
+		assert.NoError(t, err)
+		assert.Contains(t, rec.Body.String(), "email")
+		db.AssertMissing(t, "users", map[string]interface{}{
+			"id":                     1,
+			"deletion_confirm_token": "",
+		})
+	})
+	t.Run("Delete with password", func(t *testing.T) {
+		rec, err := newTestRequestWithUser(t, http.MethodPost, apiv1.UserConfirmDeletion, &testuser1, `{"password":"1234"}`, nil, nil)
+		assert.NoError(t, err)
+		assert.Contains(t, rec.Body.String(), "successfully")
+		db.AssertMissing(t, "users", map[string]interface{}{
+			"id": 1,
+		})
+		db.AssertMissing(t, "namespaces", map[string]interface{}{
+			"owner_id": 1,
+		})
+	})
+	t.Run("Transfer to a user without admin access", func(t *testing.T) {
+		_, err := newTestRequestWithUser(t, http.MethodPost, apiv1.UserConfirmDeletion, &testuser1, `{"password":"1234","transfer_to":"user2"}`, nil, nil)
+		assert.Error(t, err)
+		assertHandlerErrorCode(t, err, user.ErrCodeCannotTransferToUser)
+		db.AssertExists(t, "users", map[string]interface{}{
+			"id": 1,
+		}, false)
+	})
+	t.Run("Transfer to a user who does not exist", func(t *testing.T) {
+		_, err := newTestRequestWithUser(t, http.MethodPost, apiv1.UserConfirmDeletion, &testuser1, `{"password":"1234","transfer_to":"user9999"}`, nil, nil)
+		assert.Error(t, err)
+		assertHandlerErrorCode(t, err, user.ErrCodeUserDoesNotExist)
+	})
+	t.Run("Wrong password", func(t *testing.T) {
+		_, err := newTestRequestWithUser(t, http.MethodPost, apiv1.UserConfirmDeletion, &testuser1, `{"password":"wrong"}`, nil, nil)
+		assert.Error(t, err)
+		assertHandlerErrorCode(t, err, user.ErrCodeWrongUsernameOrPassword)
+	})
+	t.Run("Not confirmed", func(t *testing.T) {
+		_, err := newTestRequestWithUser(t, http.MethodPost, apiv1.UserConfirmDeletion, &testuser1, `{}`, nil, nil)
+		assert.Error(t, err)
+		assertHandlerErrorCode(t, err, user.ErrCodeAccountDeletionNotConfirmed)
+	})
+	t.Run("Wrong token", func(t *testing.T) {
+		_, err := newTestRequestWithUser(t, http.MethodPost, apiv1.UserConfirmDeletion, &testuser1, `{"token":"wrong"}`, nil, nil)
+		assert.Error(t, err)
+		assertHandlerErrorCode(t, err, user.ErrCodeAccountDeletionNotConfirmed)
+	})
+}
@@ -0,0 +1,55 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package integrations
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"testing"
+
+	"code.vikunja.io/api/pkg/models"
+	apiv1 "code.vikunja.io/api/pkg/routes/api/v1"
+	"code.vikunja.io/api/pkg/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserDataExport(t *testing.T) {
+	t.Run("Download without export", func(t *testing.T) {
+		_, err := newTestRequestWithUser(t, http.MethodGet, apiv1.UserDownloadDataExport, &testuser1, "", nil, nil)
+		assert.Error(t, err)
+		assertHandlerErrorCode(t, err, user.ErrCodeNoDataExportAvailable)
+	})
+	t.Run("Download", func(t *testing.T) {
+		rec, c := testRequestSetup(t, http.MethodGet, "", nil, nil)
+		// Build the export right away instead of waiting for it to be created in the background
+		err := models.ExportUserData(&user.User{ID: testuser1.ID})
+		assert.NoError(t, err)
+		addUserTokenToContext(t, &testuser1, c)
+
+		err = apiv1.UserDownloadDataExport(c)
+		assert.NoError(t, err)
+		body := rec.Body.Bytes()
+		r, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+		assert.NoError(t, err)
+		archived := make([]string, 0, len(r.File))
+		for _, f := range r.File {
+			archived = append(archived, f.Name)
+		}
+		assert.Contains(t, archived, "data.json")
+	})
+}
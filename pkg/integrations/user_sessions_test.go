@@ -0,0 +1,97 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package integrations
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	apiv1 "code.vikunja.io/api/pkg/routes/api/v1"
+	"code.vikunja.io/api/pkg/user"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// Sends the request through all routes and middlewares, authenticated with a jwt of user 1 for the given session.
+// The jwt is only created after setting up the test env because that generates a new jwt secret.
+func newTestRequestWithSession(t *testing.T, method, path string, sessionID int64) *httptest.ResponseRecorder {
+	e, err := setupTestEnv()
+	assert.NoError(t, err)
+
+	token, err := apiv1.NewUserJWTAuthtoken(&testuser1, sessionID)
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestUserSessions(t *testing.T) {
+	t.Run("valid session", func(t *testing.T) {
+		rec := newTestRequestWithSession(t, http.MethodGet, "/api/v1/user", 1)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"username":"user1"`)
+	})
+	t.Run("list sessions", func(t *testing.T) {
+		rec := newTestRequestWithSession(t, http.MethodGet, "/api/v1/user/sessions", 1)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"device_info":"vikunja-cli"`)
+		assert.Contains(t, rec.Body.String(), `"is_current":true`)
+		assert.NotContains(t, rec.Body.String(), `refresh_token`)
+	})
+	t.Run("without session", func(t *testing.T) {
+		rec := newTestRequestWithSession(t, http.MethodGet, "/api/v1/user", 0)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"code":1024`)
+	})
+	t.Run("revoked session", func(t *testing.T) {
+		rec := newTestRequestWithSession(t, http.MethodGet, "/api/v1/user", 9999)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"code":1024`)
+	})
+	t.Run("expired session", func(t *testing.T) {
+		rec := newTestRequestWithSession(t, http.MethodGet, "/api/v1/user", 3)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+	t.Run("session of another user", func(t *testing.T) {
+		rec := newTestRequestWithSession(t, http.MethodGet, "/api/v1/user", 4)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+}
+
+func TestRefreshToken(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		rec, err := newTestRequest(t, http.MethodPost, apiv1.RefreshToken, `{"refresh_token":"refreshtoken01"}`)
+		assert.NoError(t, err)
+		assert.Contains(t, rec.Body.String(), `"token":`)
+		assert.Contains(t, rec.Body.String(), `"refresh_token":`)
+	})
+	t.Run("expired", func(t *testing.T) {
+		_, err := newTestRequest(t, http.MethodPost, apiv1.RefreshToken, `{"refresh_token":"refreshtoken03"}`)
+		assert.Error(t, err)
+		assertHandlerErrorCode(t, err, user.ErrCodeInvalidRefreshToken)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		_, err := newTestRequest(t, http.MethodPost, apiv1.RefreshToken, `{"refresh_token":"lorem"}`)
+		assert.Error(t, err)
+		assertHandlerErrorCode(t, err, user.ErrCodeInvalidRefreshToken)
+	})
+}
@@ -0,0 +1,94 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package integrations
+
+import (
+	"net/http"
+	"testing"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/db"
+	apiv1 "code.vikunja.io/api/pkg/routes/api/v1"
+	"code.vikunja.io/api/pkg/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebAuthn(t *testing.T) {
+	config.AuthWebAuthnRPID.Set("localhost")
+	config.AuthWebAuthnRPOrigin.Set("http://localhost")
+	testuser11 := &user.User{ID: 11, Username: "user11"}
+
+	t.Run("List credentials", func(t *testing.T) {
+		rec, err := newTestRequestWithUser(t, http.MethodGet, apiv1.UserListWebAuthnCredentials, testuser11, "", nil, nil)
+		assert.NoError(t, err)
+		assert.Contains(t, rec.Body.String(), `"name":"Test key"`)
+		assert.NotContains(t, rec.Body.String(), "public_key")
+	})
+	t.Run("List credentials of a user without any", func(t *testing.T) {
+		rec, err := newTestRequestWithUser(t, http.MethodGet, apiv1.UserListWebAuthnCredentials, &testuser1, "", nil, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "[]\n", rec.Body.String())
+	})
+	t.Run("Begin registration", func(t *testing.T) {
+		rec, err := newTestRequestWithUser(t, http.MethodPost, apiv1.UserBeginWebAuthnRegistration, testuser11, "", nil, nil)
+		assert.NoError(t, err)
+		assert.Contains(t, rec.Body.String(), `"challenge"`)
+		// The already registered key should be excluded
+		assert.Contains(t, rec.Body.String(), `"excludeCredentials"`)
+		assert.Contains(t, rec.Body.String(), "dXNlcjExLXNlY3VyaXR5LWtleQ")
+	})
+	t.Run("Finish registration with invalid response", func(t *testing.T) {
+		_, err := newTestRequestWithUser(t, http.MethodPost, apiv1.UserFinishWebAuthnRegistration, testuser11, `{"name":"key","credential":{"id":"invalid"}}`, nil, nil)
+		assert.Error(t, err)
+		assertHandlerErrorCode(t, err, user.ErrCodeInvalidWebAuthnResponse)
+	})
+	t.Run("Delete credential", func(t *testing.T) {
+		rec, err := newTestRequestWithUser(t, http.MethodDelete, apiv1.UserDeleteWebAuthnCredential, testuser11, "", nil, map[string]string{"credential": "1"})
+		assert.NoError(t, err)
+		assert.Contains(t, rec.Body.String(), "successfully")
+		db.AssertMissing(t, "webauthn_credentials", map[string]interface{}{
+			"id": 1,
+		})
+	})
+	t.Run("Delete credential of another user", func(t *testing.T) {
+		_, err := newTestRequestWithUser(t, http.MethodDelete, apiv1.UserDeleteWebAuthnCredential, &testuser1, "", nil, map[string]string{"credential": "1"})
+		assert.Error(t, err)
+		assertHandlerErrorCode(t, err, user.ErrCodeWebAuthnCredentialDoesNotExist)
+	})
+	t.Run("Begin login", func(t *testing.T) {
+		rec, err := newTestRequest(t, http.MethodPost, apiv1.BeginWebAuthnLogin, `{"username":"user11"}`)
+		assert.NoError(t, err)
+		assert.Contains(t, rec.Body.String(), `"challenge"`)
+		assert.Contains(t, rec.Body.String(), `"allowCredentials"`)
+		assert.Contains(t, rec.Body.String(), "dXNlcjExLXNlY3VyaXR5LWtleQ")
+	})
+	t.Run("Begin login for a user without credentials", func(t *testing.T) {
+		_, err := newTestRequest(t, http.MethodPost, apiv1.BeginWebAuthnLogin, `{"username":"user1"}`)
+		assert.Error(t, err)
+		assertHandlerErrorCode(t, err, user.ErrCodeNoWebAuthnCredentials)
+	})
+	t.Run("Begin login for a nonexisting user", func(t *testing.T) {
+		_, err := newTestRequest(t, http.MethodPost, apiv1.BeginWebAuthnLogin, `{"username":"nonexisting"}`)
+		assert.Error(t, err)
+		assertHandlerErrorCode(t, err, user.ErrCodeWrongUsernameOrPassword)
+	})
+	t.Run("Passwordless login with invalid response", func(t *testing.T) {
+		_, err := newTestRequest(t, http.MethodPost, apiv1.LoginWithWebAuthn, `{"username":"user11","credential":{"id":"invalid"}}`)
+		assert.Error(t, err)
+		assertHandlerErrorCode(t, err, user.ErrCodeInvalidWebAuthnResponse)
+	})
+}
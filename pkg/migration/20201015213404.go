@@ -0,0 +1,55 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package migration
+
+import (
+	"time"
+
+	"src.techknowlogick.com/xormigrate"
+	"xorm.io/xorm"
+)
+
+type taskReminders20201015213404 struct {
+	Notified bool `xorm:"INDEX not null default false"`
+}
+
+func (taskReminders20201015213404) TableName() string {
+	return "task_reminders"
+}
+
+func init() {
+	migrations = append(migrations, &xormigrate.Migration{
+		ID:          "20201015213404",
+		Description: "Add notified flag to task reminders",
+		Migrate: func(tx *xorm.Engine) error {
+			err := tx.Sync2(taskReminders20201015213404{})
+			if err != nil {
+				return err
+			}
+
+			// All reminders which are already in the past should not be sent out once the reminder daemon starts.
+			_, err = tx.
+				Where("reminder <= ?", time.Now()).
+				Cols("notified").
+				Update(&taskReminders20201015213404{Notified: true})
+			return err
+		},
+		Rollback: func(tx *xorm.Engine) error {
+			return nil
+		},
+	})
+}
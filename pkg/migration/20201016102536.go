@@ -0,0 +1,71 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package migration
+
+import (
+	"time"
+
+	"src.techknowlogick.com/xormigrate"
+	"xorm.io/xorm"
+)
+
+type webhooks20201016102536 struct {
+	ID          int64     `xorm:"int(11) autoincr not null unique pk"`
+	TargetURL   string    `xorm:"varchar(500) not null"`
+	Events      []string  `xorm:"JSON not null"`
+	Secret      string    `xorm:"varchar(250) not null"`
+	ListID      int64     `xorm:"int(11) null INDEX"`
+	NamespaceID int64     `xorm:"int(11) null INDEX"`
+	CreatedByID int64     `xorm:"int(11) not null"`
+	Created     time.Time `xorm:"created not null"`
+	Updated     time.Time `xorm:"updated not null"`
+}
+
+func (webhooks20201016102536) TableName() string {
+	return "webhooks"
+}
+
+type webhookDeliveries20201016102536 struct {
+	ID           int64     `xorm:"int(11) autoincr not null unique pk"`
+	WebhookID    int64     `xorm:"int(11) not null INDEX"`
+	DeliveryUID  string    `xorm:"varchar(40) not null INDEX"`
+	EventName    string    `xorm:"varchar(250) not null"`
+	Attempt      int       `xorm:"int(11) not null"`
+	Payload      string    `xorm:"longtext not null"`
+	StatusCode   int       `xorm:"int(11) not null default 0"`
+	ResponseBody string    `xorm:"longtext null"`
+	Error        string    `xorm:"text null"`
+	Success      bool      `xorm:"not null default false"`
+	Created      time.Time `xorm:"created not null"`
+}
+
+func (webhookDeliveries20201016102536) TableName() string {
+	return "webhook_deliveries"
+}
+
+func init() {
+	migrations = append(migrations, &xormigrate.Migration{
+		ID:          "20201016102536",
+		Description: "Add webhooks and webhook deliveries tables",
+		Migrate: func(tx *xorm.Engine) error {
+			return tx.Sync2(webhooks20201016102536{}, webhookDeliveries20201016102536{})
+		},
+		Rollback: func(tx *xorm.Engine) error {
+			return tx.DropTables(webhooks20201016102536{}, webhookDeliveries20201016102536{})
+		},
+	})
+}
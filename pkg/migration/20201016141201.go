@@ -0,0 +1,51 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package migration
+
+import (
+	"time"
+
+	"src.techknowlogick.com/xormigrate"
+	"xorm.io/xorm"
+)
+
+type notifications20201016141201 struct {
+	ID      int64       `xorm:"int(11) autoincr not null unique pk"`
+	UserID  int64       `xorm:"int(11) not null INDEX"`
+	Name    string      `xorm:"varchar(250) not null"`
+	Data    interface{} `xorm:"JSON not null"`
+	Read    bool        `xorm:"not null default false INDEX 'is_read'"`
+	ReadAt  time.Time   `xorm:"DATETIME null"`
+	Created time.Time   `xorm:"created not null"`
+}
+
+func (notifications20201016141201) TableName() string {
+	return "notifications"
+}
+
+func init() {
+	migrations = append(migrations, &xormigrate.Migration{
+		ID:          "20201016141201",
+		Description: "Add notifications table",
+		Migrate: func(tx *xorm.Engine) error {
+			return tx.Sync2(notifications20201016141201{})
+		},
+		Rollback: func(tx *xorm.Engine) error {
+			return tx.DropTables(notifications20201016141201{})
+		},
+	})
+}
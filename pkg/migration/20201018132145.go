@@ -0,0 +1,51 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package migration
+
+import (
+	"time"
+
+	"src.techknowlogick.com/xormigrate"
+	"xorm.io/xorm"
+)
+
+type sessions20201018132145 struct {
+	ID               int64     `xorm:"int(11) autoincr not null unique pk"`
+	UserID           int64     `xorm:"int(11) not null INDEX"`
+	RefreshTokenHash string    `xorm:"varchar(64) not null unique"`
+	DeviceInfo       string    `xorm:"text null"`
+	IPAddress        string    `xorm:"varchar(100) null"`
+	LastActive       time.Time `xorm:"DATETIME not null"`
+	Created          time.Time `xorm:"created not null"`
+}
+
+func (sessions20201018132145) TableName() string {
+	return "sessions"
+}
+
+func init() {
+	migrations = append(migrations, &xormigrate.Migration{
+		ID:          "20201018132145",
+		Description: "Add sessions table",
+		Migrate: func(tx *xorm.Engine) error {
+			return tx.Sync2(sessions20201018132145{})
+		},
+		Rollback: func(tx *xorm.Engine) error {
+			return tx.DropTables(sessions20201018132145{})
+		},
+	})
+}
@@ -0,0 +1,48 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package migration
+
+import (
+	"time"
+
+	"src.techknowlogick.com/xormigrate"
+	"xorm.io/xorm"
+)
+
+type linkSharing20201018171530 struct {
+	Name               string    `xorm:"text null"`
+	ExpiresAt          time.Time `xorm:"DATETIME null"`
+	MaxAuthentications int64     `xorm:"int(11) not null default 0"`
+	Authentications    int64     `xorm:"int(11) not null default 0"`
+}
+
+func (linkSharing20201018171530) TableName() string {
+	return "link_sharing"
+}
+
+func init() {
+	migrations = append(migrations, &xormigrate.Migration{
+		ID:          "20201018171530",
+		Description: "Add name, expiry and authentication limit to link shares",
+		Migrate: func(tx *xorm.Engine) error {
+			return tx.Sync2(linkSharing20201018171530{})
+		},
+		Rollback: func(tx *xorm.Engine) error {
+			return nil
+		},
+	})
+}
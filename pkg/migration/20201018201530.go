@@ -0,0 +1,67 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package migration
+
+import (
+	"time"
+
+	"src.techknowlogick.com/xormigrate"
+	"xorm.io/xorm"
+)
+
+type webauthnCredentials20201018201530 struct {
+	ID              int64     `xorm:"int(11) autoincr not null unique pk"`
+	UserID          int64     `xorm:"int(11) not null INDEX"`
+	Name            string    `xorm:"varchar(250) not null"`
+	CredentialID    string    `xorm:"varchar(500) not null unique"`
+	PublicKey       string    `xorm:"text not null"`
+	AAGUID          string    `xorm:"varchar(50) null"`
+	AttestationType string    `xorm:"varchar(50) null"`
+	SignCount       int64     `xorm:"bigint not null default 0"`
+	LastUsed        time.Time `xorm:"DATETIME null"`
+	Created         time.Time `xorm:"created not null"`
+}
+
+func (webauthnCredentials20201018201530) TableName() string {
+	return "webauthn_credentials"
+}
+
+type webauthnChallenges20201018201530 struct {
+	ID          int64     `xorm:"int(11) autoincr not null unique pk"`
+	UserID      int64     `xorm:"int(11) not null INDEX"`
+	Challenge   string    `xorm:"varchar(250) not null unique"`
+	Ceremony    string    `xorm:"varchar(20) not null"`
+	SessionData string    `xorm:"text not null"`
+	Created     time.Time `xorm:"created not null"`
+}
+
+func (webauthnChallenges20201018201530) TableName() string {
+	return "webauthn_challenges"
+}
+
+func init() {
+	migrations = append(migrations, &xormigrate.Migration{
+		ID:          "20201018201530",
+		Description: "Add webauthn credentials and challenges tables",
+		Migrate: func(tx *xorm.Engine) error {
+			return tx.Sync2(webauthnCredentials20201018201530{}, webauthnChallenges20201018201530{})
+		},
+		Rollback: func(tx *xorm.Engine) error {
+			return tx.DropTables(webauthnCredentials20201018201530{}, webauthnChallenges20201018201530{})
+		},
+	})
+}
@@ -0,0 +1,65 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package migration
+
+import (
+	"time"
+
+	"src.techknowlogick.com/xormigrate"
+	"xorm.io/xorm"
+)
+
+type customFields20201020140000 struct {
+	ID      int64     `xorm:"int(11) autoincr not null unique pk"`
+	ListID  int64     `xorm:"int(11) not null INDEX"`
+	Name    string    `xorm:"varchar(100) not null"`
+	Title   string    `xorm:"varchar(250) not null"`
+	Type    string    `xorm:"varchar(20) not null"`
+	Options []string  `xorm:"JSON null"`
+	Created time.Time `xorm:"created not null"`
+	Updated time.Time `xorm:"updated not null"`
+}
+
+func (customFields20201020140000) TableName() string {
+	return "custom_fields"
+}
+
+type taskCustomFieldValues20201020140000 struct {
+	ID          int64     `xorm:"int(11) autoincr not null unique pk"`
+	TaskID      int64     `xorm:"int(11) not null INDEX unique(task_field)"`
+	FieldID     int64     `xorm:"int(11) not null INDEX unique(task_field)"`
+	ValueText   string    `xorm:"text null"`
+	ValueNumber float64   `xorm:"DOUBLE null"`
+	ValueDate   time.Time `xorm:"DATETIME null 'value_date'"`
+}
+
+func (taskCustomFieldValues20201020140000) TableName() string {
+	return "task_custom_field_values"
+}
+
+func init() {
+	migrations = append(migrations, &xormigrate.Migration{
+		ID:          "20201020140000",
+		Description: "Add custom fields and their values on tasks",
+		Migrate: func(tx *xorm.Engine) error {
+			return tx.Sync2(customFields20201020140000{}, taskCustomFieldValues20201020140000{})
+		},
+		Rollback: func(tx *xorm.Engine) error {
+			return tx.DropTables(customFields20201020140000{}, taskCustomFieldValues20201020140000{})
+		},
+	})
+}
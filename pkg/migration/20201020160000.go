@@ -0,0 +1,53 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package migration
+
+import (
+	"time"
+
+	"src.techknowlogick.com/xormigrate"
+	"xorm.io/xorm"
+)
+
+type listTemplates20201020160000 struct {
+	ID          int64       `xorm:"int(11) autoincr not null unique pk"`
+	Title       string      `xorm:"varchar(250) not null"`
+	Description string      `xorm:"longtext null"`
+	HexColor    string      `xorm:"varchar(6) null"`
+	OwnerID     int64       `xorm:"int(11) not null INDEX"`
+	Buckets     interface{} `xorm:"JSON null"`
+	Tasks       interface{} `xorm:"JSON null"`
+	Created     time.Time   `xorm:"created not null"`
+	Updated     time.Time   `xorm:"updated not null"`
+}
+
+func (listTemplates20201020160000) TableName() string {
+	return "list_templates"
+}
+
+func init() {
+	migrations = append(migrations, &xormigrate.Migration{
+		ID:          "20201020160000",
+		Description: "Add list templates",
+		Migrate: func(tx *xorm.Engine) error {
+			return tx.Sync2(listTemplates20201020160000{})
+		},
+		Rollback: func(tx *xorm.Engine) error {
+			return tx.DropTables(listTemplates20201020160000{})
+		},
+	})
+}
@@ -0,0 +1,298 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"time"
+
+	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+)
+
+// Activity is a single entry in the activity stream of a task. Activities are only ever created, never changed.
+type Activity struct {
+	// The unique, numeric id of this activity.
+	ID int64 `xorm:"int(11) autoincr not null unique pk" json:"id"`
+	// The task this activity belongs to.
+	TaskID int64 `xorm:"int(11) not null INDEX" json:"task_id" param:"listtask"`
+	// The list the task was in when the activity happened.
+	ListID int64 `xorm:"int(11) not null INDEX" json:"list_id" param:"list"`
+
+	// What happened, for example "task.updated" or "task.label.created".
+	Kind string `xorm:"varchar(50) not null" json:"kind"`
+	// All fields which were changed with their old and new values.
+	Changes []*ActivityChange `xorm:"JSON null" json:"changes"`
+
+	// The id of the user who did the change. Negative if it was done through a link share.
+	DoerID int64 `xorm:"int(11) not null default 0" json:"-"`
+	// The user who did the change. Empty if the change was done through a link share or not through the api.
+	Doer *user.User `xorm:"-" json:"doer"`
+
+	// A timestamp when this activity happened. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TableName returns the table name for activities
+func (a *Activity) TableName() string {
+	return "activities"
+}
+
+// ActivityChange holds the change of a single field
+type ActivityChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// Returns the value we save in the activity for a date, nil if it is not set
+func activityTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
+
+// Returns all fields which differ between the old and the new version of a task
+func getTaskChanges(oldTask, newTask *Task) (changes []*ActivityChange) {
+	add := func(field string, o, n interface{}) {
+		changes = append(changes, &ActivityChange{Field: field, Old: o, New: n})
+	}
+
+	if oldTask.Title != newTask.Title {
+		add("title", oldTask.Title, newTask.Title)
+	}
+	// The description can get really long, we only record that it was changed
+	if oldTask.Description != newTask.Description {
+		add("description", nil, nil)
+	}
+	if oldTask.Done != newTask.Done {
+		add("done", oldTask.Done, newTask.Done)
+	}
+	if !oldTask.DueDate.Equal(newTask.DueDate) {
+		add("due_date", activityTime(oldTask.DueDate), activityTime(newTask.DueDate))
+	}
+	if !oldTask.StartDate.Equal(newTask.StartDate) {
+		add("start_date", activityTime(oldTask.StartDate), activityTime(newTask.StartDate))
+	}
+	if !oldTask.EndDate.Equal(newTask.EndDate) {
+		add("end_date", activityTime(oldTask.EndDate), activityTime(newTask.EndDate))
+	}
+	if oldTask.Priority != newTask.Priority {
+		add("priority", oldTask.Priority, newTask.Priority)
+	}
+	if oldTask.PercentDone != newTask.PercentDone {
+		add("percent_done", oldTask.PercentDone, newTask.PercentDone)
+	}
+	if oldTask.Estimate != newTask.Estimate {
+		add("estimate", oldTask.Estimate, newTask.Estimate)
+	}
+	if oldTask.Points != newTask.Points {
+		add("points", oldTask.Points, newTask.Points)
+	}
+	if oldTask.BucketID != newTask.BucketID {
+		add("bucket_id", oldTask.BucketID, newTask.BucketID)
+	}
+	if oldTask.ListID != newTask.ListID {
+		add("list_id", oldTask.ListID, newTask.ListID)
+	}
+
+	return
+}
+
+func newActivityUser(u *user.User) map[string]interface{} {
+	return map[string]interface{}{
+		"id":       u.ID,
+		"username": u.Username,
+	}
+}
+
+func newActivityLabel(l *Label) map[string]interface{} {
+	return map[string]interface{}{
+		"id":    l.ID,
+		"title": l.Title,
+	}
+}
+
+func newActivityAttachment(a *TaskAttachment) map[string]interface{} {
+	snapshot := map[string]interface{}{
+		"id": a.ID,
+	}
+	if a.File != nil {
+		snapshot["file_name"] = a.File.Name
+	}
+	return snapshot
+}
+
+func newActivityRelation(r *TaskRelation) map[string]interface{} {
+	return map[string]interface{}{
+		"other_task_id": r.OtherTaskID,
+		"relation_kind": r.RelationKind,
+	}
+}
+
+// Builds the activity for an event. Returns nil if there is nothing to record.
+func newActivityFromEvent(event events.Event) *Activity {
+	var task *Task
+	var doer web.Auth
+	var changes []*ActivityChange
+
+	switch e := event.(type) {
+	case *TaskCreatedEvent:
+		task, doer = e.Task, e.Doer
+		changes = []*ActivityChange{{Field: "title", New: e.Task.Title}}
+	case *TaskUpdatedEvent:
+		task, doer = e.Task, e.Doer
+		changes = getTaskChanges(e.OldTask, e.Task)
+		if len(changes) == 0 {
+			return nil
+		}
+	case *TaskDeletedEvent:
+		task, doer = e.Task, e.Doer
+		changes = []*ActivityChange{{Field: "title", Old: e.Task.Title}}
+	case *TaskAssigneeCreatedEvent:
+		task, doer = e.Task, e.Doer
+		changes = []*ActivityChange{{Field: "assignees", New: newActivityUser(e.Assignee)}}
+	case *TaskAssigneeDeletedEvent:
+		task, doer = e.Task, e.Doer
+		changes = []*ActivityChange{{Field: "assignees", Old: newActivityUser(e.Assignee)}}
+	case *TaskLabelCreatedEvent:
+		task, doer = e.Task, e.Doer
+		changes = []*ActivityChange{{Field: "labels", New: newActivityLabel(e.Label)}}
+	case *TaskLabelDeletedEvent:
+		task, doer = e.Task, e.Doer
+		changes = []*ActivityChange{{Field: "labels", Old: newActivityLabel(e.Label)}}
+	case *TaskAttachmentCreatedEvent:
+		task, doer = e.Task, e.Doer
+		changes = []*ActivityChange{{Field: "attachments", New: newActivityAttachment(e.Attachment)}}
+	case *TaskAttachmentDeletedEvent:
+		task, doer = e.Task, e.Doer
+		changes = []*ActivityChange{{Field: "attachments", Old: newActivityAttachment(e.Attachment)}}
+	case *TaskRelationCreatedEvent:
+		task, doer = e.Task, e.Doer
+		changes = []*ActivityChange{{Field: "relations", New: newActivityRelation(e.Relation)}}
+	case *TaskRelationDeletedEvent:
+		task, doer = e.Task, e.Doer
+		changes = []*ActivityChange{{Field: "relations", Old: newActivityRelation(e.Relation)}}
+	default:
+		return nil
+	}
+
+	activity := &Activity{
+		TaskID:  task.ID,
+		ListID:  task.ListID,
+		Kind:    event.Name(),
+		Changes: changes,
+	}
+	if doer != nil {
+		activity.DoerID = doer.GetID()
+		if _, is := doer.(*LinkSharing); is {
+			activity.DoerID *= -1
+		}
+	}
+	return activity
+}
+
+// ReadAll returns the activity stream of a task or a list
+// @Summary Get the activity of a task or list
+// @Description Returns everything that happened to a task or to all tasks of a list, newest first. Changes done through a link share have no doer.
+// @tags task
+// @Produce json
+// @Security JWTKeyAuth
+// @Param listtask path int false "Task ID"
+// @Param list path int false "List ID"
+// @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
+// @Param per_page query int false "The maximum number of items per page. Note this parameter is limited by the configured maximum of items per page."
+// @Success 200 {array} models.Activity "The activities"
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task or list."
+// @Failure 404 {object} web.HTTPError "The task or list does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{listtask}/activity [get]
+// @Router /lists/{list}/activity [get]
+func (a *Activity) ReadAll(auth web.Auth, search string, page int, perPage int) (result interface{}, resultCount int, totalItems int64, err error) {
+	canRead, _, err := a.CanRead(auth)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if !canRead {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	cond := "list_id = ?"
+	id := a.ListID
+	if a.TaskID != 0 {
+		cond = "task_id = ?"
+		id = a.TaskID
+	}
+
+	limit, start := getLimitFromPageIndex(page, perPage)
+
+	activities := []*Activity{}
+	query := x.
+		Where(cond, id).
+		OrderBy("id desc")
+	if limit > 0 {
+		query = query.Limit(limit, start)
+	}
+	err = query.Find(&activities)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	err = addDoersToActivities(activities)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	totalItems, err = x.
+		Where(cond, id).
+		Count(&Activity{})
+	return activities, len(activities), totalItems, err
+}
+
+func addDoersToActivities(activities []*Activity) (err error) {
+	userIDs := make([]int64, 0, len(activities))
+	for _, a := range activities {
+		if a.DoerID > 0 {
+			userIDs = append(userIDs, a.DoerID)
+		}
+	}
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	users := make(map[int64]*user.User, len(userIDs))
+	err = x.In("id", userIDs).Find(&users)
+	if err != nil {
+		return
+	}
+
+	for _, a := range activities {
+		if u, has := users[a.DoerID]; has {
+			a.Doer = &user.User{
+				ID:       u.ID,
+				Username: u.Username,
+				Created:  u.Created,
+				Updated:  u.Updated,
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetTaskChanges(t *testing.T) {
+	t.Run("changed fields", func(t *testing.T) {
+		due := time.Date(2020, 10, 17, 12, 0, 0, 0, time.UTC)
+		oldTask := &Task{Title: "old", Done: false, BucketID: 1}
+		newTask := &Task{Title: "new", Done: true, BucketID: 2, DueDate: due}
+
+		changes := getTaskChanges(oldTask, newTask)
+		assert.Len(t, changes, 4)
+		assert.Equal(t, &ActivityChange{Field: "title", Old: "old", New: "new"}, changes[0])
+		assert.Equal(t, &ActivityChange{Field: "done", Old: false, New: true}, changes[1])
+		assert.Equal(t, &ActivityChange{Field: "due_date", Old: nil, New: due}, changes[2])
+		assert.Equal(t, &ActivityChange{Field: "bucket_id", Old: int64(1), New: int64(2)}, changes[3])
+	})
+	t.Run("nothing changed", func(t *testing.T) {
+		oldTask := &Task{Title: "task", Description: "Lorem Ipsum"}
+		newTask := &Task{Title: "task", Description: "Lorem Ipsum"}
+		assert.Len(t, getTaskChanges(oldTask, newTask), 0)
+	})
+}
+
+func TestSaveTaskActivity_Handle(t *testing.T) {
+	t.Run("task updated", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		err := (&SaveTaskActivity{}).Handle(&TaskUpdatedEvent{
+			OldTask: &Task{ID: 32, ListID: 3, Title: "old"},
+			Task:    &Task{ID: 32, ListID: 3, Title: "new"},
+			Doer:    &user.User{ID: 1},
+		})
+		assert.NoError(t, err)
+		db.AssertExists(t, "activities", map[string]interface{}{
+			"task_id": 32,
+			"list_id": 3,
+			"kind":    "task.updated",
+			"doer_id": 1,
+		}, false)
+	})
+	t.Run("task updated without changes", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		err := (&SaveTaskActivity{}).Handle(&TaskUpdatedEvent{
+			OldTask: &Task{ID: 32, ListID: 3, Title: "task"},
+			Task:    &Task{ID: 32, ListID: 3, Title: "task"},
+			Doer:    &user.User{ID: 1},
+		})
+		assert.NoError(t, err)
+		db.AssertMissing(t, "activities", map[string]interface{}{
+			"task_id": 32,
+			"doer_id": 1,
+		})
+	})
+	t.Run("label added through link share", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		err := (&SaveTaskActivity{}).Handle(&TaskLabelCreatedEvent{
+			Task:  &Task{ID: 32, ListID: 3},
+			Label: &Label{ID: 1, Title: "Label #1"},
+			Doer:  &LinkSharing{ID: 3},
+		})
+		assert.NoError(t, err)
+		db.AssertExists(t, "activities", map[string]interface{}{
+			"task_id": 32,
+			"kind":    "task.label.created",
+			"doer_id": -3,
+		}, false)
+	})
+}
+
+func TestActivity_ReadAll(t *testing.T) {
+	t.Run("task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		a := &Activity{TaskID: 1}
+		result, count, total, err := a.ReadAll(&user.User{ID: 1}, "", 0, 50)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, count)
+		assert.Equal(t, int64(2), total)
+		activities := result.([]*Activity)
+		// Newest first
+		assert.Equal(t, int64(2), activities[0].ID)
+		assert.Nil(t, activities[0].Doer)
+		assert.Equal(t, int64(1), activities[1].ID)
+		assert.Equal(t, "user1", activities[1].Doer.Username)
+		assert.Equal(t, "", activities[1].Doer.Email)
+		assert.Equal(t, "title", activities[1].Changes[0].Field)
+	})
+	t.Run("list", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		a := &Activity{ListID: 1}
+		result, count, total, err := a.ReadAll(&user.User{ID: 1}, "", 0, 50)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, count)
+		assert.Equal(t, int64(3), total)
+		assert.Equal(t, int64(3), result.([]*Activity)[0].ID)
+	})
+	t.Run("pagination", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		a := &Activity{ListID: 1}
+		result, count, total, err := a.ReadAll(&user.User{ID: 1}, "", 2, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, count)
+		assert.Equal(t, int64(3), total)
+		assert.Equal(t, int64(1), result.([]*Activity)[0].ID)
+	})
+	t.Run("no access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		a := &Activity{TaskID: 1}
+		_, _, _, err := a.ReadAll(&user.User{ID: 2}, "", 0, 50)
+		assert.Error(t, err)
+		assert.True(t, IsErrGenericForbidden(err))
+	})
+	t.Run("link share", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		a := &Activity{ListID: 3}
+		_, count, _, err := a.ReadAll(&LinkSharing{ID: 3, ListID: 3, Right: RightRead}, "", 0, 50)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, count)
+	})
+	t.Run("link share of another list", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		a := &Activity{TaskID: 1}
+		_, _, _, err := a.ReadAll(&LinkSharing{ID: 3, ListID: 3, Right: RightRead}, "", 0, 50)
+		assert.Error(t, err)
+		assert.True(t, IsErrGenericForbidden(err))
+	})
+	t.Run("nonexisting task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		a := &Activity{TaskID: 99999}
+		_, _, _, err := a.ReadAll(&user.User{ID: 1}, "", 0, 50)
+		assert.Error(t, err)
+		assert.True(t, IsErrTaskDoesNotExist(err))
+	})
+}
@@ -0,0 +1,277 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+)
+
+// APITokenPrefix is the prefix of all api tokens. It is used to tell them apart from jwt tokens.
+const APITokenPrefix = `tk_`
+
+// All levels a scope can have. A higher level includes all lower ones.
+const (
+	APITokenScopeLevelRead  = `read`
+	APITokenScopeLevelWrite = `write`
+	APITokenScopeLevelAdmin = `admin`
+)
+
+var apiTokenScopeLevels = map[string]int{
+	APITokenScopeLevelRead:  1,
+	APITokenScopeLevelWrite: 2,
+	APITokenScopeLevelAdmin: 3,
+}
+
+// All groups of routes a token can have scopes for and the highest level available for them.
+// Only lists and namespaces have an admin level, it is needed to manage their shares and webhooks.
+var apiTokenScopeGroups = map[string]string{
+	"tasks":         APITokenScopeLevelWrite,
+	"lists":         APITokenScopeLevelAdmin,
+	"namespaces":    APITokenScopeLevelAdmin,
+	"labels":        APITokenScopeLevelWrite,
+	"teams":         APITokenScopeLevelWrite,
+	"filters":       APITokenScopeLevelWrite,
+	"notifications": APITokenScopeLevelWrite,
+	"users":         APITokenScopeLevelRead,
+}
+
+// APIToken is a long-lived token a user can create to access the api from scripts without a password
+type APIToken struct {
+	// The unique, numeric id of this api token.
+	ID int64 `xorm:"int(11) autoincr not null unique pk" json:"id" param:"token"`
+	// A human-readable name for this token.
+	Title string `xorm:"varchar(250) not null" json:"title" valid:"required,runelength(1|250)" minLength:"1" maxLength:"250"`
+	// The token itself. It is only returned once when the token is created, Vikunja only stores its hash.
+	Token     string `xorm:"-" json:"token,omitempty"`
+	TokenHash string `xorm:"varchar(64) not null unique" json:"-"`
+	// The last eight characters of the token to help users tell their tokens apart.
+	TokenLastEight string `xorm:"varchar(8) not null" json:"token_last_eight"`
+	// The scopes of this token, for example "tasks:read" or "lists:admin". Every request made with this token needs
+	// one of them.
+	Scopes []string `xorm:"JSON not null" json:"scopes"`
+	// The date after which the token is no longer valid. Every token needs one.
+	ExpiresAt time.Time `xorm:"DATETIME not null" json:"expires_at"`
+	// When this token was last used to make a request.
+	LastUsedAt time.Time `xorm:"DATETIME null" json:"last_used_at"`
+
+	OwnerID int64 `xorm:"int(11) not null INDEX" json:"-"`
+
+	// A timestamp when this token was created. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TableName returns the table name for api tokens
+func (t *APIToken) TableName() string {
+	return "api_tokens"
+}
+
+func hashAPIToken(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(h[:])
+}
+
+// Api tokens must not be guessable, they therefore use crypto/rand and not the random string util
+func newAPITokenString() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return APITokenPrefix + hex.EncodeToString(b), nil
+}
+
+func validateAPITokenScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return ErrInvalidAPITokenScope{}
+	}
+
+	for _, scope := range scopes {
+		parts := strings.Split(scope, ":")
+		if len(parts) != 2 {
+			return ErrInvalidAPITokenScope{Scope: scope}
+		}
+		maxLevel, exists := apiTokenScopeGroups[parts[0]]
+		if !exists {
+			return ErrInvalidAPITokenScope{Scope: scope}
+		}
+		level, exists := apiTokenScopeLevels[parts[1]]
+		if !exists || level > apiTokenScopeLevels[maxLevel] {
+			return ErrInvalidAPITokenScope{Scope: scope}
+		}
+	}
+
+	return nil
+}
+
+// HasScope checks if the token has a scope for the group with at least the given level
+func (t *APIToken) HasScope(group, level string) bool {
+	for _, scope := range t.Scopes {
+		parts := strings.Split(scope, ":")
+		if len(parts) != 2 || parts[0] != group {
+			continue
+		}
+		if apiTokenScopeLevels[parts[1]] >= apiTokenScopeLevels[level] {
+			return true
+		}
+	}
+	return false
+}
+
+// GetAPITokenAndUser returns the api token and its owner if the token is valid and not expired.
+// It also saves when the token was last used.
+func GetAPITokenAndUser(token string) (t *APIToken, u *user.User, err error) {
+	t = &APIToken{}
+	exists, err := x.Where("token_hash = ?", hashAPIToken(token)).Get(t)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !exists || t.ExpiresAt.Before(time.Now()) {
+		return nil, nil, ErrAPITokenInvalid{}
+	}
+
+	u, err = user.GetUserWithEmail(&user.User{ID: t.OwnerID})
+	if err != nil {
+		return nil, nil, err
+	}
+	if !u.IsActive {
+		return nil, nil, ErrAPITokenInvalid{}
+	}
+
+	// Only saving it once per minute avoids a database write for every request
+	if time.Since(t.LastUsedAt) > time.Minute {
+		t.LastUsedAt = time.Now()
+		_, err = x.ID(t.ID).Cols("last_used_at").Update(t)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return
+}
+
+// Create creates a new api token
+// @Summary Create a new api token
+// @Description Creates a new api token for the current user. The token itself is only returned once in the response, make sure to save it.
+// @tags user
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param token body models.APIToken true "The api token with its title, scopes and expiry date."
+// @Success 200 {object} models.APIToken "The created api token, including the token itself."
+// @Failure 400 {object} web.HTTPError "Invalid scopes or expiry date."
+// @Failure 403 {object} web.HTTPError "Link shares cannot create api tokens."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /user/settings/tokens [put]
+func (t *APIToken) Create(a web.Auth) (err error) {
+	err = validateAPITokenScopes(t.Scopes)
+	if err != nil {
+		return err
+	}
+
+	if t.ExpiresAt.Before(time.Now()) {
+		return ErrInvalidAPITokenExpiry{}
+	}
+
+	t.ID = 0
+	t.OwnerID = a.GetID()
+	t.LastUsedAt = time.Time{}
+	t.Token, err = newAPITokenString()
+	if err != nil {
+		return err
+	}
+	t.TokenHash = hashAPIToken(t.Token)
+	t.TokenLastEight = t.Token[len(t.Token)-8:]
+
+	_, err = x.Insert(t)
+	return
+}
+
+// ReadAll returns all api tokens of the current user
+// @Summary Get all api tokens of the current user
+// @Description Returns all api tokens of the current user. The tokens themselves are never returned.
+// @tags user
+// @Produce json
+// @Security JWTKeyAuth
+// @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
+// @Param per_page query int false "The maximum number of items per page. Note this parameter is limited by the configured maximum of items per page."
+// @Success 200 {array} models.APIToken "The api tokens"
+// @Failure 403 {object} web.HTTPError "Link shares cannot have api tokens."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /user/settings/tokens [get]
+func (t *APIToken) ReadAll(a web.Auth, search string, page int, perPage int) (result interface{}, resultCount int, totalItems int64, err error) {
+	if _, is := a.(*LinkSharing); is {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	limit, start := getLimitFromPageIndex(page, perPage)
+
+	tokens := []*APIToken{}
+	query := x.
+		Where("owner_id = ?", a.GetID()).
+		OrderBy("id asc")
+	if limit > 0 {
+		query = query.Limit(limit, start)
+	}
+	err = query.Find(&tokens)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	totalItems, err = x.
+		Where("owner_id = ?", a.GetID()).
+		Count(&APIToken{})
+	return tokens, len(tokens), totalItems, err
+}
+
+// Delete revokes an api token
+// @Summary Revoke an api token
+// @Description Deletes an api token. It can't be used anymore afterwards.
+// @tags user
+// @Produce json
+// @Security JWTKeyAuth
+// @Param token path int true "Token ID"
+// @Success 200 {object} models.Message "The token was revoked successfully."
+// @Failure 403 {object} web.HTTPError "The user does not own this token."
+// @Failure 404 {object} web.HTTPError "The token does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /user/settings/tokens/{token} [delete]
+func (t *APIToken) Delete() (err error) {
+	_, err = x.Where("id = ?", t.ID).Delete(&APIToken{})
+	return
+}
+
+func getAPITokenByID(id int64) (t *APIToken, err error) {
+	t = &APIToken{}
+	exists, err := x.Where("id = ?", id).Get(t)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrAPITokenDoesNotExist{TokenID: id}
+	}
+	return
+}
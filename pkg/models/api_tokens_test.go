@@ -0,0 +1,175 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIToken_Create(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		token := &APIToken{
+			Title:     "new token",
+			Scopes:    []string{"tasks:write", "lists:admin"},
+			ExpiresAt: time.Now().Add(time.Hour),
+		}
+		can, err := token.CanCreate(u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+		err = token.Create(u)
+		assert.NoError(t, err)
+		assert.Len(t, token.Token, len(APITokenPrefix)+64)
+		assert.Equal(t, token.Token[len(token.Token)-8:], token.TokenLastEight)
+		db.AssertExists(t, "api_tokens", map[string]interface{}{
+			"id":         token.ID,
+			"title":      "new token",
+			"owner_id":   1,
+			"token_hash": hashAPIToken(token.Token),
+		}, false)
+	})
+	t.Run("invalid scope", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		token := &APIToken{
+			Title:     "new token",
+			Scopes:    []string{"tasks:write", "nonexisting:read"},
+			ExpiresAt: time.Now().Add(time.Hour),
+		}
+		err := token.Create(u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidAPITokenScope(err))
+	})
+	t.Run("level not available for group", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		token := &APIToken{
+			Title:     "new token",
+			Scopes:    []string{"tasks:admin"},
+			ExpiresAt: time.Now().Add(time.Hour),
+		}
+		err := token.Create(u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidAPITokenScope(err))
+	})
+	t.Run("no scopes", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		token := &APIToken{
+			Title:     "new token",
+			ExpiresAt: time.Now().Add(time.Hour),
+		}
+		err := token.Create(u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidAPITokenScope(err))
+	})
+	t.Run("expiry in the past", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		token := &APIToken{
+			Title:     "new token",
+			Scopes:    []string{"tasks:read"},
+			ExpiresAt: time.Now().Add(-time.Hour),
+		}
+		err := token.Create(u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidAPITokenExpiry(err))
+	})
+	t.Run("link share", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		token := &APIToken{}
+		can, err := token.CanCreate(&LinkSharing{ID: 1})
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+}
+
+func TestAPIToken_ReadAll(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	token := &APIToken{}
+	tokens, _, total, err := token.ReadAll(&user.User{ID: 1}, "", 1, 50)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Len(t, tokens, 2)
+	assert.Equal(t, int64(1), tokens.([]*APIToken)[0].ID)
+	assert.Empty(t, tokens.([]*APIToken)[0].Token)
+}
+
+func TestAPIToken_Delete(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		token := &APIToken{ID: 1}
+		can, err := token.CanDelete(&user.User{ID: 1})
+		assert.NoError(t, err)
+		assert.True(t, can)
+		err = token.Delete()
+		assert.NoError(t, err)
+		db.AssertMissing(t, "api_tokens", map[string]interface{}{
+			"id": 1,
+		})
+	})
+	t.Run("other user's token", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		token := &APIToken{ID: 3}
+		can, err := token.CanDelete(&user.User{ID: 1})
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("nonexisting", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		token := &APIToken{ID: 9999}
+		_, err := token.CanDelete(&user.User{ID: 1})
+		assert.Error(t, err)
+		assert.True(t, IsErrAPITokenDoesNotExist(err))
+	})
+}
+
+func TestGetAPITokenAndUser(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		token, u, err := GetAPITokenAndUser("tk_testtoken010000000000000000000000000000000000000000000000000000")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), token.ID)
+		assert.Equal(t, int64(1), u.ID)
+		assert.False(t, token.LastUsedAt.IsZero())
+	})
+	t.Run("expired", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		_, _, err := GetAPITokenAndUser("tk_testtoken020000000000000000000000000000000000000000000000000000")
+		assert.Error(t, err)
+		assert.True(t, IsErrAPITokenInvalid(err))
+	})
+	t.Run("nonexisting", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		_, _, err := GetAPITokenAndUser("tk_nonexisting")
+		assert.Error(t, err)
+		assert.True(t, IsErrAPITokenInvalid(err))
+	})
+}
+
+func TestAPIToken_HasScope(t *testing.T) {
+	token := &APIToken{Scopes: []string{"tasks:read", "lists:admin"}}
+	assert.True(t, token.HasScope("tasks", APITokenScopeLevelRead))
+	assert.False(t, token.HasScope("tasks", APITokenScopeLevelWrite))
+	assert.True(t, token.HasScope("lists", APITokenScopeLevelRead))
+	assert.True(t, token.HasScope("lists", APITokenScopeLevelWrite))
+	assert.True(t, token.HasScope("lists", APITokenScopeLevelAdmin))
+	assert.False(t, token.HasScope("namespaces", APITokenScopeLevelRead))
+}
@@ -53,6 +53,7 @@ func (bt *BulkTask) checkIfTasksAreOnTheSameList() (err error) {
 
 // CanUpdate checks if a user is allowed to update a task
 func (bt *BulkTask) CanUpdate(a web.Auth) (bool, error) {
+	bt.doer = a
 
 	err := bt.checkIfTasksAreOnTheSameList()
 	if err != nil {
@@ -79,6 +80,14 @@ func (bt *BulkTask) CanUpdate(a web.Auth) (bool, error) {
 // @Router /tasks/bulk [post]
 func (bt *BulkTask) Update() (err error) {
 
+	if err = validateRepeatRule(&bt.Task); err != nil {
+		return
+	}
+
+	if err = validateTaskEstimate(&bt.Task); err != nil {
+		return
+	}
+
 	sess := x.NewSession()
 	defer sess.Close()
 
@@ -93,7 +102,7 @@ func (bt *BulkTask) Update() (err error) {
 		updateDone(oldtask, &bt.Task)
 
 		// Update the assignees
-		if err := oldtask.updateTaskAssignees(sess, bt.Assignees); err != nil {
+		if err := oldtask.updateTaskAssignees(sess, bt.Assignees, bt.doer); err != nil {
 			return err
 		}
 
@@ -116,7 +125,10 @@ func (bt *BulkTask) Update() (err error) {
 				"due_date",
 				"reminders",
 				"repeat_after",
+				"repeat_rule",
 				"priority",
+				"estimate",
+				"points",
 				"start_date",
 				"end_date").
 			Update(oldtask)
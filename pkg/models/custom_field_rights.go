@@ -0,0 +1,54 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import "code.vikunja.io/web"
+
+// CanRead checks if a user can see the custom fields of a list
+func (cf *CustomField) CanRead(a web.Auth) (bool, int, error) {
+	l := &List{ID: cf.ListID}
+	return l.CanRead(a)
+}
+
+// CanCreate checks if a user can add a custom field to a list. Only list admins can do this.
+func (cf *CustomField) CanCreate(a web.Auth) (bool, error) {
+	l := &List{ID: cf.ListID}
+	return l.IsAdmin(a)
+}
+
+// CanUpdate checks if a user can update a custom field
+func (cf *CustomField) CanUpdate(a web.Auth) (bool, error) {
+	return cf.canDoCustomField(a)
+}
+
+// CanDelete checks if a user can delete a custom field
+func (cf *CustomField) CanDelete(a web.Auth) (bool, error) {
+	return cf.canDoCustomField(a)
+}
+
+// canDoCustomField checks if the custom field exists in the list and if the user is an admin of it
+func (cf *CustomField) canDoCustomField(a web.Auth) (bool, error) {
+	field, err := getCustomFieldByID(cf.ID)
+	if err != nil {
+		return false, err
+	}
+	if field.ListID != cf.ListID {
+		return false, ErrCustomFieldDoesNotExist{ID: cf.ID, ListID: cf.ListID}
+	}
+	l := &List{ID: field.ListID}
+	return l.IsAdmin(a)
+}
@@ -0,0 +1,702 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// CustomFieldType is the type of the values of a custom field
+type CustomFieldType string
+
+// All types a custom field can have
+const (
+	CustomFieldTypeText     CustomFieldType = "text"
+	CustomFieldTypeNumber   CustomFieldType = "number"
+	CustomFieldTypeDate     CustomFieldType = "date"
+	CustomFieldTypeSelect   CustomFieldType = "select"
+	CustomFieldTypeCheckbox CustomFieldType = "checkbox"
+	CustomFieldTypeUser     CustomFieldType = "user"
+)
+
+func (t CustomFieldType) isValid() bool {
+	switch t {
+	case
+		CustomFieldTypeText,
+		CustomFieldTypeNumber,
+		CustomFieldTypeDate,
+		CustomFieldTypeSelect,
+		CustomFieldTypeCheckbox,
+		CustomFieldTypeUser:
+		return true
+	}
+	return false
+}
+
+// Returns the column of the task_custom_field_values table the values of a type are saved in
+func (t CustomFieldType) valueColumn() string {
+	switch t {
+	case CustomFieldTypeText, CustomFieldTypeSelect:
+		return "value_text"
+	case CustomFieldTypeDate:
+		return "value_date"
+	default:
+		return "value_number"
+	}
+}
+
+// Tasks can be filtered and sorted by custom fields by prefixing their name with this
+const customFieldTaskPropertyPrefix = "custom."
+
+var customFieldNameRegex = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// CustomField is a field a list admin defined for all tasks of a list
+type CustomField struct {
+	// The unique, numeric id of this custom field.
+	ID int64 `xorm:"int(11) autoincr not null unique pk" json:"id" param:"customfield"`
+	// The list this custom field belongs to.
+	ListID int64 `xorm:"int(11) not null INDEX" json:"list_id" param:"list"`
+	// The name of this field. It is the key of the value of this field in the custom fields of a task and used to filter and sort tasks with `custom.<name>`. It can only contain lowercase letters, numbers and underscores and needs to be unique per list.
+	Name string `xorm:"varchar(100) not null" json:"name" valid:"runelength(1|100)" minLength:"1" maxLength:"100"`
+	// The title of this field as it is shown to users.
+	Title string `xorm:"varchar(250) not null" json:"title" valid:"runelength(1|250)" minLength:"1" maxLength:"250"`
+	// The type of the values of this field. Can be one of text, number, date, select, checkbox or user. It cannot be changed once the field was created.
+	Type CustomFieldType `xorm:"varchar(20) not null" json:"type"`
+	// All values a select field can have. Only used for select fields.
+	Options []string `xorm:"JSON null" json:"options"`
+
+	// A timestamp when this custom field was created. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+	// A timestamp when this custom field was last updated. You cannot change this value.
+	Updated time.Time `xorm:"updated not null" json:"updated"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TableName holds the table name for the custom fields table
+func (cf *CustomField) TableName() string {
+	return "custom_fields"
+}
+
+// TaskCustomFieldValue holds the value of a custom field on a task
+type TaskCustomFieldValue struct {
+	ID      int64 `xorm:"int(11) autoincr not null unique pk"`
+	TaskID  int64 `xorm:"int(11) not null INDEX unique(task_field)"`
+	FieldID int64 `xorm:"int(11) not null INDEX unique(task_field)"`
+
+	// Only the column which fits the type of the field is used.
+	ValueText   string    `xorm:"text null"`
+	ValueNumber float64   `xorm:"DOUBLE null"`
+	ValueDate   time.Time `xorm:"DATETIME null 'value_date'"`
+}
+
+// TableName holds the table name for the task custom field values table
+func (v *TaskCustomFieldValue) TableName() string {
+	return "task_custom_field_values"
+}
+
+func isCustomFieldTaskProperty(property string) bool {
+	return strings.HasPrefix(property, customFieldTaskPropertyPrefix)
+}
+
+func getCustomFieldByID(id int64) (cf *CustomField, err error) {
+	cf = &CustomField{}
+	exists, err := x.Where("id = ?", id).Get(cf)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrCustomFieldDoesNotExist{ID: id}
+	}
+	return
+}
+
+func (cf *CustomField) validate() error {
+	if !customFieldNameRegex.MatchString(cf.Name) {
+		return ErrInvalidCustomFieldName{Name: cf.Name}
+	}
+
+	if !cf.Type.isValid() {
+		return ErrInvalidCustomFieldType{Type: cf.Type}
+	}
+
+	if cf.Type != CustomFieldTypeSelect {
+		cf.Options = nil
+		return nil
+	}
+
+	options := make([]string, 0, len(cf.Options))
+	seen := make(map[string]bool, len(cf.Options))
+	for _, o := range cf.Options {
+		if o == "" || seen[o] {
+			continue
+		}
+		seen[o] = true
+		options = append(options, o)
+	}
+	if len(options) == 0 {
+		return ErrCustomFieldNeedsOptions{Name: cf.Name}
+	}
+	cf.Options = options
+
+	return nil
+}
+
+func (cf *CustomField) checkNameIsUnique() error {
+	exists, err := x.
+		Where("list_id = ? AND name = ? AND id != ?", cf.ListID, cf.Name, cf.ID).
+		Exist(&CustomField{})
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrCustomFieldNameIsNotUnique{Name: cf.Name, ListID: cf.ListID}
+	}
+	return nil
+}
+
+func (cf *CustomField) hasOption(option string) bool {
+	for _, o := range cf.Options {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+// Create creates a new custom field on a list
+// @Summary Create a custom field
+// @Description Adds a new custom field to a list. All tasks of the list can then have a value for it. Only list admins can do this.
+// @tags list
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param list path int true "List ID"
+// @Param field body models.CustomField true "The custom field object"
+// @Success 200 {object} models.CustomField "The created custom field object."
+// @Failure 400 {object} web.HTTPError "Invalid custom field object provided."
+// @Failure 403 {object} web.HTTPError "The user is not an admin of the list."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /lists/{list}/custom-fields [put]
+func (cf *CustomField) Create(a web.Auth) (err error) {
+	cf.ID = 0
+
+	if err = cf.validate(); err != nil {
+		return
+	}
+
+	if err = cf.checkNameIsUnique(); err != nil {
+		return
+	}
+
+	_, err = x.Insert(cf)
+	return
+}
+
+// Update updates a custom field
+// @Summary Update a custom field
+// @Description Updates the name, title and options of a custom field. The type of a field cannot be changed. Values of tasks which are not an option of a select field anymore are removed. Only list admins can do this.
+// @tags list
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param list path int true "List ID"
+// @Param field path int true "Custom field ID"
+// @Param field body models.CustomField true "The custom field object"
+// @Success 200 {object} models.CustomField "The updated custom field object."
+// @Failure 400 {object} web.HTTPError "Invalid custom field object provided."
+// @Failure 403 {object} web.HTTPError "The user is not an admin of the list."
+// @Failure 404 {object} web.HTTPError "The custom field does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /lists/{list}/custom-fields/{field} [post]
+func (cf *CustomField) Update() (err error) {
+	old, err := getCustomFieldByID(cf.ID)
+	if err != nil {
+		return
+	}
+
+	cf.Type = old.Type
+	cf.Created = old.Created
+	if err = cf.validate(); err != nil {
+		return
+	}
+
+	if err = cf.checkNameIsUnique(); err != nil {
+		return
+	}
+
+	s := x.NewSession()
+	defer s.Close()
+	if err = s.Begin(); err != nil {
+		return
+	}
+
+	_, err = s.ID(cf.ID).Cols("name", "title", "options").Update(cf)
+	if err != nil {
+		_ = s.Rollback()
+		return
+	}
+
+	if cf.Type == CustomFieldTypeSelect {
+		_, err = s.
+			Where("field_id = ?", cf.ID).
+			And(builder.NotIn("value_text", cf.Options)).
+			Delete(&TaskCustomFieldValue{})
+		if err != nil {
+			_ = s.Rollback()
+			return
+		}
+	}
+
+	return s.Commit()
+}
+
+// Delete removes a custom field
+// @Summary Remove a custom field
+// @Description Removes a custom field from a list together with all values of it. Only list admins can do this.
+// @tags list
+// @Produce json
+// @Security JWTKeyAuth
+// @Param list path int true "List ID"
+// @Param field path int true "Custom field ID"
+// @Success 200 {object} models.Message "The custom field was successfully deleted."
+// @Failure 403 {object} web.HTTPError "The user is not an admin of the list."
+// @Failure 404 {object} web.HTTPError "The custom field does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /lists/{list}/custom-fields/{field} [delete]
+func (cf *CustomField) Delete() (err error) {
+	s := x.NewSession()
+	defer s.Close()
+	if err = s.Begin(); err != nil {
+		return
+	}
+
+	if _, err = s.Where("field_id = ?", cf.ID).Delete(&TaskCustomFieldValue{}); err != nil {
+		_ = s.Rollback()
+		return
+	}
+
+	if _, err = s.Where("id = ? AND list_id = ?", cf.ID, cf.ListID).Delete(&CustomField{}); err != nil {
+		_ = s.Rollback()
+		return
+	}
+
+	return s.Commit()
+}
+
+// Deletes all custom fields of the given lists together with their values
+func deleteCustomFieldsForLists(s *xorm.Session, listIDs []int64) (err error) {
+	if len(listIDs) == 0 {
+		return nil
+	}
+
+	_, err = s.
+		In("field_id", builder.Select("id").From("custom_fields").Where(builder.In("list_id", listIDs))).
+		Delete(&TaskCustomFieldValue{})
+	if err != nil {
+		return
+	}
+
+	_, err = s.In("list_id", listIDs).Delete(&CustomField{})
+	return
+}
+
+// ReadAll returns all custom fields of a list
+// @Summary Get all custom fields of a list
+// @Description Returns all custom fields of a list, the oldest first. The user needs to have read access to the list.
+// @tags list
+// @Produce json
+// @Security JWTKeyAuth
+// @Param list path int true "List ID"
+// @Param s query string false "Search custom fields by their name or title."
+// @Success 200 {array} models.CustomField "The custom fields."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the list."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /lists/{list}/custom-fields [get]
+func (cf *CustomField) ReadAll(a web.Auth, search string, page int, perPage int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	canRead, _, err := cf.CanRead(a)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if !canRead {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	cond := builder.And(
+		builder.Eq{"list_id": cf.ListID},
+		builder.Or(
+			builder.Like{"name", "%" + search + "%"},
+			builder.Like{"title", "%" + search + "%"},
+		),
+	)
+
+	fields := []*CustomField{}
+	err = x.Where(cond).OrderBy("id asc").Find(&fields)
+	if err != nil {
+		return
+	}
+
+	return fields, len(fields), int64(len(fields)), nil
+}
+
+// Converts a value as it is sent over the api to the value saved for a task
+func (cf *CustomField) setValue(v *TaskCustomFieldValue, value interface{}, list *List) error {
+	invalid := func(reason string) error {
+		return ErrInvalidCustomFieldValue{Name: cf.Name, Reason: reason}
+	}
+
+	switch cf.Type {
+	case CustomFieldTypeText, CustomFieldTypeSelect:
+		text, is := value.(string)
+		if !is {
+			return invalid("the value needs to be a string")
+		}
+		if cf.Type == CustomFieldTypeSelect && !cf.hasOption(text) {
+			return invalid("the value needs to be one of the options of the field")
+		}
+		v.ValueText = text
+	case CustomFieldTypeNumber:
+		number, is := getCustomFieldNumber(value)
+		if !is {
+			return invalid("the value needs to be a number")
+		}
+		v.ValueNumber = number
+	case CustomFieldTypeDate:
+		switch date := value.(type) {
+		case time.Time:
+			v.ValueDate = date
+		case string:
+			parsed, err := time.Parse(time.RFC3339, date)
+			if err != nil {
+				return invalid("the value needs to be a date in RFC 3339 format")
+			}
+			v.ValueDate = parsed
+		default:
+			return invalid("the value needs to be a date in RFC 3339 format")
+		}
+	case CustomFieldTypeCheckbox:
+		checked, is := value.(bool)
+		if !is {
+			return invalid("the value needs to be true or false")
+		}
+		if checked {
+			v.ValueNumber = 1
+		}
+	case CustomFieldTypeUser:
+		id, is := getCustomFieldNumber(value)
+		if !is {
+			return invalid("the value needs to be the id of a user")
+		}
+		u, err := user.GetUserByID(int64(id))
+		if err != nil {
+			if user.IsErrUserDoesNotExist(err) {
+				return invalid("the user does not exist")
+			}
+			return err
+		}
+		canRead, _, err := list.CanRead(u)
+		if err != nil {
+			return err
+		}
+		if !canRead {
+			return invalid("the user does not have access to the list")
+		}
+		v.ValueNumber = float64(u.ID)
+	}
+
+	return nil
+}
+
+func getCustomFieldNumber(value interface{}) (number float64, is bool) {
+	switch n := value.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+// Converts a saved value of a task to the value which is returned over the api
+func (cf *CustomField) getValue(v *TaskCustomFieldValue) interface{} {
+	switch cf.Type {
+	case CustomFieldTypeNumber:
+		return v.ValueNumber
+	case CustomFieldTypeDate:
+		return v.ValueDate.In(config.GetTimeZone())
+	case CustomFieldTypeCheckbox:
+		return v.ValueNumber == 1
+	case CustomFieldTypeUser:
+		return int64(v.ValueNumber)
+	default:
+		return v.ValueText
+	}
+}
+
+// Converts the value of a filter for a custom field to its native type
+func (cf *CustomField) getNativeFilterValue(value string) (nativeValue interface{}, err error) {
+	switch cf.Type {
+	case CustomFieldTypeNumber:
+		return strconv.ParseFloat(value, 64)
+	case CustomFieldTypeDate:
+		date, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, err
+		}
+		return date.In(config.GetTimeZone()), nil
+	case CustomFieldTypeCheckbox:
+		checked, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, err
+		}
+		if checked {
+			return 1, nil
+		}
+		return 0, nil
+	case CustomFieldTypeUser:
+		return strconv.ParseInt(value, 10, 64)
+	default:
+		return value, nil
+	}
+}
+
+// Saves all values in the custom fields of a task. Fields which are not part of it are left untouched,
+// a value of nil removes the value of that field from the task.
+func setTaskCustomFieldValues(s *xorm.Session, t *Task) (err error) {
+	if t.CustomFields == nil {
+		return nil
+	}
+
+	fields := []*CustomField{}
+	err = s.Where("list_id = ?", t.ListID).Find(&fields)
+	if err != nil {
+		return
+	}
+	fieldsByName := make(map[string]*CustomField, len(fields))
+	for _, f := range fields {
+		fieldsByName[f.Name] = f
+	}
+
+	list := &List{ID: t.ListID}
+	for name, value := range t.CustomFields {
+		field, exists := fieldsByName[name]
+		if !exists {
+			return ErrCustomFieldDoesNotExist{ListID: t.ListID, Name: name}
+		}
+
+		_, err = s.Where("task_id = ? AND field_id = ?", t.ID, field.ID).Delete(&TaskCustomFieldValue{})
+		if err != nil {
+			return
+		}
+
+		if value == nil {
+			continue
+		}
+
+		v := &TaskCustomFieldValue{
+			TaskID:  t.ID,
+			FieldID: field.ID,
+		}
+		if err = field.setValue(v, value, list); err != nil {
+			return
+		}
+		if _, err = s.Insert(v); err != nil {
+			return
+		}
+	}
+
+	// Return all values of the task, not only the updated ones
+	values := []*TaskCustomFieldValue{}
+	err = s.Where("task_id = ?", t.ID).Find(&values)
+	if err != nil {
+		return
+	}
+
+	t.CustomFields = nil
+	for _, v := range values {
+		for _, f := range fields {
+			if f.ID != v.FieldID {
+				continue
+			}
+			if t.CustomFields == nil {
+				t.CustomFields = make(map[string]interface{})
+			}
+			t.CustomFields[f.Name] = f.getValue(v)
+		}
+	}
+
+	return
+}
+
+func addCustomFieldValuesToTasks(taskMap map[int64]*Task) (err error) {
+	taskIDs := make([]int64, 0, len(taskMap))
+	for id := range taskMap {
+		taskIDs = append(taskIDs, id)
+	}
+
+	values := []*TaskCustomFieldValue{}
+	err = x.In("task_id", taskIDs).Find(&values)
+	if err != nil || len(values) == 0 {
+		return
+	}
+
+	fieldIDs := make([]int64, 0, len(values))
+	for _, v := range values {
+		fieldIDs = append(fieldIDs, v.FieldID)
+	}
+
+	fields := make(map[int64]*CustomField, len(fieldIDs))
+	err = x.In("id", fieldIDs).Find(&fields)
+	if err != nil {
+		return
+	}
+
+	for _, v := range values {
+		f, exists := fields[v.FieldID]
+		if !exists {
+			continue
+		}
+		t := taskMap[v.TaskID]
+		if t.CustomFields == nil {
+			t.CustomFields = make(map[string]interface{})
+		}
+		t.CustomFields[f.Name] = f.getValue(v)
+	}
+
+	return
+}
+
+// Returns all custom fields with the name of a task property like custom.environment in the given lists.
+// Because they are filtered and sorted by together, all of them need to have the same type.
+func getCustomFieldsForTaskProperty(property string, listIDs []int64) (fields []*CustomField, err error) {
+	name := strings.TrimPrefix(property, customFieldTaskPropertyPrefix)
+
+	fields = []*CustomField{}
+	err = x.
+		In("list_id", listIDs).
+		And("name = ?", name).
+		Find(&fields)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(fields) == 0 {
+		return nil, ErrInvalidTaskField{TaskField: property}
+	}
+
+	for _, f := range fields {
+		if f.Type != fields[0].Type {
+			return nil, ErrCustomFieldTypesDiffer{Name: name}
+		}
+	}
+
+	return
+}
+
+func getCustomFieldIDs(fields []*CustomField) (ids []int64) {
+	ids = make([]int64, 0, len(fields))
+	for _, f := range fields {
+		ids = append(ids, f.ID)
+	}
+	return
+}
+
+// Returns the condition for a task filter by a custom field.
+// Tasks which don't have a value for the field only match not_equals, or all comparisons other than equals if nulls
+// should be included.
+func getCustomFieldFilterCond(f *taskFilter, listIDs []int64, includeNulls bool) (cond builder.Cond, err error) {
+	fields, err := getCustomFieldsForTaskProperty(f.field, listIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	rawValue, is := f.value.(string)
+	if !is {
+		return nil, ErrInvalidTaskFilterValue{Value: "", Field: f.field}
+	}
+	value, err := fields[0].getNativeFilterValue(rawValue)
+	if err != nil {
+		return nil, ErrInvalidTaskFilterValue{Value: rawValue, Field: f.field}
+	}
+
+	column := fields[0].Type.valueColumn()
+	fieldIDs := getCustomFieldIDs(fields)
+	taskIDsWithValue := func(valueCond builder.Cond) *builder.Builder {
+		cond := builder.In("field_id", fieldIDs)
+		if valueCond != nil {
+			cond = cond.And(valueCond)
+		}
+		return builder.
+			Select("task_id").
+			From("task_custom_field_values").
+			Where(cond)
+	}
+
+	var valueCond builder.Cond
+	switch f.comparator {
+	case taskFilterComparatorEquals:
+		return builder.In("id", taskIDsWithValue(builder.Eq{column: value})), nil
+	case taskFilterComparatorNotEquals:
+		return builder.NotIn("id", taskIDsWithValue(builder.Eq{column: value})), nil
+	case taskFilterComparatorGreater:
+		valueCond = builder.Gt{column: value}
+	case taskFilterComparatorGreateEquals:
+		valueCond = builder.Gte{column: value}
+	case taskFilterComparatorLess:
+		valueCond = builder.Lt{column: value}
+	case taskFilterComparatorLessEquals:
+		valueCond = builder.Lte{column: value}
+	default:
+		return nil, ErrInvalidTaskFilterComparator{Comparator: f.comparator}
+	}
+
+	cond = builder.In("id", taskIDsWithValue(valueCond))
+	if includeNulls {
+		cond = builder.Or(cond, builder.NotIn("id", taskIDsWithValue(nil)))
+	}
+	return cond, nil
+}
+
+// Returns an expression to sort tasks by the value of a custom field. Only ids are put into the expression,
+// it is therefore safe to use with xorm's OrderBy.
+func getCustomFieldOrderBy(property string, listIDs []int64) (orderBy string, err error) {
+	fields, err := getCustomFieldsForTaskProperty(property, listIDs)
+	if err != nil {
+		return "", err
+	}
+
+	ids := make([]string, 0, len(fields))
+	for _, f := range fields {
+		ids = append(ids, strconv.FormatInt(f.ID, 10))
+	}
+
+	return "(SELECT task_custom_field_values." + fields[0].Type.valueColumn() +
+		" FROM task_custom_field_values WHERE task_custom_field_values.task_id = tasks.id" +
+		" AND task_custom_field_values.field_id IN (" + strings.Join(ids, ", ") + "))", nil
+}
@@ -0,0 +1,371 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCustomField_Create(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		cf := &CustomField{
+			ListID:  2,
+			Name:    "severity",
+			Title:   "Severity",
+			Type:    CustomFieldTypeSelect,
+			Options: []string{"low", "high", "low", ""},
+		}
+		can, err := cf.CanCreate(u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+		err = cf.Create(u)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"low", "high"}, cf.Options)
+		db.AssertExists(t, "custom_fields", map[string]interface{}{
+			"id":      cf.ID,
+			"list_id": 2,
+			"name":    "severity",
+			"type":    "select",
+		}, false)
+	})
+	t.Run("options are only kept for select fields", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		cf := &CustomField{ListID: 2, Name: "notes", Title: "Notes", Type: CustomFieldTypeText, Options: []string{"a"}}
+		err := cf.Create(u)
+		assert.NoError(t, err)
+		assert.Nil(t, cf.Options)
+	})
+	t.Run("invalid name", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		cf := &CustomField{ListID: 2, Name: "Due Soon", Title: "Due soon", Type: CustomFieldTypeDate}
+		err := cf.Create(u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidCustomFieldName(err))
+	})
+	t.Run("name not unique", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		cf := &CustomField{ListID: 2, Name: "effort", Title: "Effort", Type: CustomFieldTypeNumber}
+		err := cf.Create(u)
+		assert.Error(t, err)
+		assert.True(t, IsErrCustomFieldNameIsNotUnique(err))
+	})
+	t.Run("same name in another list", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		cf := &CustomField{ListID: 1, Name: "effort", Title: "Effort", Type: CustomFieldTypeNumber}
+		err := cf.Create(u)
+		assert.NoError(t, err)
+	})
+	t.Run("invalid type", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		cf := &CustomField{ListID: 2, Name: "color", Title: "Color", Type: "color"}
+		err := cf.Create(u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidCustomFieldType(err))
+	})
+	t.Run("select without options", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		cf := &CustomField{ListID: 2, Name: "stage", Title: "Stage", Type: CustomFieldTypeSelect}
+		err := cf.Create(u)
+		assert.Error(t, err)
+		assert.True(t, IsErrCustomFieldNeedsOptions(err))
+	})
+	t.Run("no admin rights", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		cf := &CustomField{ListID: 9}
+		can, err := cf.CanCreate(u)
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+}
+
+func TestCustomField_Update(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		cf := &CustomField{ID: 2, ListID: 2, Name: "estimated_effort", Title: "Estimated effort", Type: CustomFieldTypeText}
+		can, err := cf.CanUpdate(u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+		err = cf.Update()
+		assert.NoError(t, err)
+		assert.Equal(t, CustomFieldTypeNumber, cf.Type)
+		db.AssertExists(t, "custom_fields", map[string]interface{}{
+			"id":    2,
+			"name":  "estimated_effort",
+			"type":  "number",
+			"title": "Estimated effort",
+		}, false)
+	})
+	t.Run("removed option", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		cf := &CustomField{ID: 1, ListID: 2, Name: "environment", Title: "Environment", Options: []string{"staging", "testing"}}
+		err := cf.Update()
+		assert.NoError(t, err)
+		db.AssertMissing(t, "task_custom_field_values", map[string]interface{}{
+			"id": 1,
+		})
+	})
+	t.Run("name not unique", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		cf := &CustomField{ID: 2, ListID: 2, Name: "reviewer", Title: "Effort"}
+		err := cf.Update()
+		assert.Error(t, err)
+		assert.True(t, IsErrCustomFieldNameIsNotUnique(err))
+	})
+	t.Run("nonexisting", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		cf := &CustomField{ID: 9999, ListID: 2}
+		_, err := cf.CanUpdate(u)
+		assert.Error(t, err)
+		assert.True(t, IsErrCustomFieldDoesNotExist(err))
+	})
+	t.Run("field of another list", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		cf := &CustomField{ID: 2, ListID: 1}
+		_, err := cf.CanUpdate(u)
+		assert.Error(t, err)
+		assert.True(t, IsErrCustomFieldDoesNotExist(err))
+	})
+}
+
+func TestCustomField_Delete(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	cf := &CustomField{ID: 2, ListID: 2}
+	can, err := cf.CanDelete(&user.User{ID: 1})
+	assert.NoError(t, err)
+	assert.True(t, can)
+	err = cf.Delete()
+	assert.NoError(t, err)
+	db.AssertMissing(t, "custom_fields", map[string]interface{}{
+		"id": 2,
+	})
+	db.AssertMissing(t, "task_custom_field_values", map[string]interface{}{
+		"field_id": 2,
+	})
+}
+
+func TestCustomField_ReadAll(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		cf := &CustomField{ListID: 2}
+		result, _, _, err := cf.ReadAll(&user.User{ID: 3}, "", 0, 50)
+		assert.NoError(t, err)
+		fields := result.([]*CustomField)
+		assert.Len(t, fields, 4)
+		assert.Equal(t, "environment", fields[0].Name)
+		assert.Equal(t, []string{"production", "staging"}, fields[0].Options)
+	})
+	t.Run("search", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		cf := &CustomField{ListID: 2}
+		result, _, _, err := cf.ReadAll(&user.User{ID: 3}, "review", 0, 50)
+		assert.NoError(t, err)
+		assert.Len(t, result.([]*CustomField), 1)
+	})
+	t.Run("no access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		cf := &CustomField{ListID: 2}
+		_, _, _, err := cf.ReadAll(&user.User{ID: 2}, "", 0, 50)
+		assert.Error(t, err)
+		assert.True(t, IsErrGenericForbidden(err))
+	})
+}
+
+func TestTask_CustomFields(t *testing.T) {
+	t.Run("read", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		task := &Task{ID: 13}
+		err := task.ReadOne()
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{
+			"environment": "production",
+			"effort":      float64(3),
+			"reviewer":    int64(3),
+			"approved":    true,
+		}, task.CustomFields)
+	})
+	t.Run("update values", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		task := &Task{
+			ID:     13,
+			Title:  "task #13",
+			ListID: 2,
+			CustomFields: map[string]interface{}{
+				"environment": "staging",
+				"effort":      float64(5),
+				"approved":    nil,
+			},
+		}
+		err := task.Update()
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{
+			"environment": "staging",
+			"effort":      float64(5),
+			"reviewer":    int64(3),
+		}, task.CustomFields)
+		db.AssertExists(t, "task_custom_field_values", map[string]interface{}{
+			"task_id":    13,
+			"field_id":   1,
+			"value_text": "staging",
+		}, false)
+		db.AssertMissing(t, "task_custom_field_values", map[string]interface{}{
+			"task_id":  13,
+			"field_id": 4,
+		})
+	})
+	t.Run("create", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		task := &Task{
+			Title:  "new task",
+			ListID: 2,
+			CustomFields: map[string]interface{}{
+				"reviewer": float64(1),
+			},
+		}
+		err := task.Create(&user.User{ID: 1})
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"reviewer": int64(1)}, task.CustomFields)
+	})
+	t.Run("value not an option", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		task := &Task{ID: 13, Title: "task #13", ListID: 2, CustomFields: map[string]interface{}{"environment": "testing"}}
+		err := task.Update()
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidCustomFieldValue(err))
+	})
+	t.Run("wrong value type", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		task := &Task{ID: 13, Title: "task #13", ListID: 2, CustomFields: map[string]interface{}{"effort": "a lot"}}
+		err := task.Update()
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidCustomFieldValue(err))
+	})
+	t.Run("user without access to the list", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		task := &Task{ID: 13, Title: "task #13", ListID: 2, CustomFields: map[string]interface{}{"reviewer": float64(2)}}
+		err := task.Update()
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidCustomFieldValue(err))
+	})
+	t.Run("nonexisting field", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		task := &Task{ID: 13, Title: "task #13", ListID: 2, CustomFields: map[string]interface{}{"color": "red"}}
+		err := task.Update()
+		assert.Error(t, err)
+		assert.True(t, IsErrCustomFieldDoesNotExist(err))
+	})
+	t.Run("move to another list", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		task := &Task{ID: 13, Title: "task #13", ListID: 1}
+		err := task.Update()
+		assert.NoError(t, err)
+		db.AssertMissing(t, "task_custom_field_values", map[string]interface{}{
+			"task_id": 13,
+		})
+	})
+	t.Run("delete task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		task := &Task{ID: 13}
+		err := task.Delete()
+		assert.NoError(t, err)
+		db.AssertMissing(t, "task_custom_field_values", map[string]interface{}{
+			"task_id": 13,
+		})
+	})
+}
+
+func TestTaskCollection_ReadAllByCustomFields(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("filter", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		tc := &TaskCollection{
+			ListID:           2,
+			FilterBy:         []string{"custom.environment"},
+			FilterValue:      []string{"production"},
+			FilterComparator: []string{"equals"},
+		}
+		result, _, _, err := tc.ReadAll(u, "", 1, 50)
+		assert.NoError(t, err)
+		tasks := result.([]*Task)
+		assert.Len(t, tasks, 1)
+		assert.Equal(t, int64(13), tasks[0].ID)
+	})
+	t.Run("filter with comparator", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		tc := &TaskCollection{
+			ListID:           2,
+			FilterBy:         []string{"custom.effort"},
+			FilterValue:      []string{"3"},
+			FilterComparator: []string{"greater"},
+		}
+		result, _, _, err := tc.ReadAll(u, "", 1, 50)
+		assert.NoError(t, err)
+		assert.Len(t, result.([]*Task), 0)
+	})
+	t.Run("sort", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		task := &Task{
+			Title:        "more effort",
+			ListID:       2,
+			CustomFields: map[string]interface{}{"effort": float64(8)},
+		}
+		err := task.Create(u)
+		assert.NoError(t, err)
+
+		tc := &TaskCollection{
+			ListID:  2,
+			SortBy:  []string{"custom.effort"},
+			OrderBy: []string{"desc"},
+		}
+		result, _, _, err := tc.ReadAll(u, "", 1, 50)
+		assert.NoError(t, err)
+		tasks := result.([]*Task)
+		assert.Len(t, tasks, 2)
+		assert.Equal(t, task.ID, tasks[0].ID)
+		assert.Equal(t, int64(13), tasks[1].ID)
+	})
+	t.Run("invalid filter value", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		tc := &TaskCollection{
+			ListID:      2,
+			FilterBy:    []string{"custom.effort"},
+			FilterValue: []string{"a lot"},
+		}
+		_, _, _, err := tc.ReadAll(u, "", 1, 50)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidTaskFilterValue(err))
+	})
+	t.Run("nonexisting field", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		tc := &TaskCollection{
+			ListID: 2,
+			SortBy: []string{"custom.color"},
+		}
+		_, _, _, err := tc.ReadAll(u, "", 1, 50)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidTaskField(err))
+	})
+}
@@ -0,0 +1,292 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/mail"
+	"code.vikunja.io/api/pkg/user"
+	"xorm.io/builder"
+)
+
+// The interval in which the digest daemon checks for users who should get a digest
+const digestCheckInterval = time.Minute
+
+// The number of days after today for which tasks are shown as upcoming in a digest
+const digestUpcomingDays = 7
+
+var digestDaemonQuit chan bool
+
+// digestTask holds a task and the list it belongs to so both can be shown in a digest
+type digestTask struct {
+	Task *Task
+	List *List
+}
+
+// userDigest holds all tasks which go into the digest email of a single user
+type userDigest struct {
+	User     *user.User
+	Overdue  []*digestTask
+	DueToday []*digestTask
+	Upcoming []*digestTask
+}
+
+func (d *userDigest) isEmpty() bool {
+	return len(d.Overdue) == 0 && len(d.DueToday) == 0 && len(d.Upcoming) == 0
+}
+
+// Returns the beginning of the day of a time in the configured time zone
+func startOfDay(t time.Time) time.Time {
+	t = t.In(config.GetTimeZone())
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// getDigestForUser collects all undone tasks which are overdue, due today or due in the next days
+// across all lists the user has access to.
+func getDigestForUser(u *user.User, now time.Time) (digest *userDigest, err error) {
+	lists, _, _, err := getRawListsForUser(&listOptions{
+		user: u,
+		page: -1,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	today := startOfDayForUser(u, now)
+	tomorrow := today.AddDate(0, 0, 1)
+	upcomingEnd := tomorrow.AddDate(0, 0, digestUpcomingDays)
+
+	tasks, _, _, err := getRawTasksForLists(lists, u, &taskOptions{
+		page: -1,
+		sortby: []*sortParam{
+			{
+				sortBy:  taskPropertyDueDate,
+				orderBy: orderAscending,
+			},
+		},
+		filters: []*taskFilter{
+			{
+				field:      taskPropertyDone,
+				value:      false,
+				comparator: taskFilterComparatorEquals,
+			},
+			{
+				field:      taskPropertyDueDate,
+				value:      upcomingEnd,
+				comparator: taskFilterComparatorLess,
+			},
+		},
+		filterConcat: filterConcatAnd,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	listMap := make(map[int64]*List, len(lists))
+	for _, l := range lists {
+		listMap[l.ID] = l
+	}
+
+	digest = &userDigest{User: u}
+	for _, t := range tasks {
+		// Tasks without a due date are stored with a null due date and therefore never end up here,
+		// this is only a safeguard.
+		if t.DueDate.IsZero() {
+			continue
+		}
+
+		l, exists := listMap[t.ListID]
+		if !exists {
+			continue
+		}
+		t.setIdentifier(l)
+		// The due dates are shown in the timezone of the user
+		t.DueDate = t.DueDate.In(today.Location())
+
+		dt := &digestTask{Task: t, List: l}
+		switch {
+		case t.DueDate.Before(today):
+			digest.Overdue = append(digest.Overdue, dt)
+		case t.DueDate.Before(tomorrow):
+			digest.DueToday = append(digest.DueToday, dt)
+		default:
+			digest.Upcoming = append(digest.Upcoming, dt)
+		}
+	}
+
+	return
+}
+
+// Returns the beginning of the day of a time in the timezone of a user
+func startOfDayForUser(u *user.User, t time.Time) time.Time {
+	t = t.In(u.GetTimezone())
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// Returns all users who want a digest at the given time and did not already get one today.
+// Weekly digests are sent on mondays. Both the hour and the day are evaluated in the timezone of each user.
+func getUsersToSendDigestTo(now time.Time) (users []*user.User, err error) {
+	// The hour is different for users in different timezones, so we need to check it for every user
+	candidates := []*user.User{}
+	err = x.
+		Where("is_active = ?", true).
+		And(builder.In("digest_frequency", []string{user.DigestFrequencyDaily, user.DigestFrequencyWeekly})).
+		Find(&candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	users = []*user.User{}
+	for _, u := range candidates {
+		local := now.In(u.GetTimezone())
+		if local.Hour() != u.DigestHour {
+			continue
+		}
+		if u.DigestFrequency == user.DigestFrequencyWeekly && local.Weekday() != time.Monday {
+			continue
+		}
+		if !u.DigestLastSent.IsZero() && !u.DigestLastSent.Before(startOfDayForUser(u, now)) {
+			continue
+		}
+		users = append(users, u)
+	}
+	return
+}
+
+// Claims the digest of a user for the day of the given time so it won't be sent twice, even if multiple Vikunja
+// instances share the same database or Vikunja is restarted.
+func claimDigest(u *user.User, now time.Time) (claimed bool, err error) {
+	affected, err := x.
+		Where("id = ?", u.ID).
+		And(builder.Or(
+			&builder.IsNull{"digest_last_sent"},
+			&builder.Lt{"digest_last_sent": startOfDayForUser(u, now)},
+		)).
+		Cols("digest_last_sent").
+		NoAutoTime().
+		Update(&user.User{DigestLastSent: now})
+	return affected == 1, err
+}
+
+// SendDigestForUser sends the digest email to a user right away, regardless of their digest settings.
+// No email is sent if the user does not have any overdue, due or upcoming tasks. The returned bool indicates
+// whether a digest was sent.
+func SendDigestForUser(u *user.User, now time.Time) (sent bool, err error) {
+	if u.Email == "" {
+		return false, nil
+	}
+
+	digest, err := getDigestForUser(u, now)
+	if err != nil {
+		return false, err
+	}
+
+	if digest.isEmpty() {
+		return false, nil
+	}
+
+	// Dont send a mail if we're testing
+	if !config.MailerEnabled.GetBool() {
+		return false, nil
+	}
+
+	data := map[string]interface{}{
+		"User":     digest.User,
+		"Overdue":  digest.Overdue,
+		"DueToday": digest.DueToday,
+		"Upcoming": digest.Upcoming,
+	}
+
+	mail.SendMailWithTemplate(u.Email, "Your task digest for "+now.In(config.GetTimeZone()).Format("Mon, 02 Jan 2006"), "digest-email", data)
+
+	return true, nil
+}
+
+func sendScheduledDigests(now time.Time) error {
+	users, err := getUsersToSendDigestTo(now)
+	if err != nil {
+		return err
+	}
+
+	for _, u := range users {
+		claimed, err := claimDigest(u, now)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			continue
+		}
+
+		sent, err := SendDigestForUser(u, now)
+		if err != nil {
+			log.Errorf("[Digests] Could not send digest to user %d: %s", u.ID, err)
+			continue
+		}
+		if sent {
+			log.Debugf("[Digests] Sent digest to user %d", u.ID)
+		}
+	}
+
+	return nil
+}
+
+// StartDigestDaemon starts a goroutine which periodically checks for users whose digest is due and sends it to them.
+// Every digest is claimed in the database before sending it, it is therefore safe to run multiple instances at the
+// same time or to restart them.
+func StartDigestDaemon() {
+	if !config.ServiceEnableEmailDigests.GetBool() {
+		return
+	}
+
+	if !config.MailerEnabled.GetBool() {
+		log.Info("Mailer is disabled, not sending digests.")
+		return
+	}
+
+	digestDaemonQuit = make(chan bool)
+
+	go func() {
+		ticker := time.NewTicker(digestCheckInterval)
+		defer ticker.Stop()
+
+		log.Debugf("[Digests] Started digest daemon, checking every %s", digestCheckInterval)
+
+		for {
+			select {
+			case <-digestDaemonQuit:
+				log.Debugf("[Digests] Stopped digest daemon")
+				return
+			case now := <-ticker.C:
+				if err := sendScheduledDigests(now); err != nil {
+					log.Errorf("[Digests] Could not send digests: %s", err)
+				}
+			}
+		}
+	}()
+}
+
+// StopDigestDaemon stops the digest daemon if it was started
+func StopDigestDaemon() {
+	if digestDaemonQuit == nil {
+		return
+	}
+	close(digestDaemonQuit)
+	digestDaemonQuit = nil
+}
@@ -0,0 +1,194 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetDigestForUser(t *testing.T) {
+	t.Run("overdue and due today", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		now, err := time.Parse(time.RFC3339, "2018-12-01T01:00:00Z")
+		assert.NoError(t, err)
+
+		digest, err := getDigestForUser(&user.User{ID: 1}, now)
+		assert.NoError(t, err)
+		assert.False(t, digest.isEmpty())
+		assert.Len(t, digest.Overdue, 1)
+		assert.Equal(t, int64(6), digest.Overdue[0].Task.ID)
+		assert.Equal(t, int64(1), digest.Overdue[0].List.ID)
+		assert.Len(t, digest.DueToday, 1)
+		assert.Equal(t, int64(5), digest.DueToday[0].Task.ID)
+		assert.Len(t, digest.Upcoming, 0)
+	})
+	t.Run("upcoming", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		now, err := time.Parse(time.RFC3339, "2018-11-28T10:00:00Z")
+		assert.NoError(t, err)
+
+		digest, err := getDigestForUser(&user.User{ID: 1}, now)
+		assert.NoError(t, err)
+		assert.Len(t, digest.Overdue, 0)
+		assert.Len(t, digest.DueToday, 0)
+		assert.Len(t, digest.Upcoming, 2)
+		// Sorted by due date
+		assert.Equal(t, int64(6), digest.Upcoming[0].Task.ID)
+		assert.Equal(t, int64(5), digest.Upcoming[1].Task.ID)
+	})
+	t.Run("too far in the future", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		now, err := time.Parse(time.RFC3339, "2018-11-01T10:00:00Z")
+		assert.NoError(t, err)
+
+		digest, err := getDigestForUser(&user.User{ID: 1}, now)
+		assert.NoError(t, err)
+		assert.True(t, digest.isEmpty())
+	})
+	t.Run("no access to the tasks", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		now, err := time.Parse(time.RFC3339, "2018-12-01T01:00:00Z")
+		assert.NoError(t, err)
+
+		digest, err := getDigestForUser(&user.User{ID: 2}, now)
+		assert.NoError(t, err)
+		assert.True(t, digest.isEmpty())
+	})
+}
+
+func TestGetUsersToSendDigestTo(t *testing.T) {
+	t.Run("daily", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		err := user.UpdateDigestSettings(&user.User{ID: 1}, &user.DigestSettings{
+			Frequency: user.DigestFrequencyDaily,
+			Hour:      8,
+		})
+		assert.NoError(t, err)
+
+		now, err := time.Parse(time.RFC3339, "2018-12-01T08:10:00Z")
+		assert.NoError(t, err)
+
+		users, err := getUsersToSendDigestTo(now)
+		assert.NoError(t, err)
+		assert.Len(t, users, 1)
+		assert.Equal(t, int64(1), users[0].ID)
+
+		claimed, err := claimDigest(users[0], now)
+		assert.NoError(t, err)
+		assert.True(t, claimed)
+
+		// Claiming it a second time should not work
+		claimed, err = claimDigest(users[0], now)
+		assert.NoError(t, err)
+		assert.False(t, claimed)
+
+		users, err = getUsersToSendDigestTo(now)
+		assert.NoError(t, err)
+		assert.Len(t, users, 0)
+
+		// The next day it should be sent again
+		users, err = getUsersToSendDigestTo(now.AddDate(0, 0, 1))
+		assert.NoError(t, err)
+		assert.Len(t, users, 1)
+	})
+	t.Run("other hour", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		err := user.UpdateDigestSettings(&user.User{ID: 1}, &user.DigestSettings{
+			Frequency: user.DigestFrequencyDaily,
+			Hour:      8,
+		})
+		assert.NoError(t, err)
+
+		now, err := time.Parse(time.RFC3339, "2018-12-01T09:10:00Z")
+		assert.NoError(t, err)
+
+		users, err := getUsersToSendDigestTo(now)
+		assert.NoError(t, err)
+		assert.Len(t, users, 0)
+	})
+	t.Run("weekly", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		err := user.UpdateDigestSettings(&user.User{ID: 1}, &user.DigestSettings{
+			Frequency: user.DigestFrequencyWeekly,
+			Hour:      8,
+		})
+		assert.NoError(t, err)
+
+		// Saturday
+		now, err := time.Parse(time.RFC3339, "2018-12-01T08:10:00Z")
+		assert.NoError(t, err)
+		users, err := getUsersToSendDigestTo(now)
+		assert.NoError(t, err)
+		assert.Len(t, users, 0)
+
+		// Monday
+		now, err = time.Parse(time.RFC3339, "2018-12-03T08:10:00Z")
+		assert.NoError(t, err)
+		users, err = getUsersToSendDigestTo(now)
+		assert.NoError(t, err)
+		assert.Len(t, users, 1)
+		assert.Equal(t, int64(1), users[0].ID)
+	})
+	t.Run("timezone of the user", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		err := user.UpdateDigestSettings(&user.User{ID: 1}, &user.DigestSettings{
+			Frequency: user.DigestFrequencyWeekly,
+			Hour:      8,
+			Timezone:  "America/New_York",
+		})
+		assert.NoError(t, err)
+
+		// 08:10 in UTC is not the hour the user wants the digest at
+		now, err := time.Parse(time.RFC3339, "2018-12-03T08:10:00Z")
+		assert.NoError(t, err)
+		users, err := getUsersToSendDigestTo(now)
+		assert.NoError(t, err)
+		assert.Len(t, users, 0)
+
+		// 08:10 on monday in New York
+		now, err = time.Parse(time.RFC3339, "2018-12-03T13:10:00Z")
+		assert.NoError(t, err)
+		users, err = getUsersToSendDigestTo(now)
+		assert.NoError(t, err)
+		assert.Len(t, users, 1)
+
+		claimed, err := claimDigest(users[0], now)
+		assert.NoError(t, err)
+		assert.True(t, claimed)
+
+		// Still monday in New York, but already tuesday in UTC. The digest was already sent on monday.
+		now, err = time.Parse(time.RFC3339, "2018-12-04T01:10:00Z")
+		assert.NoError(t, err)
+		users, err = getUsersToSendDigestTo(now)
+		assert.NoError(t, err)
+		assert.Len(t, users, 0)
+	})
+	t.Run("disabled", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		now, err := time.Parse(time.RFC3339, "2018-12-03T00:10:00Z")
+		assert.NoError(t, err)
+
+		users, err := getUsersToSendDigestTo(now)
+		assert.NoError(t, err)
+		assert.Len(t, users, 0)
+	})
+}
@@ -255,6 +255,170 @@ func (err ErrListIsArchived) HTTPError() web.HTTPError {
 	return web.HTTPError{HTTPCode: http.StatusPreconditionFailed, Code: ErrCodeListIsArchived, Message: "This lists is archived. Editing or creating new tasks is not possible."}
 }
 
+// ErrLinkSharePasswordRequired represents an error where a link share authentication requires a password and none was provided
+type ErrLinkSharePasswordRequired struct {
+	ShareID int64
+}
+
+// IsErrLinkSharePasswordRequired checks if an error is ErrLinkSharePasswordRequired.
+func IsErrLinkSharePasswordRequired(err error) bool {
+	_, ok := err.(ErrLinkSharePasswordRequired)
+	return ok
+}
+
+func (err ErrLinkSharePasswordRequired) Error() string {
+	return fmt.Sprintf("Link Share requires a password for authentication [ShareID: %d]", err.ShareID)
+}
+
+// ErrCodeLinkSharePasswordRequired holds the unique world-error code of this error
+const ErrCodeLinkSharePasswordRequired = 3009
+
+// HTTPError holds the http error description
+func (err ErrLinkSharePasswordRequired) HTTPError() web.HTTPError {
+	return web.HTTPError{HTTPCode: http.StatusForbidden, Code: ErrCodeLinkSharePasswordRequired, Message: "This link share requires a password for authentication, but none was provided."}
+}
+
+// ErrLinkSharePasswordInvalid represents an error where a link share authentication requires a password and the provided one was wrong
+type ErrLinkSharePasswordInvalid struct {
+	ShareID int64
+}
+
+// IsErrLinkSharePasswordInvalid checks if an error is ErrLinkSharePasswordInvalid.
+func IsErrLinkSharePasswordInvalid(err error) bool {
+	_, ok := err.(ErrLinkSharePasswordInvalid)
+	return ok
+}
+
+func (err ErrLinkSharePasswordInvalid) Error() string {
+	return fmt.Sprintf("Provided Link Share password is invalid [ShareID: %d]", err.ShareID)
+}
+
+// ErrCodeLinkSharePasswordInvalid holds the unique world-error code of this error
+const ErrCodeLinkSharePasswordInvalid = 3010
+
+// HTTPError holds the http error description
+func (err ErrLinkSharePasswordInvalid) HTTPError() web.HTTPError {
+	return web.HTTPError{HTTPCode: http.StatusForbidden, Code: ErrCodeLinkSharePasswordInvalid, Message: "The provided link share password is invalid."}
+}
+
+// ErrLinkSharePasswordEmpty represents an error where a password protected link share was created without a password
+type ErrLinkSharePasswordEmpty struct{}
+
+// IsErrLinkSharePasswordEmpty checks if an error is ErrLinkSharePasswordEmpty.
+func IsErrLinkSharePasswordEmpty(err error) bool {
+	_, ok := err.(ErrLinkSharePasswordEmpty)
+	return ok
+}
+
+func (err ErrLinkSharePasswordEmpty) Error() string {
+	return "Link Share password is empty"
+}
+
+// ErrCodeLinkSharePasswordEmpty holds the unique world-error code of this error
+const ErrCodeLinkSharePasswordEmpty = 3011
+
+// HTTPError holds the http error description
+func (err ErrLinkSharePasswordEmpty) HTTPError() web.HTTPError {
+	return web.HTTPError{HTTPCode: http.StatusBadRequest, Code: ErrCodeLinkSharePasswordEmpty, Message: "A password protected link share needs a password."}
+}
+
+// ErrLinkShareTooManyAuthAttempts represents an error where a link share was locked after too many wrong passwords
+type ErrLinkShareTooManyAuthAttempts struct {
+	Hash string
+}
+
+// IsErrLinkShareTooManyAuthAttempts checks if an error is ErrLinkShareTooManyAuthAttempts.
+func IsErrLinkShareTooManyAuthAttempts(err error) bool {
+	_, ok := err.(ErrLinkShareTooManyAuthAttempts)
+	return ok
+}
+
+func (err ErrLinkShareTooManyAuthAttempts) Error() string {
+	return fmt.Sprintf("Too many failed link share authentication attempts [Hash: %s]", err.Hash)
+}
+
+// ErrCodeLinkShareTooManyAuthAttempts holds the unique world-error code of this error
+const ErrCodeLinkShareTooManyAuthAttempts = 3012
+
+// HTTPError holds the http error description
+func (err ErrLinkShareTooManyAuthAttempts) HTTPError() web.HTTPError {
+	return web.HTTPError{HTTPCode: http.StatusTooManyRequests, Code: ErrCodeLinkShareTooManyAuthAttempts, Message: "Too many wrong passwords were provided for this link share. Please try again later."}
+}
+
+// ErrLinkShareExpired represents an error where a link share is used after it expired
+type ErrLinkShareExpired struct {
+	ShareID int64
+}
+
+// IsErrLinkShareExpired checks if an error is ErrLinkShareExpired.
+func IsErrLinkShareExpired(err error) bool {
+	_, ok := err.(ErrLinkShareExpired)
+	return ok
+}
+
+func (err ErrLinkShareExpired) Error() string {
+	return fmt.Sprintf("Link Share is expired [ShareID: %d]", err.ShareID)
+}
+
+// ErrCodeLinkShareExpired holds the unique world-error code of this error
+const ErrCodeLinkShareExpired = 3013
+
+// HTTPError holds the http error description
+func (err ErrLinkShareExpired) HTTPError() web.HTTPError {
+	return web.HTTPError{HTTPCode: http.StatusForbidden, Code: ErrCodeLinkShareExpired, Message: "This link share has expired."}
+}
+
+// ErrLinkShareAuthLimitReached represents an error where a link share was already used as often as allowed
+type ErrLinkShareAuthLimitReached struct {
+	ShareID int64
+}
+
+// IsErrLinkShareAuthLimitReached checks if an error is ErrLinkShareAuthLimitReached.
+func IsErrLinkShareAuthLimitReached(err error) bool {
+	_, ok := err.(ErrLinkShareAuthLimitReached)
+	return ok
+}
+
+func (err ErrLinkShareAuthLimitReached) Error() string {
+	return fmt.Sprintf("Link Share reached its maximum number of authentications [ShareID: %d]", err.ShareID)
+}
+
+// ErrCodeLinkShareAuthLimitReached holds the unique world-error code of this error
+const ErrCodeLinkShareAuthLimitReached = 3014
+
+// HTTPError holds the http error description
+func (err ErrLinkShareAuthLimitReached) HTTPError() web.HTTPError {
+	return web.HTTPError{HTTPCode: http.StatusForbidden, Code: ErrCodeLinkShareAuthLimitReached, Message: "This link share was already used as often as allowed."}
+}
+
+// ErrInvalidBurndownRange represents an error where the date range of a burndown is invalid
+type ErrInvalidBurndownRange struct {
+	ListID int64
+	Reason string
+}
+
+// IsErrInvalidBurndownRange checks if an error is ErrInvalidBurndownRange.
+func IsErrInvalidBurndownRange(err error) bool {
+	_, ok := err.(ErrInvalidBurndownRange)
+	return ok
+}
+
+func (err ErrInvalidBurndownRange) Error() string {
+	return fmt.Sprintf("Burndown date range is invalid [ListID: %d, Reason: %s]", err.ListID, err.Reason)
+}
+
+// ErrCodeInvalidBurndownRange holds the unique world-error code of this error
+const ErrCodeInvalidBurndownRange = 3015
+
+// HTTPError holds the http error description
+func (err ErrInvalidBurndownRange) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidBurndownRange,
+		Message:  "The date range of the burndown is invalid: " + err.Reason,
+	}
+}
+
 // ================
 // List task errors
 // ================
@@ -763,6 +927,63 @@ func (err ErrInvalidTaskFilterValue) HTTPError() web.HTTPError {
 	}
 }
 
+// ErrInvalidRepeatRule represents an error where the provided repeat rule is invalid or not supported
+type ErrInvalidRepeatRule struct {
+	Rule   string
+	Reason string
+}
+
+// IsErrInvalidRepeatRule checks if an error is ErrInvalidRepeatRule.
+func IsErrInvalidRepeatRule(err error) bool {
+	_, ok := err.(ErrInvalidRepeatRule)
+	return ok
+}
+
+func (err ErrInvalidRepeatRule) Error() string {
+	return fmt.Sprintf("Repeat rule is invalid [Rule: %s, Reason: %s]", err.Rule, err.Reason)
+}
+
+// ErrCodeInvalidRepeatRule holds the unique world-error code of this error
+const ErrCodeInvalidRepeatRule = 4020
+
+// HTTPError holds the http error description
+func (err ErrInvalidRepeatRule) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidRepeatRule,
+		Message:  fmt.Sprintf("The repeat rule '%s' is invalid: %s", err.Rule, err.Reason),
+	}
+}
+
+// ErrInvalidTaskEstimate represents an error where the estimate or the points of a task are negative
+type ErrInvalidTaskEstimate struct {
+	TaskID   int64
+	Estimate int64
+	Points   float64
+}
+
+// IsErrInvalidTaskEstimate checks if an error is ErrInvalidTaskEstimate.
+func IsErrInvalidTaskEstimate(err error) bool {
+	_, ok := err.(ErrInvalidTaskEstimate)
+	return ok
+}
+
+func (err ErrInvalidTaskEstimate) Error() string {
+	return fmt.Sprintf("Task estimate is invalid [TaskID: %d, Estimate: %d, Points: %f]", err.TaskID, err.Estimate, err.Points)
+}
+
+// ErrCodeInvalidTaskEstimate holds the unique world-error code of this error
+const ErrCodeInvalidTaskEstimate = 4021
+
+// HTTPError holds the http error description
+func (err ErrInvalidTaskEstimate) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidTaskEstimate,
+		Message:  "The estimate and the points of a task cannot be negative.",
+	}
+}
+
 // =================
 // Namespace errors
 // =================
@@ -1423,3 +1644,659 @@ func (err ErrSavedFilterNotAvailableForLinkShare) HTTPError() web.HTTPError {
 		Message:  "Saved filters are not available for link shares.",
 	}
 }
+
+// ========
+// Webhooks
+// ========
+
+// ErrWebhookDoesNotExist represents an error where a webhook does not exist
+type ErrWebhookDoesNotExist struct {
+	WebhookID int64
+}
+
+// IsErrWebhookDoesNotExist checks if an error is ErrWebhookDoesNotExist.
+func IsErrWebhookDoesNotExist(err error) bool {
+	_, ok := err.(ErrWebhookDoesNotExist)
+	return ok
+}
+
+func (err ErrWebhookDoesNotExist) Error() string {
+	return fmt.Sprintf("Webhook does not exist [WebhookID: %d]", err.WebhookID)
+}
+
+// ErrCodeWebhookDoesNotExist holds the unique world-error code of this error
+const ErrCodeWebhookDoesNotExist = 12001
+
+// HTTPError holds the http error description
+func (err ErrWebhookDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeWebhookDoesNotExist,
+		Message:  "This webhook does not exist.",
+	}
+}
+
+// ErrWebhookTargetURLInvalid represents an error where the target url of a webhook is invalid
+type ErrWebhookTargetURLInvalid struct {
+	TargetURL string
+}
+
+// IsErrWebhookTargetURLInvalid checks if an error is ErrWebhookTargetURLInvalid.
+func IsErrWebhookTargetURLInvalid(err error) bool {
+	_, ok := err.(ErrWebhookTargetURLInvalid)
+	return ok
+}
+
+func (err ErrWebhookTargetURLInvalid) Error() string {
+	return fmt.Sprintf("Webhook target url is invalid [TargetURL: %s]", err.TargetURL)
+}
+
+// ErrCodeWebhookTargetURLInvalid holds the unique world-error code of this error
+const ErrCodeWebhookTargetURLInvalid = 12002
+
+// HTTPError holds the http error description
+func (err ErrWebhookTargetURLInvalid) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeWebhookTargetURLInvalid,
+		Message:  "The webhook target url is invalid. It must be an absolute http or https url pointing to a public address.",
+	}
+}
+
+// ErrWebhookEventInvalid represents an error where a webhook has no or an unknown event
+type ErrWebhookEventInvalid struct {
+	EventName string
+}
+
+// IsErrWebhookEventInvalid checks if an error is ErrWebhookEventInvalid.
+func IsErrWebhookEventInvalid(err error) bool {
+	_, ok := err.(ErrWebhookEventInvalid)
+	return ok
+}
+
+func (err ErrWebhookEventInvalid) Error() string {
+	return fmt.Sprintf("Webhook event is invalid [EventName: %s]", err.EventName)
+}
+
+// ErrCodeWebhookEventInvalid holds the unique world-error code of this error
+const ErrCodeWebhookEventInvalid = 12003
+
+// HTTPError holds the http error description
+func (err ErrWebhookEventInvalid) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeWebhookEventInvalid,
+		Message:  "A webhook needs at least one event and all events must be valid.",
+	}
+}
+
+// =============
+// Notifications
+// =============
+
+// ErrNotificationDoesNotExist represents an error where a notification does not exist
+type ErrNotificationDoesNotExist struct {
+	NotificationID int64
+}
+
+// IsErrNotificationDoesNotExist checks if an error is ErrNotificationDoesNotExist.
+func IsErrNotificationDoesNotExist(err error) bool {
+	_, ok := err.(ErrNotificationDoesNotExist)
+	return ok
+}
+
+func (err ErrNotificationDoesNotExist) Error() string {
+	return fmt.Sprintf("Notification does not exist [NotificationID: %d]", err.NotificationID)
+}
+
+// ErrCodeNotificationDoesNotExist holds the unique world-error code of this error
+const ErrCodeNotificationDoesNotExist = 13001
+
+// HTTPError holds the http error description
+func (err ErrNotificationDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeNotificationDoesNotExist,
+		Message:  "This notification does not exist.",
+	}
+}
+
+// ==============
+// Task Revisions
+// ==============
+
+// ErrTaskRevisionDoesNotExist represents an error where a task revision does not exist
+type ErrTaskRevisionDoesNotExist struct {
+	TaskID     int64
+	RevisionID int64
+}
+
+// IsErrTaskRevisionDoesNotExist checks if an error is ErrTaskRevisionDoesNotExist.
+func IsErrTaskRevisionDoesNotExist(err error) bool {
+	_, ok := err.(ErrTaskRevisionDoesNotExist)
+	return ok
+}
+
+func (err ErrTaskRevisionDoesNotExist) Error() string {
+	return fmt.Sprintf("Task revision does not exist [TaskID: %d, RevisionID: %d]", err.TaskID, err.RevisionID)
+}
+
+// ErrCodeTaskRevisionDoesNotExist holds the unique world-error code of this error
+const ErrCodeTaskRevisionDoesNotExist = 14001
+
+// HTTPError holds the http error description
+func (err ErrTaskRevisionDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeTaskRevisionDoesNotExist,
+		Message:  "This task revision does not exist.",
+	}
+}
+
+// ==========
+// API Tokens
+// ==========
+
+// ErrAPITokenDoesNotExist represents an error where an api token does not exist
+type ErrAPITokenDoesNotExist struct {
+	TokenID int64
+}
+
+// IsErrAPITokenDoesNotExist checks if an error is ErrAPITokenDoesNotExist.
+func IsErrAPITokenDoesNotExist(err error) bool {
+	_, ok := err.(ErrAPITokenDoesNotExist)
+	return ok
+}
+
+func (err ErrAPITokenDoesNotExist) Error() string {
+	return fmt.Sprintf("API token does not exist [TokenID: %d]", err.TokenID)
+}
+
+// ErrCodeAPITokenDoesNotExist holds the unique world-error code of this error
+const ErrCodeAPITokenDoesNotExist = 16001
+
+// HTTPError holds the http error description
+func (err ErrAPITokenDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeAPITokenDoesNotExist,
+		Message:  "This api token does not exist.",
+	}
+}
+
+// ErrInvalidAPITokenScope represents an error where an api token scope is invalid
+type ErrInvalidAPITokenScope struct {
+	Scope string
+}
+
+// IsErrInvalidAPITokenScope checks if an error is ErrInvalidAPITokenScope.
+func IsErrInvalidAPITokenScope(err error) bool {
+	_, ok := err.(ErrInvalidAPITokenScope)
+	return ok
+}
+
+func (err ErrInvalidAPITokenScope) Error() string {
+	return fmt.Sprintf("API token scope is invalid [Scope: %s]", err.Scope)
+}
+
+// ErrCodeInvalidAPITokenScope holds the unique world-error code of this error
+const ErrCodeInvalidAPITokenScope = 16002
+
+// HTTPError holds the http error description
+func (err ErrInvalidAPITokenScope) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidAPITokenScope,
+		Message:  "An api token needs at least one scope and all scopes must be valid.",
+	}
+}
+
+// ErrInvalidAPITokenExpiry represents an error where the expiry date of an api token is not in the future
+type ErrInvalidAPITokenExpiry struct{}
+
+// IsErrInvalidAPITokenExpiry checks if an error is ErrInvalidAPITokenExpiry.
+func IsErrInvalidAPITokenExpiry(err error) bool {
+	_, ok := err.(ErrInvalidAPITokenExpiry)
+	return ok
+}
+
+func (err ErrInvalidAPITokenExpiry) Error() string {
+	return "API token expiry date is invalid"
+}
+
+// ErrCodeInvalidAPITokenExpiry holds the unique world-error code of this error
+const ErrCodeInvalidAPITokenExpiry = 16003
+
+// HTTPError holds the http error description
+func (err ErrInvalidAPITokenExpiry) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidAPITokenExpiry,
+		Message:  "An api token needs an expiry date in the future.",
+	}
+}
+
+// ErrAPITokenInvalid represents an error where an api token used to authenticate is invalid or expired
+type ErrAPITokenInvalid struct{}
+
+// IsErrAPITokenInvalid checks if an error is ErrAPITokenInvalid.
+func IsErrAPITokenInvalid(err error) bool {
+	_, ok := err.(ErrAPITokenInvalid)
+	return ok
+}
+
+func (err ErrAPITokenInvalid) Error() string {
+	return "API token is invalid"
+}
+
+// ErrCodeAPITokenInvalid holds the unique world-error code of this error
+const ErrCodeAPITokenInvalid = 16004
+
+// HTTPError holds the http error description
+func (err ErrAPITokenInvalid) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusUnauthorized,
+		Code:     ErrCodeAPITokenInvalid,
+		Message:  "The api token is invalid or expired.",
+	}
+}
+
+// ErrAPITokenMissingScope represents an error where an api token does not have the scope needed for a request
+type ErrAPITokenMissingScope struct {
+	Scope string
+}
+
+// IsErrAPITokenMissingScope checks if an error is ErrAPITokenMissingScope.
+func IsErrAPITokenMissingScope(err error) bool {
+	_, ok := err.(ErrAPITokenMissingScope)
+	return ok
+}
+
+func (err ErrAPITokenMissingScope) Error() string {
+	return fmt.Sprintf("API token is missing a scope [Scope: %s]", err.Scope)
+}
+
+// ErrCodeAPITokenMissingScope holds the unique world-error code of this error
+const ErrCodeAPITokenMissingScope = 16005
+
+// HTTPError holds the http error description
+func (err ErrAPITokenMissingScope) HTTPError() web.HTTPError {
+	msg := "The api token cannot be used for this request."
+	if err.Scope != "" {
+		msg = "The api token needs the scope " + err.Scope + " for this request."
+	}
+	return web.HTTPError{
+		HTTPCode: http.StatusForbidden,
+		Code:     ErrCodeAPITokenMissingScope,
+		Message:  msg,
+	}
+}
+
+// =============
+// Time tracking
+// =============
+
+// ErrTimeEntryDoesNotExist represents an error where a time entry does not exist
+type ErrTimeEntryDoesNotExist struct {
+	ID     int64
+	TaskID int64
+}
+
+// IsErrTimeEntryDoesNotExist checks if an error is ErrTimeEntryDoesNotExist.
+func IsErrTimeEntryDoesNotExist(err error) bool {
+	_, ok := err.(ErrTimeEntryDoesNotExist)
+	return ok
+}
+
+func (err ErrTimeEntryDoesNotExist) Error() string {
+	return fmt.Sprintf("Time entry does not exist [ID: %d, TaskID: %d]", err.ID, err.TaskID)
+}
+
+// ErrCodeTimeEntryDoesNotExist holds the unique world-error code of this error
+const ErrCodeTimeEntryDoesNotExist = 17001
+
+// HTTPError holds the http error description
+func (err ErrTimeEntryDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeTimeEntryDoesNotExist,
+		Message:  "This time entry does not exist.",
+	}
+}
+
+// ErrInvalidTimeEntry represents an error where the times of a time entry are invalid
+type ErrInvalidTimeEntry struct {
+	Reason string
+}
+
+// IsErrInvalidTimeEntry checks if an error is ErrInvalidTimeEntry.
+func IsErrInvalidTimeEntry(err error) bool {
+	_, ok := err.(ErrInvalidTimeEntry)
+	return ok
+}
+
+func (err ErrInvalidTimeEntry) Error() string {
+	return fmt.Sprintf("Time entry is invalid [Reason: %s]", err.Reason)
+}
+
+// ErrCodeInvalidTimeEntry holds the unique world-error code of this error
+const ErrCodeInvalidTimeEntry = 17002
+
+// HTTPError holds the http error description
+func (err ErrInvalidTimeEntry) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidTimeEntry,
+		Message:  "The time entry is invalid: " + err.Reason,
+	}
+}
+
+// ErrNoRunningTimer represents an error where a user does not have a running timer
+type ErrNoRunningTimer struct {
+	UserID int64
+}
+
+// IsErrNoRunningTimer checks if an error is ErrNoRunningTimer.
+func IsErrNoRunningTimer(err error) bool {
+	_, ok := err.(ErrNoRunningTimer)
+	return ok
+}
+
+func (err ErrNoRunningTimer) Error() string {
+	return fmt.Sprintf("User does not have a running timer [UserID: %d]", err.UserID)
+}
+
+// ErrCodeNoRunningTimer holds the unique world-error code of this error
+const ErrCodeNoRunningTimer = 17003
+
+// HTTPError holds the http error description
+func (err ErrNoRunningTimer) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeNoRunningTimer,
+		Message:  "You don't have a running timer.",
+	}
+}
+
+// ErrTimeTrackingNotAvailableForLinkShare represents an error where a link share tries to track time
+type ErrTimeTrackingNotAvailableForLinkShare struct {
+	LinkShareID int64
+}
+
+// IsErrTimeTrackingNotAvailableForLinkShare checks if an error is ErrTimeTrackingNotAvailableForLinkShare.
+func IsErrTimeTrackingNotAvailableForLinkShare(err error) bool {
+	_, ok := err.(ErrTimeTrackingNotAvailableForLinkShare)
+	return ok
+}
+
+func (err ErrTimeTrackingNotAvailableForLinkShare) Error() string {
+	return fmt.Sprintf("Time tracking is not available for link shares [LinkShareID: %d]", err.LinkShareID)
+}
+
+// ErrCodeTimeTrackingNotAvailableForLinkShare holds the unique world-error code of this error
+const ErrCodeTimeTrackingNotAvailableForLinkShare = 17004
+
+// HTTPError holds the http error description
+func (err ErrTimeTrackingNotAvailableForLinkShare) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusPreconditionFailed,
+		Code:     ErrCodeTimeTrackingNotAvailableForLinkShare,
+		Message:  "Time tracking is not available for link shares.",
+	}
+}
+
+// ErrInvalidTimeReportFormat represents an error where a time report was requested in an unknown format
+type ErrInvalidTimeReportFormat struct {
+	Format string
+}
+
+// IsErrInvalidTimeReportFormat checks if an error is ErrInvalidTimeReportFormat.
+func IsErrInvalidTimeReportFormat(err error) bool {
+	_, ok := err.(ErrInvalidTimeReportFormat)
+	return ok
+}
+
+func (err ErrInvalidTimeReportFormat) Error() string {
+	return fmt.Sprintf("Time report format is invalid [Format: %s]", err.Format)
+}
+
+// ErrCodeInvalidTimeReportFormat holds the unique world-error code of this error
+const ErrCodeInvalidTimeReportFormat = 17005
+
+// HTTPError holds the http error description
+func (err ErrInvalidTimeReportFormat) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidTimeReportFormat,
+		Message:  fmt.Sprintf("The time report format '%s' is invalid, it must be either json or csv.", err.Format),
+	}
+}
+
+// =============
+// Custom fields
+// =============
+
+// ErrCustomFieldDoesNotExist represents an error where a custom field does not exist
+type ErrCustomFieldDoesNotExist struct {
+	ID     int64
+	ListID int64
+	Name   string
+}
+
+// IsErrCustomFieldDoesNotExist checks if an error is ErrCustomFieldDoesNotExist.
+func IsErrCustomFieldDoesNotExist(err error) bool {
+	_, ok := err.(ErrCustomFieldDoesNotExist)
+	return ok
+}
+
+func (err ErrCustomFieldDoesNotExist) Error() string {
+	return fmt.Sprintf("Custom field does not exist [ID: %d, ListID: %d, Name: %s]", err.ID, err.ListID, err.Name)
+}
+
+// ErrCodeCustomFieldDoesNotExist holds the unique world-error code of this error
+const ErrCodeCustomFieldDoesNotExist = 18001
+
+// HTTPError holds the http error description
+func (err ErrCustomFieldDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeCustomFieldDoesNotExist,
+		Message:  "This custom field does not exist.",
+	}
+}
+
+// ErrInvalidCustomFieldName represents an error where the name of a custom field contains invalid characters
+type ErrInvalidCustomFieldName struct {
+	Name string
+}
+
+// IsErrInvalidCustomFieldName checks if an error is ErrInvalidCustomFieldName.
+func IsErrInvalidCustomFieldName(err error) bool {
+	_, ok := err.(ErrInvalidCustomFieldName)
+	return ok
+}
+
+func (err ErrInvalidCustomFieldName) Error() string {
+	return fmt.Sprintf("Custom field name is invalid [Name: %s]", err.Name)
+}
+
+// ErrCodeInvalidCustomFieldName holds the unique world-error code of this error
+const ErrCodeInvalidCustomFieldName = 18002
+
+// HTTPError holds the http error description
+func (err ErrInvalidCustomFieldName) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidCustomFieldName,
+		Message:  "The name of a custom field can only contain lowercase letters, numbers and underscores.",
+	}
+}
+
+// ErrCustomFieldNameIsNotUnique represents an error where a list already has a custom field with the same name
+type ErrCustomFieldNameIsNotUnique struct {
+	Name   string
+	ListID int64
+}
+
+// IsErrCustomFieldNameIsNotUnique checks if an error is ErrCustomFieldNameIsNotUnique.
+func IsErrCustomFieldNameIsNotUnique(err error) bool {
+	_, ok := err.(ErrCustomFieldNameIsNotUnique)
+	return ok
+}
+
+func (err ErrCustomFieldNameIsNotUnique) Error() string {
+	return fmt.Sprintf("Custom field name is not unique [Name: %s, ListID: %d]", err.Name, err.ListID)
+}
+
+// ErrCodeCustomFieldNameIsNotUnique holds the unique world-error code of this error
+const ErrCodeCustomFieldNameIsNotUnique = 18003
+
+// HTTPError holds the http error description
+func (err ErrCustomFieldNameIsNotUnique) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeCustomFieldNameIsNotUnique,
+		Message:  "This list already has a custom field with this name.",
+	}
+}
+
+// ErrInvalidCustomFieldType represents an error where the type of a custom field is not supported
+type ErrInvalidCustomFieldType struct {
+	Type CustomFieldType
+}
+
+// IsErrInvalidCustomFieldType checks if an error is ErrInvalidCustomFieldType.
+func IsErrInvalidCustomFieldType(err error) bool {
+	_, ok := err.(ErrInvalidCustomFieldType)
+	return ok
+}
+
+func (err ErrInvalidCustomFieldType) Error() string {
+	return fmt.Sprintf("Custom field type is invalid [Type: %s]", err.Type)
+}
+
+// ErrCodeInvalidCustomFieldType holds the unique world-error code of this error
+const ErrCodeInvalidCustomFieldType = 18004
+
+// HTTPError holds the http error description
+func (err ErrInvalidCustomFieldType) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidCustomFieldType,
+		Message:  fmt.Sprintf("The custom field type '%s' is invalid, it must be one of text, number, date, select, checkbox or user.", err.Type),
+	}
+}
+
+// ErrCustomFieldNeedsOptions represents an error where a select field was created without any options
+type ErrCustomFieldNeedsOptions struct {
+	Name string
+}
+
+// IsErrCustomFieldNeedsOptions checks if an error is ErrCustomFieldNeedsOptions.
+func IsErrCustomFieldNeedsOptions(err error) bool {
+	_, ok := err.(ErrCustomFieldNeedsOptions)
+	return ok
+}
+
+func (err ErrCustomFieldNeedsOptions) Error() string {
+	return fmt.Sprintf("Custom select field needs options [Name: %s]", err.Name)
+}
+
+// ErrCodeCustomFieldNeedsOptions holds the unique world-error code of this error
+const ErrCodeCustomFieldNeedsOptions = 18005
+
+// HTTPError holds the http error description
+func (err ErrCustomFieldNeedsOptions) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeCustomFieldNeedsOptions,
+		Message:  "A select field needs at least one option.",
+	}
+}
+
+// ErrInvalidCustomFieldValue represents an error where the value of a custom field on a task does not fit its type
+type ErrInvalidCustomFieldValue struct {
+	Name   string
+	Reason string
+}
+
+// IsErrInvalidCustomFieldValue checks if an error is ErrInvalidCustomFieldValue.
+func IsErrInvalidCustomFieldValue(err error) bool {
+	_, ok := err.(ErrInvalidCustomFieldValue)
+	return ok
+}
+
+func (err ErrInvalidCustomFieldValue) Error() string {
+	return fmt.Sprintf("Custom field value is invalid [Name: %s, Reason: %s]", err.Name, err.Reason)
+}
+
+// ErrCodeInvalidCustomFieldValue holds the unique world-error code of this error
+const ErrCodeInvalidCustomFieldValue = 18006
+
+// HTTPError holds the http error description
+func (err ErrInvalidCustomFieldValue) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidCustomFieldValue,
+		Message:  fmt.Sprintf("The value of the custom field '%s' is invalid: %s", err.Name, err.Reason),
+	}
+}
+
+// ErrCustomFieldTypesDiffer represents an error where tasks of multiple lists are filtered or sorted by custom fields
+// which have the same name but a different type
+type ErrCustomFieldTypesDiffer struct {
+	Name string
+}
+
+// IsErrCustomFieldTypesDiffer checks if an error is ErrCustomFieldTypesDiffer.
+func IsErrCustomFieldTypesDiffer(err error) bool {
+	_, ok := err.(ErrCustomFieldTypesDiffer)
+	return ok
+}
+
+func (err ErrCustomFieldTypesDiffer) Error() string {
+	return fmt.Sprintf("Custom fields with the same name have different types [Name: %s]", err.Name)
+}
+
+// ErrCodeCustomFieldTypesDiffer holds the unique world-error code of this error
+const ErrCodeCustomFieldTypesDiffer = 18007
+
+// HTTPError holds the http error description
+func (err ErrCustomFieldTypesDiffer) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeCustomFieldTypesDiffer,
+		Message:  fmt.Sprintf("The custom fields named '%s' have different types in the lists of these tasks, they cannot be filtered or sorted by together.", err.Name),
+	}
+}
+
+// ==============
+// List templates
+// ==============
+
+// ErrListTemplateDoesNotExist represents an error where a list template does not exist
+type ErrListTemplateDoesNotExist struct {
+	TemplateID int64
+}
+
+// IsErrListTemplateDoesNotExist checks if an error is ErrListTemplateDoesNotExist.
+func IsErrListTemplateDoesNotExist(err error) bool {
+	_, ok := err.(ErrListTemplateDoesNotExist)
+	return ok
+}
+
+func (err ErrListTemplateDoesNotExist) Error() string {
+	return fmt.Sprintf("List template does not exist [TemplateID: %d]", err.TemplateID)
+}
+
+// ErrCodeListTemplateDoesNotExist holds the unique world-error code of this error
+const ErrCodeListTemplateDoesNotExist = 19001
+
+// HTTPError holds the http error description
+func (err ErrListTemplateDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeListTemplateDoesNotExist,
+		Message:  "This list template does not exist.",
+	}
+}
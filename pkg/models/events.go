@@ -0,0 +1,367 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+)
+
+/////////////////
+// Task Events //
+/////////////////
+
+// TaskCreatedEvent represents an event where a task has been created
+type TaskCreatedEvent struct {
+	Task *Task    `json:"task"`
+	Doer web.Auth `json:"doer"`
+}
+
+// Name defines the name for TaskCreatedEvent
+func (t *TaskCreatedEvent) Name() string {
+	return "task.created"
+}
+
+// TaskUpdatedEvent represents an event where a task has been updated
+type TaskUpdatedEvent struct {
+	// The task with the values it had before the update
+	OldTask *Task `json:"old_task"`
+	Task    *Task `json:"task"`
+	// The user or link share who updated the task. Might be nil if the update did not come through the api.
+	Doer web.Auth `json:"doer"`
+}
+
+// Name defines the name for TaskUpdatedEvent
+func (t *TaskUpdatedEvent) Name() string {
+	return "task.updated"
+}
+
+// TaskDeletedEvent represents an event where a task has been deleted
+type TaskDeletedEvent struct {
+	Task *Task    `json:"task"`
+	Doer web.Auth `json:"doer"`
+}
+
+// Name defines the name for TaskDeletedEvent
+func (t *TaskDeletedEvent) Name() string {
+	return "task.deleted"
+}
+
+// TaskAssigneeCreatedEvent represents an event where a user has been assigned to a task
+type TaskAssigneeCreatedEvent struct {
+	Task     *Task      `json:"task"`
+	Assignee *user.User `json:"assignee"`
+	Doer     web.Auth   `json:"doer"`
+}
+
+// Name defines the name for TaskAssigneeCreatedEvent
+func (t *TaskAssigneeCreatedEvent) Name() string {
+	return "task.assignee.created"
+}
+
+// TaskAssigneeDeletedEvent represents an event where a user has been unassigned from a task
+type TaskAssigneeDeletedEvent struct {
+	Task     *Task      `json:"task"`
+	Assignee *user.User `json:"assignee"`
+	Doer     web.Auth   `json:"doer"`
+}
+
+// Name defines the name for TaskAssigneeDeletedEvent
+func (t *TaskAssigneeDeletedEvent) Name() string {
+	return "task.assignee.deleted"
+}
+
+// TaskLabelCreatedEvent represents an event where a label has been added to a task
+type TaskLabelCreatedEvent struct {
+	Task  *Task    `json:"task"`
+	Label *Label   `json:"label"`
+	Doer  web.Auth `json:"doer"`
+}
+
+// Name defines the name for TaskLabelCreatedEvent
+func (t *TaskLabelCreatedEvent) Name() string {
+	return "task.label.created"
+}
+
+// TaskLabelDeletedEvent represents an event where a label has been removed from a task
+type TaskLabelDeletedEvent struct {
+	Task  *Task    `json:"task"`
+	Label *Label   `json:"label"`
+	Doer  web.Auth `json:"doer"`
+}
+
+// Name defines the name for TaskLabelDeletedEvent
+func (t *TaskLabelDeletedEvent) Name() string {
+	return "task.label.deleted"
+}
+
+// TaskAttachmentCreatedEvent represents an event where a file has been attached to a task
+type TaskAttachmentCreatedEvent struct {
+	Task       *Task           `json:"task"`
+	Attachment *TaskAttachment `json:"attachment"`
+	Doer       web.Auth        `json:"doer"`
+}
+
+// Name defines the name for TaskAttachmentCreatedEvent
+func (t *TaskAttachmentCreatedEvent) Name() string {
+	return "task.attachment.created"
+}
+
+// TaskAttachmentDeletedEvent represents an event where an attachment has been removed from a task
+type TaskAttachmentDeletedEvent struct {
+	Task       *Task           `json:"task"`
+	Attachment *TaskAttachment `json:"attachment"`
+	Doer       web.Auth        `json:"doer"`
+}
+
+// Name defines the name for TaskAttachmentDeletedEvent
+func (t *TaskAttachmentDeletedEvent) Name() string {
+	return "task.attachment.deleted"
+}
+
+// TaskRelationCreatedEvent represents an event where a relation from a task to another task has been created
+type TaskRelationCreatedEvent struct {
+	Task     *Task         `json:"task"`
+	Relation *TaskRelation `json:"relation"`
+	Doer     web.Auth      `json:"doer"`
+}
+
+// Name defines the name for TaskRelationCreatedEvent
+func (t *TaskRelationCreatedEvent) Name() string {
+	return "task.relation.created"
+}
+
+// TaskRelationDeletedEvent represents an event where a relation from a task to another task has been deleted
+type TaskRelationDeletedEvent struct {
+	Task     *Task         `json:"task"`
+	Relation *TaskRelation `json:"relation"`
+	Doer     web.Auth      `json:"doer"`
+}
+
+// Name defines the name for TaskRelationDeletedEvent
+func (t *TaskRelationDeletedEvent) Name() string {
+	return "task.relation.deleted"
+}
+
+// TaskCommentCreatedEvent represents an event where a comment on a task has been created
+type TaskCommentCreatedEvent struct {
+	Task    *Task        `json:"task"`
+	Comment *TaskComment `json:"comment"`
+	Doer    web.Auth     `json:"doer"`
+}
+
+// Name defines the name for TaskCommentCreatedEvent
+func (t *TaskCommentCreatedEvent) Name() string {
+	return "task.comment.created"
+}
+
+// TaskCommentUpdatedEvent represents an event where a comment on a task has been updated
+type TaskCommentUpdatedEvent struct {
+	Task    *Task        `json:"task"`
+	Comment *TaskComment `json:"comment"`
+	Doer    web.Auth     `json:"doer"`
+}
+
+// Name defines the name for TaskCommentUpdatedEvent
+func (t *TaskCommentUpdatedEvent) Name() string {
+	return "task.comment.updated"
+}
+
+// TaskCommentDeletedEvent represents an event where a comment on a task has been deleted
+type TaskCommentDeletedEvent struct {
+	Task    *Task        `json:"task"`
+	Comment *TaskComment `json:"comment"`
+	Doer    web.Auth     `json:"doer"`
+}
+
+// Name defines the name for TaskCommentDeletedEvent
+func (t *TaskCommentDeletedEvent) Name() string {
+	return "task.comment.deleted"
+}
+
+// UserMentionedEvent represents an event where a user has been mentioned in a task description or comment
+type UserMentionedEvent struct {
+	Task *Task `json:"task"`
+	// The comment the user was mentioned in. Nil if the user was mentioned in the task description.
+	Comment   *TaskComment `json:"comment"`
+	Mentioned *user.User   `json:"mentioned"`
+	Doer      web.Auth     `json:"doer"`
+}
+
+// Name defines the name for UserMentionedEvent
+func (t *UserMentionedEvent) Name() string {
+	return "user.mentioned"
+}
+
+/////////////////
+// List Events //
+/////////////////
+
+// ListCreatedEvent represents an event where a list has been created
+type ListCreatedEvent struct {
+	List *List `json:"list"`
+}
+
+// Name defines the name for ListCreatedEvent
+func (l *ListCreatedEvent) Name() string {
+	return "list.created"
+}
+
+// ListDeletedEvent represents an event where a list has been deleted
+type ListDeletedEvent struct {
+	List *List `json:"list"`
+}
+
+// Name defines the name for ListDeletedEvent
+func (l *ListDeletedEvent) Name() string {
+	return "list.deleted"
+}
+
+// ListSharedWithUserEvent represents an event where a list has been shared with a user
+type ListSharedWithUserEvent struct {
+	List *List      `json:"list"`
+	User *user.User `json:"user"`
+	Doer web.Auth   `json:"doer"`
+}
+
+// Name defines the name for ListSharedWithUserEvent
+func (l *ListSharedWithUserEvent) Name() string {
+	return "list.shared.user"
+}
+
+// ListSharedWithTeamEvent represents an event where a list has been shared with a team
+type ListSharedWithTeamEvent struct {
+	List *List    `json:"list"`
+	Team *Team    `json:"team"`
+	Doer web.Auth `json:"doer"`
+}
+
+// Name defines the name for ListSharedWithTeamEvent
+func (l *ListSharedWithTeamEvent) Name() string {
+	return "list.shared.team"
+}
+
+///////////////////
+// Bucket Events //
+///////////////////
+
+// BucketCreatedEvent represents an event where a kanban bucket has been created
+type BucketCreatedEvent struct {
+	Bucket *Bucket  `json:"bucket"`
+	Doer   web.Auth `json:"doer"`
+}
+
+// Name defines the name for BucketCreatedEvent
+func (b *BucketCreatedEvent) Name() string {
+	return "bucket.created"
+}
+
+// BucketUpdatedEvent represents an event where a kanban bucket has been updated
+type BucketUpdatedEvent struct {
+	Bucket *Bucket  `json:"bucket"`
+	Doer   web.Auth `json:"doer"`
+}
+
+// Name defines the name for BucketUpdatedEvent
+func (b *BucketUpdatedEvent) Name() string {
+	return "bucket.updated"
+}
+
+// BucketDeletedEvent represents an event where a kanban bucket has been deleted
+type BucketDeletedEvent struct {
+	Bucket *Bucket  `json:"bucket"`
+	Doer   web.Auth `json:"doer"`
+}
+
+// Name defines the name for BucketDeletedEvent
+func (b *BucketDeletedEvent) Name() string {
+	return "bucket.deleted"
+}
+
+//////////////////////
+// Namespace Events //
+//////////////////////
+
+// NamespaceCreatedEvent represents an event where a namespace has been created
+type NamespaceCreatedEvent struct {
+	Namespace *Namespace `json:"namespace"`
+}
+
+// Name defines the name for NamespaceCreatedEvent
+func (n *NamespaceCreatedEvent) Name() string {
+	return "namespace.created"
+}
+
+// NamespaceDeletedEvent represents an event where a namespace has been deleted
+type NamespaceDeletedEvent struct {
+	Namespace *Namespace `json:"namespace"`
+}
+
+// Name defines the name for NamespaceDeletedEvent
+func (n *NamespaceDeletedEvent) Name() string {
+	return "namespace.deleted"
+}
+
+/////////////////
+// Team Events //
+/////////////////
+
+// TeamCreatedEvent represents an event where a team has been created
+type TeamCreatedEvent struct {
+	Team *Team `json:"team"`
+}
+
+// Name defines the name for TeamCreatedEvent
+func (t *TeamCreatedEvent) Name() string {
+	return "team.created"
+}
+
+// TeamDeletedEvent represents an event where a team has been deleted
+type TeamDeletedEvent struct {
+	Team *Team `json:"team"`
+}
+
+// Name defines the name for TeamDeletedEvent
+func (t *TeamDeletedEvent) Name() string {
+	return "team.deleted"
+}
+
+// TeamMemberAddedEvent represents an event where a user has been added to a team
+type TeamMemberAddedEvent struct {
+	Team   *Team      `json:"team"`
+	Member *user.User `json:"member"`
+	Doer   web.Auth   `json:"doer"`
+}
+
+// Name defines the name for TeamMemberAddedEvent
+func (t *TeamMemberAddedEvent) Name() string {
+	return "team.member.added"
+}
+
+/////////////////
+// User Events //
+/////////////////
+
+// UserDataExportRequestedEvent represents an event where a user requested an export of their data
+type UserDataExportRequestedEvent struct {
+	User *user.User `json:"user"`
+}
+
+// Name defines the name for UserDataExportRequestedEvent
+func (u *UserDataExportRequestedEvent) Name() string {
+	return "user.export.requested"
+}
@@ -0,0 +1,466 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/files"
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/mail"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/api/pkg/utils"
+	"code.vikunja.io/api/pkg/version"
+)
+
+// How long a data export can be downloaded after it was created
+const dataExportValidity = 7 * 24 * time.Hour
+
+// The interval in which expired data exports are deleted
+const dataExportCleanupInterval = time.Hour
+
+var dataExportCleanupDaemonQuit chan bool
+
+// The ids of all users for which a data export is currently being created. Only one export per user can be created
+// at a time, otherwise they would overwrite each other's export_file_id and leave the other export file behind.
+var (
+	runningDataExports     = make(map[int64]bool)
+	runningDataExportsLock sync.Mutex
+)
+
+// TaskWithComments represents a task with all of its comments. It is only used in data exports.
+type TaskWithComments struct {
+	Task
+	Comments []*TaskComment `xorm:"-" json:"comments"`
+}
+
+// ListWithTasksAndBuckets represents a list with all of its tasks, kanban buckets and custom fields. It is only used in data exports.
+type ListWithTasksAndBuckets struct {
+	List
+	// Tasks and BackgroundFileID overwrite the fields of the list which are not serialized otherwise.
+	Tasks            []*TaskWithComments `xorm:"-" json:"tasks"`
+	Buckets          []*Bucket           `xorm:"-" json:"buckets"`
+	CustomFields     []*CustomField      `xorm:"-" json:"custom_fields"`
+	BackgroundFileID int64               `xorm:"null" json:"background_file_id"`
+}
+
+// NamespaceWithListsAndTasks represents a namespace with all of its lists, tasks and buckets.
+// It is the structure a data export is serialized as.
+type NamespaceWithListsAndTasks struct {
+	Namespace
+	Lists []*ListWithTasksAndBuckets `xorm:"-" json:"lists"`
+}
+
+// RequestUserDataExport starts building a new data export for the user in the background.
+// The user gets an email once it is ready. It returns ErrDataExportInProgress if an export for the user is already
+// being created.
+func RequestUserDataExport(u *user.User) error {
+	runningDataExportsLock.Lock()
+	defer runningDataExportsLock.Unlock()
+
+	if runningDataExports[u.ID] {
+		return user.ErrDataExportInProgress{UserID: u.ID}
+	}
+	runningDataExports[u.ID] = true
+
+	events.Dispatch(&UserDataExportRequestedEvent{User: u})
+	return nil
+}
+
+// Marks the data export of a user as done so the user can request a new one.
+func finishUserDataExport(userID int64) {
+	runningDataExportsLock.Lock()
+	defer runningDataExportsLock.Unlock()
+	delete(runningDataExports, userID)
+}
+
+// ExportUserData builds a zip archive with all namespaces, lists, tasks, comments, buckets, saved filters and
+// attachments the user has access to and saves it as their data export, replacing the previous one.
+func ExportUserData(u *user.User) (err error) {
+	// Exports can get quite big, so we write them to a temporary file first instead of keeping them in memory.
+	tmpFile, err := ioutil.TempFile("", "vikunja-export-*.zip")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+	defer tmpFile.Close()
+
+	exportWriter := zip.NewWriter(tmpFile)
+	err = writeUserDataToZip(u, exportWriter)
+	if err != nil {
+		return err
+	}
+	err = exportWriter.Close()
+	if err != nil {
+		return err
+	}
+
+	stat, err := tmpFile.Stat()
+	if err != nil {
+		return err
+	}
+	_, err = tmpFile.Seek(0, io.SeekStart)
+	if err != nil {
+		return err
+	}
+
+	exportFile, err := files.CreateWithoutSizeLimit(tmpFile, "vikunja-export.zip", uint64(stat.Size()), u, "application/zip")
+	if err != nil {
+		return err
+	}
+
+	// Replace the previous export of the user
+	previous := &user.User{}
+	_, err = x.Where("id = ?", u.ID).Get(previous)
+	if err != nil {
+		return err
+	}
+
+	_, err = x.
+		Where("id = ?", u.ID).
+		Cols("export_file_id").
+		NoAutoTime().
+		Update(&user.User{ExportFileID: exportFile.ID})
+	if err != nil {
+		return err
+	}
+	u.ExportFileID = exportFile.ID
+
+	if previous.ExportFileID != 0 {
+		err = (&files.File{ID: previous.ExportFileID}).Delete()
+		if err != nil && !files.IsErrFileDoesNotExist(err) {
+			return err
+		}
+	}
+
+	log.Debugf("[Data Export] Created data export %d for user %d", exportFile.ID, u.ID)
+
+	// Dont send a mail if we're testing
+	if !config.MailerEnabled.GetBool() || u.Email == "" {
+		return nil
+	}
+
+	data := map[string]interface{}{
+		"User":      u,
+		"ValidDays": int(dataExportValidity.Hours() / 24),
+	}
+
+	mail.SendMailWithTemplate(u.Email, "Your Vikunja data export is ready", "data-export-ready", data)
+	return nil
+}
+
+func writeUserDataToZip(u *user.User, writer *zip.Writer) (err error) {
+	err = utils.WriteBytesToZip("VERSION", []byte(version.Version), writer)
+	if err != nil {
+		return err
+	}
+
+	namespaces, err := getNamespacesForExport(u)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(namespaces)
+	if err != nil {
+		return err
+	}
+	err = utils.WriteBytesToZip("data.json", data, writer)
+	if err != nil {
+		return err
+	}
+
+	filters, err := getSavedFiltersForUser(u)
+	if err != nil {
+		return err
+	}
+	data, err = json.Marshal(filters)
+	if err != nil {
+		return err
+	}
+	err = utils.WriteBytesToZip("filters.json", data, writer)
+	if err != nil {
+		return err
+	}
+
+	// Attachments and list backgrounds are saved in the files folder of the archive, named after the file id
+	// they have in the exported data.
+	fileIDs := []int64{}
+	for _, n := range namespaces {
+		for _, l := range n.Lists {
+			if l.BackgroundFileID != 0 {
+				fileIDs = append(fileIDs, l.BackgroundFileID)
+			}
+			for _, t := range l.Tasks {
+				for _, a := range t.Attachments {
+					fileIDs = append(fileIDs, a.FileID)
+				}
+			}
+		}
+	}
+
+	// Only one file is opened at a time to not run out of file handles for users with a lot of attachments
+	for _, id := range fileIDs {
+		err = writeFileToExport(id, writer)
+		if err != nil {
+			if os.IsNotExist(err) {
+				log.Debugf("[Data Export] Not exporting file %d for user %d because it does not exist", id, u.ID)
+				continue
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeFileToExport(id int64, writer *zip.Writer) error {
+	f := &files.File{ID: id}
+	err := f.LoadFileByID()
+	if err != nil {
+		return err
+	}
+	defer f.File.Close()
+
+	return utils.WriteReaderToZip("files/"+strconv.FormatInt(id, 10), f.File, writer)
+}
+
+// Returns all namespaces and lists the user has access to, including archived ones, together with all of their tasks,
+// comments and buckets.
+func getNamespacesForExport(u *user.User) (namespaces []*NamespaceWithListsAndTasks, err error) {
+	rawNamespaces, _, _, err := (&Namespace{IsArchived: true}).ReadAll(u, "", -1, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces = []*NamespaceWithListsAndTasks{}
+	lists := []*List{}
+	listMap := make(map[int64]*ListWithTasksAndBuckets)
+	for _, n := range rawNamespaces.([]*NamespaceWithLists) {
+		// Favorite lists are part of their namespace already and saved filters are exported separately.
+		if n.ID == FavoritesPseudoNamespace.ID || n.ID == SavedFiltersPseudoNamespace.ID {
+			continue
+		}
+
+		namespace := &NamespaceWithListsAndTasks{
+			Namespace: n.Namespace,
+			Lists:     []*ListWithTasksAndBuckets{},
+		}
+		for _, l := range n.Lists {
+			if _, exists := listMap[l.ID]; exists {
+				continue
+			}
+			list := &ListWithTasksAndBuckets{
+				List:             *l,
+				Tasks:            []*TaskWithComments{},
+				Buckets:          []*Bucket{},
+				CustomFields:     []*CustomField{},
+				BackgroundFileID: l.BackgroundFileID,
+			}
+			namespace.Lists = append(namespace.Lists, list)
+			listMap[l.ID] = list
+			lists = append(lists, l)
+		}
+		namespaces = append(namespaces, namespace)
+	}
+
+	if len(lists) == 0 {
+		return
+	}
+
+	tasks, _, _, err := getTasksForLists(lists, u, &taskOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	taskIDs := make([]int64, 0, len(tasks))
+	taskMap := make(map[int64]*TaskWithComments, len(tasks))
+	for _, t := range tasks {
+		task := &TaskWithComments{
+			Task:     *t,
+			Comments: []*TaskComment{},
+		}
+		taskIDs = append(taskIDs, t.ID)
+		taskMap[t.ID] = task
+		listMap[t.ListID].Tasks = append(listMap[t.ListID].Tasks, task)
+	}
+
+	if len(taskIDs) > 0 {
+		comments := []*TaskComment{}
+		err = x.In("task_id", taskIDs).OrderBy("id asc").Find(&comments)
+		if err != nil {
+			return nil, err
+		}
+
+		authorIDs := make([]int64, 0, len(comments))
+		for _, c := range comments {
+			authorIDs = append(authorIDs, c.AuthorID)
+		}
+		authors := make(map[int64]*user.User, len(authorIDs))
+		err = x.In("id", authorIDs).Find(&authors)
+		if err != nil {
+			return nil, err
+		}
+		// Obfuscate all user emails
+		for _, a := range authors {
+			a.Email = ""
+		}
+
+		for _, c := range comments {
+			c.Author = authors[c.AuthorID]
+			taskMap[c.TaskID].Comments = append(taskMap[c.TaskID].Comments, c)
+		}
+	}
+
+	listIDs := make([]int64, 0, len(listMap))
+	for id := range listMap {
+		listIDs = append(listIDs, id)
+	}
+	buckets := []*Bucket{}
+	err = x.In("list_id", listIDs).OrderBy("id asc").Find(&buckets)
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range buckets {
+		listMap[b.ListID].Buckets = append(listMap[b.ListID].Buckets, b)
+	}
+
+	customFields := []*CustomField{}
+	err = x.In("list_id", listIDs).OrderBy("id asc").Find(&customFields)
+	if err != nil {
+		return nil, err
+	}
+	for _, cf := range customFields {
+		listMap[cf.ListID].CustomFields = append(listMap[cf.ListID].CustomFields, cf)
+	}
+
+	return
+}
+
+// GetUserDataExport returns the data export of a user, ready to be read. It returns ErrNoDataExportAvailable if the
+// user never requested one or if it already expired.
+func GetUserDataExport(u *user.User) (exportFile *files.File, err error) {
+	u, err = user.GetUserByID(u.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.ExportFileID == 0 {
+		return nil, user.ErrNoDataExportAvailable{UserID: u.ID}
+	}
+
+	exportFile = &files.File{ID: u.ExportFileID}
+	err = exportFile.LoadFileMetaByID()
+	if files.IsErrFileDoesNotExist(err) {
+		return nil, user.ErrNoDataExportAvailable{UserID: u.ID}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if exportFile.Created.Add(dataExportValidity).Before(time.Now()) {
+		return nil, user.ErrNoDataExportAvailable{UserID: u.ID}
+	}
+
+	err = exportFile.LoadFileByID()
+	return
+}
+
+// DeleteExpiredUserDataExports deletes all data exports which were created longer ago than they are valid at the
+// given time and returns how many were deleted.
+func DeleteExpiredUserDataExports(now time.Time) (deleted int, err error) {
+	users := []*user.User{}
+	err = x.Where("export_file_id IS NOT NULL AND export_file_id != 0").Find(&users)
+	if err != nil {
+		return
+	}
+
+	for _, u := range users {
+		f := &files.File{ID: u.ExportFileID}
+		err = f.LoadFileMetaByID()
+		if err != nil && !files.IsErrFileDoesNotExist(err) {
+			return
+		}
+		if err == nil {
+			if f.Created.Add(dataExportValidity).After(now) {
+				continue
+			}
+			err = f.Delete()
+			if err != nil && !files.IsErrFileDoesNotExist(err) {
+				return
+			}
+		}
+
+		_, err = x.
+			Where("id = ?", u.ID).
+			Cols("export_file_id").
+			NoAutoTime().
+			Update(&user.User{ExportFileID: 0})
+		if err != nil {
+			return
+		}
+		deleted++
+	}
+
+	return deleted, nil
+}
+
+// StartDataExportCleanupDaemon starts a goroutine which periodically deletes all expired user data exports.
+// Deleting them is idempotent, it is therefore safe to run multiple instances at the same time.
+func StartDataExportCleanupDaemon() {
+	dataExportCleanupDaemonQuit = make(chan bool)
+
+	go func() {
+		ticker := time.NewTicker(dataExportCleanupInterval)
+		defer ticker.Stop()
+
+		log.Debugf("[Data Export] Started cleanup daemon, checking every %s", dataExportCleanupInterval)
+
+		for {
+			select {
+			case <-dataExportCleanupDaemonQuit:
+				log.Debugf("[Data Export] Stopped cleanup daemon")
+				return
+			case now := <-ticker.C:
+				deleted, err := DeleteExpiredUserDataExports(now)
+				if err != nil {
+					log.Errorf("[Data Export] Could not delete expired data exports: %s", err)
+					continue
+				}
+				if deleted > 0 {
+					log.Debugf("[Data Export] Deleted %d expired data exports", deleted)
+				}
+			}
+		}
+	}()
+}
+
+// StopDataExportCleanupDaemon stops the data export cleanup daemon if it was started
+func StopDataExportCleanupDaemon() {
+	if dataExportCleanupDaemonQuit == nil {
+		return
+	}
+	close(dataExportCleanupDaemonQuit)
+	dataExportCleanupDaemonQuit = nil
+}
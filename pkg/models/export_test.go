@@ -0,0 +1,211 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/files"
+	"code.vikunja.io/api/pkg/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetNamespacesForExport(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	u := &user.User{ID: 1}
+
+	namespaces, err := getNamespacesForExport(u)
+	assert.NoError(t, err)
+
+	var list, list2 *ListWithTasksAndBuckets
+	for _, n := range namespaces {
+		assert.NotEqual(t, FavoritesPseudoNamespace.ID, n.ID)
+		assert.NotEqual(t, SavedFiltersPseudoNamespace.ID, n.ID)
+		for _, l := range n.Lists {
+			if l.ID == 1 {
+				list = l
+			}
+			if l.ID == 2 {
+				list2 = l
+			}
+		}
+	}
+	if !assert.NotNil(t, list) || !assert.NotNil(t, list2) {
+		return
+	}
+
+	assert.Len(t, list.Buckets, 3)
+	assert.Len(t, list.CustomFields, 0)
+	assert.Len(t, list2.CustomFields, 4)
+	var task *TaskWithComments
+	for _, tk := range list.Tasks {
+		assert.Equal(t, int64(1), tk.ListID)
+		if tk.ID == 1 {
+			task = tk
+		}
+	}
+	if !assert.NotNil(t, task) {
+		return
+	}
+	assert.Len(t, task.Comments, 1)
+	assert.Equal(t, "Lorem Ipsum Dolor Sit Amet", task.Comments[0].Comment)
+	assert.Len(t, task.Attachments, 2)
+}
+
+func TestExportUserData(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		files.InitTestFileFixtures(t)
+		u := &user.User{ID: 1}
+
+		err := ExportUserData(u)
+		assert.NoError(t, err)
+		assert.NotEqual(t, int64(0), u.ExportFileID)
+		db.AssertExists(t, "users", map[string]interface{}{
+			"id":             1,
+			"export_file_id": u.ExportFileID,
+		}, false)
+
+		exportFile, err := GetUserDataExport(u)
+		assert.NoError(t, err)
+		defer exportFile.File.Close()
+
+		r, err := zip.NewReader(exportFile.File, int64(exportFile.Size))
+		assert.NoError(t, err)
+
+		archived := make(map[string]*zip.File, len(r.File))
+		for _, f := range r.File {
+			archived[f.Name] = f
+		}
+		assert.Contains(t, archived, "VERSION")
+		assert.Contains(t, archived, "filters.json")
+		// The attachment of task 1
+		assert.Contains(t, archived, "files/1")
+		// The file of the other attachment of task 1 does not exist
+		assert.NotContains(t, archived, "files/9999")
+
+		rc, err := archived["data.json"].Open()
+		assert.NoError(t, err)
+		defer rc.Close()
+		content, err := ioutil.ReadAll(rc)
+		assert.NoError(t, err)
+		namespaces := []*NamespaceWithListsAndTasks{}
+		err = json.Unmarshal(content, &namespaces)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, namespaces)
+	})
+	t.Run("replaces previous export", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		files.InitTestFileFixtures(t)
+		u := &user.User{ID: 1}
+
+		err := ExportUserData(u)
+		assert.NoError(t, err)
+		previousExportFileID := u.ExportFileID
+
+		err = ExportUserData(u)
+		assert.NoError(t, err)
+		assert.NotEqual(t, previousExportFileID, u.ExportFileID)
+		db.AssertMissing(t, "files", map[string]interface{}{
+			"id": previousExportFileID,
+		})
+	})
+}
+
+func TestRequestUserDataExport(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		defer finishUserDataExport(1)
+		err := RequestUserDataExport(&user.User{ID: 1})
+		assert.NoError(t, err)
+		events.AssertDispatched(t, &UserDataExportRequestedEvent{})
+	})
+	t.Run("already running", func(t *testing.T) {
+		defer finishUserDataExport(1)
+		err := RequestUserDataExport(&user.User{ID: 1})
+		assert.NoError(t, err)
+		err = RequestUserDataExport(&user.User{ID: 1})
+		assert.Error(t, err)
+		assert.True(t, user.IsErrDataExportInProgress(err))
+
+		// Other users can still request their export
+		defer finishUserDataExport(2)
+		err = RequestUserDataExport(&user.User{ID: 2})
+		assert.NoError(t, err)
+	})
+	t.Run("again after finishing", func(t *testing.T) {
+		err := RequestUserDataExport(&user.User{ID: 1})
+		assert.NoError(t, err)
+		finishUserDataExport(1)
+		err = RequestUserDataExport(&user.User{ID: 1})
+		assert.NoError(t, err)
+		finishUserDataExport(1)
+	})
+}
+
+func TestGetUserDataExport(t *testing.T) {
+	t.Run("no export", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+
+		_, err := GetUserDataExport(&user.User{ID: 1})
+		assert.Error(t, err)
+		assert.True(t, user.IsErrNoDataExportAvailable(err))
+	})
+}
+
+func TestDeleteExpiredUserDataExports(t *testing.T) {
+	t.Run("not expired", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		files.InitTestFileFixtures(t)
+		u := &user.User{ID: 1}
+		err := ExportUserData(u)
+		assert.NoError(t, err)
+
+		deleted, err := DeleteExpiredUserDataExports(time.Now())
+		assert.NoError(t, err)
+		assert.Equal(t, 0, deleted)
+		db.AssertExists(t, "files", map[string]interface{}{
+			"id": u.ExportFileID,
+		}, false)
+	})
+	t.Run("expired", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		files.InitTestFileFixtures(t)
+		u := &user.User{ID: 1}
+		err := ExportUserData(u)
+		assert.NoError(t, err)
+
+		deleted, err := DeleteExpiredUserDataExports(time.Now().Add(dataExportValidity + time.Hour))
+		assert.NoError(t, err)
+		assert.Equal(t, 1, deleted)
+		db.AssertMissing(t, "files", map[string]interface{}{
+			"id": u.ExportFileID,
+		})
+		db.AssertExists(t, "users", map[string]interface{}{
+			"id":             1,
+			"export_file_id": 0,
+		}, false)
+
+		_, err = GetUserDataExport(u)
+		assert.True(t, user.IsErrNoDataExportAvailable(err))
+	})
+}
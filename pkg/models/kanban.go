@@ -19,6 +19,7 @@ package models
 import (
 	"time"
 
+	"code.vikunja.io/api/pkg/events"
 	"code.vikunja.io/api/pkg/log"
 	"code.vikunja.io/api/pkg/user"
 	"code.vikunja.io/web"
@@ -48,6 +49,9 @@ type Bucket struct {
 	CreatedBy   *user.User `xorm:"-" json:"created_by" valid:"-"`
 	CreatedByID int64      `xorm:"int(11) not null" json:"-"`
 
+	// The user or link share who is currently changing this, set when checking the rights.
+	doer web.Auth `xorm:"-"`
+
 	web.Rights   `xorm:"-" json:"-"`
 	web.CRUDable `xorm:"-" json:"-"`
 }
@@ -167,6 +171,11 @@ func (b *Bucket) Create(a web.Auth) (err error) {
 	b.CreatedByID = a.GetID()
 
 	_, err = x.Insert(b)
+	if err != nil {
+		return
+	}
+
+	events.Dispatch(&BucketCreatedEvent{Bucket: b, Doer: a})
 	return
 }
 
@@ -187,6 +196,11 @@ func (b *Bucket) Create(a web.Auth) (err error) {
 // @Router /lists/{listID}/buckets/{bucketID} [post]
 func (b *Bucket) Update() (err error) {
 	_, err = x.Where("id = ?", b.ID).Update(b)
+	if err != nil {
+		return
+	}
+
+	events.Dispatch(&BucketUpdatedEvent{Bucket: b, Doer: b.doer})
 	return
 }
 
@@ -241,5 +255,11 @@ func (b *Bucket) Delete() (err error) {
 		return
 	}
 
-	return s.Commit()
+	err = s.Commit()
+	if err != nil {
+		return
+	}
+
+	events.Dispatch(&BucketDeletedEvent{Bucket: b, Doer: b.doer})
+	return
 }
@@ -26,11 +26,13 @@ func (b *Bucket) CanCreate(a web.Auth) (bool, error) {
 
 // CanUpdate checks if a user can update an existing bucket
 func (b *Bucket) CanUpdate(a web.Auth) (bool, error) {
+	b.doer = a
 	return b.canDoBucket(a)
 }
 
 // CanDelete checks if a user can delete an existing bucket
 func (b *Bucket) CanDelete(a web.Auth) (bool, error) {
+	b.doer = a
 	return b.canDoBucket(a)
 }
 
@@ -19,6 +19,7 @@ package models
 import (
 	"time"
 
+	"code.vikunja.io/api/pkg/events"
 	"code.vikunja.io/api/pkg/user"
 	"code.vikunja.io/web"
 	"xorm.io/builder"
@@ -34,6 +35,9 @@ type LabelTask struct {
 	// A timestamp when this task was created. You cannot change this value.
 	Created time.Time `xorm:"created not null" json:"created"`
 
+	// The user or link share who is currently changing this, set when checking the rights.
+	doer web.Auth `xorm:"-"`
+
 	web.CRUDable `xorm:"-" json:"-"`
 	web.Rights   `xorm:"-" json:"-"`
 }
@@ -58,8 +62,27 @@ func (LabelTask) TableName() string {
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /tasks/{task}/labels/{label} [delete]
 func (lt *LabelTask) Delete() (err error) {
-	_, err = x.Delete(&LabelTask{LabelID: lt.LabelID, TaskID: lt.TaskID})
-	return err
+	deleted, err := x.Delete(&LabelTask{LabelID: lt.LabelID, TaskID: lt.TaskID})
+	if err != nil || deleted == 0 {
+		return err
+	}
+
+	task, err := GetTaskByIDSimple(lt.TaskID)
+	if err != nil {
+		return err
+	}
+
+	label, err := getLabelByIDSimple(lt.LabelID)
+	if err != nil {
+		return err
+	}
+
+	events.Dispatch(&TaskLabelDeletedEvent{
+		Task:  &task,
+		Label: label,
+		Doer:  lt.doer,
+	})
+	return nil
 }
 
 // Create adds a label to a task
@@ -94,7 +117,27 @@ func (lt *LabelTask) Create(a web.Auth) (err error) {
 	}
 
 	err = updateListByTaskID(lt.TaskID)
-	return
+	if err != nil {
+		return err
+	}
+
+	task, err := GetTaskByIDSimple(lt.TaskID)
+	if err != nil {
+		return err
+	}
+
+	label := &Label{}
+	_, err = x.Where("id = ?", lt.LabelID).Get(label)
+	if err != nil {
+		return err
+	}
+
+	events.Dispatch(&TaskLabelCreatedEvent{
+		Task:  &task,
+		Label: label,
+		Doer:  a,
+	})
+	return nil
 }
 
 // ReadAll gets all labels on a task
@@ -230,7 +273,17 @@ func (t *Task) updateTaskLabels(creator web.Auth, labels []*Label) (err error) {
 	if len(labels) == 0 && len(t.Labels) > 0 {
 		_, err = x.Where("task_id = ?", t.ID).
 			Delete(LabelTask{})
-		return err
+		if err != nil {
+			return err
+		}
+		for _, oldLabel := range t.Labels {
+			events.Dispatch(&TaskLabelDeletedEvent{
+				Task:  t,
+				Label: oldLabel,
+				Doer:  creator,
+			})
+		}
+		return nil
 	}
 
 	// If we didn't change anything (from 0 to zero) don't do anything.
@@ -275,6 +328,13 @@ func (t *Task) updateTaskLabels(creator web.Auth, labels []*Label) (err error) {
 		if err != nil {
 			return err
 		}
+		for _, id := range labelsToDelete {
+			events.Dispatch(&TaskLabelDeletedEvent{
+				Task:  t,
+				Label: oldLabels[id],
+				Doer:  creator,
+			})
+		}
 	}
 
 	// Loop through our labels and add them
@@ -307,6 +367,12 @@ func (t *Task) updateTaskLabels(creator web.Auth, labels []*Label) (err error) {
 			return err
 		}
 		t.Labels = append(t.Labels, label)
+
+		events.Dispatch(&TaskLabelCreatedEvent{
+			Task:  t,
+			Label: label,
+			Doer:  creator,
+		})
 	}
 
 	err = updateListLastUpdated(&List{ID: t.ListID})
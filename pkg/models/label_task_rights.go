@@ -42,6 +42,7 @@ func (lt *LabelTask) CanCreate(a web.Auth) (bool, error) {
 
 // CanDelete checks if a user can delete a label from a task
 func (lt *LabelTask) CanDelete(a web.Auth) (bool, error) {
+	lt.doer = a
 	canDoLabelTask, err := canDoLabelTask(lt.TaskID, a)
 	if err != nil {
 		return false, err
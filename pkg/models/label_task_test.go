@@ -53,11 +53,14 @@ func TestLabelTask_ReadAll(t *testing.T) {
 						Updated:     testUpdatedTime,
 						CreatedByID: 2,
 						CreatedBy: &user.User{
-							ID:       2,
-							Username: "user2",
-							Password: "$2a$14$dcadBoMBL9jQoOcZK8Fju.cy0Ptx2oZECkKLnaa8ekRoTFe1w7To.",
-							Created:  testCreatedTime,
-							Updated:  testUpdatedTime,
+							ID:                      2,
+							Username:                "user2",
+							EmailNotifyTaskAssigned: true,
+							EmailNotifyTaskComment:  true,
+							EmailNotifyTaskDone:     true,
+							Password:                "$2a$14$dcadBoMBL9jQoOcZK8Fju.cy0Ptx2oZECkKLnaa8ekRoTFe1w7To.",
+							Created:                 testCreatedTime,
+							Updated:                 testUpdatedTime,
 						},
 					},
 				},
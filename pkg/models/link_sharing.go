@@ -18,12 +18,16 @@
 package models
 
 import (
+	"errors"
 	"time"
 
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/log"
 	"code.vikunja.io/api/pkg/user"
 	"code.vikunja.io/api/pkg/utils"
 	"code.vikunja.io/web"
 	"github.com/dgrijalva/jwt-go"
+	"golang.org/x/crypto/bcrypt"
 )
 
 // SharingType holds the sharing type
@@ -47,9 +51,21 @@ type LinkSharing struct {
 	// The right this list is shared with. 0 = Read only, 1 = Read & Write, 2 = Admin. See the docs for more details.
 	Right Right `xorm:"int(11) INDEX not null default 0" json:"right" valid:"length(0|2)" maximum:"2" default:"0"`
 
-	// The kind of this link. 0 = undefined, 1 = without password, 2 = with password (currently not implemented).
+	// The kind of this link. 0 = undefined, 1 = without password, 2 = with password.
 	SharingType SharingType `xorm:"int(11) INDEX not null default 0" json:"sharing_type" valid:"length(0|2)" maximum:"2" default:"0"`
 
+	// The password of this link share. You can only set it when creating the share, it is never returned.
+	Password string `xorm:"text null" json:"password,omitempty"`
+
+	// An optional name to tell this link share apart from the others.
+	Name string `xorm:"text null" json:"name"`
+	// The date when this link share expires. After that, nobody can use it anymore. If not set, it never expires.
+	ExpiresAt time.Time `xorm:"DATETIME null" json:"expires_at"`
+	// How often this link share can be used to authenticate. 0 means no limit.
+	MaxAuthentications int64 `xorm:"int(11) not null default 0" json:"max_authentications"`
+	// How often this link share was used to authenticate. You cannot change this value.
+	Authentications int64 `xorm:"int(11) not null default 0" json:"authentications"`
+
 	// The user who shared this list
 	SharedBy   *user.User `xorm:"-" json:"shared_by"`
 	SharedByID int64      `xorm:"int(11) INDEX not null" json:"-"`
@@ -74,16 +90,32 @@ func (share *LinkSharing) GetID() int64 {
 }
 
 // GetLinkShareFromClaims builds a link sharing object from jwt claims
+// The share is loaded from the database to make sure tokens of deleted or expired shares stop working.
 func GetLinkShareFromClaims(claims jwt.MapClaims) (share *LinkSharing, err error) {
+	id := int64(claims["id"].(float64))
+	hash := claims["hash"].(string)
+
+	// The share needs to be empty, otherwise xorm would use everything in it as a condition
 	share = &LinkSharing{}
-	share.ID = int64(claims["id"].(float64))
-	share.Hash = claims["hash"].(string)
-	share.ListID = int64(claims["list_id"].(float64))
-	share.Right = Right(claims["right"].(float64))
-	share.SharedByID = int64(claims["sharedByID"].(float64))
+	exists, err := x.Where("id = ?", id).Get(share)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrListShareDoesNotExist{ID: id, Hash: hash}
+	}
+	if share.isExpired() {
+		return nil, ErrLinkShareExpired{ShareID: share.ID}
+	}
+
+	share.Password = ""
 	return
 }
 
+func (share *LinkSharing) isExpired() bool {
+	return !share.ExpiresAt.IsZero() && share.ExpiresAt.Before(time.Now())
+}
+
 // Create creates a new link share for a given list
 // @Summary Share a list via link
 // @Description Share a list via link. The user needs to have write-access to the list to be able do this.
@@ -106,9 +138,31 @@ func (share *LinkSharing) Create(a web.Auth) (err error) {
 		return
 	}
 
+	if share.Password != "" {
+		share.SharingType = SharingTypeWithPassword
+	}
+
+	if share.SharingType == SharingTypeWithPassword {
+		if share.Password == "" {
+			return ErrLinkSharePasswordEmpty{}
+		}
+
+		hashed, err := bcrypt.GenerateFromPassword([]byte(share.Password), 11)
+		if err != nil {
+			return err
+		}
+		share.Password = string(hashed)
+	}
+
+	if share.MaxAuthentications < 0 {
+		share.MaxAuthentications = 0
+	}
+
 	share.SharedByID = a.GetID()
 	share.Hash = utils.MakeRandomString(40)
+	share.Authentications = 0
 	_, err = x.Insert(share)
+	share.Password = ""
 	share.SharedBy, _ = user.GetFromAuth(a)
 	return
 }
@@ -135,6 +189,7 @@ func (share *LinkSharing) ReadOne() (err error) {
 	if !exists {
 		return ErrListShareDoesNotExist{ID: share.ID, Hash: share.Hash}
 	}
+	share.Password = ""
 	return
 }
 
@@ -189,6 +244,7 @@ func (share *LinkSharing) ReadAll(a web.Auth, search string, page int, perPage i
 
 	for _, s := range shares {
 		s.SharedBy = users[s.SharedByID]
+		s.Password = ""
 	}
 
 	// Total count
@@ -245,3 +301,128 @@ func GetListByShareHash(hash string) (list *List, err error) {
 	err = list.GetSimpleByID()
 	return
 }
+
+// VerifyLinkSharePassword checks the password of a password protected link share.
+// Link shares without a password don't need one.
+func VerifyLinkSharePassword(share *LinkSharing, password string) (err error) {
+	if share.SharingType != SharingTypeWithPassword {
+		return nil
+	}
+
+	if password == "" {
+		return ErrLinkSharePasswordRequired{ShareID: share.ID}
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(share.Password), []byte(password))
+	if err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return ErrLinkSharePasswordInvalid{ShareID: share.ID}
+		}
+		return err
+	}
+	return nil
+}
+
+// AuthenticateLinkShare checks if a link share can still be used and if the password is correct for password protected
+// ones. Every successful authentication counts against the maximum number of authentications of the share.
+func AuthenticateLinkShare(share *LinkSharing, password string) (err error) {
+	if share.isExpired() {
+		return ErrLinkShareExpired{ShareID: share.ID}
+	}
+
+	if share.MaxAuthentications > 0 && share.Authentications >= share.MaxAuthentications {
+		return ErrLinkShareAuthLimitReached{ShareID: share.ID}
+	}
+
+	err = VerifyLinkSharePassword(share, password)
+	if err != nil {
+		return
+	}
+
+	// Checking the limit again in the update makes sure concurrent requests can't authenticate more often than allowed
+	updated, err := x.
+		Where("id = ? AND (max_authentications = 0 OR authentications < max_authentications)", share.ID).
+		Incr("authentications").
+		Update(&LinkSharing{})
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return ErrLinkShareAuthLimitReached{ShareID: share.ID}
+	}
+	share.Authentications++
+
+	return nil
+}
+
+// DeleteExpiredLinkShares removes all link shares which expired before the given time
+func DeleteExpiredLinkShares(now time.Time) (deleted int64, err error) {
+	shares := []*LinkSharing{}
+	err = x.Where("expires_at < ?", now).Find(&shares)
+	if err != nil {
+		return
+	}
+
+	ids := make([]int64, 0, len(shares))
+	for _, share := range shares {
+		// Shares without an expiry date never expire
+		if share.ExpiresAt.IsZero() {
+			continue
+		}
+		ids = append(ids, share.ID)
+	}
+
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	return x.In("id", ids).Delete(&LinkSharing{})
+}
+
+// The interval in which expired link shares are deleted
+const linkShareCleanupInterval = time.Hour
+
+var linkShareCleanupDaemonQuit chan bool
+
+// StartLinkShareCleanupDaemon starts a goroutine which periodically deletes all expired link shares.
+// Deleting them is idempotent, it is therefore safe to run multiple instances at the same time.
+func StartLinkShareCleanupDaemon() {
+	if !config.ServiceEnableLinkSharing.GetBool() {
+		return
+	}
+
+	linkShareCleanupDaemonQuit = make(chan bool)
+
+	go func() {
+		ticker := time.NewTicker(linkShareCleanupInterval)
+		defer ticker.Stop()
+
+		log.Debugf("[Link Shares] Started cleanup daemon, checking every %s", linkShareCleanupInterval)
+
+		for {
+			select {
+			case <-linkShareCleanupDaemonQuit:
+				log.Debugf("[Link Shares] Stopped cleanup daemon")
+				return
+			case now := <-ticker.C:
+				deleted, err := DeleteExpiredLinkShares(now)
+				if err != nil {
+					log.Errorf("[Link Shares] Could not delete expired link shares: %s", err)
+					continue
+				}
+				if deleted > 0 {
+					log.Debugf("[Link Shares] Deleted %d expired link shares", deleted)
+				}
+			}
+		}
+	}()
+}
+
+// StopLinkShareCleanupDaemon stops the link share cleanup daemon if it was started
+func StopLinkShareCleanupDaemon() {
+	if linkShareCleanupDaemonQuit == nil {
+		return
+	}
+	close(linkShareCleanupDaemonQuit)
+	linkShareCleanupDaemonQuit = nil
+}
@@ -0,0 +1,222 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLinkSharing_Create(t *testing.T) {
+	doer := &user.User{ID: 1}
+
+	t.Run("without password", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		share := &LinkSharing{
+			ListID: 1,
+			Right:  RightRead,
+		}
+		err := share.Create(doer)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, share.Hash)
+		db.AssertExists(t, "link_sharing", map[string]interface{}{
+			"id":           share.ID,
+			"sharing_type": SharingTypeUnknown,
+		}, false)
+	})
+	t.Run("with password", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		share := &LinkSharing{
+			ListID:   1,
+			Right:    RightRead,
+			Password: "somePassword",
+		}
+		err := share.Create(doer)
+		assert.NoError(t, err)
+		assert.Equal(t, SharingTypeWithPassword, share.SharingType)
+		assert.Empty(t, share.Password)
+
+		// Only the hash should be stored
+		s := &LinkSharing{}
+		_, err = x.Where("id = ?", share.ID).Get(s)
+		assert.NoError(t, err)
+		assert.NotEqual(t, "somePassword", s.Password)
+		assert.NoError(t, VerifyLinkSharePassword(s, "somePassword"))
+	})
+	t.Run("password type without password", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		share := &LinkSharing{
+			ListID:      1,
+			Right:       RightRead,
+			SharingType: SharingTypeWithPassword,
+		}
+		err := share.Create(doer)
+		assert.Error(t, err)
+		assert.True(t, IsErrLinkSharePasswordEmpty(err))
+	})
+}
+
+func TestLinkSharing_ReadAll(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	share := &LinkSharing{ListID: 1}
+	shares, _, _, err := share.ReadAll(&user.User{ID: 1}, "", 1, 50)
+	assert.NoError(t, err)
+	for _, s := range shares.([]*LinkSharing) {
+		assert.Empty(t, s.Password)
+	}
+}
+
+func TestVerifyLinkSharePassword(t *testing.T) {
+	t.Run("without password", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		share, err := GetLinkShareByHash("test")
+		assert.NoError(t, err)
+		assert.NoError(t, VerifyLinkSharePassword(share, ""))
+	})
+	t.Run("correct password", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		share, err := GetLinkShareByHash("testWithPassword")
+		assert.NoError(t, err)
+		assert.NoError(t, VerifyLinkSharePassword(share, "1234"))
+	})
+	t.Run("no password", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		share, err := GetLinkShareByHash("testWithPassword")
+		assert.NoError(t, err)
+		err = VerifyLinkSharePassword(share, "")
+		assert.Error(t, err)
+		assert.True(t, IsErrLinkSharePasswordRequired(err))
+	})
+	t.Run("wrong password", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		share, err := GetLinkShareByHash("testWithPassword")
+		assert.NoError(t, err)
+		err = VerifyLinkSharePassword(share, "wrong")
+		assert.Error(t, err)
+		assert.True(t, IsErrLinkSharePasswordInvalid(err))
+	})
+}
+
+func TestAuthenticateLinkShare(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		share, err := GetLinkShareByHash("test")
+		assert.NoError(t, err)
+		err = AuthenticateLinkShare(share, "")
+		assert.NoError(t, err)
+		db.AssertExists(t, "link_sharing", map[string]interface{}{
+			"id":              1,
+			"authentications": 1,
+		}, false)
+	})
+	t.Run("expired", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		share, err := GetLinkShareByHash("testExpired")
+		assert.NoError(t, err)
+		err = AuthenticateLinkShare(share, "")
+		assert.Error(t, err)
+		assert.True(t, IsErrLinkShareExpired(err))
+	})
+	t.Run("limit reached", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		share, err := GetLinkShareByHash("testUsedUp")
+		assert.NoError(t, err)
+		err = AuthenticateLinkShare(share, "")
+		assert.Error(t, err)
+		assert.True(t, IsErrLinkShareAuthLimitReached(err))
+	})
+	t.Run("last authentication", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		share, err := GetLinkShareByHash("testLimited")
+		assert.NoError(t, err)
+		err = AuthenticateLinkShare(share, "")
+		assert.NoError(t, err)
+
+		err = AuthenticateLinkShare(share, "")
+		assert.Error(t, err)
+		assert.True(t, IsErrLinkShareAuthLimitReached(err))
+
+		// This share was loaded before the last authentication, only the update can prevent this one
+		stale := &LinkSharing{ID: 7, MaxAuthentications: 2, Authentications: 1}
+		err = AuthenticateLinkShare(stale, "")
+		assert.Error(t, err)
+		assert.True(t, IsErrLinkShareAuthLimitReached(err))
+	})
+	t.Run("wrong password does not count", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		share, err := GetLinkShareByHash("testWithPassword")
+		assert.NoError(t, err)
+		err = AuthenticateLinkShare(share, "wrong")
+		assert.Error(t, err)
+		assert.True(t, IsErrLinkSharePasswordInvalid(err))
+		db.AssertExists(t, "link_sharing", map[string]interface{}{
+			"id":              4,
+			"authentications": 0,
+		}, false)
+	})
+}
+
+func TestGetLinkShareFromClaims(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		share, err := GetLinkShareFromClaims(map[string]interface{}{
+			"id":   float64(1),
+			"hash": "test",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), share.ListID)
+		assert.Equal(t, RightRead, share.Right)
+	})
+	t.Run("expired", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		_, err := GetLinkShareFromClaims(map[string]interface{}{
+			"id":   float64(5),
+			"hash": "testExpired",
+		})
+		assert.Error(t, err)
+		assert.True(t, IsErrLinkShareExpired(err))
+	})
+	t.Run("deleted", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		_, err := GetLinkShareFromClaims(map[string]interface{}{
+			"id":   float64(9999),
+			"hash": "test",
+		})
+		assert.Error(t, err)
+		assert.True(t, IsErrListShareDoesNotExist(err))
+	})
+}
+
+func TestDeleteExpiredLinkShares(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	deleted, err := DeleteExpiredLinkShares(time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+	db.AssertMissing(t, "link_sharing", map[string]interface{}{
+		"id": 5,
+	})
+	db.AssertExists(t, "link_sharing", map[string]interface{}{
+		"id": 1,
+	}, false)
+	db.AssertExists(t, "link_sharing", map[string]interface{}{
+		"id": 7,
+	}, false)
+}
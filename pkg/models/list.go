@@ -20,8 +20,8 @@ import (
 	"strings"
 	"time"
 
+	"code.vikunja.io/api/pkg/events"
 	"code.vikunja.io/api/pkg/files"
-	"code.vikunja.io/api/pkg/metrics"
 	"code.vikunja.io/api/pkg/user"
 	"code.vikunja.io/web"
 	"xorm.io/builder"
@@ -514,9 +514,9 @@ func CreateOrUpdateList(list *List) (err error) {
 		}
 	}
 
-	if list.ID == 0 {
+	isNew := list.ID == 0
+	if isNew {
 		_, err = x.Insert(list)
-		metrics.UpdateCount(1, metrics.ListCountKey)
 	} else {
 		// We need to specify the cols we want to update here to be able to un-archive lists
 		colsToUpdate := []string{
@@ -546,8 +546,14 @@ func CreateOrUpdateList(list *List) (err error) {
 	}
 
 	err = list.ReadOne()
-	return
+	if err != nil {
+		return
+	}
 
+	if isNew {
+		events.Dispatch(&ListCreatedEvent{List: list})
+	}
+	return
 }
 
 // Update implements the update method of CRUDable
@@ -648,19 +654,61 @@ func (l *List) Create(a web.Auth) (err error) {
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /lists/{id} [delete]
 func (l *List) Delete() (err error) {
+	s := x.NewSession()
+	defer s.Close()
+	if err = s.Begin(); err != nil {
+		return
+	}
 
-	// Delete the list
-	_, err = x.ID(l.ID).Delete(&List{})
-	if err != nil {
+	if err = deleteLists(s, []int64{l.ID}); err != nil {
+		_ = s.Rollback()
+		return
+	}
+
+	if err = s.Commit(); err != nil {
 		return
 	}
-	metrics.UpdateCount(-1, metrics.ListCountKey)
 
-	// Delete all todotasks on that list
-	_, err = x.Where("list_id = ?", l.ID).Delete(&Task{})
+	events.Dispatch(&ListDeletedEvent{List: l})
 	return
 }
 
+// Deletes the given lists together with their tasks, webhooks and custom fields.
+func deleteLists(s *xorm.Session, listIDs []int64) (err error) {
+	if len(listIDs) == 0 {
+		return nil
+	}
+
+	// Delete the lists
+	_, err = s.In("id", listIDs).Delete(&List{})
+	if err != nil {
+		return
+	}
+
+	// Delete all time entries of the tasks on these lists
+	_, err = s.
+		In("task_id", builder.Select("id").From("tasks").Where(builder.In("list_id", listIDs))).
+		Delete(&TimeEntry{})
+	if err != nil {
+		return
+	}
+
+	// Delete all todotasks on these lists
+	_, err = s.In("list_id", listIDs).Delete(&Task{})
+	if err != nil {
+		return
+	}
+
+	// Delete all webhooks of these lists
+	err = deleteWebhooksForListsAndNamespaces(s, listIDs, nil)
+	if err != nil {
+		return
+	}
+
+	// Delete all custom fields of these lists
+	return deleteCustomFieldsForLists(s, listIDs)
+}
+
 // SetListBackground sets a background file as list background in the db
 func SetListBackground(listID int64, background *files.File) (err error) {
 	l := &List{
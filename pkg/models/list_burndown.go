@@ -0,0 +1,235 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/web"
+)
+
+// The maximum number of days a burndown can span
+const burndownMaxDays = 366
+
+// ListPoints holds how many of the story points and estimated time of a list are done
+type ListPoints struct {
+	// The list these totals belong to.
+	ListID int64 `json:"list_id" param:"list"`
+
+	// The number of tasks in the list.
+	Tasks int64 `json:"tasks"`
+	// The number of done tasks in the list.
+	DoneTasks int64 `json:"done_tasks"`
+
+	// The story points of all tasks in the list.
+	TotalPoints float64 `json:"total_points"`
+	// The story points of all done tasks in the list.
+	DonePoints float64 `json:"done_points"`
+	// The story points of all tasks in the list which are not done yet.
+	RemainingPoints float64 `json:"remaining_points"`
+
+	// The estimates of all tasks in the list in seconds.
+	TotalEstimate int64 `json:"total_estimate"`
+	// The estimates of all done tasks in the list in seconds.
+	DoneEstimate int64 `json:"done_estimate"`
+	// The estimates of all tasks in the list which are not done yet in seconds.
+	RemainingEstimate int64 `json:"remaining_estimate"`
+
+	web.CRUDable `json:"-"`
+	web.Rights   `json:"-"`
+}
+
+// ListBurndown holds the remaining story points and estimates of a list for every day in a date range
+type ListBurndown struct {
+	// The list this burndown belongs to.
+	ListID int64 `json:"list_id" param:"list"`
+	// The first day of the burndown as a date like 2020-10-01. Defaults to the day the first task of the list was created.
+	From string `json:"from" query:"from"`
+	// The last day of the burndown as a date like 2020-10-31. Defaults to today.
+	To string `json:"to" query:"to"`
+
+	// One entry for every day between from and to, the oldest first.
+	Days []*BurndownDay `json:"days"`
+
+	web.CRUDable `json:"-"`
+	web.Rights   `json:"-"`
+}
+
+// BurndownDay holds the state of the tasks of a list at the end of a day
+type BurndownDay struct {
+	// The start of the day in the configured timezone.
+	Date time.Time `json:"date"`
+
+	// The story points of all tasks which existed at the end of the day.
+	TotalPoints float64 `json:"total_points"`
+	// The story points of all tasks which were not done at the end of the day.
+	RemainingPoints float64 `json:"remaining_points"`
+	// The story points of all tasks which were done on this day.
+	DonePoints float64 `json:"done_points"`
+
+	// The estimates of all tasks which existed at the end of the day in seconds.
+	TotalEstimate int64 `json:"total_estimate"`
+	// The estimates of all tasks which were not done at the end of the day in seconds.
+	RemainingEstimate int64 `json:"remaining_estimate"`
+	// The estimates of all tasks which were done on this day in seconds.
+	DoneEstimate int64 `json:"done_estimate"`
+}
+
+func getTasksForBurndown(listID int64) (tasks []*Task, err error) {
+	tasks = []*Task{}
+	err = x.
+		Where("list_id = ?", listID).
+		Cols("id", "done", "done_at", "estimate", "points", "created").
+		Find(&tasks)
+	return
+}
+
+// Returns when a task was done. Tasks which were marked as done before Vikunja saved when that happened count as
+// done since they were created.
+func getBurndownDoneAt(t *Task) time.Time {
+	if t.DoneAt.IsZero() {
+		return t.Created
+	}
+	return t.DoneAt
+}
+
+// ReadOne returns the total and done story points and estimates of a list
+// @Summary Get the story points of a list
+// @Description Returns the story points and estimates of all tasks in a list, split into the done and the remaining ones.
+// @tags list
+// @Produce json
+// @Security JWTKeyAuth
+// @Param list path int true "List ID"
+// @Success 200 {object} models.ListPoints "The points of the list."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the list."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /lists/{list}/points [get]
+func (lp *ListPoints) ReadOne() (err error) {
+	tasks, err := getTasksForBurndown(lp.ListID)
+	if err != nil {
+		return
+	}
+
+	for _, t := range tasks {
+		lp.Tasks++
+		lp.TotalPoints += t.Points
+		lp.TotalEstimate += t.Estimate
+
+		if t.Done {
+			lp.DoneTasks++
+			lp.DonePoints += t.Points
+			lp.DoneEstimate += t.Estimate
+			continue
+		}
+
+		lp.RemainingPoints += t.Points
+		lp.RemainingEstimate += t.Estimate
+	}
+
+	return
+}
+
+// ReadOne returns a daily burndown series of a list
+// @Summary Get the burndown of a list
+// @Description Returns how many story points and how much estimated time of a list were remaining at the end of every day in a date range, based on when its tasks were created and done. Dates are interpreted in the configured timezone. The range can span at most 366 days.
+// @tags list
+// @Produce json
+// @Security JWTKeyAuth
+// @Param list path int true "List ID"
+// @Param from query string false "The first day of the burndown as a date like 2020-10-01. Defaults to the day the first task of the list was created."
+// @Param to query string false "The last day of the burndown as a date like 2020-10-31. Defaults to today."
+// @Success 200 {object} models.ListBurndown "The burndown of the list."
+// @Failure 400 {object} web.HTTPError "The date range is invalid."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the list."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /lists/{list}/burndown [get]
+func (lb *ListBurndown) ReadOne() (err error) {
+	to := startOfDay(time.Now())
+	if lb.To != "" {
+		to, err = time.ParseInLocation("2006-01-02", lb.To, config.GetTimeZone())
+		if err != nil {
+			return ErrInvalidBurndownRange{ListID: lb.ListID, Reason: "to needs to be a date like 2020-10-31"}
+		}
+	}
+
+	tasks, err := getTasksForBurndown(lb.ListID)
+	if err != nil {
+		return
+	}
+
+	var from time.Time
+	if lb.From != "" {
+		from, err = time.ParseInLocation("2006-01-02", lb.From, config.GetTimeZone())
+		if err != nil {
+			return ErrInvalidBurndownRange{ListID: lb.ListID, Reason: "from needs to be a date like 2020-10-01"}
+		}
+	} else {
+		from = to
+		for _, t := range tasks {
+			created := startOfDay(t.Created)
+			if created.Before(from) {
+				from = created
+			}
+		}
+		// Only show the most recent days of lists which exist for a long time
+		if !from.AddDate(0, 0, burndownMaxDays).After(to) {
+			from = to.AddDate(0, 0, -burndownMaxDays+1)
+		}
+	}
+
+	if from.After(to) {
+		return ErrInvalidBurndownRange{ListID: lb.ListID, Reason: "from cannot be after to"}
+	}
+	if !from.AddDate(0, 0, burndownMaxDays).After(to) {
+		return ErrInvalidBurndownRange{ListID: lb.ListID, Reason: "the range cannot span more than 366 days"}
+	}
+
+	lb.From = from.Format("2006-01-02")
+	lb.To = to.Format("2006-01-02")
+	lb.Days = []*BurndownDay{}
+
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		end := day.AddDate(0, 0, 1)
+		d := &BurndownDay{Date: day}
+
+		for _, t := range tasks {
+			if !t.Created.Before(end) {
+				continue
+			}
+
+			d.TotalPoints += t.Points
+			d.TotalEstimate += t.Estimate
+
+			doneAt := getBurndownDoneAt(t)
+			if !t.Done || !doneAt.Before(end) {
+				d.RemainingPoints += t.Points
+				d.RemainingEstimate += t.Estimate
+				continue
+			}
+
+			if !doneAt.Before(day) {
+				d.DonePoints += t.Points
+				d.DoneEstimate += t.Estimate
+			}
+		}
+
+		lb.Days = append(lb.Days, d)
+	}
+
+	return
+}
@@ -0,0 +1,31 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import "code.vikunja.io/web"
+
+// CanRead checks if a user can see the story points of a list
+func (lp *ListPoints) CanRead(a web.Auth) (bool, int, error) {
+	l := &List{ID: lp.ListID}
+	return l.CanRead(a)
+}
+
+// CanRead checks if a user can see the burndown of a list
+func (lb *ListBurndown) CanRead(a web.Auth) (bool, int, error) {
+	l := &List{ID: lb.ListID}
+	return l.CanRead(a)
+}
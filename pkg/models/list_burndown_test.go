@@ -0,0 +1,138 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+	"github.com/stretchr/testify/assert"
+)
+
+// Gives task 1 (undone) 3 points and task 2 (done on the second of december) 5 points
+func setupBurndownTasks(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+
+	_, err := x.ID(1).Cols("points", "estimate").Update(&Task{Points: 3, Estimate: 3600})
+	assert.NoError(t, err)
+	_, err = x.ID(2).Cols("points", "estimate", "done_at").Update(&Task{
+		Points:   5,
+		Estimate: 7200,
+		DoneAt:   time.Date(2018, 12, 2, 10, 0, 0, 0, config.GetTimeZone()),
+	})
+	assert.NoError(t, err)
+}
+
+func TestListPoints_ReadOne(t *testing.T) {
+	setupBurndownTasks(t)
+
+	lp := &ListPoints{ListID: 1}
+	err := lp.ReadOne()
+	assert.NoError(t, err)
+	assert.Equal(t, float64(8), lp.TotalPoints)
+	assert.Equal(t, float64(5), lp.DonePoints)
+	assert.Equal(t, float64(3), lp.RemainingPoints)
+	assert.Equal(t, int64(10800), lp.TotalEstimate)
+	assert.Equal(t, int64(7200), lp.DoneEstimate)
+	assert.Equal(t, int64(3600), lp.RemainingEstimate)
+	assert.True(t, lp.Tasks > lp.DoneTasks)
+}
+
+func TestListBurndown_ReadOne(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		setupBurndownTasks(t)
+
+		lb := &ListBurndown{ListID: 1, From: "2018-11-30", To: "2018-12-03"}
+		err := lb.ReadOne()
+		assert.NoError(t, err)
+		assert.Len(t, lb.Days, 4)
+
+		// Before the tasks were created
+		assert.Equal(t, float64(0), lb.Days[0].TotalPoints)
+		assert.Equal(t, float64(0), lb.Days[0].RemainingPoints)
+
+		// Created
+		assert.Equal(t, float64(8), lb.Days[1].TotalPoints)
+		assert.Equal(t, float64(8), lb.Days[1].RemainingPoints)
+		assert.Equal(t, float64(0), lb.Days[1].DonePoints)
+
+		// Task 2 was done
+		assert.Equal(t, float64(3), lb.Days[2].RemainingPoints)
+		assert.Equal(t, float64(5), lb.Days[2].DonePoints)
+		assert.Equal(t, int64(3600), lb.Days[2].RemainingEstimate)
+		assert.Equal(t, int64(7200), lb.Days[2].DoneEstimate)
+
+		assert.Equal(t, float64(3), lb.Days[3].RemainingPoints)
+		assert.Equal(t, float64(0), lb.Days[3].DonePoints)
+	})
+	t.Run("default range", func(t *testing.T) {
+		setupBurndownTasks(t)
+
+		lb := &ListBurndown{ListID: 1, To: "2018-12-03"}
+		err := lb.ReadOne()
+		assert.NoError(t, err)
+		assert.Equal(t, "2018-12-01", lb.From)
+		assert.Len(t, lb.Days, 3)
+	})
+	t.Run("from after to", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+
+		lb := &ListBurndown{ListID: 1, From: "2018-12-03", To: "2018-12-01"}
+		err := lb.ReadOne()
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidBurndownRange(err))
+	})
+	t.Run("too long", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+
+		lb := &ListBurndown{ListID: 1, From: "2018-01-01", To: "2019-12-31"}
+		err := lb.ReadOne()
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidBurndownRange(err))
+	})
+	t.Run("invalid date", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+
+		lb := &ListBurndown{ListID: 1, From: "yesterday"}
+		err := lb.ReadOne()
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidBurndownRange(err))
+	})
+}
+
+func TestTaskCollection_ReadAllByPoints(t *testing.T) {
+	setupBurndownTasks(t)
+
+	tc := &TaskCollection{
+		ListID:           1,
+		SortBy:           []string{"points"},
+		OrderBy:          []string{"desc"},
+		FilterBy:         []string{"points"},
+		FilterValue:      []string{"1"},
+		FilterComparator: []string{"greater"},
+	}
+	result, _, _, err := tc.ReadAll(&user.User{ID: 1}, "", 1, 50)
+	assert.NoError(t, err)
+	tasks := result.([]*Task)
+	assert.Len(t, tasks, 2)
+	assert.Equal(t, int64(2), tasks[0].ID)
+	assert.Equal(t, float64(5), tasks[0].Points)
+	assert.Equal(t, int64(1), tasks[1].ID)
+}
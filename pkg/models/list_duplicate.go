@@ -17,8 +17,10 @@
 package models
 
 import (
+	"code.vikunja.io/api/pkg/events"
 	"code.vikunja.io/api/pkg/files"
 	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/user"
 	"code.vikunja.io/api/pkg/utils"
 	"code.vikunja.io/web"
 )
@@ -85,26 +87,32 @@ func (ld *ListDuplicate) Create(a web.Auth) (err error) {
 
 	log.Debugf("Duplicated list %d into new list %d", ld.ListID, ld.List.ID)
 
-	// Duplicate kanban buckets
-	// Old bucket ID as key, new id as value
-	// Used to map the newly created tasks to their new buckets
-	bucketMap := make(map[int64]int64)
-	buckets := []*Bucket{}
-	err = x.Where("list_id = ?", ld.ListID).Find(&buckets)
+	// Duplicate custom fields
+	// Old field as key, new field as value
+	// Used to copy the values of the tasks to the new fields
+	customFieldMap := make(map[int64]*CustomField)
+	customFields := []*CustomField{}
+	err = x.Where("list_id = ?", ld.ListID).Find(&customFields)
 	if err != nil {
 		return
 	}
-	for _, b := range buckets {
-		oldID := b.ID
-		b.ID = 0
-		b.ListID = ld.List.ID
-		if err := b.Create(a); err != nil {
+	for _, cf := range customFields {
+		oldID := cf.ID
+		cf.ID = 0
+		cf.ListID = ld.List.ID
+		if _, err := x.Insert(cf); err != nil {
 			return err
 		}
-		bucketMap[oldID] = b.ID
+		customFieldMap[oldID] = cf
 	}
 
-	log.Debugf("Duplicated all buckets from list %d into %d", ld.ListID, ld.List.ID)
+	log.Debugf("Duplicated all custom fields from list %d into %d", ld.ListID, ld.List.ID)
+
+	buckets := []*Bucket{}
+	err = x.Where("list_id = ?", ld.ListID).Find(&buckets)
+	if err != nil {
+		return
+	}
 
 	// Get all tasks + all task details
 	tasks, _, _, err := getTasksForLists([]*List{{ID: ld.ListID}}, a, &taskOptions{})
@@ -112,28 +120,41 @@ func (ld *ListDuplicate) Create(a web.Auth) (err error) {
 		return err
 	}
 
-	// This map contains the old task id as key and the new duplicated task id as value.
-	// It is used to map old task items to new ones.
-	taskMap := make(map[int64]int64)
-	// Create + update all tasks (includes reminders)
-	oldTaskIDs := make([]int64, len(tasks))
+	oldTaskIDs := make([]int64, 0, len(tasks))
 	for _, t := range tasks {
-		oldID := t.ID
-		t.ID = 0
-		t.ListID = ld.List.ID
-		t.BucketID = bucketMap[t.BucketID]
-		t.UID = ""
-		s := x.NewSession()
-		err := createTask(s, t, a, false)
-		if err != nil {
-			_ = s.Rollback()
-			return err
-		}
-		taskMap[oldID] = t.ID
-		oldTaskIDs = append(oldTaskIDs, oldID)
+		// The values of custom fields are copied later on
+		t.CustomFields = nil
+		oldTaskIDs = append(oldTaskIDs, t.ID)
+	}
+
+	// Copy label tasks (not the labels)
+	labelTasks := []*LabelTask{}
+	err = x.In("task_id", oldTaskIDs).Find(&labelTasks)
+	if err != nil {
+		return
+	}
+
+	// Relations in that list
+	// Low-Effort: Only copy those relations which are between tasks in the same list
+	// because we can do that without a lot of hassle
+	relations := []*TaskRelation{}
+	err = x.In("task_id", oldTaskIDs).Find(&relations)
+	if err != nil {
+		return
+	}
+
+	lc := &listCopy{
+		buckets:    buckets,
+		tasks:      tasks,
+		labelTasks: labelTasks,
+		relations:  relations,
+	}
+	_, taskMap, err := lc.createInList(ld.List.ID, a)
+	if err != nil {
+		return err
 	}
 
-	log.Debugf("Duplicated all tasks from list %d into %d", ld.ListID, ld.List.ID)
+	log.Debugf("Duplicated all buckets, tasks, labels and task relations from list %d into %d", ld.ListID, ld.List.ID)
 
 	// Save all attachments
 	// We also duplicate all underlying files since they could be modified in one list which would result in
@@ -178,22 +199,36 @@ func (ld *ListDuplicate) Create(a web.Auth) (err error) {
 
 	log.Debugf("Duplicated all attachments from list %d into %d", ld.ListID, ld.List.ID)
 
-	// Copy label tasks (not the labels)
-	labelTasks := []*LabelTask{}
-	err = x.In("task_id", oldTaskIDs).Find(&labelTasks)
+	// Custom field values
+	// Only copy those users who have access to the new list
+	customFieldValues := []*TaskCustomFieldValue{}
+	err = x.In("task_id", oldTaskIDs).Find(&customFieldValues)
 	if err != nil {
 		return
 	}
-
-	for _, lt := range labelTasks {
-		lt.ID = 0
-		lt.TaskID = taskMap[lt.TaskID]
-		if _, err := x.Insert(lt); err != nil {
+	for _, v := range customFieldValues {
+		cf, exists := customFieldMap[v.FieldID]
+		if !exists {
+			continue
+		}
+		if cf.Type == CustomFieldTypeUser {
+			canRead, _, err := (&List{ID: ld.List.ID}).CanRead(&user.User{ID: int64(v.ValueNumber)})
+			if err != nil {
+				return err
+			}
+			if !canRead {
+				continue
+			}
+		}
+		v.ID = 0
+		v.TaskID = taskMap[v.TaskID]
+		v.FieldID = cf.ID
+		if _, err := x.Insert(v); err != nil {
 			return err
 		}
 	}
 
-	log.Debugf("Duplicated all labels from list %d into %d", ld.ListID, ld.List.ID)
+	log.Debugf("Duplicated all custom field values from list %d into %d", ld.ListID, ld.List.ID)
 
 	// Assignees
 	// Only copy those assignees who have access to the task
@@ -202,12 +237,12 @@ func (ld *ListDuplicate) Create(a web.Auth) (err error) {
 	if err != nil {
 		return
 	}
-	for _, a := range assignees {
+	for _, assignee := range assignees {
 		t := &Task{
-			ID:     taskMap[a.TaskID],
+			ID:     taskMap[assignee.TaskID],
 			ListID: ld.List.ID,
 		}
-		if err := t.addNewAssigneeByID(a.UserID, ld.List); err != nil {
+		if _, err := t.insertAssigneeByID(assignee.UserID, ld.List); err != nil {
 			if IsErrUserDoesNotHaveAccessToList(err) {
 				continue
 			}
@@ -233,29 +268,6 @@ func (ld *ListDuplicate) Create(a web.Auth) (err error) {
 
 	log.Debugf("Duplicated all comments from list %d into %d", ld.ListID, ld.List.ID)
 
-	// Relations in that list
-	// Low-Effort: Only copy those relations which are between tasks in the same list
-	// because we can do that without a lot of hassle
-	relations := []*TaskRelation{}
-	err = x.In("task_id", oldTaskIDs).Find(&relations)
-	if err != nil {
-		return
-	}
-	for _, r := range relations {
-		otherTaskID, exists := taskMap[r.OtherTaskID]
-		if !exists {
-			continue
-		}
-		r.ID = 0
-		r.OtherTaskID = otherTaskID
-		r.TaskID = taskMap[r.TaskID]
-		if _, err := x.Insert(r); err != nil {
-			return err
-		}
-	}
-
-	log.Debugf("Duplicated all task relations from list %d into %d", ld.ListID, ld.List.ID)
-
 	// Background files + unsplash info
 	if ld.List.BackgroundFileID != 0 {
 
@@ -335,6 +347,7 @@ func (ld *ListDuplicate) Create(a web.Auth) (err error) {
 		share.ID = 0
 		share.ListID = ld.List.ID
 		share.Hash = utils.MakeRandomString(40)
+		share.Authentications = 0
 		if _, err := x.Insert(share); err != nil {
 			return err
 		}
@@ -344,3 +357,114 @@ func (ld *ListDuplicate) Create(a web.Auth) (err error) {
 
 	return
 }
+
+// listCopy holds the kanban buckets, tasks, labels and relations which are copied into a new list.
+// All ids in it are the ones of the source, they are only used to map everything to the newly created ids.
+type listCopy struct {
+	buckets    []*Bucket
+	tasks      []*Task
+	labelTasks []*LabelTask
+	relations  []*TaskRelation
+}
+
+// Creates all buckets, tasks, labels and relations of the copy in a list.
+// Returns the new bucket and the new task id for every bucket and task id of the source.
+func (lc *listCopy) createInList(listID int64, a web.Auth) (bucketMap map[int64]*Bucket, taskMap map[int64]int64, err error) {
+	bucketMap = make(map[int64]*Bucket, len(lc.buckets))
+	for _, b := range lc.buckets {
+		oldID := b.ID
+		limit := b.Limit
+		b.ID = 0
+		b.ListID = listID
+		// The limit is only set after all tasks are in their buckets to not run into it while creating the tasks
+		b.Limit = 0
+		if err = b.Create(a); err != nil {
+			return
+		}
+		b.Limit = limit
+		bucketMap[oldID] = b
+	}
+	// Every list needs at least one bucket
+	if len(lc.buckets) == 0 {
+		b := &Bucket{
+			ListID: listID,
+			Title:  "New Bucket",
+		}
+		if err = b.Create(a); err != nil {
+			return
+		}
+	}
+
+	log.Debugf("Created all buckets in list %d", listID)
+
+	taskMap = make(map[int64]int64, len(lc.tasks))
+	for _, t := range lc.tasks {
+		oldID := t.ID
+		t.ID = 0
+		t.ListID = listID
+		t.UID = ""
+		if b, exists := bucketMap[t.BucketID]; exists {
+			t.BucketID = b.ID
+		} else {
+			t.BucketID = 0
+		}
+		s := x.NewSession()
+		err = createTask(s, t, a, false)
+		if err != nil {
+			_ = s.Rollback()
+			return
+		}
+		if err = s.Commit(); err != nil {
+			return
+		}
+		events.Dispatch(&TaskCreatedEvent{Task: t, Doer: a})
+		taskMap[oldID] = t.ID
+	}
+
+	log.Debugf("Created all tasks in list %d", listID)
+
+	for _, lt := range lc.labelTasks {
+		taskID, exists := taskMap[lt.TaskID]
+		if !exists {
+			continue
+		}
+		lt.ID = 0
+		lt.TaskID = taskID
+		if _, err = x.Insert(lt); err != nil {
+			return
+		}
+	}
+
+	log.Debugf("Created all labels of the tasks in list %d", listID)
+
+	// Only relations between tasks of the copy are created
+	for _, r := range lc.relations {
+		taskID, exists := taskMap[r.TaskID]
+		if !exists {
+			continue
+		}
+		otherTaskID, exists := taskMap[r.OtherTaskID]
+		if !exists {
+			continue
+		}
+		r.ID = 0
+		r.TaskID = taskID
+		r.OtherTaskID = otherTaskID
+		if _, err = x.Insert(r); err != nil {
+			return
+		}
+	}
+
+	log.Debugf("Created all task relations in list %d", listID)
+
+	for _, b := range bucketMap {
+		if b.Limit == 0 {
+			continue
+		}
+		if err = b.Update(); err != nil {
+			return
+		}
+	}
+
+	return
+}
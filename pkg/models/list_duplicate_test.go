@@ -20,6 +20,7 @@ import (
 	"testing"
 
 	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/events"
 	"code.vikunja.io/api/pkg/files"
 	"code.vikunja.io/api/pkg/user"
 	"github.com/stretchr/testify/assert"
@@ -41,8 +42,11 @@ func TestListDuplicate(t *testing.T) {
 	can, err := l.CanCreate(u)
 	assert.NoError(t, err)
 	assert.True(t, can)
+	events.Fake()
 	err = l.Create(u)
 	assert.NoError(t, err)
+	// Copying the assignees must not notify them about being assigned again
+	events.AssertNotDispatched(t, &TaskAssigneeCreatedEvent{})
 	// To make this test 100% useful, it would need to assert a lot more stuff, but it is good enough for now.
 	// Also, we're lacking utility functions to do all needed assertions.
 }
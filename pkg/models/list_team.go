@@ -19,6 +19,7 @@ package models
 import (
 	"time"
 
+	"code.vikunja.io/api/pkg/events"
 	"code.vikunja.io/web"
 )
 
@@ -76,7 +77,7 @@ func (tl *TeamList) Create(a web.Auth) (err error) {
 	}
 
 	// Check if the team exists
-	_, err = GetTeamByID(tl.TeamID)
+	team, err := GetTeamByID(tl.TeamID)
 	if err != nil {
 		return
 	}
@@ -105,6 +106,15 @@ func (tl *TeamList) Create(a web.Auth) (err error) {
 	}
 
 	err = updateListLastUpdated(l)
+	if err != nil {
+		return
+	}
+
+	events.Dispatch(&ListSharedWithTeamEvent{
+		List: l,
+		Team: team,
+		Doer: a,
+	})
 	return
 }
 
@@ -0,0 +1,68 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import "code.vikunja.io/web"
+
+// CanCreate checks if a user can save a list as template. They need read access to the list.
+func (lt *ListTemplate) CanCreate(a web.Auth) (bool, error) {
+	// Templates belong to users, link shares can't create them
+	if _, is := a.(*LinkSharing); is {
+		return false, nil
+	}
+
+	l := &List{ID: lt.ListID}
+	canRead, _, err := l.CanRead(a)
+	return canRead, err
+}
+
+// CanRead checks if a user can see a list template
+func (lt *ListTemplate) CanRead(a web.Auth) (bool, int, error) {
+	can, err := lt.canDoTemplate(a)
+	return can, int(RightAdmin), err
+}
+
+// CanUpdate checks if a user can update a list template
+func (lt *ListTemplate) CanUpdate(a web.Auth) (bool, error) {
+	// A normal check would replace the passed struct which in our case would override the values we want to update.
+	t := &ListTemplate{ID: lt.ID}
+	return t.canDoTemplate(a)
+}
+
+// CanDelete checks if a user can delete a list template
+func (lt *ListTemplate) CanDelete(a web.Auth) (bool, error) {
+	return lt.canDoTemplate(a)
+}
+
+// Only owners are allowed to do something with a template
+func (lt *ListTemplate) canDoTemplate(a web.Auth) (bool, error) {
+	if _, is := a.(*LinkSharing); is {
+		return false, nil
+	}
+
+	t, err := getListTemplateByID(lt.ID)
+	if err != nil {
+		return false, err
+	}
+
+	if t.OwnerID != a.GetID() {
+		return false, nil
+	}
+
+	*lt = *t
+	return true, nil
+}
@@ -0,0 +1,556 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+)
+
+// ListTemplate is a snapshot of a list with its buckets and tasks which can be used to create new lists.
+// Templates are stored separately from lists, they don't show up anywhere lists do.
+type ListTemplate struct {
+	// The unique, numeric id of this template.
+	ID int64 `xorm:"int(11) autoincr not null unique pk" json:"id" param:"template"`
+	// The title of the template. This is also the title of lists created from it.
+	Title string `xorm:"varchar(250) not null" json:"title" valid:"runelength(0|250)" maxLength:"250"`
+	// The description of the template. This is also the description of lists created from it.
+	Description string `xorm:"longtext null" json:"description"`
+	// The hex color of lists created from this template.
+	HexColor string `xorm:"varchar(6) null" json:"hex_color" valid:"runelength(0|6)" maxLength:"6"`
+	OwnerID  int64  `xorm:"int(11) not null INDEX" json:"-"`
+
+	// The user who owns this template. Only they can see and use it.
+	Owner *user.User `xorm:"-" json:"owner" valid:"-"`
+
+	// The id of the list this template is created from. Only used when creating a template.
+	ListID int64 `xorm:"-" json:"-" param:"list"`
+
+	// The kanban buckets of the template.
+	Buckets []*ListTemplateBucket `xorm:"JSON null" json:"buckets"`
+	// The tasks of the template.
+	Tasks []*ListTemplateTask `xorm:"JSON null" json:"tasks"`
+
+	// A timestamp when this template was created. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+	// A timestamp when this template was last updated. You cannot change this value.
+	Updated time.Time `xorm:"updated not null" json:"updated"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TableName returns the table name for list templates
+func (lt *ListTemplate) TableName() string {
+	return "list_templates"
+}
+
+// ListTemplateBucket is a kanban bucket in a list template
+type ListTemplateBucket struct {
+	// The id of the bucket in the template. It is only used to put the tasks of the template into their buckets.
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+	Limit int64  `json:"limit"`
+}
+
+// ListTemplateDate is a date of a task in a list template.
+// It is saved as a day relative to the start date of the template and the time on that day, this way a date
+// is still at the same time of the day if the new list spans a change to or from daylight saving time.
+type ListTemplateDate struct {
+	// The number of days after the start date of the template.
+	Day int `json:"day"`
+	// The time on that day in seconds after midnight.
+	Time int `json:"time"`
+}
+
+// ListTemplateTask is a task in a list template.
+// All dates are saved relative to the start date of the template.
+type ListTemplateTask struct {
+	// The id of the task in the template. It is only used to map relations between tasks of the template.
+	ID                    int64   `json:"id"`
+	Title                 string  `json:"title"`
+	Description           string  `json:"description"`
+	Priority              int64   `json:"priority"`
+	HexColor              string  `json:"hex_color"`
+	PercentDone           float64 `json:"percent_done"`
+	Estimate              int64   `json:"estimate"`
+	Points                float64 `json:"points"`
+	RepeatAfter           int64   `json:"repeat_after"`
+	RepeatFromCurrentDate bool    `json:"repeat_from_current_date"`
+	RepeatRule            string  `json:"repeat_rule"`
+	Position              float64 `json:"position"`
+	// The id of the bucket of the template this task is in.
+	BucketID int64 `json:"bucket_id"`
+
+	// When the task is due, starts or ends relative to the start date. Null if the task does not have that date.
+	DueDate   *ListTemplateDate `json:"due_date"`
+	StartDate *ListTemplateDate `json:"start_date"`
+	EndDate   *ListTemplateDate `json:"end_date"`
+	// All reminders of the task relative to the start date.
+	Reminders []*ListTemplateDate `json:"reminder_dates"`
+
+	// The ids of the labels of the task. Only labels the user has access to are added to new tasks.
+	LabelIDs []int64 `json:"label_ids"`
+	// The ids of the tasks in the template this task is related to, grouped by their relation kind.
+	RelatedTasks map[RelationKind][]int64 `json:"related_tasks"`
+}
+
+// Returns the date all dates in a template are relative to. This is the start of the day of the earliest date in the tasks.
+func getListTemplateStartDate(tasks []*Task) (start time.Time) {
+	for _, t := range tasks {
+		dates := append([]time.Time{t.DueDate, t.StartDate, t.EndDate}, t.Reminders...)
+		for _, d := range dates {
+			if d.IsZero() {
+				continue
+			}
+			if start.IsZero() || d.Before(start) {
+				start = d
+			}
+		}
+	}
+
+	if start.IsZero() {
+		return
+	}
+	return startOfDay(start)
+}
+
+func getListTemplateDateOffset(date time.Time, start time.Time) *ListTemplateDate {
+	if date.IsZero() {
+		return nil
+	}
+	date = date.In(start.Location())
+	// The days are compared in UTC because days in other time zones are not always 24 hours long
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	startDay := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	return &ListTemplateDate{
+		Day:  int(day.Sub(startDay).Hours() / 24),
+		Time: date.Hour()*3600 + date.Minute()*60 + date.Second(),
+	}
+}
+
+func getListTemplateDate(offset *ListTemplateDate, start time.Time) time.Time {
+	if offset == nil {
+		return time.Time{}
+	}
+	return time.Date(
+		start.Year(),
+		start.Month(),
+		start.Day()+offset.Day,
+		offset.Time/3600,
+		offset.Time%3600/60,
+		offset.Time%60,
+		0,
+		start.Location(),
+	)
+}
+
+func getListTemplateByID(id int64) (lt *ListTemplate, err error) {
+	lt = &ListTemplate{}
+	exists, err := x.Where("id = ?", id).Get(lt)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrListTemplateDoesNotExist{TemplateID: id}
+	}
+	return
+}
+
+// Create saves a list as a new template
+// @Summary Save a list as template
+// @Description Saves a list with its kanban buckets and tasks including their labels, relations and reminders as a new template. All dates of the tasks are saved as the day relative to the day of the earliest date and the time on that day. Done tasks are saved as undone. If no title, description or color is provided, the ones of the list are used. The user needs read access to the list.
+// @tags list
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param list path int true "List ID"
+// @Param template body models.ListTemplate true "The template object"
+// @Success 200 {object} models.ListTemplate "The created template."
+// @Failure 400 {object} web.HTTPError "Invalid template object provided."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the list."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /lists/{list}/template [put]
+func (lt *ListTemplate) Create(a web.Auth) (err error) {
+	l := &List{ID: lt.ListID}
+	if err = l.GetSimpleByID(); err != nil {
+		return
+	}
+
+	lt.ID = 0
+	lt.OwnerID = a.GetID()
+	if lt.Title == "" {
+		lt.Title = l.Title
+	}
+	if lt.Description == "" {
+		lt.Description = l.Description
+	}
+	if lt.HexColor == "" {
+		lt.HexColor = l.HexColor
+	}
+
+	log.Debugf("Creating template from list %d", lt.ListID)
+
+	buckets := []*Bucket{}
+	err = x.Where("list_id = ?", lt.ListID).OrderBy("id asc").Find(&buckets)
+	if err != nil {
+		return
+	}
+	lt.Buckets = make([]*ListTemplateBucket, 0, len(buckets))
+	for _, b := range buckets {
+		lt.Buckets = append(lt.Buckets, &ListTemplateBucket{
+			ID:    b.ID,
+			Title: b.Title,
+			Limit: b.Limit,
+		})
+	}
+
+	// Get all tasks + all task details
+	tasks, _, _, err := getTasksForLists([]*List{{ID: lt.ListID}}, a, &taskOptions{})
+	if err != nil {
+		return err
+	}
+
+	start := getListTemplateStartDate(tasks)
+	inList := make(map[int64]bool, len(tasks))
+	for _, t := range tasks {
+		inList[t.ID] = true
+	}
+
+	lt.Tasks = make([]*ListTemplateTask, 0, len(tasks))
+	for _, t := range tasks {
+		tt := &ListTemplateTask{
+			ID:                    t.ID,
+			Title:                 t.Title,
+			Description:           t.Description,
+			Priority:              t.Priority,
+			HexColor:              t.HexColor,
+			PercentDone:           t.PercentDone,
+			Estimate:              t.Estimate,
+			Points:                t.Points,
+			RepeatAfter:           t.RepeatAfter,
+			RepeatFromCurrentDate: t.RepeatFromCurrentDate,
+			RepeatRule:            t.RepeatRule,
+			Position:              t.Position,
+			BucketID:              t.BucketID,
+			DueDate:               getListTemplateDateOffset(t.DueDate, start),
+			StartDate:             getListTemplateDateOffset(t.StartDate, start),
+			EndDate:               getListTemplateDateOffset(t.EndDate, start),
+			Reminders:             make([]*ListTemplateDate, 0, len(t.Reminders)),
+			LabelIDs:              make([]int64, 0, len(t.Labels)),
+			RelatedTasks:          make(map[RelationKind][]int64),
+		}
+		for _, r := range t.Reminders {
+			tt.Reminders = append(tt.Reminders, getListTemplateDateOffset(r, start))
+		}
+		for _, label := range t.Labels {
+			tt.LabelIDs = append(tt.LabelIDs, label.ID)
+		}
+		// Only relations between tasks in the list are kept
+		for kind, related := range t.RelatedTasks {
+			for _, rt := range related {
+				if inList[rt.ID] {
+					tt.RelatedTasks[kind] = append(tt.RelatedTasks[kind], rt.ID)
+				}
+			}
+		}
+		lt.Tasks = append(lt.Tasks, tt)
+	}
+
+	_, err = x.Insert(lt)
+	if err != nil {
+		return
+	}
+
+	log.Debugf("Created template %d from list %d with %d buckets and %d tasks", lt.ID, lt.ListID, len(lt.Buckets), len(lt.Tasks))
+
+	lt.Owner, err = user.GetUserByID(lt.OwnerID)
+	return
+}
+
+// ReadOne returns one list template
+// @Summary Get one list template
+// @Description Returns a list template with its buckets and tasks by its ID.
+// @tags list
+// @Produce json
+// @Security JWTKeyAuth
+// @Param template path int true "Template ID"
+// @Success 200 {object} models.ListTemplate "The template."
+// @Failure 403 {object} web.HTTPError "The user does not own the template."
+// @Failure 404 {object} web.HTTPError "The template does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /templates/{template} [get]
+func (lt *ListTemplate) ReadOne() (err error) {
+	// lt already contains the full template from the rights check, we only need to add the user
+	lt.Owner, err = user.GetUserByID(lt.OwnerID)
+	return
+}
+
+// ReadAll returns all list templates of the current user
+// @Summary Get all list templates
+// @Description Returns all list templates the current user created.
+// @tags list
+// @Produce json
+// @Security JWTKeyAuth
+// @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
+// @Param per_page query int false "The maximum number of items per page. Note this parameter is limited by the configured maximum of items per page."
+// @Param s query string false "Search templates by their title."
+// @Success 200 {array} models.ListTemplate "The templates."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /templates [get]
+func (lt *ListTemplate) ReadAll(a web.Auth, search string, page int, perPage int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	if _, is := a.(*LinkSharing); is {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	owner, err := user.GetUserByID(a.GetID())
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	templates := []*ListTemplate{}
+	query := x.
+		Where("owner_id = ?", a.GetID()).
+		And("title LIKE ?", "%"+search+"%").
+		OrderBy("id asc")
+	limit, start := getLimitFromPageIndex(page, perPage)
+	if limit > 0 {
+		query = query.Limit(limit, start)
+	}
+	err = query.Find(&templates)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	for _, t := range templates {
+		t.Owner = owner
+	}
+
+	numberOfTotalItems, err = x.
+		Where("owner_id = ?", a.GetID()).
+		And("title LIKE ?", "%"+search+"%").
+		Count(&ListTemplate{})
+	return templates, len(templates), numberOfTotalItems, err
+}
+
+// Update updates a list template
+// @Summary Update a list template
+// @Description Updates the title, description and color of a list template. Its buckets and tasks cannot be changed, create a new template from a list instead.
+// @tags list
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param template path int true "Template ID"
+// @Param template body models.ListTemplate true "The template object"
+// @Success 200 {object} models.ListTemplate "The updated template."
+// @Failure 400 {object} web.HTTPError "Invalid template object provided."
+// @Failure 403 {object} web.HTTPError "The user does not own the template."
+// @Failure 404 {object} web.HTTPError "The template does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /templates/{template} [post]
+func (lt *ListTemplate) Update() (err error) {
+	_, err = x.
+		ID(lt.ID).
+		Cols("title", "description", "hex_color").
+		Update(lt)
+	if err != nil {
+		return
+	}
+
+	full, err := getListTemplateByID(lt.ID)
+	if err != nil {
+		return
+	}
+	*lt = *full
+	lt.Owner, err = user.GetUserByID(lt.OwnerID)
+	return
+}
+
+// Delete removes a list template
+// @Summary Remove a list template
+// @Description Removes a list template. Lists created from it are not changed.
+// @tags list
+// @Produce json
+// @Security JWTKeyAuth
+// @Param template path int true "Template ID"
+// @Success 200 {object} models.Message "The template was successfully deleted."
+// @Failure 403 {object} web.HTTPError "The user does not own the template."
+// @Failure 404 {object} web.HTTPError "The template does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /templates/{template} [delete]
+func (lt *ListTemplate) Delete() (err error) {
+	_, err = x.Where("id = ?", lt.ID).Delete(&ListTemplate{})
+	return
+}
+
+// ListFromTemplate holds everything needed to create a new list from a template
+type ListFromTemplate struct {
+	// The id of the template to create the list from
+	TemplateID int64 `json:"-" param:"template"`
+	// The namespace the new list should be created in
+	NamespaceID int64 `json:"namespace_id"`
+	// The date all dates of the tasks are shifted to. The earliest date of the list the template was created from
+	// will be on this day. Defaults to today.
+	StartDate time.Time `json:"start_date"`
+	// The title of the new list. Defaults to the title of the template.
+	Title string `json:"title" valid:"runelength(0|250)" maxLength:"250"`
+
+	// The created list
+	List *List `json:"list,omitempty"`
+
+	template *ListTemplate
+
+	web.Rights   `json:"-"`
+	web.CRUDable `json:"-"`
+}
+
+// CanCreate checks if a user can create a list from a template. They need to own the template and have write
+// access to the namespace.
+func (lf *ListFromTemplate) CanCreate(a web.Auth) (bool, error) {
+	lt := &ListTemplate{ID: lf.TemplateID}
+	can, _, err := lt.CanRead(a)
+	if err != nil || !can {
+		return can, err
+	}
+	lf.template = lt
+
+	l := &List{NamespaceID: lf.NamespaceID}
+	return l.CanCreate(a)
+}
+
+// Create creates a new list from a template
+// @Summary Create a list from a template
+// @Description Creates a new list with the kanban buckets and tasks of a template in a namespace. All dates of the tasks are shifted relative to the start date. Labels the user does not have access to anymore are not added to the tasks. The user needs to own the template and have write access to the namespace.
+// @tags list
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param template path int true "Template ID"
+// @Param list body models.ListFromTemplate true "The namespace, start date and title of the new list."
+// @Success 200 {object} models.ListFromTemplate "The created list."
+// @Failure 400 {object} web.HTTPError "Invalid object provided."
+// @Failure 403 {object} web.HTTPError "The user does not own the template or does not have write access to the namespace."
+// @Failure 404 {object} web.HTTPError "The template does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /templates/{template}/instantiate [put]
+func (lf *ListFromTemplate) Create(a web.Auth) (err error) {
+	if lf.template == nil {
+		lf.template, err = getListTemplateByID(lf.TemplateID)
+		if err != nil {
+			return
+		}
+	}
+	lt := lf.template
+
+	if lf.StartDate.IsZero() {
+		lf.StartDate = time.Now()
+	}
+	start := startOfDay(lf.StartDate.In(config.GetTimeZone()))
+
+	log.Debugf("Creating list from template %d", lt.ID)
+
+	lf.List = &List{
+		Title:       lf.Title,
+		Description: lt.Description,
+		HexColor:    lt.HexColor,
+		NamespaceID: lf.NamespaceID,
+		OwnerID:     a.GetID(),
+	}
+	if lf.List.Title == "" {
+		lf.List.Title = lt.Title
+	}
+	if err = CreateOrUpdateList(lf.List); err != nil {
+		return
+	}
+
+	lc := &listCopy{
+		buckets: make([]*Bucket, 0, len(lt.Buckets)),
+		tasks:   make([]*Task, 0, len(lt.Tasks)),
+	}
+	for _, tb := range lt.Buckets {
+		lc.buckets = append(lc.buckets, &Bucket{
+			ID:    tb.ID,
+			Title: tb.Title,
+			Limit: tb.Limit,
+		})
+	}
+
+	// Only add labels which still exist and the user has access to
+	labelAccess := make(map[int64]bool)
+	for _, tt := range lt.Tasks {
+		t := &Task{
+			ID:                    tt.ID,
+			Title:                 tt.Title,
+			Description:           tt.Description,
+			Priority:              tt.Priority,
+			HexColor:              tt.HexColor,
+			PercentDone:           tt.PercentDone,
+			Estimate:              tt.Estimate,
+			Points:                tt.Points,
+			RepeatAfter:           tt.RepeatAfter,
+			RepeatFromCurrentDate: tt.RepeatFromCurrentDate,
+			RepeatRule:            tt.RepeatRule,
+			Position:              tt.Position,
+			BucketID:              tt.BucketID,
+			DueDate:               getListTemplateDate(tt.DueDate, start),
+			StartDate:             getListTemplateDate(tt.StartDate, start),
+			EndDate:               getListTemplateDate(tt.EndDate, start),
+		}
+		for _, r := range tt.Reminders {
+			t.Reminders = append(t.Reminders, getListTemplateDate(r, start))
+		}
+		lc.tasks = append(lc.tasks, t)
+
+		for _, labelID := range tt.LabelIDs {
+			has, checked := labelAccess[labelID]
+			if !checked {
+				has, _, err = (&Label{ID: labelID}).CanRead(a)
+				if err != nil {
+					return err
+				}
+				labelAccess[labelID] = has
+			}
+			if has {
+				lc.labelTasks = append(lc.labelTasks, &LabelTask{LabelID: labelID, TaskID: tt.ID})
+			}
+		}
+
+		// Both directions of a relation are part of the template already
+		for kind, related := range tt.RelatedTasks {
+			for _, otherID := range related {
+				lc.relations = append(lc.relations, &TaskRelation{
+					TaskID:       tt.ID,
+					OtherTaskID:  otherID,
+					RelationKind: kind,
+					CreatedByID:  a.GetID(),
+				})
+			}
+		}
+	}
+
+	if _, _, err = lc.createInList(lf.List.ID, a); err != nil {
+		return
+	}
+
+	log.Debugf("Created list %d from template %d", lf.List.ID, lt.ID)
+
+	return
+}
@@ -0,0 +1,259 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"4d63.com/tz"
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListTemplate_Create(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		lt := &ListTemplate{ListID: 1}
+		can, err := lt.CanCreate(u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+		err = lt.Create(u)
+		assert.NoError(t, err)
+		assert.Equal(t, "Test1", lt.Title)
+		assert.Equal(t, int64(1), lt.Owner.ID)
+		assert.Len(t, lt.Buckets, 3)
+		db.AssertExists(t, "list_templates", map[string]interface{}{
+			"id":       lt.ID,
+			"title":    "Test1",
+			"owner_id": 1,
+		}, false)
+
+		tasks := make(map[int64]*ListTemplateTask, len(lt.Tasks))
+		for _, tt := range lt.Tasks {
+			tasks[tt.ID] = tt
+		}
+
+		// Only relations to tasks in the same list are kept
+		assert.Equal(t, map[RelationKind][]int64{RelationKindSubtask: {29}}, tasks[1].RelatedTasks)
+		assert.Equal(t, []int64{4}, tasks[1].LabelIDs)
+		assert.Nil(t, tasks[1].DueDate)
+
+		// Dates are saved as the day relative to each other and the time on that day
+		if assert.NotNil(t, tasks[5].DueDate) && assert.NotNil(t, tasks[6].DueDate) {
+			assert.Equal(t, 1, tasks[5].DueDate.Day-tasks[6].DueDate.Day)
+			assert.Equal(t, 3*3600+58*60+44, tasks[5].DueDate.Time)
+			assert.Equal(t, 22*3600+25*60+24, tasks[6].DueDate.Time)
+			assert.True(t, tasks[6].DueDate.Day >= 0)
+		}
+	})
+	t.Run("with title", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		lt := &ListTemplate{ListID: 1, Title: "Weekly planning"}
+		err := lt.Create(u)
+		assert.NoError(t, err)
+		assert.Equal(t, "Weekly planning", lt.Title)
+		assert.Equal(t, "Lorem Ipsum", lt.Description)
+	})
+	t.Run("no access to the list", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		lt := &ListTemplate{ListID: 2}
+		can, err := lt.CanCreate(&user.User{ID: 2})
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("link share", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		lt := &ListTemplate{ListID: 1}
+		can, err := lt.CanCreate(&LinkSharing{ID: 1, ListID: 1})
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+}
+
+func TestListTemplateDates(t *testing.T) {
+	berlin, err := tz.LoadLocation("Europe/Berlin")
+	assert.NoError(t, err)
+
+	// Daylight saving time ends on the 25th
+	templateStart := time.Date(2020, 10, 24, 0, 0, 0, 0, berlin)
+	offset := getListTemplateDateOffset(time.Date(2020, 10, 26, 9, 0, 0, 0, berlin), templateStart)
+	assert.Equal(t, &ListTemplateDate{Day: 2, Time: 9 * 3600}, offset)
+
+	// Daylight saving time starts on the 28th
+	start := time.Date(2021, 3, 27, 0, 0, 0, 0, berlin)
+	assert.Equal(t, time.Date(2021, 3, 29, 9, 0, 0, 0, berlin), getListTemplateDate(offset, start))
+	assert.True(t, getListTemplateDate(nil, start).IsZero())
+}
+
+func TestListTemplate_ReadAll(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	lt := &ListTemplate{}
+	result, _, total, err := lt.ReadAll(&user.User{ID: 1}, "", 1, 50)
+	assert.NoError(t, err)
+	templates := result.([]*ListTemplate)
+	assert.Len(t, templates, 1)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, "Onboarding", templates[0].Title)
+	assert.Len(t, templates[0].Tasks, 2)
+}
+
+func TestListTemplate_Rights(t *testing.T) {
+	t.Run("owner", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		lt := &ListTemplate{ID: 1}
+		can, _, err := lt.CanRead(&user.User{ID: 1})
+		assert.NoError(t, err)
+		assert.True(t, can)
+		assert.Equal(t, "Onboarding", lt.Title)
+	})
+	t.Run("other user", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		lt := &ListTemplate{ID: 2}
+		can, err := lt.CanDelete(&user.User{ID: 1})
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("nonexisting", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		lt := &ListTemplate{ID: 9999}
+		_, _, err := lt.CanRead(&user.User{ID: 1})
+		assert.Error(t, err)
+		assert.True(t, IsErrListTemplateDoesNotExist(err))
+	})
+}
+
+func TestListTemplate_Update(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	lt := &ListTemplate{ID: 1, Title: "Onboarding v2"}
+	can, err := lt.CanUpdate(&user.User{ID: 1})
+	assert.NoError(t, err)
+	assert.True(t, can)
+	err = lt.Update()
+	assert.NoError(t, err)
+	assert.Equal(t, "Onboarding v2", lt.Title)
+	assert.Len(t, lt.Tasks, 2)
+}
+
+func TestListTemplate_Delete(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	lt := &ListTemplate{ID: 1}
+	err := lt.Delete()
+	assert.NoError(t, err)
+	db.AssertMissing(t, "list_templates", map[string]interface{}{
+		"id": 1,
+	})
+}
+
+func TestListFromTemplate_Create(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		start := time.Date(2020, 10, 19, 15, 30, 0, 0, config.GetTimeZone())
+		lf := &ListFromTemplate{
+			TemplateID:  1,
+			NamespaceID: 1,
+			StartDate:   start,
+		}
+		can, err := lf.CanCreate(u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+		err = lf.Create(u)
+		assert.NoError(t, err)
+		assert.Equal(t, "Onboarding", lf.List.Title)
+		assert.Equal(t, "00ff00", lf.List.HexColor)
+		db.AssertExists(t, "list", map[string]interface{}{
+			"id":           lf.List.ID,
+			"title":        "Onboarding",
+			"namespace_id": 1,
+			"owner_id":     1,
+		}, false)
+		bucket := &Bucket{}
+		exists, err := x.Where("list_id = ? AND title = ?", lf.List.ID, "Waiting").Get(bucket)
+		assert.NoError(t, err)
+		assert.True(t, exists)
+		assert.Equal(t, int64(1), bucket.Limit)
+
+		tasks, _, _, err := getTasksForLists([]*List{{ID: lf.List.ID}}, u, &taskOptions{})
+		assert.NoError(t, err)
+		if !assert.Len(t, tasks, 2) {
+			return
+		}
+
+		// Dates are relative to the start of the day
+		day := startOfDay(start)
+		assert.Equal(t, "Prepare laptop", tasks[0].Title)
+		assert.Equal(t, day.Add(9*time.Hour).Unix(), tasks[0].DueDate.Unix())
+		if assert.Len(t, tasks[0].Reminders, 1) {
+			assert.Equal(t, day.Add(8*time.Hour).Unix(), tasks[0].Reminders[0].Unix())
+		}
+		assert.Equal(t, int64(3600), tasks[0].Estimate)
+		assert.Equal(t, day.Add(33*time.Hour).Unix(), tasks[1].DueDate.Unix())
+		assert.True(t, tasks[1].StartDate.IsZero())
+
+		// Labels the user has no access to are not added
+		if assert.Len(t, tasks[0].Labels, 1) {
+			assert.Equal(t, int64(1), tasks[0].Labels[0].ID)
+		}
+
+		db.AssertExists(t, "task_relations", map[string]interface{}{
+			"task_id":       tasks[0].ID,
+			"other_task_id": tasks[1].ID,
+			"relation_kind": RelationKindSubtask,
+		}, false)
+		db.AssertExists(t, "task_relations", map[string]interface{}{
+			"task_id":       tasks[1].ID,
+			"other_task_id": tasks[0].ID,
+			"relation_kind": RelationKindParenttask,
+		}, false)
+	})
+	t.Run("with title", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		lf := &ListFromTemplate{TemplateID: 1, NamespaceID: 1, Title: "Onboarding Jane"}
+		err := lf.Create(u)
+		assert.NoError(t, err)
+		assert.Equal(t, "Onboarding Jane", lf.List.Title)
+	})
+	t.Run("template of another user", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		lf := &ListFromTemplate{TemplateID: 2, NamespaceID: 1}
+		can, err := lf.CanCreate(u)
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("no write access to the namespace", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		lf := &ListFromTemplate{TemplateID: 1, NamespaceID: 3}
+		can, err := lf.CanCreate(u)
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("template without buckets", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		lf := &ListFromTemplate{TemplateID: 2, NamespaceID: 3}
+		err := lf.Create(&user.User{ID: 3})
+		assert.NoError(t, err)
+		db.AssertExists(t, "buckets", map[string]interface{}{
+			"list_id": lf.List.ID,
+		}, false)
+	})
+}
@@ -160,6 +160,14 @@ func TestList_Delete(t *testing.T) {
 	db.AssertMissing(t, "list", map[string]interface{}{
 		"id": 1,
 	})
+
+	// The time entries of its tasks are deleted as well
+	list = List{ID: 2}
+	err = list.Delete()
+	assert.NoError(t, err)
+	db.AssertMissing(t, "time_entries", map[string]interface{}{
+		"task_id": 13,
+	})
 }
 
 func TestList_ReadAll(t *testing.T) {
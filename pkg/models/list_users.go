@@ -19,6 +19,7 @@ package models
 import (
 	"time"
 
+	"code.vikunja.io/api/pkg/events"
 	"code.vikunja.io/api/pkg/user"
 	"code.vikunja.io/web"
 )
@@ -112,6 +113,15 @@ func (lu *ListUser) Create(a web.Auth) (err error) {
 	}
 
 	err = updateListLastUpdated(l)
+	if err != nil {
+		return
+	}
+
+	events.Dispatch(&ListSharedWithUserEvent{
+		List: l,
+		User: user,
+		Doer: a,
+	})
 	return
 }
 
@@ -172,22 +172,28 @@ func TestListUser_ReadAll(t *testing.T) {
 			want: []*UserWithRight{
 				{
 					User: user.User{
-						ID:       1,
-						Username: "user1",
-						Password: "$2a$14$dcadBoMBL9jQoOcZK8Fju.cy0Ptx2oZECkKLnaa8ekRoTFe1w7To.",
-						IsActive: true,
-						Created:  testCreatedTime,
-						Updated:  testUpdatedTime,
+						ID:                      1,
+						Username:                "user1",
+						EmailNotifyTaskAssigned: true,
+						EmailNotifyTaskComment:  true,
+						EmailNotifyTaskDone:     true,
+						Password:                "$2a$14$dcadBoMBL9jQoOcZK8Fju.cy0Ptx2oZECkKLnaa8ekRoTFe1w7To.",
+						IsActive:                true,
+						Created:                 testCreatedTime,
+						Updated:                 testUpdatedTime,
 					},
 					Right: RightRead,
 				},
 				{
 					User: user.User{
-						ID:       2,
-						Username: "user2",
-						Password: "$2a$14$dcadBoMBL9jQoOcZK8Fju.cy0Ptx2oZECkKLnaa8ekRoTFe1w7To.",
-						Created:  testCreatedTime,
-						Updated:  testUpdatedTime,
+						ID:                      2,
+						Username:                "user2",
+						EmailNotifyTaskAssigned: true,
+						EmailNotifyTaskComment:  true,
+						EmailNotifyTaskDone:     true,
+						Password:                "$2a$14$dcadBoMBL9jQoOcZK8Fju.cy0Ptx2oZECkKLnaa8ekRoTFe1w7To.",
+						Created:                 testCreatedTime,
+						Updated:                 testUpdatedTime,
 					},
 					Right: RightRead,
 				},
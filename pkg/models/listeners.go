@@ -0,0 +1,654 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/metrics"
+	"code.vikunja.io/api/pkg/modules/realtime"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+)
+
+// Registers all event listeners of the models package
+func init() {
+	events.RegisterListener((&TaskCreatedEvent{}).Name(), &IncreaseTaskCounter{})
+	events.RegisterListener((&TaskDeletedEvent{}).Name(), &DecreaseTaskCounter{})
+	events.RegisterListener((&ListCreatedEvent{}).Name(), &IncreaseListCounter{})
+	events.RegisterListener((&ListDeletedEvent{}).Name(), &DecreaseListCounter{})
+	events.RegisterListener((&NamespaceCreatedEvent{}).Name(), &IncreaseNamespaceCounter{})
+	events.RegisterListener((&NamespaceDeletedEvent{}).Name(), &DecreaseNamespaceCounter{})
+	events.RegisterListener((&TeamCreatedEvent{}).Name(), &IncreaseTeamCounter{})
+	events.RegisterListener((&TeamDeletedEvent{}).Name(), &DecreaseTeamCounter{})
+
+	events.RegisterListener((&TaskAssigneeCreatedEvent{}).Name(), &NotifyTaskAssignee{})
+	events.RegisterListener((&TaskCommentCreatedEvent{}).Name(), &NotifyTaskComment{})
+	events.RegisterListener((&ListSharedWithUserEvent{}).Name(), &NotifyListSharedWithUser{})
+	events.RegisterListener((&ListSharedWithTeamEvent{}).Name(), &NotifyListSharedWithTeam{})
+	events.RegisterListener((&UserMentionedEvent{}).Name(), &NotifyMentionedUser{})
+
+	events.RegisterListener((&TaskAssigneeCreatedEvent{}).Name(), &SendTaskAssignedEmail{})
+	events.RegisterListener((&TaskCommentCreatedEvent{}).Name(), &SendTaskCommentEmail{})
+	events.RegisterListener((&TaskUpdatedEvent{}).Name(), &SendTaskDoneEmail{})
+
+	for _, e := range []events.Event{
+		&TaskCreatedEvent{},
+		&TaskUpdatedEvent{},
+		&TaskDeletedEvent{},
+		&TaskAssigneeCreatedEvent{},
+		&TaskAssigneeDeletedEvent{},
+		&TaskLabelCreatedEvent{},
+		&TaskLabelDeletedEvent{},
+		&TaskAttachmentCreatedEvent{},
+		&TaskAttachmentDeletedEvent{},
+		&TaskRelationCreatedEvent{},
+		&TaskRelationDeletedEvent{},
+	} {
+		events.RegisterListener(e.Name(), &SaveTaskActivity{})
+	}
+	events.RegisterListener((&TaskCreatedEvent{}).Name(), &SaveTaskRevision{})
+	events.RegisterListener((&TaskUpdatedEvent{}).Name(), &SaveTaskRevision{})
+
+	for _, e := range []events.Event{
+		&TaskCreatedEvent{},
+		&TaskUpdatedEvent{},
+		&TaskDeletedEvent{},
+		&TaskAssigneeCreatedEvent{},
+		&TaskAssigneeDeletedEvent{},
+		&TaskLabelCreatedEvent{},
+		&TaskLabelDeletedEvent{},
+		&TaskAttachmentCreatedEvent{},
+		&TaskAttachmentDeletedEvent{},
+		&TaskRelationCreatedEvent{},
+		&TaskRelationDeletedEvent{},
+		&TaskCommentCreatedEvent{},
+		&TaskCommentUpdatedEvent{},
+		&TaskCommentDeletedEvent{},
+		&BucketCreatedEvent{},
+		&BucketUpdatedEvent{},
+		&BucketDeletedEvent{},
+	} {
+		events.RegisterListener(e.Name(), &PublishRealtimeEvent{})
+	}
+
+	for eventName := range availableWebhookEvents {
+		events.RegisterListener(eventName, &SendWebhooks{})
+	}
+
+	events.RegisterListener((&user.CreatedEvent{}).Name(), &CreateNamespaceForExternalUser{})
+	events.RegisterListener((&UserDataExportRequestedEvent{}).Name(), &HandleUserDataExport{})
+}
+
+//////
+// Users
+
+// CreateNamespaceForExternalUser represents a listener
+type CreateNamespaceForExternalUser struct{}
+
+// Name defines the name for the CreateNamespaceForExternalUser listener
+func (s *CreateNamespaceForExternalUser) Name() string {
+	return "user.namespace.create"
+}
+
+// Handle is executed when the event CreateNamespaceForExternalUser listens on is fired
+// Users who register get their namespace when registering, users from external providers like ldap or openid
+// connect are created while logging in and get it here.
+func (s *CreateNamespaceForExternalUser) Handle(e events.Event) (err error) {
+	event := e.(*user.CreatedEvent)
+	if event.User.IsLocalUser() {
+		return nil
+	}
+
+	n := &Namespace{
+		Title:       event.User.Username,
+		Description: event.User.Username + "'s namespace.",
+		Owner:       event.User,
+	}
+	return n.Create(event.User)
+}
+
+// HandleUserDataExport represents a listener
+type HandleUserDataExport struct{}
+
+// Name defines the name for the HandleUserDataExport listener
+func (s *HandleUserDataExport) Name() string {
+	return "user.export.create"
+}
+
+// Handle is executed when the event HandleUserDataExport listens on is fired
+func (s *HandleUserDataExport) Handle(e events.Event) (err error) {
+	event := e.(*UserDataExportRequestedEvent)
+
+	// Building the export can take a long time for users with a lot of tasks or attachments,
+	// so we don't want to block the request which triggered it.
+	go func(u *user.User) {
+		defer finishUserDataExport(u.ID)
+		if err := ExportUserData(u); err != nil {
+			log.Errorf("[Data Export] Could not create data export for user %d: %s", u.ID, err)
+		}
+	}(event.User)
+
+	return nil
+}
+
+//////
+// Metrics
+
+// IncreaseTaskCounter represents a listener
+type IncreaseTaskCounter struct{}
+
+// Name defines the name for the IncreaseTaskCounter listener
+func (s *IncreaseTaskCounter) Name() string {
+	return "task.counter.increase"
+}
+
+// Handle is executed when the event IncreaseTaskCounter listens on is fired
+func (s *IncreaseTaskCounter) Handle(_ events.Event) (err error) {
+	metrics.UpdateCount(1, metrics.TaskCountKey)
+	return nil
+}
+
+// DecreaseTaskCounter represents a listener
+type DecreaseTaskCounter struct{}
+
+// Name defines the name for the DecreaseTaskCounter listener
+func (s *DecreaseTaskCounter) Name() string {
+	return "task.counter.decrease"
+}
+
+// Handle is executed when the event DecreaseTaskCounter listens on is fired
+func (s *DecreaseTaskCounter) Handle(_ events.Event) (err error) {
+	metrics.UpdateCount(-1, metrics.TaskCountKey)
+	return nil
+}
+
+// IncreaseListCounter represents a listener
+type IncreaseListCounter struct{}
+
+// Name defines the name for the IncreaseListCounter listener
+func (s *IncreaseListCounter) Name() string {
+	return "list.counter.increase"
+}
+
+// Handle is executed when the event IncreaseListCounter listens on is fired
+func (s *IncreaseListCounter) Handle(_ events.Event) (err error) {
+	metrics.UpdateCount(1, metrics.ListCountKey)
+	return nil
+}
+
+// DecreaseListCounter represents a listener
+type DecreaseListCounter struct{}
+
+// Name defines the name for the DecreaseListCounter listener
+func (s *DecreaseListCounter) Name() string {
+	return "list.counter.decrease"
+}
+
+// Handle is executed when the event DecreaseListCounter listens on is fired
+func (s *DecreaseListCounter) Handle(_ events.Event) (err error) {
+	metrics.UpdateCount(-1, metrics.ListCountKey)
+	return nil
+}
+
+// IncreaseNamespaceCounter represents a listener
+type IncreaseNamespaceCounter struct{}
+
+// Name defines the name for the IncreaseNamespaceCounter listener
+func (s *IncreaseNamespaceCounter) Name() string {
+	return "namespace.counter.increase"
+}
+
+// Handle is executed when the event IncreaseNamespaceCounter listens on is fired
+func (s *IncreaseNamespaceCounter) Handle(_ events.Event) (err error) {
+	metrics.UpdateCount(1, metrics.NamespaceCountKey)
+	return nil
+}
+
+// DecreaseNamespaceCounter represents a listener
+type DecreaseNamespaceCounter struct{}
+
+// Name defines the name for the DecreaseNamespaceCounter listener
+func (s *DecreaseNamespaceCounter) Name() string {
+	return "namespace.counter.decrease"
+}
+
+// Handle is executed when the event DecreaseNamespaceCounter listens on is fired
+func (s *DecreaseNamespaceCounter) Handle(_ events.Event) (err error) {
+	metrics.UpdateCount(-1, metrics.NamespaceCountKey)
+	return nil
+}
+
+// IncreaseTeamCounter represents a listener
+type IncreaseTeamCounter struct{}
+
+// Name defines the name for the IncreaseTeamCounter listener
+func (s *IncreaseTeamCounter) Name() string {
+	return "team.counter.increase"
+}
+
+// Handle is executed when the event IncreaseTeamCounter listens on is fired
+func (s *IncreaseTeamCounter) Handle(_ events.Event) (err error) {
+	metrics.UpdateCount(1, metrics.TeamCountKey)
+	return nil
+}
+
+// DecreaseTeamCounter represents a listener
+type DecreaseTeamCounter struct{}
+
+// Name defines the name for the DecreaseTeamCounter listener
+func (s *DecreaseTeamCounter) Name() string {
+	return "team.counter.decrease"
+}
+
+// Handle is executed when the event DecreaseTeamCounter listens on is fired
+func (s *DecreaseTeamCounter) Handle(_ events.Event) (err error) {
+	metrics.UpdateCount(-1, metrics.TeamCountKey)
+	return nil
+}
+
+//////
+// Activity
+
+// SaveTaskActivity represents a listener
+type SaveTaskActivity struct{}
+
+// Name defines the name for the SaveTaskActivity listener
+func (s *SaveTaskActivity) Name() string {
+	return "task.activity.save"
+}
+
+// Handle is executed when the event SaveTaskActivity listens on is fired
+func (s *SaveTaskActivity) Handle(event events.Event) (err error) {
+	activity := newActivityFromEvent(event)
+	if activity == nil {
+		return nil
+	}
+
+	_, err = x.Insert(activity)
+	return
+}
+
+// SaveTaskRevision represents a listener
+type SaveTaskRevision struct{}
+
+// Name defines the name for the SaveTaskRevision listener
+func (s *SaveTaskRevision) Name() string {
+	return "task.revision.save"
+}
+
+// Handle is executed when the event SaveTaskRevision listens on is fired
+func (s *SaveTaskRevision) Handle(event events.Event) (err error) {
+	switch e := event.(type) {
+	case *TaskCreatedEvent:
+		return saveTaskRevision(e.Task, e.Doer)
+	case *TaskUpdatedEvent:
+		if e.OldTask.Title == e.Task.Title && e.OldTask.Description == e.Task.Description {
+			return nil
+		}
+		err = saveInitialTaskRevision(e.OldTask)
+		if err != nil {
+			return err
+		}
+		return saveTaskRevision(e.Task, e.Doer)
+	}
+	return nil
+}
+
+//////
+// Realtime
+
+// PublishRealtimeEvent represents a listener
+type PublishRealtimeEvent struct{}
+
+// Name defines the name for the PublishRealtimeEvent listener
+func (s *PublishRealtimeEvent) Name() string {
+	return "realtime.publish"
+}
+
+// Handle is executed when the event PublishRealtimeEvent listens on is fired
+func (s *PublishRealtimeEvent) Handle(event events.Event) (err error) {
+	for _, msg := range getRealtimeMessages(event) {
+		err = realtime.Publish(msg.topic, event.Name(), msg.data)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//////
+// Webhooks
+
+// SendWebhooks represents a listener
+type SendWebhooks struct{}
+
+// Name defines the name for the SendWebhooks listener
+func (s *SendWebhooks) Name() string {
+	return "webhooks.send"
+}
+
+// Handle is executed when the event SendWebhooks listens on is fired
+func (s *SendWebhooks) Handle(event events.Event) (err error) {
+	if !config.WebhooksEnabled.GetBool() {
+		return nil
+	}
+
+	listID := getListIDForWebhookEvent(event)
+	if listID == 0 {
+		return nil
+	}
+
+	webhooks, err := getWebhooksForListEvent(listID, event.Name())
+	if err != nil {
+		return err
+	}
+
+	for _, w := range webhooks {
+		// Delivering a webhook can take a long time if the receiver is slow or down and we need to retry,
+		// so we don't want to block the request which triggered the event.
+		go func(w *Webhook) {
+			_, err := w.deliver(event)
+			if err != nil {
+				log.Errorf("[Webhooks] Could not deliver webhook %d: %s", w.ID, err)
+			}
+		}(w)
+	}
+
+	return nil
+}
+
+//////
+// Notifications
+
+// Returns whether the user with the given id is the one who triggered an event.
+// Nobody should get notified about what they did themselves.
+func isDoer(doer web.Auth, userID int64) bool {
+	if doer == nil {
+		return false
+	}
+	if _, is := doer.(*LinkSharing); is {
+		return false
+	}
+	return doer.GetID() == userID
+}
+
+// NotifyTaskAssignee represents a listener
+type NotifyTaskAssignee struct{}
+
+// Name defines the name for the NotifyTaskAssignee listener
+func (s *NotifyTaskAssignee) Name() string {
+	return "task.assignee.notify"
+}
+
+// Handle is executed when the event NotifyTaskAssignee listens on is fired
+func (s *NotifyTaskAssignee) Handle(event events.Event) (err error) {
+	e := event.(*TaskAssigneeCreatedEvent)
+	if isDoer(e.Doer, e.Assignee.ID) {
+		return nil
+	}
+
+	return notifyUsers([]int64{e.Assignee.ID}, NotificationNameTaskAssigned, &NotificationData{
+		Task: newNotificationTask(e.Task),
+		Doer: newNotificationDoer(e.Doer),
+	})
+}
+
+// NotifyTaskComment represents a listener
+type NotifyTaskComment struct{}
+
+// Name defines the name for the NotifyTaskComment listener
+func (s *NotifyTaskComment) Name() string {
+	return "task.comment.notify"
+}
+
+// Handle is executed when the event NotifyTaskComment listens on is fired
+func (s *NotifyTaskComment) Handle(event events.Event) (err error) {
+	e := event.(*TaskCommentCreatedEvent)
+
+	list := &List{ID: e.Task.ListID}
+	if err := list.GetSimpleByID(); err != nil {
+		return err
+	}
+
+	users, err := getUsersToNotifyForTask(e.Task, list)
+	if err != nil {
+		return err
+	}
+
+	userIDs := make([]int64, 0, len(users))
+	for _, u := range users {
+		if isDoer(e.Doer, u.ID) {
+			continue
+		}
+		userIDs = append(userIDs, u.ID)
+	}
+
+	return notifyUsers(userIDs, NotificationNameTaskComment, &NotificationData{
+		Task: newNotificationTask(e.Task),
+		Comment: &TaskComment{
+			ID:      e.Comment.ID,
+			Comment: e.Comment.Comment,
+		},
+		Doer: newNotificationDoer(e.Doer),
+	})
+}
+
+// NotifyListSharedWithUser represents a listener
+type NotifyListSharedWithUser struct{}
+
+// Name defines the name for the NotifyListSharedWithUser listener
+func (s *NotifyListSharedWithUser) Name() string {
+	return "list.shared.user.notify"
+}
+
+// Handle is executed when the event NotifyListSharedWithUser listens on is fired
+func (s *NotifyListSharedWithUser) Handle(event events.Event) (err error) {
+	e := event.(*ListSharedWithUserEvent)
+	if isDoer(e.Doer, e.User.ID) {
+		return nil
+	}
+
+	return notifyUsers([]int64{e.User.ID}, NotificationNameListShared, &NotificationData{
+		List: newNotificationList(e.List),
+		Doer: newNotificationDoer(e.Doer),
+	})
+}
+
+// NotifyListSharedWithTeam represents a listener
+type NotifyListSharedWithTeam struct{}
+
+// Name defines the name for the NotifyListSharedWithTeam listener
+func (s *NotifyListSharedWithTeam) Name() string {
+	return "list.shared.team.notify"
+}
+
+// Handle is executed when the event NotifyListSharedWithTeam listens on is fired
+func (s *NotifyListSharedWithTeam) Handle(event events.Event) (err error) {
+	e := event.(*ListSharedWithTeamEvent)
+
+	members := []*TeamMember{}
+	err = x.Where("team_id = ?", e.Team.ID).Find(&members)
+	if err != nil {
+		return err
+	}
+
+	userIDs := make([]int64, 0, len(members))
+	for _, m := range members {
+		if isDoer(e.Doer, m.UserID) {
+			continue
+		}
+		userIDs = append(userIDs, m.UserID)
+	}
+
+	return notifyUsers(userIDs, NotificationNameListShared, &NotificationData{
+		List: newNotificationList(e.List),
+		Team: &Team{
+			ID:   e.Team.ID,
+			Name: e.Team.Name,
+		},
+		Doer: newNotificationDoer(e.Doer),
+	})
+}
+
+// NotifyMentionedUser represents a listener
+type NotifyMentionedUser struct{}
+
+// Name defines the name for the NotifyMentionedUser listener
+func (s *NotifyMentionedUser) Name() string {
+	return "user.mentioned.notify"
+}
+
+// Handle is executed when the event NotifyMentionedUser listens on is fired
+func (s *NotifyMentionedUser) Handle(event events.Event) (err error) {
+	e := event.(*UserMentionedEvent)
+
+	data := &NotificationData{
+		Task: newNotificationTask(e.Task),
+		Doer: newNotificationDoer(e.Doer),
+	}
+	if e.Comment != nil {
+		data.Comment = &TaskComment{
+			ID:      e.Comment.ID,
+			Comment: e.Comment.Comment,
+		}
+	}
+
+	return notifyUsers([]int64{e.Mentioned.ID}, NotificationNameMentioned, data)
+}
+
+//////
+// Emails
+
+// SendTaskAssignedEmail represents a listener
+type SendTaskAssignedEmail struct{}
+
+// Name defines the name for the SendTaskAssignedEmail listener
+func (s *SendTaskAssignedEmail) Name() string {
+	return "task.assignee.email"
+}
+
+// Handle is executed when the event SendTaskAssignedEmail listens on is fired
+func (s *SendTaskAssignedEmail) Handle(event events.Event) (err error) {
+	e := event.(*TaskAssigneeCreatedEvent)
+	if isDoer(e.Doer, e.Assignee.ID) {
+		return nil
+	}
+
+	assignee, err := user.GetUserWithEmail(&user.User{ID: e.Assignee.ID})
+	if err != nil {
+		return err
+	}
+	if assignee.Email == "" || !assignee.EmailNotifyTaskAssigned {
+		return nil
+	}
+
+	task, list, err := getTaskAndListForEmail(e.Task.ID)
+	if err != nil {
+		return err
+	}
+
+	sendTaskEmails(
+		[]*user.User{assignee},
+		"You were assigned to \""+task.Title+"\" ("+list.Title+")",
+		"task-assigned",
+		task,
+		list,
+		e.Doer,
+		nil,
+	)
+	return nil
+}
+
+// SendTaskCommentEmail represents a listener
+type SendTaskCommentEmail struct{}
+
+// Name defines the name for the SendTaskCommentEmail listener
+func (s *SendTaskCommentEmail) Name() string {
+	return "task.comment.email"
+}
+
+// Handle is executed when the event SendTaskCommentEmail listens on is fired
+func (s *SendTaskCommentEmail) Handle(event events.Event) (err error) {
+	e := event.(*TaskCommentCreatedEvent)
+
+	task, list, err := getTaskAndListForEmail(e.Task.ID)
+	if err != nil {
+		return err
+	}
+
+	users, err := getUsersToEmailAboutTask(task, list, e.Doer, func(u *user.User) bool {
+		return u.EmailNotifyTaskComment
+	})
+	if err != nil {
+		return err
+	}
+
+	sendTaskEmails(
+		users,
+		"New comment on \""+task.Title+"\" ("+list.Title+")",
+		"task-comment",
+		task,
+		list,
+		e.Doer,
+		map[string]interface{}{
+			"Comment": e.Comment,
+		},
+	)
+	return nil
+}
+
+// SendTaskDoneEmail represents a listener
+type SendTaskDoneEmail struct{}
+
+// Name defines the name for the SendTaskDoneEmail listener
+func (s *SendTaskDoneEmail) Name() string {
+	return "task.done.email"
+}
+
+// Handle is executed when the event SendTaskDoneEmail listens on is fired
+// Repeating tasks are never saved as done and therefore don't trigger this email.
+func (s *SendTaskDoneEmail) Handle(event events.Event) (err error) {
+	e := event.(*TaskUpdatedEvent)
+	if e.OldTask.Done || !e.Task.Done {
+		return nil
+	}
+
+	task, list, err := getTaskAndListForEmail(e.Task.ID)
+	if err != nil {
+		return err
+	}
+
+	users, err := getUsersToEmailAboutTask(task, list, e.Doer, func(u *user.User) bool {
+		return u.EmailNotifyTaskDone
+	})
+	if err != nil {
+		return err
+	}
+
+	sendTaskEmails(
+		users,
+		"\""+task.Title+"\" ("+list.Title+") was marked as done",
+		"task-done",
+		task,
+		list,
+		e.Doer,
+		nil,
+	)
+	return nil
+}
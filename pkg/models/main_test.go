@@ -23,6 +23,7 @@ import (
 	"time"
 
 	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/events"
 	"code.vikunja.io/api/pkg/files"
 	"code.vikunja.io/api/pkg/user"
 )
@@ -64,5 +65,7 @@ func TestMain(m *testing.M) {
 
 	SetupTests()
 
+	events.Fake()
+
 	os.Exit(m.Run())
 }
@@ -0,0 +1,292 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"regexp"
+	"time"
+
+	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+	"xorm.io/builder"
+)
+
+// Matches @username everywhere except in the middle of a word, for example in email addresses.
+var mentionRegex = regexp.MustCompile(`(?:^|[^\w@.])@([\w.-]*\w)`)
+
+// Mention represents a user being mentioned with @username in the description of a task or in a task comment
+type Mention struct {
+	// The unique, numeric id of this mention.
+	ID int64 `xorm:"int(11) autoincr not null unique pk" json:"id"`
+	// The id of the mentioned user
+	UserID int64 `xorm:"int(11) not null INDEX" json:"-"`
+	// The id of the task in which the user was mentioned.
+	TaskID int64 `xorm:"int(11) not null INDEX" json:"task_id"`
+	// The id of the comment in which the user was mentioned. 0 if the user was mentioned in the task description.
+	CommentID int64 `xorm:"int(11) not null default 0 INDEX" json:"comment_id"`
+	// The id of the user who mentioned the other user. 0 if it was done through a link share.
+	CreatedByID int64 `xorm:"int(11) not null default 0" json:"-"`
+
+	// The task in which the user was mentioned.
+	Task *Task `xorm:"-" json:"task"`
+	// The comment in which the user was mentioned. Empty if the user was mentioned in the task description.
+	Comment *TaskComment `xorm:"-" json:"comment"`
+	// The user who mentioned the other user. Empty if it was done through a link share.
+	CreatedBy *user.User `xorm:"-" json:"created_by"`
+
+	// A timestamp when this mention was created. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TableName returns the table name for mentions
+func (m *Mention) TableName() string {
+	return "mentions"
+}
+
+// Returns all distinct usernames mentioned with @username in a text
+func getMentionedUsernames(text string) (usernames []string) {
+	seen := make(map[string]bool)
+	for _, match := range mentionRegex.FindAllStringSubmatch(text, -1) {
+		username := match[1]
+		if seen[username] {
+			continue
+		}
+		seen[username] = true
+		usernames = append(usernames, username)
+	}
+	return
+}
+
+// Saves a mention for every user mentioned in the text who has access to the task and was not already mentioned at
+// the same place before. Every newly mentioned user gets notified.
+// Users without access to the task are silently ignored so mentions can't be used to leak the contents of a task.
+func saveMentions(task *Task, comment *TaskComment, text string, doer web.Auth) (err error) {
+	usernames := getMentionedUsernames(text)
+	if len(usernames) == 0 {
+		return nil
+	}
+
+	list := &List{ID: task.ListID}
+	err = list.GetSimpleByID()
+	if err != nil {
+		return err
+	}
+
+	var commentID int64
+	if comment != nil {
+		commentID = comment.ID
+	}
+
+	var createdByID int64
+	if _, is := doer.(*LinkSharing); !is && doer != nil {
+		createdByID = doer.GetID()
+	}
+
+	for _, username := range usernames {
+		u, err := user.GetUser(&user.User{Username: username})
+		if err != nil {
+			if user.IsErrUserDoesNotExist(err) {
+				continue
+			}
+			return err
+		}
+
+		if isDoer(doer, u.ID) {
+			continue
+		}
+
+		canRead, _, err := list.CanRead(u)
+		if err != nil {
+			return err
+		}
+		if !canRead {
+			continue
+		}
+
+		exists, err := x.
+			Where("user_id = ? AND task_id = ? AND comment_id = ?", u.ID, task.ID, commentID).
+			Exist(&Mention{})
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+
+		mention := &Mention{
+			UserID:      u.ID,
+			TaskID:      task.ID,
+			CommentID:   commentID,
+			CreatedByID: createdByID,
+		}
+		_, err = x.Insert(mention)
+		if err != nil {
+			return err
+		}
+
+		events.Dispatch(&UserMentionedEvent{
+			Task:      task,
+			Comment:   comment,
+			Mentioned: u,
+			Doer:      doer,
+		})
+	}
+
+	return nil
+}
+
+// Deletes all mentions in a task, including the ones in its comments
+func deleteMentionsForTask(taskID int64) (err error) {
+	_, err = x.Where("task_id = ?", taskID).Delete(&Mention{})
+	return
+}
+
+// Deletes all mentions in a comment
+func deleteMentionsForComment(commentID int64) (err error) {
+	_, err = x.Where("comment_id = ?", commentID).Delete(&Mention{})
+	return
+}
+
+// ReadAll returns all mentions of the current user
+// @Summary Get all mentions of the current user
+// @Description Returns all places where the current user was mentioned with @username, newest first. Only mentions in tasks the user still has access to are returned.
+// @tags user
+// @Accept json
+// @Produce json
+// @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
+// @Param per_page query int false "The maximum number of items per page. Note this parameter is limited by the configured maximum of items per page."
+// @Security JWTKeyAuth
+// @Success 200 {array} models.Mention "The mentions"
+// @Failure 403 {object} web.HTTPError "Link shares cannot be mentioned."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /mentions [get]
+func (m *Mention) ReadAll(a web.Auth, search string, page int, perPage int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	if _, is := a.(*LinkSharing); is {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	lists, _, _, err := getRawListsForUser(&listOptions{
+		user: &user.User{ID: a.GetID()},
+		page: -1,
+	})
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	listIDs := make([]int64, 0, len(lists))
+	for _, l := range lists {
+		listIDs = append(listIDs, l.ID)
+	}
+
+	if len(listIDs) == 0 {
+		return []*Mention{}, 0, 0, nil
+	}
+
+	cond := builder.And(
+		builder.Eq{"mentions.user_id": a.GetID()},
+		builder.In("tasks.list_id", listIDs),
+	)
+
+	limit, start := getLimitFromPageIndex(page, perPage)
+
+	mentions := []*Mention{}
+	query := x.
+		Select("mentions.*").
+		Join("INNER", "tasks", "tasks.id = mentions.task_id").
+		Where(cond).
+		OrderBy("mentions.id desc")
+	if limit > 0 {
+		query = query.Limit(limit, start)
+	}
+	err = query.Find(&mentions)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	err = addMoreInfoToMentions(mentions)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	numberOfTotalItems, err = x.
+		Join("INNER", "tasks", "tasks.id = mentions.task_id").
+		Where(cond).
+		Count(&Mention{})
+	return mentions, len(mentions), numberOfTotalItems, err
+}
+
+// Adds the task, comment and creator to all mentions
+func addMoreInfoToMentions(mentions []*Mention) (err error) {
+	if len(mentions) == 0 {
+		return nil
+	}
+
+	taskIDs := make([]int64, 0, len(mentions))
+	commentIDs := make([]int64, 0, len(mentions))
+	userIDs := make([]int64, 0, len(mentions))
+	for _, m := range mentions {
+		taskIDs = append(taskIDs, m.TaskID)
+		if m.CommentID != 0 {
+			commentIDs = append(commentIDs, m.CommentID)
+		}
+		if m.CreatedByID != 0 {
+			userIDs = append(userIDs, m.CreatedByID)
+		}
+	}
+
+	tasks := make(map[int64]*Task, len(taskIDs))
+	err = x.In("id", taskIDs).Find(&tasks)
+	if err != nil {
+		return
+	}
+
+	comments := make(map[int64]*TaskComment, len(commentIDs))
+	if len(commentIDs) > 0 {
+		err = x.In("id", commentIDs).Find(&comments)
+		if err != nil {
+			return
+		}
+	}
+
+	users := make(map[int64]*user.User, len(userIDs))
+	if len(userIDs) > 0 {
+		err = x.In("id", userIDs).Find(&users)
+		if err != nil {
+			return
+		}
+	}
+
+	for _, m := range mentions {
+		m.Task = tasks[m.TaskID]
+		m.Comment = comments[m.CommentID]
+		if u, has := users[m.CreatedByID]; has {
+			// Don't leak the email address of other users
+			m.CreatedBy = &user.User{
+				ID:       u.ID,
+				Username: u.Username,
+				Created:  u.Created,
+				Updated:  u.Updated,
+			}
+		}
+	}
+
+	return
+}
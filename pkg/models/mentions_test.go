@@ -0,0 +1,122 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetMentionedUsernames(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		usernames := getMentionedUsernames("@user1 and @user2, write to user3@example.com. Thanks @user-4.")
+		assert.Equal(t, []string{"user1", "user2", "user-4"}, usernames)
+	})
+	t.Run("html", func(t *testing.T) {
+		usernames := getMentionedUsernames("<p>@user1</p><p>Lorem&nbsp;@user2 @user1</p>")
+		assert.Equal(t, []string{"user1", "user2"}, usernames)
+	})
+	t.Run("none", func(t *testing.T) {
+		usernames := getMentionedUsernames("Lorem Ipsum @ Dolor")
+		assert.Len(t, usernames, 0)
+	})
+}
+
+func TestSaveMentions(t *testing.T) {
+	t.Run("description", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		task := &Task{ID: 32, ListID: 3}
+		err := saveMentions(task, nil, "@user1 @user2 @user3 @user4 @doesnotexist", &user.User{ID: 3})
+		assert.NoError(t, err)
+
+		events.AssertDispatched(t, &UserMentionedEvent{})
+		db.AssertExists(t, "mentions", map[string]interface{}{
+			"user_id":       1,
+			"task_id":       32,
+			"comment_id":    0,
+			"created_by_id": 3,
+		}, false)
+		db.AssertExists(t, "mentions", map[string]interface{}{
+			"user_id": 2,
+			"task_id": 32,
+		}, false)
+		// Users should not be able to mention themselves
+		db.AssertMissing(t, "mentions", map[string]interface{}{
+			"user_id": 3,
+		})
+		// User 4 does not have access to the list
+		db.AssertMissing(t, "mentions", map[string]interface{}{
+			"user_id": 4,
+		})
+	})
+	t.Run("mentioned twice", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		task := &Task{ID: 32, ListID: 3}
+		err := saveMentions(task, nil, "@user1", &user.User{ID: 3})
+		assert.NoError(t, err)
+		err = saveMentions(task, nil, "@user1 Lorem Ipsum", &user.User{ID: 3})
+		assert.NoError(t, err)
+
+		count, err := x.Where("user_id = ? AND task_id = ?", 1, 32).Count(&Mention{})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+	})
+	t.Run("comment by a link share", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		task := &Task{ID: 32, ListID: 3}
+		err := saveMentions(task, &TaskComment{ID: 10, TaskID: 32}, "@user1", &LinkSharing{ID: 3, ListID: 3})
+		assert.NoError(t, err)
+		db.AssertExists(t, "mentions", map[string]interface{}{
+			"user_id":       1,
+			"task_id":       32,
+			"comment_id":    10,
+			"created_by_id": 0,
+		}, false)
+	})
+}
+
+func TestMention_ReadAll(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		m := &Mention{}
+		result, _, total, err := m.ReadAll(&user.User{ID: 1}, "", 0, 50)
+		assert.NoError(t, err)
+		mentions := result.([]*Mention)
+		// Mention 3 is in a list user 1 does not have access to
+		assert.Len(t, mentions, 2)
+		assert.Equal(t, int64(2), total)
+		assert.Equal(t, int64(2), mentions[0].ID)
+		assert.Equal(t, int64(1), mentions[0].Task.ID)
+		assert.Equal(t, int64(1), mentions[0].Comment.ID)
+		assert.Nil(t, mentions[0].CreatedBy)
+		assert.Equal(t, int64(1), mentions[1].ID)
+		assert.Nil(t, mentions[1].Comment)
+		assert.Equal(t, "user2", mentions[1].CreatedBy.Username)
+		assert.Empty(t, mentions[1].CreatedBy.Email)
+	})
+	t.Run("link share", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		m := &Mention{}
+		_, _, _, err := m.ReadAll(&LinkSharing{ID: 1}, "", 0, 50)
+		assert.Error(t, err)
+		assert.True(t, IsErrGenericForbidden(err))
+	})
+}
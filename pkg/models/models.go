@@ -59,6 +59,17 @@ func GetTables() []interface{} {
 		&Bucket{},
 		&UnsplashPhoto{},
 		&SavedFilter{},
+		&Webhook{},
+		&WebhookDelivery{},
+		&Notification{},
+		&Mention{},
+		&Activity{},
+		&TaskRevision{},
+		&APIToken{},
+		&TimeEntry{},
+		&CustomField{},
+		&TaskCustomFieldValue{},
+		&ListTemplate{},
 	}
 }
 
@@ -20,7 +20,7 @@ import (
 	"sort"
 	"time"
 
-	"code.vikunja.io/api/pkg/metrics"
+	"code.vikunja.io/api/pkg/events"
 	"code.vikunja.io/api/pkg/user"
 	"code.vikunja.io/web"
 	"github.com/imdario/mergo"
@@ -441,7 +441,7 @@ func (n *Namespace) Create(a web.Auth) (err error) {
 		return err
 	}
 
-	metrics.UpdateCount(1, metrics.NamespaceCountKey)
+	events.Dispatch(&NamespaceCreatedEvent{Namespace: n})
 	return
 }
 
@@ -465,38 +465,47 @@ func (n *Namespace) Delete() (err error) {
 		return
 	}
 
-	// Delete the namespace
-	_, err = x.ID(n.ID).Delete(&Namespace{})
-	if err != nil {
-		return
-	}
-
 	// Delete all lists with their tasks
 	lists, err := GetListsByNamespaceID(n.ID, &user.User{})
 	if err != nil {
 		return
 	}
-	var listIDs []int64
-	// We need to do that for here because we need the list ids to delete two times:
-	// 1) to delete the lists itself
-	// 2) to delete the list tasks
+	listIDs := make([]int64, 0, len(lists))
 	for _, l := range lists {
 		listIDs = append(listIDs, l.ID)
 	}
 
-	// Delete tasks
-	_, err = x.In("list_id", listIDs).Delete(&Task{})
+	s := x.NewSession()
+	defer s.Close()
+	if err = s.Begin(); err != nil {
+		return
+	}
+
+	// Delete the namespace
+	_, err = s.ID(n.ID).Delete(&Namespace{})
+	if err != nil {
+		_ = s.Rollback()
+		return
+	}
+
+	err = deleteLists(s, listIDs)
 	if err != nil {
+		_ = s.Rollback()
 		return
 	}
 
-	// Delete the lists
-	_, err = x.In("id", listIDs).Delete(&List{})
+	// Delete all webhooks of the namespace
+	err = deleteWebhooksForListsAndNamespaces(s, nil, []int64{n.ID})
 	if err != nil {
+		_ = s.Rollback()
+		return
+	}
+
+	if err = s.Commit(); err != nil {
 		return
 	}
 
-	metrics.UpdateCount(-1, metrics.NamespaceCountKey)
+	events.Dispatch(&NamespaceDeletedEvent{Namespace: n})
 
 	return
 }
@@ -208,3 +208,27 @@ func TestNamespace_ReadAll(t *testing.T) {
 		assert.NotEqual(t, SavedFiltersPseudoNamespace.ID, namespaces[0].ID)
 	})
 }
+
+func TestCreateNamespaceForExternalUser(t *testing.T) {
+	t.Run("external user", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		u := &user.User{ID: 1, Username: "user1", Issuer: "ldap", Subject: "uid=user1,dc=example,dc=com"}
+		l := &CreateNamespaceForExternalUser{}
+		err := l.Handle(&user.CreatedEvent{User: u})
+		assert.NoError(t, err)
+		db.AssertExists(t, "namespaces", map[string]interface{}{
+			"title":    "user1",
+			"owner_id": 1,
+		}, false)
+	})
+	t.Run("local user", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		u := &user.User{ID: 1, Username: "user1"}
+		l := &CreateNamespaceForExternalUser{}
+		err := l.Handle(&user.CreatedEvent{User: u})
+		assert.NoError(t, err)
+		db.AssertMissing(t, "namespaces", map[string]interface{}{
+			"title": "user1",
+		})
+	})
+}
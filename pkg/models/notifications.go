@@ -0,0 +1,228 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"time"
+
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+)
+
+// These are all kinds of notifications a user can get
+const (
+	NotificationNameTaskAssigned = `task.assigned`
+	NotificationNameTaskComment  = `task.comment`
+	NotificationNameListShared   = `list.shared`
+	NotificationNameMentioned    = `task.mentioned`
+)
+
+// Notification is a notification for a single user about something that happened in Vikunja
+type Notification struct {
+	// The unique, numeric id of this notification.
+	ID     int64 `xorm:"int(11) autoincr not null unique pk" json:"id" param:"notificationid"`
+	UserID int64 `xorm:"int(11) not null INDEX" json:"-"`
+
+	// The kind of this notification, for example "task.assigned". Use this to decide how to display it.
+	Name string `xorm:"varchar(250) not null" json:"name"`
+	// Everything needed to display the notification. Which fields are set depends on the kind of the notification.
+	Data *NotificationData `xorm:"JSON not null" json:"data"`
+
+	// Whether the notification was already read. Set this to true to mark a notification as read.
+	Read bool `xorm:"not null default false INDEX 'is_read'" json:"read"`
+	// A timestamp when this notification was marked as read.
+	ReadAt time.Time `xorm:"DATETIME null" json:"read_at"`
+
+	// A timestamp when this notification was created. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TableName returns the table name for notifications
+func (n *Notification) TableName() string {
+	return "notifications"
+}
+
+// NotificationData holds a snapshot of everything a notification is about.
+// Only the fields needed to display the notification are saved, not the full objects.
+type NotificationData struct {
+	Task    *Task        `json:"task,omitempty"`
+	Comment *TaskComment `json:"comment,omitempty"`
+	List    *List        `json:"list,omitempty"`
+	Team    *Team        `json:"team,omitempty"`
+	// The user who did whatever the notification is about. Empty if it was done through a link share.
+	Doer *user.User `json:"doer,omitempty"`
+}
+
+func newNotificationTask(t *Task) *Task {
+	return &Task{
+		ID:         t.ID,
+		Title:      t.Title,
+		ListID:     t.ListID,
+		Index:      t.Index,
+		Identifier: t.Identifier,
+	}
+}
+
+func newNotificationList(l *List) *List {
+	return &List{
+		ID:    l.ID,
+		Title: l.Title,
+	}
+}
+
+func newNotificationDoer(a web.Auth) *user.User {
+	if a == nil {
+		return nil
+	}
+	if _, is := a.(*LinkSharing); is {
+		return nil
+	}
+	u, err := user.GetUserByID(a.GetID())
+	if err != nil {
+		return nil
+	}
+	return &user.User{
+		ID:       u.ID,
+		Username: u.Username,
+	}
+}
+
+// Creates the same notification for multiple users
+func notifyUsers(userIDs []int64, name string, data *NotificationData) (err error) {
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	notifications := make([]*Notification, 0, len(userIDs))
+	for _, id := range userIDs {
+		notifications = append(notifications, &Notification{
+			UserID: id,
+			Name:   name,
+			Data:   data,
+		})
+	}
+
+	_, err = x.Insert(&notifications)
+	if err != nil {
+		return
+	}
+
+	for _, n := range notifications {
+		if err := publishRealtimeNotification(n); err != nil {
+			log.Errorf("[Realtime] Could not publish notification for user %d: %s", n.UserID, err)
+		}
+	}
+	return nil
+}
+
+// ReadAll returns all notifications of the current user
+// @Summary Get all notifications for the current user
+// @Description Returns an array with all notifications for the current user, newest first.
+// @tags notifications
+// @Produce json
+// @Security JWTKeyAuth
+// @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
+// @Param per_page query int false "The maximum number of items per page. Note this parameter is limited by the configured maximum of items per page."
+// @Success 200 {array} models.Notification "The notifications"
+// @Failure 403 {object} web.HTTPError "Link shares cannot have notifications."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /notifications [get]
+func (n *Notification) ReadAll(a web.Auth, search string, page int, perPage int) (result interface{}, resultCount int, totalItems int64, err error) {
+	if _, is := a.(*LinkSharing); is {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	limit, start := getLimitFromPageIndex(page, perPage)
+
+	notifications := []*Notification{}
+	query := x.
+		Where("user_id = ?", a.GetID()).
+		OrderBy("id desc")
+	if limit > 0 {
+		query = query.Limit(limit, start)
+	}
+	err = query.Find(&notifications)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	totalItems, err = x.
+		Where("user_id = ?", a.GetID()).
+		Count(&Notification{})
+	return notifications, len(notifications), totalItems, err
+}
+
+// Update marks a notification as read or unread
+// @Summary Mark a notification as (un-)read
+// @Description Marks a notification as read or unread, depending on the read field.
+// @tags notifications
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param id path int true "Notification ID"
+// @Param notification body models.Notification true "The notification. Only the read field is used."
+// @Success 200 {object} models.Notification "The updated notification."
+// @Failure 403 {object} web.HTTPError "The user does not have access to that notification."
+// @Failure 404 {object} web.HTTPError "The notification does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /notifications/{id} [post]
+func (n *Notification) Update() (err error) {
+	n.ReadAt = time.Time{}
+	if n.Read {
+		n.ReadAt = time.Now()
+	}
+
+	_, err = x.
+		Where("id = ?", n.ID).
+		Cols("is_read", "read_at").
+		Update(n)
+	if err != nil {
+		return
+	}
+
+	updated, err := getNotificationByID(n.ID)
+	if err != nil {
+		return
+	}
+	*n = *updated
+	return
+}
+
+func getNotificationByID(id int64) (n *Notification, err error) {
+	n = &Notification{}
+	exists, err := x.Where("id = ?", id).Get(n)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrNotificationDoesNotExist{NotificationID: id}
+	}
+	return
+}
+
+// MarkAllNotificationsAsRead marks all unread notifications of a user as read
+func MarkAllNotificationsAsRead(u *user.User) (err error) {
+	_, err = x.
+		Where("user_id = ? AND is_read = ?", u.ID, false).
+		Cols("is_read", "read_at").
+		Update(&Notification{Read: true, ReadAt: time.Now()})
+	return
+}
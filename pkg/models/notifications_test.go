@@ -0,0 +1,197 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotification_ReadAll(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		n := &Notification{}
+		result, _, total, err := n.ReadAll(&user.User{ID: 1}, "", 0, 50)
+		assert.NoError(t, err)
+		notifications := result.([]*Notification)
+		assert.Len(t, notifications, 2)
+		assert.Equal(t, int64(2), total)
+		// Newest first
+		assert.Equal(t, int64(2), notifications[0].ID)
+		assert.True(t, notifications[0].Read)
+		assert.Equal(t, NotificationNameListShared, notifications[0].Name)
+		assert.Equal(t, "Test3", notifications[0].Data.List.Title)
+		assert.Equal(t, int64(1), notifications[1].ID)
+		assert.False(t, notifications[1].Read)
+	})
+	t.Run("link share", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		n := &Notification{}
+		_, _, _, err := n.ReadAll(&LinkSharing{ID: 1}, "", 0, 50)
+		assert.Error(t, err)
+		assert.True(t, IsErrGenericForbidden(err))
+	})
+}
+
+func TestNotification_Update(t *testing.T) {
+	t.Run("mark as read", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		n := &Notification{ID: 1, Read: true}
+		can, err := n.CanUpdate(&user.User{ID: 1})
+		assert.NoError(t, err)
+		assert.True(t, can)
+		err = n.Update()
+		assert.NoError(t, err)
+		assert.True(t, n.Read)
+		assert.False(t, n.ReadAt.IsZero())
+		assert.Equal(t, NotificationNameTaskAssigned, n.Name)
+		db.AssertExists(t, "notifications", map[string]interface{}{
+			"id":      1,
+			"is_read": true,
+		}, false)
+	})
+	t.Run("mark as unread", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		n := &Notification{ID: 2, Read: false}
+		err := n.Update()
+		assert.NoError(t, err)
+		assert.False(t, n.Read)
+		db.AssertExists(t, "notifications", map[string]interface{}{
+			"id":      2,
+			"is_read": false,
+		}, false)
+	})
+	t.Run("other user", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		n := &Notification{ID: 3, Read: true}
+		can, err := n.CanUpdate(&user.User{ID: 1})
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("nonexisting", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		n := &Notification{ID: 9999, Read: true}
+		_, err := n.CanUpdate(&user.User{ID: 1})
+		assert.Error(t, err)
+		assert.True(t, IsErrNotificationDoesNotExist(err))
+	})
+}
+
+func TestMarkAllNotificationsAsRead(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	err := MarkAllNotificationsAsRead(&user.User{ID: 1})
+	assert.NoError(t, err)
+	db.AssertExists(t, "notifications", map[string]interface{}{
+		"id":      1,
+		"is_read": true,
+	}, false)
+	// Notifications of other users should not be touched
+	db.AssertExists(t, "notifications", map[string]interface{}{
+		"id":      3,
+		"is_read": false,
+	}, false)
+}
+
+func TestNotificationListeners(t *testing.T) {
+	t.Run("task assigned", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		l := &NotifyTaskAssignee{}
+		err := l.Handle(&TaskAssigneeCreatedEvent{
+			Task:     &Task{ID: 1, Title: "task #1", ListID: 1},
+			Assignee: &user.User{ID: 2},
+			Doer:     &user.User{ID: 1},
+		})
+		assert.NoError(t, err)
+		db.AssertExists(t, "notifications", map[string]interface{}{
+			"user_id": 2,
+			"name":    NotificationNameTaskAssigned,
+		}, false)
+	})
+	t.Run("self assigned", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		l := &NotifyTaskAssignee{}
+		err := l.Handle(&TaskAssigneeCreatedEvent{
+			Task:     &Task{ID: 1, Title: "task #1", ListID: 1},
+			Assignee: &user.User{ID: 1},
+			Doer:     &user.User{ID: 1},
+		})
+		assert.NoError(t, err)
+		// Only the notifications from the fixtures should exist
+		count, err := x.Where("user_id = ?", 1).Count(&Notification{})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), count)
+	})
+	t.Run("comment", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		task, err := GetTaskByIDSimple(30)
+		assert.NoError(t, err)
+		l := &NotifyTaskComment{}
+		err = l.Handle(&TaskCommentCreatedEvent{
+			Task:    &task,
+			Comment: &TaskComment{ID: 1, Comment: "Lorem Ipsum", TaskID: 30},
+			Doer:    &LinkSharing{ID: 1, ListID: 1},
+		})
+		assert.NoError(t, err)
+		// The creator of the task should get notified
+		db.AssertExists(t, "notifications", map[string]interface{}{
+			"user_id": 1,
+			"name":    NotificationNameTaskComment,
+		}, false)
+		// User 2 is assigned to the task but does not have access to the list
+		db.AssertMissing(t, "notifications", map[string]interface{}{
+			"user_id": 2,
+			"name":    NotificationNameTaskComment,
+		})
+	})
+	t.Run("comment by the creator", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		task, err := GetTaskByIDSimple(30)
+		assert.NoError(t, err)
+		l := &NotifyTaskComment{}
+		err = l.Handle(&TaskCommentCreatedEvent{
+			Task:    &task,
+			Comment: &TaskComment{ID: 1, Comment: "Lorem Ipsum", TaskID: 30},
+			Doer:    &user.User{ID: 1},
+		})
+		assert.NoError(t, err)
+		db.AssertMissing(t, "notifications", map[string]interface{}{
+			"name": NotificationNameTaskComment,
+		})
+	})
+	t.Run("list shared with team", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		l := &NotifyListSharedWithTeam{}
+		err := l.Handle(&ListSharedWithTeamEvent{
+			List: &List{ID: 1, Title: "Test1"},
+			Team: &Team{ID: 1, Name: "testteam1"},
+			Doer: &user.User{ID: 1},
+		})
+		assert.NoError(t, err)
+		db.AssertExists(t, "notifications", map[string]interface{}{
+			"user_id": 2,
+			"name":    NotificationNameListShared,
+		}, false)
+		db.AssertMissing(t, "notifications", map[string]interface{}{
+			"user_id": 1,
+			"name":    NotificationNameListShared,
+			"is_read": false,
+		})
+	})
+}
@@ -0,0 +1,238 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/modules/realtime"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+)
+
+// realtimeEventData is what realtime subscribers get sent for an event.
+// All users in it only contain their id and username to make sure no email addresses are leaked.
+type realtimeEventData struct {
+	Task       *Task           `json:"task,omitempty"`
+	Bucket     *Bucket         `json:"bucket,omitempty"`
+	Comment    *TaskComment    `json:"comment,omitempty"`
+	Assignee   *user.User      `json:"assignee,omitempty"`
+	Label      *Label          `json:"label,omitempty"`
+	Attachment *TaskAttachment `json:"attachment,omitempty"`
+	Relation   *TaskRelation   `json:"relation,omitempty"`
+	// The user who did the change. Empty if it was done through a link share.
+	Doer *user.User `json:"doer,omitempty"`
+	// Only set for subscribers of the list a task was moved away from
+	MovedTask *realtimeMovedTask `json:"moved_task,omitempty"`
+}
+
+// realtimeMovedTask is all subscribers of a list get to know about a task which was moved to another list.
+// They might not have access to the new list, so they only get the ids.
+type realtimeMovedTask struct {
+	ID     int64 `json:"id"`
+	ListID int64 `json:"list_id"`
+}
+
+// realtimeMessage is the data sent to the subscribers of one topic
+type realtimeMessage struct {
+	topic string
+	data  *realtimeEventData
+}
+
+func newRealtimeUser(u *user.User) *user.User {
+	if u == nil {
+		return nil
+	}
+	return &user.User{
+		ID:       u.ID,
+		Username: u.Username,
+		Created:  u.Created,
+		Updated:  u.Updated,
+	}
+}
+
+func newRealtimeTask(t *Task) *Task {
+	task := *t
+	task.CreatedBy = newRealtimeUser(t.CreatedBy)
+	task.Assignees = make([]*user.User, 0, len(t.Assignees))
+	for _, a := range t.Assignees {
+		task.Assignees = append(task.Assignees, newRealtimeUser(a))
+	}
+	return &task
+}
+
+// Returns the messages to publish for an event, every one with the topic it should be published on.
+// Returns no messages for events which are not interesting for realtime subscribers.
+func getRealtimeMessages(event events.Event) (messages []*realtimeMessage) {
+	var task *Task
+	var doer web.Auth
+	var topics []string
+	data := &realtimeEventData{}
+
+	switch e := event.(type) {
+	case *TaskCreatedEvent:
+		task, doer = e.Task, e.Doer
+	case *TaskUpdatedEvent:
+		task, doer = e.Task, e.Doer
+		// Subscribers of the old list need to know the task is gone
+		if e.OldTask.ListID != e.Task.ListID {
+			messages = append(messages, &realtimeMessage{
+				topic: realtime.ListTopic(e.OldTask.ListID),
+				data: &realtimeEventData{
+					MovedTask: &realtimeMovedTask{ID: e.Task.ID, ListID: e.Task.ListID},
+				},
+			})
+		}
+	case *TaskDeletedEvent:
+		task, doer = e.Task, e.Doer
+	case *TaskAssigneeCreatedEvent:
+		task, doer = e.Task, e.Doer
+		data.Assignee = newRealtimeUser(e.Assignee)
+	case *TaskAssigneeDeletedEvent:
+		task, doer = e.Task, e.Doer
+		data.Assignee = newRealtimeUser(e.Assignee)
+	case *TaskLabelCreatedEvent:
+		task, doer = e.Task, e.Doer
+		data.Label = e.Label
+	case *TaskLabelDeletedEvent:
+		task, doer = e.Task, e.Doer
+		data.Label = e.Label
+	case *TaskAttachmentCreatedEvent:
+		task, doer = e.Task, e.Doer
+		data.Attachment = e.Attachment
+	case *TaskAttachmentDeletedEvent:
+		task, doer = e.Task, e.Doer
+		data.Attachment = e.Attachment
+	case *TaskRelationCreatedEvent:
+		task, doer = e.Task, e.Doer
+		data.Relation = e.Relation
+	case *TaskRelationDeletedEvent:
+		task, doer = e.Task, e.Doer
+		data.Relation = e.Relation
+	case *TaskCommentCreatedEvent:
+		task, doer = e.Task, e.Doer
+		data.Comment = e.Comment
+	case *TaskCommentUpdatedEvent:
+		task, doer = e.Task, e.Doer
+		data.Comment = e.Comment
+	case *TaskCommentDeletedEvent:
+		task, doer = e.Task, e.Doer
+		data.Comment = e.Comment
+	case *BucketCreatedEvent:
+		data.Bucket = e.Bucket
+		doer = e.Doer
+		topics = append(topics, realtime.ListTopic(e.Bucket.ListID))
+	case *BucketUpdatedEvent:
+		data.Bucket = e.Bucket
+		doer = e.Doer
+		topics = append(topics, realtime.ListTopic(e.Bucket.ListID))
+	case *BucketDeletedEvent:
+		data.Bucket = e.Bucket
+		doer = e.Doer
+		topics = append(topics, realtime.ListTopic(e.Bucket.ListID))
+	default:
+		return nil
+	}
+
+	if task != nil {
+		data.Task = newRealtimeTask(task)
+		topics = append(topics, realtime.ListTopic(task.ListID), realtime.TaskTopic(task.ID))
+	}
+	if data.Comment != nil {
+		comment := *data.Comment
+		comment.Author = newRealtimeUser(comment.Author)
+		data.Comment = &comment
+	}
+	data.Doer = newNotificationDoer(doer)
+
+	for _, topic := range topics {
+		messages = append(messages, &realtimeMessage{topic: topic, data: data})
+	}
+	return
+}
+
+// Sends a new notification to the realtime feed of its user
+func publishRealtimeNotification(n *Notification) error {
+	return realtime.Publish(realtime.UserTopic(n.UserID), "notification.created", n)
+}
+
+// GetRealtimeTopics checks if a user or link share has access to everything it wants to subscribe to and returns
+// the topics for it. Only users can subscribe to their own feed.
+func GetRealtimeTopics(a web.Auth, listIDs []int64, taskIDs []int64, feed bool) (topics []string, err error) {
+	for _, id := range listIDs {
+		l := &List{ID: id}
+		canRead, _, err := l.CanRead(a)
+		if err != nil {
+			return nil, err
+		}
+		if !canRead {
+			return nil, ErrGenericForbidden{}
+		}
+		topics = append(topics, realtime.ListTopic(id))
+	}
+
+	for _, id := range taskIDs {
+		t := &Task{ID: id}
+		canRead, _, err := t.CanRead(a)
+		if err != nil {
+			return nil, err
+		}
+		if !canRead {
+			return nil, ErrGenericForbidden{}
+		}
+		topics = append(topics, realtime.TaskTopic(id))
+	}
+
+	if feed {
+		if _, is := a.(*LinkSharing); is {
+			return nil, ErrGenericForbidden{}
+		}
+		topics = append(topics, realtime.UserTopic(a.GetID()))
+	}
+
+	return
+}
+
+// CanReadRealtimeTopic checks if a user or link share still has access to a topic it subscribed to.
+// This is checked for every message because access might have been revoked since subscribing.
+func CanReadRealtimeTopic(a web.Auth, topic string) (bool, error) {
+	kind, id, err := realtime.ParseTopic(topic)
+	if err != nil {
+		return false, err
+	}
+
+	switch kind {
+	case realtime.TopicKindList:
+		l := &List{ID: id}
+		canRead, _, err := l.CanRead(a)
+		return canRead, err
+	case realtime.TopicKindTask:
+		t := &Task{ID: id}
+		canRead, _, err := t.CanRead(a)
+		// The subscriber had access to the task when subscribing, it should be told when it gets deleted.
+		if IsErrTaskDoesNotExist(err) {
+			return true, nil
+		}
+		return canRead, err
+	case realtime.TopicKindUser:
+		if _, is := a.(*LinkSharing); is {
+			return false, nil
+		}
+		return a.GetID() == id, nil
+	}
+
+	return false, nil
+}
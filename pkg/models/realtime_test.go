@@ -0,0 +1,126 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func getRealtimeTopics(messages []*realtimeMessage) (topics []string) {
+	for _, msg := range messages {
+		topics = append(topics, msg.topic)
+	}
+	return
+}
+
+func TestGetRealtimeMessages(t *testing.T) {
+	t.Run("task moved to another list", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		messages := getRealtimeMessages(&TaskUpdatedEvent{
+			OldTask: &Task{ID: 1, ListID: 1},
+			Task: &Task{
+				ID:        1,
+				Title:     "Moved task",
+				ListID:    2,
+				Assignees: []*user.User{{ID: 1, Username: "user1", Email: "user1@example.com"}},
+			},
+			Doer: &user.User{ID: 1, Username: "user1"},
+		})
+		assert.Equal(t, []string{"list.1", "list.2", "task.1"}, getRealtimeTopics(messages))
+
+		// The old list only gets to know where the task went
+		assert.Equal(t, &realtimeEventData{MovedTask: &realtimeMovedTask{ID: 1, ListID: 2}}, messages[0].data)
+
+		data := messages[1].data
+		assert.Equal(t, "Moved task", data.Task.Title)
+		assert.Equal(t, "user1", data.Task.Assignees[0].Username)
+		assert.Equal(t, "", data.Task.Assignees[0].Email)
+		assert.Equal(t, "user1", data.Doer.Username)
+		assert.Nil(t, data.MovedTask)
+	})
+	t.Run("bucket", func(t *testing.T) {
+		messages := getRealtimeMessages(&BucketCreatedEvent{
+			Bucket: &Bucket{ID: 1, ListID: 1},
+		})
+		assert.Equal(t, []string{"list.1"}, getRealtimeTopics(messages))
+		assert.Equal(t, int64(1), messages[0].data.Bucket.ID)
+		assert.Nil(t, messages[0].data.Task)
+	})
+	t.Run("not a realtime event", func(t *testing.T) {
+		messages := getRealtimeMessages(&TeamCreatedEvent{})
+		assert.Len(t, messages, 0)
+	})
+}
+
+func TestGetRealtimeTopics(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		topics, err := GetRealtimeTopics(&user.User{ID: 1}, []int64{1, 3}, []int64{32}, true)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"list.1", "list.3", "task.32", "user.1"}, topics)
+	})
+	t.Run("no access to list", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		_, err := GetRealtimeTopics(&user.User{ID: 2}, []int64{1}, nil, false)
+		assert.Error(t, err)
+		assert.True(t, IsErrGenericForbidden(err))
+	})
+	t.Run("no access to task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		_, err := GetRealtimeTopics(&user.User{ID: 1}, nil, []int64{14}, false)
+		assert.Error(t, err)
+		assert.True(t, IsErrGenericForbidden(err))
+	})
+	t.Run("link share", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		share := &LinkSharing{ID: 1, ListID: 1, Right: RightRead}
+		topics, err := GetRealtimeTopics(share, []int64{1}, nil, false)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"list.1"}, topics)
+
+		_, err = GetRealtimeTopics(share, nil, nil, true)
+		assert.Error(t, err)
+		assert.True(t, IsErrGenericForbidden(err))
+	})
+}
+
+func TestCanReadRealtimeTopic(t *testing.T) {
+	t.Run("list", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		can, err := CanReadRealtimeTopic(&user.User{ID: 1}, "list.1")
+		assert.NoError(t, err)
+		assert.True(t, can)
+		can, err = CanReadRealtimeTopic(&user.User{ID: 2}, "list.1")
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("deleted task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		can, err := CanReadRealtimeTopic(&user.User{ID: 1}, "task.99999")
+		assert.NoError(t, err)
+		assert.True(t, can)
+	})
+	t.Run("feed of another user", func(t *testing.T) {
+		can, err := CanReadRealtimeTopic(&user.User{ID: 1}, "user.2")
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+}
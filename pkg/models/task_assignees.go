@@ -19,6 +19,7 @@ package models
 import (
 	"time"
 
+	"code.vikunja.io/api/pkg/events"
 	"code.vikunja.io/api/pkg/user"
 	"code.vikunja.io/web"
 	"xorm.io/xorm"
@@ -31,6 +32,9 @@ type TaskAssginee struct {
 	UserID  int64     `xorm:"int(11) INDEX not null" json:"user_id" param:"user"`
 	Created time.Time `xorm:"created not null"`
 
+	// The user or link share who is currently changing this, set when checking the rights.
+	doer web.Auth `xorm:"-"`
+
 	web.CRUDable `xorm:"-" json:"-"`
 	web.Rights   `xorm:"-" json:"-"`
 }
@@ -57,7 +61,7 @@ func getRawTaskAssigneesForTasks(taskIDs []int64) (taskAssignees []*TaskAssignee
 }
 
 // Create or update a bunch of task assignees
-func (t *Task) updateTaskAssignees(s *xorm.Session, assignees []*user.User) (err error) {
+func (t *Task) updateTaskAssignees(s *xorm.Session, assignees []*user.User, doer web.Auth) (err error) {
 
 	// Load the current assignees
 	currentAssignees, err := getRawTaskAssigneesForTasks([]int64{t.ID})
@@ -74,8 +78,18 @@ func (t *Task) updateTaskAssignees(s *xorm.Session, assignees []*user.User) (err
 	if len(assignees) == 0 && len(t.Assignees) > 0 {
 		_, err = s.Where("task_id = ?", t.ID).
 			Delete(TaskAssginee{})
+		if err != nil {
+			return err
+		}
+		for _, oldAssignee := range t.Assignees {
+			events.Dispatch(&TaskAssigneeDeletedEvent{
+				Task:     t,
+				Assignee: oldAssignee,
+				Doer:     doer,
+			})
+		}
 		t.setTaskAssignees(assignees)
-		return err
+		return nil
 	}
 
 	// If we didn't change anything (from 0 to zero) don't do anything.
@@ -115,6 +129,13 @@ func (t *Task) updateTaskAssignees(s *xorm.Session, assignees []*user.User) (err
 		if err != nil {
 			return err
 		}
+		for _, id := range assigneesToDelete {
+			events.Dispatch(&TaskAssigneeDeletedEvent{
+				Task:     t,
+				Assignee: oldAssignees[id],
+				Doer:     doer,
+			})
+		}
 	}
 
 	// Get the list to perform later checks
@@ -133,7 +154,7 @@ func (t *Task) updateTaskAssignees(s *xorm.Session, assignees []*user.User) (err
 		}
 
 		// Add the new assignee
-		err = t.addNewAssigneeByID(u.ID, &list)
+		err = t.addNewAssigneeByID(u.ID, &list, doer)
 		if err != nil {
 			return err
 		}
@@ -168,12 +189,31 @@ func (t *Task) setTaskAssignees(assignees []*user.User) {
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /tasks/{taskID}/assignees/{userID} [delete]
 func (la *TaskAssginee) Delete() (err error) {
-	_, err = x.Delete(&TaskAssginee{TaskID: la.TaskID, UserID: la.UserID})
+	deleted, err := x.Delete(&TaskAssginee{TaskID: la.TaskID, UserID: la.UserID})
 	if err != nil {
 		return err
 	}
 
 	err = updateListByTaskID(la.TaskID)
+	if err != nil || deleted == 0 {
+		return err
+	}
+
+	task, err := GetTaskByIDSimple(la.TaskID)
+	if err != nil {
+		return err
+	}
+
+	assignee, err := user.GetUserByID(la.UserID)
+	if err != nil {
+		return err
+	}
+
+	events.Dispatch(&TaskAssigneeDeletedEvent{
+		Task:     &task,
+		Assignee: assignee,
+		Doer:     la.doer,
+	})
 	return
 }
 
@@ -198,22 +238,42 @@ func (la *TaskAssginee) Create(a web.Auth) (err error) {
 		return
 	}
 
-	task := &Task{ID: la.TaskID}
-	return task.addNewAssigneeByID(la.UserID, list)
+	task, err := GetTaskByIDSimple(la.TaskID)
+	if err != nil {
+		return err
+	}
+
+	return task.addNewAssigneeByID(la.UserID, list, a)
 }
 
-func (t *Task) addNewAssigneeByID(newAssigneeID int64, list *List) (err error) {
-	// Check if the user exists and has access to the list
-	newAssignee, err := user.GetUserByID(newAssigneeID)
+func (t *Task) addNewAssigneeByID(newAssigneeID int64, list *List, doer web.Auth) (err error) {
+	newAssignee, err := t.insertAssigneeByID(newAssigneeID, list)
 	if err != nil {
 		return err
 	}
+
+	events.Dispatch(&TaskAssigneeCreatedEvent{
+		Task:     t,
+		Assignee: newAssignee,
+		Doer:     doer,
+	})
+	return
+}
+
+// Adds an assignee to a task without notifying anyone about it.
+// This is used when copying assignees, nobody was assigned to anything new in that case.
+func (t *Task) insertAssigneeByID(newAssigneeID int64, list *List) (newAssignee *user.User, err error) {
+	// Check if the user exists and has access to the list
+	newAssignee, err = user.GetUserByID(newAssigneeID)
+	if err != nil {
+		return nil, err
+	}
 	canRead, _, err := list.CanRead(newAssignee)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if !canRead {
-		return ErrUserDoesNotHaveAccessToList{list.ID, newAssigneeID}
+		return nil, ErrUserDoesNotHaveAccessToList{list.ID, newAssigneeID}
 	}
 
 	_, err = x.Insert(TaskAssginee{
@@ -221,11 +281,11 @@ func (t *Task) addNewAssigneeByID(newAssigneeID int64, list *List) (err error) {
 		UserID: newAssigneeID,
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	err = updateListLastUpdated(&List{ID: t.ListID})
-	return
+	return newAssignee, err
 }
 
 // ReadAll gets all assignees for a task
@@ -316,7 +376,7 @@ func (ba *BulkAssignees) Create(a web.Auth) (err error) {
 		task.Assignees = append(task.Assignees, &a.User)
 	}
 
-	err = task.updateTaskAssignees(s, ba.Assignees)
+	err = task.updateTaskAssignees(s, ba.Assignees, a)
 	if err != nil {
 		_ = s.Rollback()
 		return err
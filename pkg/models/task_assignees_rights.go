@@ -32,6 +32,7 @@ func (ba *BulkAssignees) CanCreate(a web.Auth) (bool, error) {
 
 // CanDelete checks if a user can delete an assignee
 func (la *TaskAssginee) CanDelete(a web.Auth) (bool, error) {
+	la.doer = a
 	return canDoTaskAssingee(la.TaskID, a)
 }
 
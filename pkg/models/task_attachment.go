@@ -20,6 +20,7 @@ import (
 	"io"
 	"time"
 
+	"code.vikunja.io/api/pkg/events"
 	"code.vikunja.io/api/pkg/files"
 	"code.vikunja.io/api/pkg/user"
 	"code.vikunja.io/web"
@@ -38,6 +39,9 @@ type TaskAttachment struct {
 
 	Created time.Time `xorm:"created" json:"created"`
 
+	// The user or link share who is currently changing this, set when checking the rights.
+	doer web.Auth `xorm:"-"`
+
 	web.CRUDable `xorm:"-" json:"-"`
 	web.Rights   `xorm:"-" json:"-"`
 }
@@ -73,6 +77,17 @@ func (ta *TaskAttachment) NewAttachment(f io.ReadCloser, realname string, realsi
 		return err
 	}
 
+	task, err := GetTaskByIDSimple(ta.TaskID)
+	if err != nil {
+		return err
+	}
+
+	events.Dispatch(&TaskAttachmentCreatedEvent{
+		Task:       &task,
+		Attachment: ta,
+		Doer:       a,
+	})
+
 	return nil
 }
 
@@ -180,12 +195,23 @@ func (ta *TaskAttachment) Delete() error {
 		return err
 	}
 
+	task, err := GetTaskByIDSimple(ta.TaskID)
+	if err != nil {
+		return err
+	}
+
 	// Delete it
 	_, err = x.Where("task_id = ? AND id = ?", ta.TaskID, ta.ID).Delete(ta)
 	if err != nil {
 		return err
 	}
 
+	events.Dispatch(&TaskAttachmentDeletedEvent{
+		Task:       &task,
+		Attachment: ta,
+		Doer:       ta.doer,
+	})
+
 	// Delete the underlying file
 	err = ta.File.Delete()
 	// If the file does not exist, we don't want to error out
@@ -26,6 +26,7 @@ func (ta *TaskAttachment) CanRead(a web.Auth) (bool, int, error) {
 
 // CanDelete checks if the user can delete an attachment
 func (ta *TaskAttachment) CanDelete(a web.Auth) (bool, error) {
+	ta.doer = a
 	t := &Task{ID: ta.TaskID}
 	return t.CanWrite(a)
 }
@@ -18,6 +18,8 @@
 package models
 
 import (
+	"strings"
+
 	"code.vikunja.io/api/pkg/user"
 	"code.vikunja.io/web"
 )
@@ -53,6 +55,13 @@ type TaskCollection struct {
 }
 
 func validateTaskField(fieldName string) error {
+	if isCustomFieldTaskProperty(fieldName) {
+		if customFieldNameRegex.MatchString(strings.TrimPrefix(fieldName, customFieldTaskPropertyPrefix)) {
+			return nil
+		}
+		return ErrInvalidTaskField{TaskField: fieldName}
+	}
+
 	switch fieldName {
 	case
 		taskPropertyID,
@@ -69,6 +78,8 @@ func validateTaskField(fieldName string) error {
 		taskPropertyEndDate,
 		taskPropertyHexColor,
 		taskPropertyPercentDone,
+		taskPropertyEstimate,
+		taskPropertyPoints,
 		taskPropertyUID,
 		taskPropertyCreated,
 		taskPropertyUpdated,
@@ -89,9 +100,9 @@ func validateTaskField(fieldName string) error {
 // @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
 // @Param per_page query int false "The maximum number of items per page. Note this parameter is limited by the configured maximum of items per page."
 // @Param s query string false "Search tasks by task text."
-// @Param sort_by query string false "The sorting parameter. You can pass this multiple times to get the tasks ordered by multiple different parametes, along with `order_by`. Possible values to sort by are `id`, `title`, `description`, `done`, `done_at`, `due_date`, `created_by_id`, `list_id`, `repeat_after`, `priority`, `start_date`, `end_date`, `hex_color`, `percent_done`, `uid`, `created`, `updated`. Default is `id`."
+// @Param sort_by query string false "The sorting parameter. You can pass this multiple times to get the tasks ordered by multiple different parametes, along with `order_by`. Possible values to sort by are `id`, `title`, `description`, `done`, `done_at`, `due_date`, `created_by_id`, `list_id`, `repeat_after`, `priority`, `start_date`, `end_date`, `hex_color`, `percent_done`, `estimate`, `points`, `uid`, `created`, `updated` and `custom.<name>` for the custom field `<name>` of the list. Default is `id`."
 // @Param order_by query string false "The ordering parameter. Possible values to order by are `asc` or `desc`. Default is `asc`."
-// @Param filter_by query string false "The name of the field to filter by. Accepts an array for multiple filters which will be chanied together, all supplied filter must match."
+// @Param filter_by query string false "The name of the field to filter by. Accepts an array for multiple filters which will be chanied together, all supplied filter must match. Custom fields of the list can be filtered by with `custom.<name>`."
 // @Param filter_value query string false "The value to filter for."
 // @Param filter_comparator query string false "The comparator to use for a filter. Available values are `equals`, `greater`, `greater_equals`, `less` and `less_equals`. Defaults to `equals`"
 // @Param filter_concat query string false "The concatinator to use for filters. Available values are `and` or `or`. Defaults to `or`."
@@ -87,7 +87,15 @@ func getTaskFiltersByCollections(c *TaskCollection) (filters []*taskFilter, err
 		}
 
 		// Cast the field value to its native type
-		if len(c.FilterValue) > i {
+		// The type of custom fields depends on the lists the tasks are in, their values are therefore converted later
+		if isCustomFieldTaskProperty(filter.field) {
+			if err = validateTaskField(filter.field); err != nil {
+				return
+			}
+			if len(c.FilterValue) > i {
+				filter.value = c.FilterValue[i]
+			}
+		} else if len(c.FilterValue) > i {
 			filter.value, err = getNativeValueForTaskField(filter.field, c.FilterValue[i])
 			if err != nil {
 				return nil, ErrInvalidTaskFilterValue{
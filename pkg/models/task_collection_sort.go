@@ -40,6 +40,8 @@ const (
 	taskPropertyEndDate     string = "end_date"
 	taskPropertyHexColor    string = "hex_color"
 	taskPropertyPercentDone string = "percent_done"
+	taskPropertyEstimate    string = "estimate"
+	taskPropertyPoints      string = "points"
 	taskPropertyUID         string = "uid"
 	taskPropertyCreated     string = "created"
 	taskPropertyUpdated     string = "updated"
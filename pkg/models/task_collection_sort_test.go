@@ -57,6 +57,8 @@ func TestSortParamValidation(t *testing.T) {
 			taskPropertyEndDate,
 			taskPropertyHexColor,
 			taskPropertyPercentDone,
+			taskPropertyEstimate,
+			taskPropertyPoints,
 			taskPropertyUID,
 			taskPropertyCreated,
 			taskPropertyUpdated,
@@ -27,12 +27,14 @@ func (tc *TaskComment) CanRead(a web.Auth) (bool, int, error) {
 
 // CanDelete checks if a user can delete a comment
 func (tc *TaskComment) CanDelete(a web.Auth) (bool, error) {
+	tc.doer = a
 	t := Task{ID: tc.TaskID}
 	return t.CanWrite(a)
 }
 
 // CanUpdate checks if a user can update a comment
 func (tc *TaskComment) CanUpdate(a web.Auth) (bool, error) {
+	tc.doer = a
 	t := Task{ID: tc.TaskID}
 	return t.CanWrite(a)
 }
@@ -20,6 +20,7 @@ package models
 import (
 	"time"
 
+	"code.vikunja.io/api/pkg/events"
 	"code.vikunja.io/api/pkg/user"
 	"code.vikunja.io/web"
 )
@@ -35,6 +36,9 @@ type TaskComment struct {
 	Created time.Time `xorm:"created" json:"created"`
 	Updated time.Time `xorm:"updated" json:"updated"`
 
+	// The user or link share who updates the comment, set when checking the rights.
+	doer web.Auth `xorm:"-"`
+
 	web.CRUDable `xorm:"-" json:"-"`
 	web.Rights   `xorm:"-" json:"-"`
 }
@@ -59,7 +63,7 @@ func (tc *TaskComment) TableName() string {
 // @Router /tasks/{taskID}/comments [put]
 func (tc *TaskComment) Create(a web.Auth) (err error) {
 	// Check if the task exists
-	_, err = GetTaskSimple(&Task{ID: tc.TaskID})
+	task, err := GetTaskSimple(&Task{ID: tc.TaskID})
 	if err != nil {
 		return err
 	}
@@ -69,6 +73,18 @@ func (tc *TaskComment) Create(a web.Auth) (err error) {
 	if err != nil {
 		return
 	}
+
+	events.Dispatch(&TaskCommentCreatedEvent{
+		Task:    &task,
+		Comment: tc,
+		Doer:    a,
+	})
+
+	err = saveMentions(&task, tc, tc.Comment, a)
+	if err != nil {
+		return
+	}
+
 	tc.Author, err = user.GetUserByID(a.GetID())
 	return
 }
@@ -88,11 +104,26 @@ func (tc *TaskComment) Create(a web.Auth) (err error) {
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /tasks/{taskID}/comments/{commentID} [delete]
 func (tc *TaskComment) Delete() error {
+	task, err := getTaskForComment(tc.ID)
+	if err != nil {
+		return err
+	}
+
 	deleted, err := x.ID(tc.ID).NoAutoCondition().Delete(tc)
 	if deleted == 0 {
 		return ErrTaskCommentDoesNotExist{ID: tc.ID}
 	}
-	return err
+	if err != nil {
+		return err
+	}
+
+	err = deleteMentionsForComment(tc.ID)
+	if err != nil {
+		return err
+	}
+
+	events.Dispatch(&TaskCommentDeletedEvent{Task: &task, Comment: tc, Doer: tc.doer})
+	return nil
 }
 
 // Update updates a task text by its ID
@@ -114,7 +145,31 @@ func (tc *TaskComment) Update() error {
 	if updated == 0 {
 		return ErrTaskCommentDoesNotExist{ID: tc.ID}
 	}
-	return err
+	if err != nil {
+		return err
+	}
+
+	task, err := getTaskForComment(tc.ID)
+	if err != nil {
+		return err
+	}
+
+	events.Dispatch(&TaskCommentUpdatedEvent{Task: &task, Comment: tc, Doer: tc.doer})
+
+	return saveMentions(&task, tc, tc.Comment, tc.doer)
+}
+
+// Returns the task a comment belongs to. Only the id of the comment needs to be known.
+func getTaskForComment(commentID int64) (task Task, err error) {
+	comment := &TaskComment{}
+	exists, err := x.Where("id = ?", commentID).Cols("task_id").Get(comment)
+	if err != nil {
+		return
+	}
+	if !exists {
+		return task, ErrTaskCommentDoesNotExist{ID: commentID}
+	}
+	return GetTaskByIDSimple(comment.TaskID)
 }
 
 // ReadOne handles getting a single comment
@@ -0,0 +1,85 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/mail"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+)
+
+// Returns a task and its list with everything needed to show them in an email
+func getTaskAndListForEmail(taskID int64) (task *Task, list *List, err error) {
+	t, err := GetTaskByIDSimple(taskID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	list = &List{ID: t.ListID}
+	err = list.GetSimpleByID()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	t.setIdentifier(list)
+	return &t, list, nil
+}
+
+// Returns everyone who should get an email about something which happened to a task. These are the creator and all
+// assignees of the task who still have access to it, have turned on the email with wantsEmail and did not trigger it
+// themselves.
+func getUsersToEmailAboutTask(task *Task, list *List, doer web.Auth, wantsEmail func(u *user.User) bool) (users []*user.User, err error) {
+	candidates, err := getUsersToNotifyForTask(task, list)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, u := range candidates {
+		if u.Email == "" || isDoer(doer, u.ID) || !wantsEmail(u) {
+			continue
+		}
+		users = append(users, u)
+	}
+
+	return
+}
+
+// Sends the same email about a task to multiple users
+func sendTaskEmails(users []*user.User, subject, tpl string, task *Task, list *List, doer web.Auth, extra map[string]interface{}) {
+	// Dont send a mail if we're testing
+	if !config.MailerEnabled.GetBool() {
+		return
+	}
+
+	d := newNotificationDoer(doer)
+
+	for _, u := range users {
+		// Every mail needs its own data map since sending the mail modifies it
+		data := map[string]interface{}{
+			"User": u,
+			"Task": task,
+			"List": list,
+			"Doer": d,
+		}
+		for k, v := range extra {
+			data[k] = v
+		}
+
+		mail.SendMailWithTemplate(u.Email, subject, tpl, data)
+	}
+}
@@ -0,0 +1,76 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetUsersToEmailAboutTask(t *testing.T) {
+	wantsCommentEmail := func(u *user.User) bool {
+		return u.EmailNotifyTaskComment
+	}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		task, list, err := getTaskAndListForEmail(30)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), list.ID)
+
+		users, err := getUsersToEmailAboutTask(task, list, &LinkSharing{ID: 1, ListID: 1}, wantsCommentEmail)
+		assert.NoError(t, err)
+		// User 2 is assigned to the task but does not have access to the list
+		assert.Len(t, users, 1)
+		assert.Equal(t, int64(1), users[0].ID)
+		assert.Equal(t, "user1@example.com", users[0].Email)
+	})
+	t.Run("done by the user", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		task, list, err := getTaskAndListForEmail(30)
+		assert.NoError(t, err)
+
+		users, err := getUsersToEmailAboutTask(task, list, &user.User{ID: 1}, wantsCommentEmail)
+		assert.NoError(t, err)
+		assert.Len(t, users, 0)
+	})
+	t.Run("turned off", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		err := user.UpdateEmailNotificationSettings(&user.User{ID: 1}, &user.EmailNotificationSettings{
+			TaskAssigned: true,
+			TaskComment:  false,
+			TaskDone:     true,
+		})
+		assert.NoError(t, err)
+
+		task, list, err := getTaskAndListForEmail(30)
+		assert.NoError(t, err)
+
+		users, err := getUsersToEmailAboutTask(task, list, &LinkSharing{ID: 1, ListID: 1}, wantsCommentEmail)
+		assert.NoError(t, err)
+		assert.Len(t, users, 0)
+
+		users, err = getUsersToEmailAboutTask(task, list, &LinkSharing{ID: 1, ListID: 1}, func(u *user.User) bool {
+			return u.EmailNotifyTaskDone
+		})
+		assert.NoError(t, err)
+		assert.Len(t, users, 1)
+	})
+}
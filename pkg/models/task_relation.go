@@ -20,6 +20,7 @@ package models
 import (
 	"time"
 
+	"code.vikunja.io/api/pkg/events"
 	"code.vikunja.io/api/pkg/user"
 	"code.vikunja.io/web"
 )
@@ -91,6 +92,9 @@ type TaskRelation struct {
 	// A timestamp when this label was created. You cannot change this value.
 	Created time.Time `xorm:"created not null" json:"created"`
 
+	// The user or link share who is currently changing this, set when checking the rights.
+	doer web.Auth `xorm:"-"`
+
 	web.CRUDable `xorm:"-" json:"-"`
 	web.Rights   `xorm:"-" json:"-"`
 }
@@ -184,7 +188,24 @@ func (rel *TaskRelation) Create(a web.Auth) error {
 		rel,
 		otherRelation,
 	})
-	return err
+	if err != nil {
+		return err
+	}
+
+	// Both tasks get an activity entry, each from their own point of view
+	for _, r := range []*TaskRelation{rel, otherRelation} {
+		task, err := GetTaskByIDSimple(r.TaskID)
+		if err != nil {
+			return err
+		}
+		events.Dispatch(&TaskRelationCreatedEvent{
+			Task:     &task,
+			Relation: r,
+			Doer:     a,
+		})
+	}
+
+	return nil
 }
 
 // Delete removes a task relation
@@ -217,5 +238,19 @@ func (rel *TaskRelation) Delete() error {
 	}
 
 	_, err = x.Delete(rel)
-	return err
+	if err != nil {
+		return err
+	}
+
+	task, err := GetTaskByIDSimple(rel.TaskID)
+	if err != nil {
+		return err
+	}
+
+	events.Dispatch(&TaskRelationDeletedEvent{
+		Task:     &task,
+		Relation: rel,
+		Doer:     rel.doer,
+	})
+	return nil
 }
@@ -21,6 +21,7 @@ import "code.vikunja.io/web"
 
 // CanDelete checks if a user can delete a task relation
 func (rel *TaskRelation) CanDelete(a web.Auth) (bool, error) {
+	rel.doer = a
 	// A user can delete a relation if it can update the base task
 	baseTask := &Task{ID: rel.TaskID}
 	return baseTask.CanUpdate(a)
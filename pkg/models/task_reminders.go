@@ -0,0 +1,252 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/mail"
+	"code.vikunja.io/api/pkg/user"
+)
+
+// The interval in which the reminder daemon checks for due reminders
+const reminderCheckInterval = time.Minute
+
+var reminderDaemonQuit chan bool
+
+// taskReminderNotification holds everything needed to notify a single user about a due reminder
+type taskReminderNotification struct {
+	User     *user.User
+	Task     *Task
+	List     *List
+	Reminder time.Time
+}
+
+// Claims a reminder so it won't be sent by another instance or after a restart.
+// Because the update only affects the reminder row if it was not already notified, only one caller will ever
+// successfully claim it, even when multiple Vikunja instances share the same database.
+func claimReminder(reminder *TaskReminder) (claimed bool, err error) {
+	affected, err := x.
+		Where("id = ? AND notified = ?", reminder.ID, false).
+		Cols("notified").
+		Update(&TaskReminder{Notified: true})
+	return affected == 1, err
+}
+
+// Returns all users who should get notified about a reminder of a task. These are the task creator and all assignees.
+// Every user returned still has access to the task.
+func getUsersToNotifyForTask(task *Task, list *List) (users []*user.User, err error) {
+
+	userIDs := []int64{}
+	// Tasks created through a link share have a negative created by id
+	if task.CreatedByID > 0 {
+		userIDs = append(userIDs, task.CreatedByID)
+	}
+
+	assignees := []*TaskAssginee{}
+	err = x.Where("task_id = ?", task.ID).Find(&assignees)
+	if err != nil {
+		return
+	}
+	for _, a := range assignees {
+		userIDs = append(userIDs, a.UserID)
+	}
+
+	if len(userIDs) == 0 {
+		return
+	}
+
+	// Using a map here takes care of duplicates if the creator is also assigned to the task
+	usersMap := make(map[int64]*user.User, len(userIDs))
+	err = x.In("id", userIDs).Find(&usersMap)
+	if err != nil {
+		return
+	}
+
+	for _, u := range usersMap {
+		if !u.IsActive {
+			continue
+		}
+
+		canRead, _, err := list.CanRead(u)
+		if err != nil {
+			return nil, err
+		}
+		if !canRead {
+			continue
+		}
+
+		users = append(users, u)
+	}
+
+	return
+}
+
+// getReminderNotifications claims all reminders which are due at the given time and returns who needs to be notified
+// about them. Reminders of tasks which are already done are claimed but not returned.
+func getReminderNotifications(now time.Time) (notifications []*taskReminderNotification, err error) {
+	reminders := []*TaskReminder{}
+	err = x.
+		Where("reminder <= ? AND notified = ?", now, false).
+		OrderBy("reminder asc").
+		Find(&reminders)
+	if err != nil {
+		return
+	}
+
+	if len(reminders) == 0 {
+		return
+	}
+
+	taskIDs := make([]int64, 0, len(reminders))
+	for _, r := range reminders {
+		taskIDs = append(taskIDs, r.TaskID)
+	}
+
+	tasks := make(map[int64]*Task, len(taskIDs))
+	err = x.In("id", taskIDs).Find(&tasks)
+	if err != nil {
+		return
+	}
+
+	listIDs := make([]int64, 0, len(tasks))
+	for _, t := range tasks {
+		listIDs = append(listIDs, t.ListID)
+	}
+
+	lists := make(map[int64]*List, len(listIDs))
+	err = x.In("id", listIDs).Find(&lists)
+	if err != nil {
+		return
+	}
+
+	for _, r := range reminders {
+		claimed, err := claimReminder(r)
+		if err != nil {
+			return nil, err
+		}
+		if !claimed {
+			continue
+		}
+
+		task, exists := tasks[r.TaskID]
+		if !exists || task.Done {
+			continue
+		}
+
+		list, exists := lists[task.ListID]
+		if !exists {
+			continue
+		}
+
+		task.setIdentifier(list)
+
+		users, err := getUsersToNotifyForTask(task, list)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, u := range users {
+			if u.Email == "" {
+				continue
+			}
+			notifications = append(notifications, &taskReminderNotification{
+				User:     u,
+				Task:     task,
+				List:     list,
+				Reminder: r.Reminder,
+			})
+		}
+	}
+
+	return
+}
+
+func sendReminderNotifications(now time.Time) error {
+	notifications, err := getReminderNotifications(now)
+	if err != nil {
+		return err
+	}
+
+	if len(notifications) > 0 {
+		log.Debugf("[Task Reminders] Sending %d reminder notifications", len(notifications))
+	}
+
+	// Dont send a mail if we're testing
+	if !config.MailerEnabled.GetBool() {
+		return nil
+	}
+
+	for _, n := range notifications {
+		data := map[string]interface{}{
+			"User": n.User,
+			"Task": n.Task,
+			"List": n.List,
+		}
+
+		mail.SendMailWithTemplate(n.User.Email, "Reminder for \""+n.Task.Title+"\" ("+n.List.Title+")", "reminder-email", data)
+	}
+
+	return nil
+}
+
+// StartReminderDaemon starts a goroutine which periodically checks for due task reminders and sends an email
+// to the creator and assignees of the task.
+// Every reminder is marked as notified in the database before sending it, it is therefore safe to run multiple
+// instances at the same time or to restart them.
+func StartReminderDaemon() {
+	if !config.ServiceEnableEmailReminders.GetBool() {
+		return
+	}
+
+	if !config.MailerEnabled.GetBool() {
+		log.Info("Mailer is disabled, not sending task reminders.")
+		return
+	}
+
+	reminderDaemonQuit = make(chan bool)
+
+	go func() {
+		ticker := time.NewTicker(reminderCheckInterval)
+		defer ticker.Stop()
+
+		log.Debugf("[Task Reminders] Started reminder daemon, checking every %s", reminderCheckInterval)
+
+		for {
+			select {
+			case <-reminderDaemonQuit:
+				log.Debugf("[Task Reminders] Stopped reminder daemon")
+				return
+			case now := <-ticker.C:
+				if err := sendReminderNotifications(now); err != nil {
+					log.Errorf("[Task Reminders] Could not send reminder notifications: %s", err)
+				}
+			}
+		}
+	}()
+}
+
+// StopReminderDaemon stops the reminder daemon if it was started
+func StopReminderDaemon() {
+	if reminderDaemonQuit == nil {
+		return
+	}
+	close(reminderDaemonQuit)
+	reminderDaemonQuit = nil
+}
@@ -0,0 +1,76 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetReminderNotifications(t *testing.T) {
+	t.Run("due reminders", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		now, err := time.Parse(time.RFC3339, "2018-12-01T01:13:00Z")
+		assert.NoError(t, err)
+
+		notifications, err := getReminderNotifications(now)
+		assert.NoError(t, err)
+		assert.Len(t, notifications, 1)
+		assert.Equal(t, int64(27), notifications[0].Task.ID)
+		assert.Equal(t, int64(1), notifications[0].User.ID)
+		assert.Equal(t, "user1@example.com", notifications[0].User.Email)
+
+		db.AssertExists(t, "task_reminders", map[string]interface{}{
+			"id":       1,
+			"notified": true,
+		}, false)
+		db.AssertExists(t, "task_reminders", map[string]interface{}{
+			"id":       2,
+			"notified": false,
+		}, false)
+	})
+	t.Run("already notified", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		now, err := time.Parse(time.RFC3339, "2018-12-01T01:15:00Z")
+		assert.NoError(t, err)
+
+		notifications, err := getReminderNotifications(now)
+		assert.NoError(t, err)
+		assert.Len(t, notifications, 2)
+
+		// Running it a second time should not return anything since all reminders were already sent
+		notifications, err = getReminderNotifications(now)
+		assert.NoError(t, err)
+		assert.Len(t, notifications, 0)
+	})
+	t.Run("no due reminders", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		now, err := time.Parse(time.RFC3339, "2018-11-30T00:00:00Z")
+		assert.NoError(t, err)
+
+		notifications, err := getReminderNotifications(now)
+		assert.NoError(t, err)
+		assert.Len(t, notifications, 0)
+		db.AssertExists(t, "task_reminders", map[string]interface{}{
+			"id":       1,
+			"notified": false,
+		}, false)
+	})
+}
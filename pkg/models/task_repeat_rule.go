@@ -0,0 +1,640 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+)
+
+// How many years after a given date we look for the next occurrence of a repeat rule at most.
+// This prevents endless loops for rules which never have an occurrence, like the 30th of February.
+const repeatRuleHorizonYears = 100
+
+// The maximum number of periods we look at when searching for the next occurrence of a repeat rule.
+// Rules which don't skip to the current date, like monthly ones or ones with a count and filters, would otherwise
+// take very long for dates far in the past.
+const repeatRuleMaxPeriods = 100000
+
+type repeatFrequency int
+
+const (
+	repeatFrequencyMinutely repeatFrequency = iota
+	repeatFrequencyHourly
+	repeatFrequencyDaily
+	repeatFrequencyWeekly
+	repeatFrequencyMonthly
+	repeatFrequencyYearly
+)
+
+var repeatFrequencies = map[string]repeatFrequency{
+	"MINUTELY": repeatFrequencyMinutely,
+	"HOURLY":   repeatFrequencyHourly,
+	"DAILY":    repeatFrequencyDaily,
+	"WEEKLY":   repeatFrequencyWeekly,
+	"MONTHLY":  repeatFrequencyMonthly,
+	"YEARLY":   repeatFrequencyYearly,
+}
+
+var repeatWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// repeatWeekday holds a single BYDAY value like "MO" or "-1FR"
+type repeatWeekday struct {
+	weekday time.Weekday
+	// The nth occurrence of the weekday in the month or year. Negative values count from the end, 0 means every
+	// occurrence.
+	n int
+}
+
+// repeatRule is a parsed RFC 5545 recurrence rule.
+// Only the parts which make sense for tasks are supported, everything else is rejected when parsing the rule.
+type repeatRule struct {
+	freq       repeatFrequency
+	interval   int
+	count      int
+	until      time.Time
+	byDay      []repeatWeekday
+	byMonthDay []int
+	byMonth    []time.Month
+	bySetPos   []int
+	weekStart  time.Weekday
+}
+
+// normalizeRepeatRule brings a repeat rule into the form we save in the db.
+// It accepts rules with or without the "RRULE:" prefix of the ical property.
+func normalizeRepeatRule(rule string) string {
+	rule = strings.ToUpper(strings.TrimSpace(rule))
+	rule = strings.TrimPrefix(rule, "RRULE:")
+	return strings.Trim(rule, ";")
+}
+
+// validateRepeatRule normalizes the repeat rule of a task and checks if it is a valid rule we can work with.
+func validateRepeatRule(t *Task) error {
+	if t.RepeatRule == "" {
+		return nil
+	}
+
+	t.RepeatRule = normalizeRepeatRule(t.RepeatRule)
+	r, err := parseRepeatRule(t.RepeatRule)
+	if err != nil {
+		return err
+	}
+
+	// Rules which never have an occurrence would only make us search for one every time the task is done.
+	// Count and until are ignored here because a rule which already ended is still a valid rule.
+	start := getRepeatRuleBaseDate(t)
+	if start.IsZero() {
+		start = time.Now()
+	}
+	unlimited := *r
+	unlimited.count = 0
+	unlimited.until = time.Time{}
+	if _, _, ok := unlimited.next(start, start); !ok {
+		return ErrInvalidRepeatRule{Rule: t.RepeatRule, Reason: "the rule does not have any occurrences"}
+	}
+	return nil
+}
+
+// CheckRepeatRule checks if Vikunja supports all parts of a repeat rule.
+func CheckRepeatRule(rule string) error {
+	_, err := parseRepeatRule(rule)
+	return err
+}
+
+// getRepeatRuleBaseDate returns the date of a task a repeat rule is evaluated with. This is the first date set out
+// of due date, start date, end date and the earliest reminder.
+func getRepeatRuleBaseDate(t *Task) (base time.Time) {
+	switch {
+	case !t.DueDate.IsZero():
+		return t.DueDate
+	case !t.StartDate.IsZero():
+		return t.StartDate
+	case !t.EndDate.IsZero():
+		return t.EndDate
+	}
+	for _, r := range t.Reminders {
+		if base.IsZero() || r.Before(base) {
+			base = r
+		}
+	}
+	return
+}
+
+func parseRepeatRuleNumbers(value string, min, max int) (numbers []int, ok bool) {
+	for _, v := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(v)
+		if err != nil || n == 0 || n < min || n > max {
+			return nil, false
+		}
+		numbers = append(numbers, n)
+	}
+	return numbers, true
+}
+
+func parseRepeatWeekday(value string) (wd repeatWeekday, ok bool) {
+	if len(value) < 2 {
+		return
+	}
+
+	wd.weekday, ok = repeatWeekdays[value[len(value)-2:]]
+	if !ok {
+		return
+	}
+
+	if len(value) > 2 {
+		n, err := strconv.Atoi(value[:len(value)-2])
+		if err != nil || n == 0 || n < -53 || n > 53 {
+			return wd, false
+		}
+		wd.n = n
+	}
+
+	return wd, true
+}
+
+// parseRepeatRuleUntil parses the UNTIL value of a rule. Times without a timezone and dates are interpreted in the
+// configured timezone, a date includes the whole day.
+func parseRepeatRuleUntil(value string) (until time.Time, err error) {
+	if strings.HasSuffix(value, "Z") {
+		return time.Parse("20060102T150405Z", value)
+	}
+	if len(value) == len("20060102") {
+		until, err = time.ParseInLocation("20060102", value, config.GetTimeZone())
+		if err != nil {
+			return
+		}
+		return until.AddDate(0, 0, 1).Add(-time.Second), nil
+	}
+	return time.ParseInLocation("20060102T150405", value, config.GetTimeZone())
+}
+
+//nolint:gocyclo
+func parseRepeatRule(rule string) (r *repeatRule, err error) {
+	invalid := func(reason string) error {
+		return ErrInvalidRepeatRule{Rule: rule, Reason: reason}
+	}
+
+	r = &repeatRule{
+		interval:  1,
+		weekStart: time.Monday,
+	}
+
+	var hasFreq bool
+	for _, part := range strings.Split(normalizeRepeatRule(rule), ";") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 || kv[1] == "" {
+			return nil, invalid("'" + part + "' is not a valid rule part")
+		}
+
+		key, value := kv[0], kv[1]
+		switch key {
+		case "FREQ":
+			r.freq, hasFreq = repeatFrequencies[value]
+			if !hasFreq {
+				return nil, invalid("the frequency " + value + " is not supported")
+			}
+		case "INTERVAL":
+			r.interval, err = strconv.Atoi(value)
+			if err != nil || r.interval < 1 {
+				return nil, invalid("the interval must be a positive number")
+			}
+		case "COUNT":
+			r.count, err = strconv.Atoi(value)
+			if err != nil || r.count < 1 {
+				return nil, invalid("the count must be a positive number")
+			}
+		case "UNTIL":
+			r.until, err = parseRepeatRuleUntil(value)
+			if err != nil {
+				return nil, invalid("the until date is not a valid date")
+			}
+		case "BYDAY":
+			for _, v := range strings.Split(value, ",") {
+				wd, ok := parseRepeatWeekday(v)
+				if !ok {
+					return nil, invalid("'" + v + "' is not a valid weekday")
+				}
+				r.byDay = append(r.byDay, wd)
+			}
+		case "BYMONTHDAY":
+			var ok bool
+			r.byMonthDay, ok = parseRepeatRuleNumbers(value, -31, 31)
+			if !ok {
+				return nil, invalid("the month days must be between -31 and 31")
+			}
+		case "BYMONTH":
+			months, ok := parseRepeatRuleNumbers(value, 1, 12)
+			if !ok {
+				return nil, invalid("the months must be between 1 and 12")
+			}
+			for _, m := range months {
+				r.byMonth = append(r.byMonth, time.Month(m))
+			}
+		case "BYSETPOS":
+			var ok bool
+			r.bySetPos, ok = parseRepeatRuleNumbers(value, -366, 366)
+			if !ok {
+				return nil, invalid("the set positions must be between -366 and 366")
+			}
+		case "WKST":
+			var ok bool
+			r.weekStart, ok = repeatWeekdays[value]
+			if !ok {
+				return nil, invalid("'" + value + "' is not a valid weekday")
+			}
+		default:
+			return nil, invalid(key + " is not supported")
+		}
+	}
+
+	if !hasFreq {
+		return nil, invalid("the rule needs a frequency")
+	}
+
+	if r.count > 0 && !r.until.IsZero() {
+		return nil, invalid("count and until cannot be used together")
+	}
+
+	for _, wd := range r.byDay {
+		if wd.n != 0 && r.freq != repeatFrequencyMonthly && r.freq != repeatFrequencyYearly {
+			return nil, invalid("numbered weekdays can only be used with a monthly or yearly frequency")
+		}
+	}
+
+	if len(r.byMonthDay) > 0 && r.freq == repeatFrequencyWeekly {
+		return nil, invalid("month days cannot be used with a weekly frequency")
+	}
+
+	if len(r.bySetPos) > 0 && len(r.byDay) == 0 && len(r.byMonthDay) == 0 && len(r.byMonth) == 0 {
+		return nil, invalid("set positions need weekdays, month days or months to select from")
+	}
+
+	return r, nil
+}
+
+// setRepeatRuleCount returns the rule with its COUNT part replaced by the given count.
+func setRepeatRuleCount(rule string, count int) string {
+	parts := strings.Split(normalizeRepeatRule(rule), ";")
+	for i, part := range parts {
+		if strings.HasPrefix(part, "COUNT=") {
+			parts[i] = "COUNT=" + strconv.Itoa(count)
+		}
+	}
+	return strings.Join(parts, ";")
+}
+
+func daysInMonth(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// Returns all days in a range of days which fall on the weekday, taking its number into account.
+// The range starts at the given date and is days long.
+func (wd repeatWeekday) days(year int, month time.Month, firstDay, days int) (matching []int) {
+	all := []int{}
+	for d := firstDay; d < firstDay+days; d++ {
+		if time.Date(year, month, d, 0, 0, 0, 0, time.UTC).Weekday() == wd.weekday {
+			all = append(all, d)
+		}
+	}
+
+	switch {
+	case wd.n == 0:
+		return all
+	case wd.n > 0 && wd.n <= len(all):
+		return []int{all[wd.n-1]}
+	case wd.n < 0 && -wd.n <= len(all):
+		return []int{all[len(all)+wd.n]}
+	}
+	return nil
+}
+
+// Returns all days of the month which are selected by the rule, sorted. If the rule does not select any days,
+// the day of the start date is used.
+func (r *repeatRule) daysOfMonth(year int, month time.Month, startDay int) (days []int) {
+	last := daysInMonth(year, month)
+
+	if len(r.byMonthDay) == 0 && len(r.byDay) == 0 {
+		if startDay <= last {
+			return []int{startDay}
+		}
+		return nil
+	}
+
+	byMonthDay := make(map[int]bool)
+	for _, md := range r.byMonthDay {
+		if md < 0 {
+			md = last + md + 1
+		}
+		if md >= 1 && md <= last {
+			byMonthDay[md] = true
+		}
+	}
+
+	byDay := make(map[int]bool)
+	for _, wd := range r.byDay {
+		for _, d := range wd.days(year, month, 1, last) {
+			byDay[d] = true
+		}
+	}
+
+	for d := 1; d <= last; d++ {
+		if len(r.byMonthDay) > 0 && !byMonthDay[d] {
+			continue
+		}
+		if len(r.byDay) > 0 && !byDay[d] {
+			continue
+		}
+		days = append(days, d)
+	}
+
+	return
+}
+
+// Checks if the month is part of the BYMONTH part of the rule
+func (r *repeatRule) inMonths(month time.Month) bool {
+	if len(r.byMonth) == 0 {
+		return true
+	}
+	for _, m := range r.byMonth {
+		if m == month {
+			return true
+		}
+	}
+	return false
+}
+
+// Checks the BYMONTH, BYMONTHDAY and BYDAY parts of the rule for frequencies where they limit the occurrences
+// instead of expanding them.
+func (r *repeatRule) matches(t time.Time) bool {
+	if !r.inMonths(t.Month()) {
+		return false
+	}
+
+	if len(r.byMonthDay) > 0 {
+		found := false
+		last := daysInMonth(t.Year(), t.Month())
+		for _, md := range r.byMonthDay {
+			if t.Day() == md || t.Day() == last+md+1 {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if len(r.byDay) > 0 {
+		found := false
+		for _, wd := range r.byDay {
+			if t.Weekday() == wd.weekday {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Returns all occurrences of the rule in the nth period after the start date, sorted.
+// The start date needs to be in the configured timezone.
+//nolint:gocyclo
+func (r *repeatRule) occurrencesInPeriod(start time.Time, period int) (occurrences []time.Time) {
+	year, month, day := start.Date()
+	hour, min, sec := start.Clock()
+	loc := start.Location()
+	steps := period * r.interval
+
+	date := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, hour, min, sec, 0, loc)
+	}
+
+	switch r.freq {
+	case repeatFrequencyMinutely, repeatFrequencyHourly:
+		unit := time.Minute
+		if r.freq == repeatFrequencyHourly {
+			unit = time.Hour
+		}
+		occurrence := start.Add(time.Duration(steps) * unit)
+		if r.matches(occurrence) {
+			occurrences = append(occurrences, occurrence)
+		}
+	case repeatFrequencyDaily:
+		occurrence := date(year, month, day+steps)
+		if r.matches(occurrence) {
+			occurrences = append(occurrences, occurrence)
+		}
+	case repeatFrequencyWeekly:
+		firstDayOfWeek := day - (int(start.Weekday())-int(r.weekStart)+7)%7 + steps*7
+		weekdays := []time.Weekday{start.Weekday()}
+		if len(r.byDay) > 0 {
+			weekdays = []time.Weekday{}
+			for _, wd := range r.byDay {
+				weekdays = append(weekdays, wd.weekday)
+			}
+		}
+		for _, wd := range weekdays {
+			occurrence := date(year, month, firstDayOfWeek+(int(wd)-int(r.weekStart)+7)%7)
+			if r.matches(occurrence) {
+				occurrences = append(occurrences, occurrence)
+			}
+		}
+	case repeatFrequencyMonthly:
+		months := int(month) - 1 + steps
+		y, m := year+months/12, time.Month(months%12+1)
+		if !r.inMonths(m) {
+			break
+		}
+		for _, d := range r.daysOfMonth(y, m, day) {
+			occurrences = append(occurrences, date(y, m, d))
+		}
+	case repeatFrequencyYearly:
+		y := year + steps
+		if len(r.byDay) > 0 && len(r.byMonthDay) == 0 && len(r.byMonth) == 0 {
+			// Numbered weekdays are relative to the whole year in this case
+			daysInYear := time.Date(y, time.December, 31, 0, 0, 0, 0, time.UTC).YearDay()
+			for _, wd := range r.byDay {
+				for _, d := range wd.days(y, time.January, 1, daysInYear) {
+					occurrences = append(occurrences, date(y, time.January, d))
+				}
+			}
+			break
+		}
+		months := r.byMonth
+		if len(months) == 0 {
+			months = []time.Month{month}
+		}
+		for _, m := range months {
+			for _, d := range r.daysOfMonth(y, m, day) {
+				occurrences = append(occurrences, date(y, m, d))
+			}
+		}
+	}
+
+	sort.Slice(occurrences, func(i, j int) bool {
+		return occurrences[i].Before(occurrences[j])
+	})
+
+	if len(r.bySetPos) == 0 {
+		return
+	}
+
+	selected := []time.Time{}
+	for i, o := range occurrences {
+		for _, pos := range r.bySetPos {
+			if pos == i+1 || pos == i-len(occurrences) {
+				selected = append(selected, o)
+				break
+			}
+		}
+	}
+	return selected
+}
+
+// Returns whether the rule has parts which limit its occurrences instead of expanding them.
+// Without those, a minutely, hourly, daily or weekly rule has exactly one occurrence in every period.
+func (r *repeatRule) hasFilters() bool {
+	return len(r.byDay) > 0 || len(r.byMonthDay) > 0 || len(r.byMonth) > 0 || len(r.bySetPos) > 0
+}
+
+// Returns the point in time where the nth period after the start date begins.
+func (r *repeatRule) periodStart(start time.Time, period int) time.Time {
+	year, month, day := start.Date()
+	steps := period * r.interval
+
+	switch r.freq {
+	case repeatFrequencyMinutely:
+		return start.Add(time.Duration(steps) * time.Minute)
+	case repeatFrequencyHourly:
+		return start.Add(time.Duration(steps) * time.Hour)
+	case repeatFrequencyDaily:
+		return time.Date(year, month, day+steps, 0, 0, 0, 0, start.Location())
+	case repeatFrequencyWeekly:
+		firstDayOfWeek := day - (int(start.Weekday())-int(r.weekStart)+7)%7 + steps*7
+		return time.Date(year, month, firstDayOfWeek, 0, 0, 0, 0, start.Location())
+	case repeatFrequencyMonthly:
+		return time.Date(year, month+time.Month(steps), 1, 0, 0, 0, 0, start.Location())
+	default:
+		return time.Date(year+steps, time.January, 1, 0, 0, 0, 0, start.Location())
+	}
+}
+
+// Returns the number of whole periods between the start date and the given time.
+// Only frequencies where every period has the same length can be calculated, all others return 0.
+func (r *repeatRule) periodsBetween(start, t time.Time) int {
+	if !t.After(start) {
+		return 0
+	}
+
+	// Calendar days, so daylight saving time doesn't change the result
+	days := func() int {
+		y1, m1, d1 := start.Date()
+		y2, m2, d2 := t.In(start.Location()).Date()
+		from := time.Date(y1, m1, d1, 0, 0, 0, 0, time.UTC)
+		to := time.Date(y2, m2, d2, 0, 0, 0, 0, time.UTC)
+		return int(to.Sub(from).Hours() / 24)
+	}
+
+	switch r.freq {
+	case repeatFrequencyMinutely:
+		return int(t.Sub(start) / time.Minute / time.Duration(r.interval))
+	case repeatFrequencyHourly:
+		return int(t.Sub(start) / time.Hour / time.Duration(r.interval))
+	case repeatFrequencyDaily:
+		return days() / r.interval
+	case repeatFrequencyWeekly:
+		return days() / 7 / r.interval
+	}
+	return 0
+}
+
+// next returns the first occurrence of the rule after the given time. The start date is the first occurrence of
+// the rule and never returned itself.
+// It also returns the position of the occurrence in the set of all occurrences of the rule where the start date
+// has position 0. This is used to keep track of the count of a rule. Rules without a count skip straight to the
+// given time, their position is therefore only correct if they don't have any filters.
+// If the rule does not have another occurrence in the next 100 years after the given time, ok is false.
+func (r *repeatRule) next(start, after time.Time) (next time.Time, position int, ok bool) {
+	start = start.In(config.GetTimeZone())
+
+	horizon := after
+	if start.After(horizon) {
+		horizon = start
+	}
+	horizon = horizon.AddDate(repeatRuleHorizonYears, 0, 0)
+
+	// Fixed-length periods before the given time can be skipped instead of checking every single one of them.
+	// Without filters every skipped period except the first one (the start date) holds exactly one occurrence.
+	first := 0
+	if r.count == 0 || !r.hasFilters() {
+		first = r.periodsBetween(start, after) - 1
+		if first < 0 {
+			first = 0
+		}
+		if first > 0 {
+			position = first - 1
+		}
+	}
+
+	for period := first; period < first+repeatRuleMaxPeriods; period++ {
+		if r.periodStart(start, period).After(horizon) {
+			break
+		}
+
+		occurrences := r.occurrencesInPeriod(start, period)
+		for _, occurrence := range occurrences {
+			if !occurrence.After(start) {
+				continue
+			}
+			if !r.until.IsZero() && occurrence.After(r.until) {
+				return time.Time{}, position, false
+			}
+			position++
+			if occurrence.After(after) {
+				return occurrence, position, true
+			}
+		}
+
+		// All filters of the rule apply to whole days, if one period of a minutely or hourly rule does not match
+		// the rest of its day won't either.
+		if len(occurrences) == 0 && (r.freq == repeatFrequencyMinutely || r.freq == repeatFrequencyHourly) {
+			p := r.periodStart(start, period)
+			nextDay := time.Date(p.Year(), p.Month(), p.Day()+1, 0, 0, 0, 0, p.Location())
+			if skip := r.periodsBetween(start, nextDay) - 1; skip > period {
+				period = skip
+			}
+		}
+	}
+
+	return time.Time{}, position, false
+}
@@ -0,0 +1,310 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"4d63.com/tz"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRepeatRule(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		rule, err := parseRepeatRule("FREQ=MONTHLY;INTERVAL=2;BYDAY=-1FR,MO;COUNT=5")
+		assert.NoError(t, err)
+		assert.Equal(t, repeatFrequencyMonthly, rule.freq)
+		assert.Equal(t, 2, rule.interval)
+		assert.Equal(t, 5, rule.count)
+		assert.Equal(t, []repeatWeekday{{weekday: time.Friday, n: -1}, {weekday: time.Monday}}, rule.byDay)
+	})
+	t.Run("with prefix and lowercase", func(t *testing.T) {
+		rule, err := parseRepeatRule("rrule:freq=weekly;byday=mo,fr;")
+		assert.NoError(t, err)
+		assert.Equal(t, repeatFrequencyWeekly, rule.freq)
+		assert.Equal(t, 1, rule.interval)
+		assert.Len(t, rule.byDay, 2)
+	})
+	t.Run("until", func(t *testing.T) {
+		rule, err := parseRepeatRule("FREQ=DAILY;UNTIL=20201231T100000Z")
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2020, 12, 31, 10, 0, 0, 0, time.UTC), rule.until.UTC())
+	})
+	t.Run("until date", func(t *testing.T) {
+		rule, err := parseRepeatRule("FREQ=DAILY;UNTIL=20201231")
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2020, 12, 31, 23, 59, 59, 0, time.UTC), rule.until.UTC())
+	})
+	t.Run("invalid", func(t *testing.T) {
+		rules := []string{
+			"",
+			"INTERVAL=2",
+			"FREQ=SECONDLY",
+			"FREQ=DAILY;INTERVAL=0",
+			"FREQ=DAILY;BYHOUR=9",
+			"FREQ=DAILY;BYDAY=XY",
+			"FREQ=WEEKLY;BYDAY=1MO",
+			"FREQ=WEEKLY;BYMONTHDAY=1",
+			"FREQ=MONTHLY;BYMONTHDAY=32",
+			"FREQ=MONTHLY;BYSETPOS=1",
+			"FREQ=DAILY;COUNT=2;UNTIL=20201231",
+			"FREQ=DAILY;UNTIL=tomorrow",
+		}
+		for _, rule := range rules {
+			_, err := parseRepeatRule(rule)
+			assert.Error(t, err, rule)
+			assert.True(t, IsErrInvalidRepeatRule(err), rule)
+		}
+	})
+}
+
+func TestValidateRepeatRule(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		task := &Task{RepeatRule: "rrule:freq=daily;", DueDate: time.Date(2020, time.January, 1, 9, 0, 0, 0, time.UTC)}
+		err := validateRepeatRule(task)
+		assert.NoError(t, err)
+		assert.Equal(t, "FREQ=DAILY", task.RepeatRule)
+	})
+	t.Run("already ended", func(t *testing.T) {
+		task := &Task{RepeatRule: "FREQ=DAILY;UNTIL=20200102T000000Z", DueDate: time.Date(2020, time.January, 5, 9, 0, 0, 0, time.UTC)}
+		err := validateRepeatRule(task)
+		assert.NoError(t, err)
+	})
+	t.Run("without any occurrences", func(t *testing.T) {
+		rules := []string{
+			"FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30",
+			"FREQ=YEARLY;BYDAY=MO;BYSETPOS=200",
+			"FREQ=MINUTELY;BYMONTH=4;BYMONTHDAY=31",
+		}
+		for _, rule := range rules {
+			err := validateRepeatRule(&Task{RepeatRule: rule})
+			assert.Error(t, err, rule)
+			assert.True(t, IsErrInvalidRepeatRule(err), rule)
+		}
+	})
+}
+
+func TestSetRepeatRuleCount(t *testing.T) {
+	assert.Equal(t, "FREQ=DAILY;COUNT=3;INTERVAL=2", setRepeatRuleCount("FREQ=DAILY;COUNT=4;INTERVAL=2", 3))
+}
+
+func TestRepeatRule_Next(t *testing.T) {
+	date := func(year int, month time.Month, day, hour int) time.Time {
+		return time.Date(year, month, day, hour, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name     string
+		rule     string
+		start    time.Time
+		after    time.Time
+		wantNext time.Time
+		// Not checked if 0, rules with filters and without a count don't keep track of it
+		wantPosition int
+		wantOk       bool
+	}{
+		{
+			name:         "every month on the 15th",
+			rule:         "FREQ=MONTHLY;BYMONTHDAY=15",
+			start:        date(2020, time.January, 15, 9),
+			after:        date(2020, time.January, 15, 9),
+			wantNext:     date(2020, time.February, 15, 9),
+			wantPosition: 1,
+			wantOk:       true,
+		},
+		{
+			name:         "every weekday",
+			rule:         "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR",
+			start:        date(2020, time.October, 16, 9),
+			after:        date(2020, time.October, 16, 9),
+			wantNext:     date(2020, time.October, 19, 9),
+			wantPosition: 1,
+			wantOk:       true,
+		},
+		{
+			name:         "every weekday with a daily frequency",
+			rule:         "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR",
+			start:        date(2020, time.October, 16, 9),
+			after:        date(2020, time.October, 16, 9),
+			wantNext:     date(2020, time.October, 19, 9),
+			wantPosition: 1,
+			wantOk:       true,
+		},
+		{
+			name:         "last friday of the month",
+			rule:         "FREQ=MONTHLY;BYDAY=-1FR",
+			start:        date(2020, time.October, 30, 9),
+			after:        date(2020, time.October, 30, 9),
+			wantNext:     date(2020, time.November, 27, 9),
+			wantPosition: 1,
+			wantOk:       true,
+		},
+		{
+			name:         "last weekday of the month",
+			rule:         "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1",
+			start:        date(2020, time.October, 30, 9),
+			after:        date(2020, time.October, 30, 9),
+			wantNext:     date(2020, time.November, 30, 9),
+			wantPosition: 1,
+			wantOk:       true,
+		},
+		{
+			name:         "skip months without the day",
+			rule:         "FREQ=MONTHLY",
+			start:        date(2020, time.January, 31, 9),
+			after:        date(2020, time.January, 31, 9),
+			wantNext:     date(2020, time.March, 31, 9),
+			wantPosition: 1,
+			wantOk:       true,
+		},
+		{
+			name:         "last day of the month",
+			rule:         "FREQ=MONTHLY;BYMONTHDAY=-1",
+			start:        date(2020, time.January, 31, 9),
+			after:        date(2020, time.January, 31, 9),
+			wantNext:     date(2020, time.February, 29, 9),
+			wantPosition: 1,
+			wantOk:       true,
+		},
+		{
+			name:         "interval with skipped occurrences",
+			rule:         "FREQ=DAILY;INTERVAL=3",
+			start:        date(2020, time.January, 1, 9),
+			after:        date(2020, time.January, 5, 9),
+			wantNext:     date(2020, time.January, 7, 9),
+			wantPosition: 2,
+			wantOk:       true,
+		},
+		{
+			name:         "every two weeks",
+			rule:         "FREQ=WEEKLY;INTERVAL=2",
+			start:        date(2020, time.October, 14, 9),
+			after:        date(2020, time.October, 14, 9),
+			wantNext:     date(2020, time.October, 28, 9),
+			wantPosition: 1,
+			wantOk:       true,
+		},
+		{
+			name:         "leap day",
+			rule:         "FREQ=YEARLY",
+			start:        date(2020, time.February, 29, 9),
+			after:        date(2020, time.February, 29, 9),
+			wantNext:     date(2024, time.February, 29, 9),
+			wantPosition: 1,
+			wantOk:       true,
+		},
+		{
+			name:         "fourth thursday in november",
+			rule:         "FREQ=YEARLY;BYMONTH=11;BYDAY=4TH",
+			start:        date(2020, time.November, 26, 9),
+			after:        date(2020, time.November, 26, 9),
+			wantNext:     date(2021, time.November, 25, 9),
+			wantPosition: 1,
+			wantOk:       true,
+		},
+		{
+			name:         "first monday of the year",
+			rule:         "FREQ=YEARLY;BYDAY=1MO",
+			start:        date(2020, time.January, 6, 9),
+			after:        date(2020, time.January, 6, 9),
+			wantNext:     date(2021, time.January, 4, 9),
+			wantPosition: 1,
+			wantOk:       true,
+		},
+		{
+			name:         "hourly",
+			rule:         "FREQ=HOURLY;INTERVAL=6",
+			start:        date(2020, time.January, 1, 9),
+			after:        date(2020, time.January, 1, 10),
+			wantNext:     date(2020, time.January, 1, 15),
+			wantPosition: 1,
+			wantOk:       true,
+		},
+		{
+			name:         "minutely with a date long ago",
+			rule:         "FREQ=MINUTELY;INTERVAL=30",
+			start:        date(2015, time.January, 1, 9),
+			after:        date(2020, time.January, 1, 10),
+			wantNext:     date(2020, time.January, 1, 10).Add(30 * time.Minute),
+			wantPosition: 87651,
+			wantOk:       true,
+		},
+		{
+			name:     "hourly on mondays with a date long ago",
+			rule:     "FREQ=HOURLY;BYDAY=MO",
+			start:    date(2015, time.January, 5, 9),
+			after:    date(2020, time.October, 14, 9),
+			wantNext: date(2020, time.October, 19, 0),
+			wantOk:   true,
+		},
+		{
+			name:         "daily with a count and a date long ago",
+			rule:         "FREQ=DAILY;COUNT=5000",
+			start:        date(2010, time.January, 1, 9),
+			after:        date(2020, time.January, 1, 10),
+			wantNext:     date(2020, time.January, 2, 9),
+			wantPosition: 3653,
+			wantOk:       true,
+		},
+		{
+			name:   "until reached",
+			rule:   "FREQ=DAILY;UNTIL=20200102T000000Z",
+			start:  date(2020, time.January, 1, 9),
+			after:  date(2020, time.January, 1, 9),
+			wantOk: false,
+		},
+		{
+			name:   "no occurrence at all",
+			rule:   "FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30",
+			start:  date(2020, time.January, 1, 9),
+			after:  date(2020, time.January, 1, 9),
+			wantOk: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := parseRepeatRule(tt.rule)
+			assert.NoError(t, err)
+
+			next, position, ok := rule.next(tt.start, tt.after)
+			assert.Equal(t, tt.wantOk, ok)
+			if !tt.wantOk {
+				return
+			}
+			assert.Equal(t, tt.wantNext, next.UTC())
+			if tt.wantPosition != 0 {
+				assert.Equal(t, tt.wantPosition, position)
+			}
+		})
+	}
+}
+
+func TestRepeatRule_DaylightSavingTime(t *testing.T) {
+	loc, err := tz.LoadLocation("Europe/Berlin")
+	assert.NoError(t, err)
+
+	rule, err := parseRepeatRule("FREQ=DAILY")
+	assert.NoError(t, err)
+
+	// The clocks change on 2020-03-29 in Berlin
+	start := time.Date(2020, time.March, 27, 9, 0, 0, 0, loc)
+	occurrences := rule.occurrencesInPeriod(start, 3)
+	assert.Len(t, occurrences, 1)
+	assert.Equal(t, 9, occurrences[0].Hour())
+	assert.Equal(t, 3*24*time.Hour-time.Hour, occurrences[0].Sub(start))
+}
@@ -0,0 +1,396 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"strings"
+	"time"
+
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+)
+
+// TaskRevision holds the title and description of a task at some point in time
+type TaskRevision struct {
+	// The unique, numeric id of this revision.
+	ID int64 `xorm:"int(11) autoincr not null unique pk" json:"id" param:"revision"`
+	// The task this revision belongs to.
+	TaskID int64 `xorm:"int(11) not null INDEX" json:"task_id" param:"listtask"`
+
+	// The title the task had in this revision.
+	Title string `xorm:"varchar(250) not null" json:"title"`
+	// The description the task had in this revision.
+	Description string `xorm:"longtext null" json:"description"`
+
+	// The id of the user who created this revision. Negative if it was created through a link share.
+	CreatedByID int64 `xorm:"int(11) not null default 0" json:"-"`
+	// The user who created this revision. Empty if it was created through a link share.
+	CreatedBy *user.User `xorm:"-" json:"created_by"`
+
+	// A timestamp when this revision was created. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TableName returns the table name for task revisions
+func (tr *TaskRevision) TableName() string {
+	return "task_revisions"
+}
+
+// Returns the id we save as creator of a revision, negative for link shares
+func getRevisionCreatorID(a web.Auth) int64 {
+	if a == nil {
+		return 0
+	}
+	if _, is := a.(*LinkSharing); is {
+		return a.GetID() * -1
+	}
+	return a.GetID()
+}
+
+// Saves the current title and description of a task as a new revision
+func saveTaskRevision(task *Task, doer web.Auth) (err error) {
+	_, err = x.Insert(&TaskRevision{
+		TaskID:      task.ID,
+		Title:       task.Title,
+		Description: task.Description,
+		CreatedByID: getRevisionCreatorID(doer),
+	})
+	return
+}
+
+// Tasks created before revisions were introduced don't have any. To be able to restore the state they had before
+// their first change, we save it with the time of their last update before saving the new revision.
+func saveInitialTaskRevision(oldTask *Task) (err error) {
+	exists, err := x.Where("task_id = ?", oldTask.ID).Exist(&TaskRevision{})
+	if err != nil || exists {
+		return err
+	}
+
+	_, err = x.NoAutoTime().Insert(&TaskRevision{
+		TaskID:      oldTask.ID,
+		Title:       oldTask.Title,
+		Description: oldTask.Description,
+		CreatedByID: oldTask.CreatedByID,
+		Created:     oldTask.Updated,
+	})
+	return
+}
+
+func deleteRevisionsForTask(taskID int64) (err error) {
+	_, err = x.Where("task_id = ?", taskID).Delete(&TaskRevision{})
+	return
+}
+
+func getTaskRevision(taskID, revisionID int64) (revision *TaskRevision, err error) {
+	revision = &TaskRevision{}
+	exists, err := x.
+		Where("id = ? AND task_id = ?", revisionID, taskID).
+		Get(revision)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrTaskRevisionDoesNotExist{TaskID: taskID, RevisionID: revisionID}
+	}
+	return
+}
+
+func addCreatorsToRevisions(revisions []*TaskRevision) (err error) {
+	userIDs := make([]int64, 0, len(revisions))
+	for _, r := range revisions {
+		if r.CreatedByID > 0 {
+			userIDs = append(userIDs, r.CreatedByID)
+		}
+	}
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	users := make(map[int64]*user.User, len(userIDs))
+	err = x.In("id", userIDs).Find(&users)
+	if err != nil {
+		return
+	}
+
+	for _, r := range revisions {
+		if u, has := users[r.CreatedByID]; has {
+			u.Email = "" // Obfuscate the email
+			r.CreatedBy = u
+		}
+	}
+	return nil
+}
+
+// ReadAll returns all revisions of a task
+// @Summary Get all revisions of a task
+// @Description Returns all saved versions of the title and description of a task, newest first.
+// @tags task
+// @Produce json
+// @Security JWTKeyAuth
+// @Param listtask path int true "Task ID"
+// @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
+// @Param per_page query int false "The maximum number of items per page. Note this parameter is limited by the configured maximum of items per page."
+// @Success 200 {array} models.TaskRevision "The revisions"
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task."
+// @Failure 404 {object} web.HTTPError "The task does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{listtask}/revisions [get]
+func (tr *TaskRevision) ReadAll(a web.Auth, search string, page int, perPage int) (result interface{}, resultCount int, totalItems int64, err error) {
+	canRead, _, err := tr.CanRead(a)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if !canRead {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	limit, start := getLimitFromPageIndex(page, perPage)
+
+	revisions := []*TaskRevision{}
+	query := x.
+		Where("task_id = ?", tr.TaskID).
+		OrderBy("id desc")
+	if limit > 0 {
+		query = query.Limit(limit, start)
+	}
+	err = query.Find(&revisions)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	err = addCreatorsToRevisions(revisions)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	totalItems, err = x.
+		Where("task_id = ?", tr.TaskID).
+		Count(&TaskRevision{})
+	return revisions, len(revisions), totalItems, err
+}
+
+// ReadOne returns one revision of a task
+// @Summary Get one revision of a task
+// @Description Returns one saved version of the title and description of a task.
+// @tags task
+// @Produce json
+// @Security JWTKeyAuth
+// @Param listtask path int true "Task ID"
+// @Param revision path int true "Revision ID"
+// @Success 200 {object} models.TaskRevision "The revision"
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task."
+// @Failure 404 {object} web.HTTPError "The task or revision does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{listtask}/revisions/{revision} [get]
+func (tr *TaskRevision) ReadOne() (err error) {
+	revision, err := getTaskRevision(tr.TaskID, tr.ID)
+	if err != nil {
+		return err
+	}
+
+	err = addCreatorsToRevisions([]*TaskRevision{revision})
+	if err != nil {
+		return err
+	}
+
+	*tr = *revision
+	return nil
+}
+
+// Restore sets the title and description of a task to the ones of a revision.
+// The task is saved through the normal update, which creates a new revision.
+func (tr *TaskRevision) Restore(a web.Auth) (task *Task, err error) {
+	task = &Task{ID: tr.TaskID}
+	can, err := task.CanUpdate(a)
+	if err != nil {
+		return nil, err
+	}
+	if !can {
+		return nil, ErrGenericForbidden{}
+	}
+
+	revision, err := getTaskRevision(tr.TaskID, tr.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Load the task with everything on it so the update does not change anything but the title and description
+	err = task.ReadOne()
+	if err != nil {
+		return nil, err
+	}
+	task.doer = a
+	task.Title = revision.Title
+	task.Description = revision.Description
+
+	err = task.Update()
+	return
+}
+
+// The kinds of lines in a diff
+const (
+	DiffLineEqual  = `equal`
+	DiffLineInsert = `insert`
+	DiffLineDelete = `delete`
+)
+
+// DiffLine is a single line of a diff between two texts
+type DiffLine struct {
+	// Either "equal", "insert" or "delete".
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// TaskRevisionDiff holds the differences between two revisions of a task
+type TaskRevisionDiff struct {
+	TaskID          int64 `json:"task_id" param:"listtask"`
+	RevisionID      int64 `json:"revision_id" param:"revision"`
+	OtherRevisionID int64 `json:"other_revision_id" param:"otherrevision"`
+
+	// The changes it takes to get from the title of the first revision to the title of the other one.
+	Title []*DiffLine `json:"title"`
+	// The changes it takes to get from the description of the first revision to the description of the other one, line by line.
+	Description []*DiffLine `json:"description"`
+
+	web.CRUDable `json:"-"`
+	web.Rights   `json:"-"`
+}
+
+// ReadOne returns the differences between two revisions of a task
+// @Summary Compare two revisions of a task
+// @Description Returns the line by line differences of title and description between two revisions of the same task.
+// @tags task
+// @Produce json
+// @Security JWTKeyAuth
+// @Param listtask path int true "Task ID"
+// @Param revision path int true "The revision to compare from"
+// @Param otherrevision path int true "The revision to compare to"
+// @Success 200 {object} models.TaskRevisionDiff "The differences"
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task."
+// @Failure 404 {object} web.HTTPError "The task or one of the revisions does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{listtask}/revisions/{revision}/diff/{otherrevision} [get]
+func (d *TaskRevisionDiff) ReadOne() (err error) {
+	from, err := getTaskRevision(d.TaskID, d.RevisionID)
+	if err != nil {
+		return err
+	}
+	to, err := getTaskRevision(d.TaskID, d.OtherRevisionID)
+	if err != nil {
+		return err
+	}
+
+	d.Title = diffLines(from.Title, to.Title)
+	d.Description = diffLines(from.Description, to.Description)
+	return nil
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return []string{}
+	}
+	return strings.Split(text, "\n")
+}
+
+// The maximum number of lines on each side of a diff which are compared line by line. The memory needed to diff
+// two texts grows with the product of their number of lines, larger changes are therefore shown as a whole block
+// of removed lines followed by the added ones.
+const diffMaxLines = 2000
+
+// Computes a line based diff between two texts, using the longest common subsequence of their lines.
+func diffLines(from, to string) (lines []*DiffLine) {
+	a := splitLines(from)
+	b := splitLines(to)
+	lines = []*DiffLine{}
+
+	// Lines which are the same at the start and the end don't need to be compared
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		lines = append(lines, &DiffLine{Type: DiffLineEqual, Text: a[prefix]})
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	commonEnd := a[len(a)-suffix:]
+	a = a[prefix : len(a)-suffix]
+	b = b[prefix : len(b)-suffix]
+
+	if len(a) > diffMaxLines || len(b) > diffMaxLines {
+		for _, l := range a {
+			lines = append(lines, &DiffLine{Type: DiffLineDelete, Text: l})
+		}
+		for _, l := range b {
+			lines = append(lines, &DiffLine{Type: DiffLineInsert, Text: l})
+		}
+	} else {
+		lines = append(lines, diffLinesLCS(a, b)...)
+	}
+
+	for _, l := range commonEnd {
+		lines = append(lines, &DiffLine{Type: DiffLineEqual, Text: l})
+	}
+	return
+}
+
+func diffLinesLCS(a, b []string) (lines []*DiffLine) {
+	// lcs[i][j] holds the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int32, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, &DiffLine{Type: DiffLineEqual, Text: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, &DiffLine{Type: DiffLineDelete, Text: a[i]})
+			i++
+		default:
+			lines = append(lines, &DiffLine{Type: DiffLineInsert, Text: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		lines = append(lines, &DiffLine{Type: DiffLineDelete, Text: a[i]})
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, &DiffLine{Type: DiffLineInsert, Text: b[j]})
+	}
+
+	return
+}
@@ -0,0 +1,31 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import "code.vikunja.io/web"
+
+// CanRead checks if a user or link share can see the revisions of a task
+func (tr *TaskRevision) CanRead(a web.Auth) (bool, int, error) {
+	t := &Task{ID: tr.TaskID}
+	return t.CanRead(a)
+}
+
+// CanRead checks if a user or link share can compare two revisions of a task
+func (d *TaskRevisionDiff) CanRead(a web.Auth) (bool, int, error) {
+	t := &Task{ID: d.TaskID}
+	return t.CanRead(a)
+}
@@ -0,0 +1,222 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffLines(t *testing.T) {
+	t.Run("changed lines", func(t *testing.T) {
+		lines := diffLines("Lorem\nIpsum\nDolor", "Lorem\nSit\nDolor\nAmet")
+		assert.Equal(t, []*DiffLine{
+			{Type: DiffLineEqual, Text: "Lorem"},
+			{Type: DiffLineDelete, Text: "Ipsum"},
+			{Type: DiffLineInsert, Text: "Sit"},
+			{Type: DiffLineEqual, Text: "Dolor"},
+			{Type: DiffLineInsert, Text: "Amet"},
+		}, lines)
+	})
+	t.Run("from empty", func(t *testing.T) {
+		lines := diffLines("", "Lorem")
+		assert.Equal(t, []*DiffLine{{Type: DiffLineInsert, Text: "Lorem"}}, lines)
+	})
+	t.Run("equal", func(t *testing.T) {
+		lines := diffLines("", "")
+		assert.Len(t, lines, 0)
+	})
+	t.Run("large", func(t *testing.T) {
+		from := "Lorem\n" + strings.Repeat("a\n", 100000) + "Dolor"
+		to := "Lorem\n" + strings.Repeat("b\n", 100000) + "Dolor"
+		lines := diffLines(from, to)
+		assert.Len(t, lines, 200002)
+		assert.Equal(t, &DiffLine{Type: DiffLineEqual, Text: "Lorem"}, lines[0])
+		assert.Equal(t, &DiffLine{Type: DiffLineDelete, Text: "a"}, lines[1])
+		assert.Equal(t, &DiffLine{Type: DiffLineInsert, Text: "b"}, lines[100001])
+		assert.Equal(t, &DiffLine{Type: DiffLineEqual, Text: "Dolor"}, lines[200001])
+	})
+}
+
+func TestSaveTaskRevision_Handle(t *testing.T) {
+	t.Run("task created", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		err := (&SaveTaskRevision{}).Handle(&TaskCreatedEvent{
+			Task: &Task{ID: 2, Title: "task #2 done", Description: "Lorem"},
+			Doer: &user.User{ID: 1},
+		})
+		assert.NoError(t, err)
+		db.AssertExists(t, "task_revisions", map[string]interface{}{
+			"task_id":       2,
+			"title":         "task #2 done",
+			"description":   "Lorem",
+			"created_by_id": 1,
+		}, false)
+	})
+	t.Run("task updated", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		err := (&SaveTaskRevision{}).Handle(&TaskUpdatedEvent{
+			OldTask: &Task{ID: 1, Title: "task #1", Description: "Lorem\nDolor\nIpsum"},
+			Task:    &Task{ID: 1, Title: "task #1", Description: "Lorem"},
+			Doer:    &LinkSharing{ID: 1},
+		})
+		assert.NoError(t, err)
+		db.AssertExists(t, "task_revisions", map[string]interface{}{
+			"task_id":       1,
+			"description":   "Lorem",
+			"created_by_id": -1,
+		}, false)
+		count, err := x.Where("task_id = ?", 1).Count(&TaskRevision{})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), count)
+	})
+	t.Run("task without revisions updated", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		err := (&SaveTaskRevision{}).Handle(&TaskUpdatedEvent{
+			OldTask: &Task{ID: 2, Title: "task #2 done", CreatedByID: 1},
+			Task:    &Task{ID: 2, Title: "task #2"},
+			Doer:    &user.User{ID: 1},
+		})
+		assert.NoError(t, err)
+		db.AssertExists(t, "task_revisions", map[string]interface{}{
+			"task_id": 2,
+			"title":   "task #2 done",
+		}, false)
+		db.AssertExists(t, "task_revisions", map[string]interface{}{
+			"task_id": 2,
+			"title":   "task #2",
+		}, false)
+	})
+	t.Run("neither title nor description changed", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		err := (&SaveTaskRevision{}).Handle(&TaskUpdatedEvent{
+			OldTask: &Task{ID: 2, Title: "task #2 done"},
+			Task:    &Task{ID: 2, Title: "task #2 done", Done: true},
+			Doer:    &user.User{ID: 1},
+		})
+		assert.NoError(t, err)
+		db.AssertMissing(t, "task_revisions", map[string]interface{}{
+			"task_id": 2,
+		})
+	})
+}
+
+func TestTaskRevision_ReadAll(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		tr := &TaskRevision{TaskID: 1}
+		result, count, total, err := tr.ReadAll(&user.User{ID: 1}, "", 0, 50)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, count)
+		assert.Equal(t, int64(2), total)
+		revisions := result.([]*TaskRevision)
+		assert.Equal(t, int64(2), revisions[0].ID)
+		assert.Nil(t, revisions[0].CreatedBy)
+		assert.Equal(t, "user1", revisions[1].CreatedBy.Username)
+		assert.Equal(t, "", revisions[1].CreatedBy.Email)
+	})
+	t.Run("link share", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		tr := &TaskRevision{TaskID: 1}
+		_, count, _, err := tr.ReadAll(&LinkSharing{ID: 1, ListID: 1, Right: RightRead}, "", 0, 50)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, count)
+	})
+	t.Run("no access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		tr := &TaskRevision{TaskID: 1}
+		_, _, _, err := tr.ReadAll(&user.User{ID: 2}, "", 0, 50)
+		assert.Error(t, err)
+		assert.True(t, IsErrGenericForbidden(err))
+	})
+}
+
+func TestTaskRevision_ReadOne(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		tr := &TaskRevision{ID: 1, TaskID: 1}
+		err := tr.ReadOne()
+		assert.NoError(t, err)
+		assert.Equal(t, "Lorem\nIpsum", tr.Description)
+		assert.Equal(t, int64(1), tr.CreatedBy.ID)
+	})
+	t.Run("revision of another task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		tr := &TaskRevision{ID: 3, TaskID: 1}
+		err := tr.ReadOne()
+		assert.Error(t, err)
+		assert.True(t, IsErrTaskRevisionDoesNotExist(err))
+	})
+}
+
+func TestTaskRevisionDiff_ReadOne(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		d := &TaskRevisionDiff{TaskID: 1, RevisionID: 1, OtherRevisionID: 2}
+		err := d.ReadOne()
+		assert.NoError(t, err)
+		assert.Equal(t, []*DiffLine{{Type: DiffLineEqual, Text: "task #1"}}, d.Title)
+		assert.Equal(t, []*DiffLine{
+			{Type: DiffLineEqual, Text: "Lorem"},
+			{Type: DiffLineInsert, Text: "Dolor"},
+			{Type: DiffLineEqual, Text: "Ipsum"},
+		}, d.Description)
+	})
+	t.Run("nonexisting revision", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		d := &TaskRevisionDiff{TaskID: 1, RevisionID: 1, OtherRevisionID: 3}
+		err := d.ReadOne()
+		assert.Error(t, err)
+		assert.True(t, IsErrTaskRevisionDoesNotExist(err))
+	})
+}
+
+func TestTaskRevision_Restore(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		tr := &TaskRevision{ID: 1, TaskID: 1}
+		task, err := tr.Restore(&user.User{ID: 1})
+		assert.NoError(t, err)
+		assert.Equal(t, "Lorem\nIpsum", task.Description)
+		events.AssertDispatched(t, &TaskUpdatedEvent{})
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"id":          1,
+			"title":       "task #1",
+			"description": "Lorem\nIpsum",
+			"bucket_id":   1,
+		}, false)
+	})
+	t.Run("no write access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		tr := &TaskRevision{ID: 1, TaskID: 1}
+		_, err := tr.Restore(&LinkSharing{ID: 1, ListID: 1, Right: RightRead})
+		assert.Error(t, err)
+		assert.True(t, IsErrGenericForbidden(err))
+	})
+	t.Run("nonexisting revision", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		tr := &TaskRevision{ID: 9999, TaskID: 1}
+		_, err := tr.Restore(&user.User{ID: 1})
+		assert.Error(t, err)
+		assert.True(t, IsErrTaskRevisionDoesNotExist(err))
+	})
+}
@@ -23,7 +23,8 @@ import (
 	"time"
 
 	"code.vikunja.io/api/pkg/config"
-	"code.vikunja.io/api/pkg/metrics"
+	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/log"
 	"code.vikunja.io/api/pkg/user"
 	"code.vikunja.io/api/pkg/utils"
 	"code.vikunja.io/web"
@@ -56,6 +57,8 @@ type Task struct {
 	RepeatAfter int64 `xorm:"int(11) INDEX null" json:"repeat_after"`
 	// If specified, a repeating task will repeat from the current date rather than the last set date.
 	RepeatFromCurrentDate bool `xorm:"null" json:"repeat_from_current_date"`
+	// An RFC 5545 recurrence rule, the value of an RRULE line like `FREQ=MONTHLY;BYMONTHDAY=15`. If this is set, it is used instead of repeat_after when marking the task as done.
+	RepeatRule string `xorm:"varchar(500) null" json:"repeat_rule" maxLength:"500"`
 	// The task priority. Can be anything you want, it is possible to sort by this later.
 	Priority int64 `xorm:"int(11) null" json:"priority"`
 	// When this task starts.
@@ -70,6 +73,10 @@ type Task struct {
 	HexColor string `xorm:"varchar(6) null" json:"hex_color" valid:"runelength(0|6)" maxLength:"6"`
 	// Determines how far a task is left from being done
 	PercentDone float64 `xorm:"DOUBLE null" json:"percent_done"`
+	// How long this task is estimated to take, in seconds.
+	Estimate int64 `xorm:"bigint null" json:"estimate"`
+	// The story points of this task, used to plan sprints.
+	Points float64 `xorm:"DOUBLE null" json:"points"`
 
 	// The task identifier, based on the list identifier and the task's index
 	Identifier string `xorm:"-" json:"identifier"`
@@ -85,6 +92,12 @@ type Task struct {
 	// All attachments this task has
 	Attachments []*TaskAttachment `xorm:"-" json:"attachments"`
 
+	// The time tracked on this task and its list. This is null if no time was tracked on the list of this task yet.
+	TimeTracked *TaskTimeTracked `xorm:"-" json:"time_tracked"`
+
+	// The values of the custom fields of the list of this task, keyed by the name of the field. Fields without a value are not included. When updating a task, only the values of fields included here are changed, a value of null removes it.
+	CustomFields map[string]interface{} `xorm:"-" json:"custom_fields"`
+
 	// True if a task is a favorite task. Favorite tasks show up in a separate "Important" list
 	IsFavorite bool `xorm:"default false" json:"is_favorite"`
 
@@ -107,6 +120,10 @@ type Task struct {
 	// The user who initially created the task.
 	CreatedBy *user.User `xorm:"-" json:"created_by" valid:"-"`
 
+	// The user or link share who is currently changing the task. This is set when checking the rights
+	// to update or delete the task and used to tell listeners who did a change.
+	doer web.Auth `xorm:"-"`
+
 	web.CRUDable `xorm:"-" json:"-"`
 	web.Rights   `xorm:"-" json:"-"`
 }
@@ -122,6 +139,8 @@ type TaskReminder struct {
 	TaskID   int64     `xorm:"int(11) not null INDEX"`
 	Reminder time.Time `xorm:"DATETIME not null INDEX 'reminder'"`
 	Created  time.Time `xorm:"created not null"`
+	// Whether the reminder was already sent to everyone who should be notified about it.
+	Notified bool `xorm:"INDEX not null default false"`
 }
 
 // TableName returns a pretty table name
@@ -155,7 +174,7 @@ type taskOptions struct {
 // @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
 // @Param per_page query int false "The maximum number of items per page. Note this parameter is limited by the configured maximum of items per page."
 // @Param s query string false "Search tasks by task text."
-// @Param sort_by query string false "The sorting parameter. You can pass this multiple times to get the tasks ordered by multiple different parametes, along with `order_by`. Possible values to sort by are `id`, `text`, `description`, `done`, `done_at`, `due_date`, `created_by_id`, `list_id`, `repeat_after`, `priority`, `start_date`, `end_date`, `hex_color`, `percent_done`, `uid`, `created`, `updated`. Default is `id`."
+// @Param sort_by query string false "The sorting parameter. You can pass this multiple times to get the tasks ordered by multiple different parametes, along with `order_by`. Possible values to sort by are `id`, `text`, `description`, `done`, `done_at`, `due_date`, `created_by_id`, `list_id`, `repeat_after`, `priority`, `start_date`, `end_date`, `hex_color`, `percent_done`, `estimate`, `points`, `uid`, `created`, `updated`. Default is `id`."
 // @Param order_by query string false "The ordering parameter. Possible values to order by are `asc` or `desc`. Default is `asc`."
 // @Param filter_by query string false "The name of the field to filter by. Accepts an array for multiple filters which will be chanied together, all supplied filter must match."
 // @Param filter_value query string false "The value to filter for."
@@ -192,6 +211,35 @@ func getRawTasksForLists(lists []*List, a web.Auth, opts *taskOptions) (tasks []
 		listIDs = append(listIDs, l.ID)
 	}
 
+	var listIDCond builder.Cond
+	var listCond builder.Cond
+	if len(listIDs) > 0 {
+		listIDCond = builder.In("list_id", listIDs)
+		listCond = listIDCond
+	}
+
+	// Custom fields used to filter or sort are looked up in all lists tasks may come from
+	customFieldListIDs := listIDs
+
+	if hasFavoriteLists {
+		// Make sure users can only see their favorites
+		userLists, _, _, err := getRawListsForUser(&listOptions{
+			user: &user.User{ID: a.GetID()},
+			page: -1,
+		})
+		if err != nil {
+			return nil, 0, 0, err
+		}
+
+		userListIDs := make([]int64, len(userLists))
+		for _, l := range userLists {
+			userListIDs = append(userListIDs, l.ID)
+		}
+
+		listCond = builder.Or(listIDCond, builder.And(builder.Eq{"is_favorite": true}, builder.In("list_id", userListIDs)))
+		customFieldListIDs = append(customFieldListIDs, userListIDs...)
+	}
+
 	// Add the id parameter as the last parameter to sorty by default, but only if it is not already passed as the last parameter.
 	if len(opts.sortby) == 0 ||
 		len(opts.sortby) > 0 && opts.sortby[len(opts.sortby)-1].sortBy != taskPropertyID {
@@ -210,7 +258,15 @@ func getRawTasksForLists(lists []*List, a web.Auth, opts *taskOptions) (tasks []
 		if err := param.validate(); err != nil {
 			return nil, 0, 0, err
 		}
-		orderby += param.sortBy + " " + param.orderBy.String()
+		if isCustomFieldTaskProperty(param.sortBy) {
+			customFieldOrderBy, err := getCustomFieldOrderBy(param.sortBy, customFieldListIDs)
+			if err != nil {
+				return nil, 0, 0, err
+			}
+			orderby += customFieldOrderBy + " " + param.orderBy.String()
+		} else {
+			orderby += param.sortBy + " " + param.orderBy.String()
+		}
 
 		// Postgres sorts by default entries with null values after ones with values.
 		// To make that consistent with the sort order we have and other dbms, we're adding a separate clause here.
@@ -231,6 +287,15 @@ func getRawTasksForLists(lists []*List, a web.Auth, opts *taskOptions) (tasks []
 	var filters = make([]builder.Cond, 0, len(opts.filters))
 	// To still find tasks with nil values, we exclude 0s when comparing with >/< values.
 	for _, f := range opts.filters {
+		if isCustomFieldTaskProperty(f.field) {
+			cond, err := getCustomFieldFilterCond(f, customFieldListIDs, opts.filterIncludeNulls)
+			if err != nil {
+				return nil, 0, 0, err
+			}
+			filters = append(filters, cond)
+			continue
+		}
+
 		switch f.comparator {
 		case taskFilterComparatorEquals:
 			filters = append(filters, &builder.Eq{f.field: f.value})
@@ -285,31 +350,6 @@ func getRawTasksForLists(lists []*List, a web.Auth, opts *taskOptions) (tasks []
 		}
 	}
 
-	var listIDCond builder.Cond
-	var listCond builder.Cond
-	if len(listIDs) > 0 {
-		listIDCond = builder.In("list_id", listIDs)
-		listCond = listIDCond
-	}
-
-	if hasFavoriteLists {
-		// Make sure users can only see their favorites
-		userLists, _, _, err := getRawListsForUser(&listOptions{
-			user: &user.User{ID: a.GetID()},
-			page: -1,
-		})
-		if err != nil {
-			return nil, 0, 0, err
-		}
-
-		userListIDs := make([]int64, len(userLists))
-		for _, l := range userLists {
-			userListIDs = append(userListIDs, l.ID)
-		}
-
-		listCond = builder.Or(listIDCond, builder.And(builder.Eq{"is_favorite": true}, builder.In("list_id", userListIDs)))
-	}
-
 	query = query.Where(listCond)
 	queryCount = queryCount.Where(listCond)
 
@@ -427,6 +467,13 @@ func getRemindersForTasks(taskIDs []int64) (reminders []*TaskReminder, err error
 	return
 }
 
+func validateTaskEstimate(t *Task) error {
+	if t.Estimate < 0 || t.Points < 0 {
+		return ErrInvalidTaskEstimate{TaskID: t.ID, Estimate: t.Estimate, Points: t.Points}
+	}
+	return nil
+}
+
 func (t *Task) setIdentifier(list *List) {
 	t.Identifier = list.Identifier + "-" + strconv.FormatInt(t.Index, 10)
 }
@@ -535,6 +582,16 @@ func addMoreInfoToTasks(taskMap map[int64]*Task) (err error) {
 		task.setIdentifier(lists[task.ListID])
 	}
 
+	err = addTimeTrackedToTasks(taskMap, listIDs)
+	if err != nil {
+		return
+	}
+
+	err = addCustomFieldValuesToTasks(taskMap)
+	if err != nil {
+		return
+	}
+
 	// Get all related tasks
 	relatedTasks := []*TaskRelation{}
 	err = x.In("task_id", taskIDs).Find(&relatedTasks)
@@ -626,7 +683,13 @@ func (t *Task) Create(a web.Auth) (err error) {
 		_ = s.Rollback()
 		return err
 	}
-	return s.Commit()
+	if err = s.Commit(); err != nil {
+		return err
+	}
+
+	events.Dispatch(&TaskCreatedEvent{Task: t, Doer: a})
+
+	return saveMentions(t, nil, t.Description, a)
 }
 
 func createTask(s *xorm.Session, t *Task, a web.Auth, updateAssignees bool) (err error) {
@@ -638,6 +701,14 @@ func createTask(s *xorm.Session, t *Task, a web.Auth, updateAssignees bool) (err
 		return ErrTaskCannotBeEmpty{}
 	}
 
+	if err = validateRepeatRule(t); err != nil {
+		return
+	}
+
+	if err = validateTaskEstimate(t); err != nil {
+		return
+	}
+
 	// Check if the list exists
 	l := &List{ID: t.ListID}
 	if err = l.getSimpleByID(s); err != nil {
@@ -700,7 +771,7 @@ func createTask(s *xorm.Session, t *Task, a web.Auth, updateAssignees bool) (err
 
 	// Update the assignees
 	if updateAssignees {
-		if err := t.updateTaskAssignees(s, t.Assignees); err != nil {
+		if err := t.updateTaskAssignees(s, t.Assignees, a); err != nil {
 			return err
 		}
 	}
@@ -710,7 +781,9 @@ func createTask(s *xorm.Session, t *Task, a web.Auth, updateAssignees bool) (err
 		return err
 	}
 
-	metrics.UpdateCount(1, metrics.TaskCountKey)
+	if err := setTaskCustomFieldValues(s, t); err != nil {
+		return err
+	}
 
 	t.setIdentifier(l)
 
@@ -735,6 +808,14 @@ func createTask(s *xorm.Session, t *Task, a web.Auth, updateAssignees bool) (err
 //nolint:gocyclo
 func (t *Task) Update() (err error) {
 
+	if err = validateRepeatRule(t); err != nil {
+		return
+	}
+
+	if err = validateTaskEstimate(t); err != nil {
+		return
+	}
+
 	s := x.NewSession()
 
 	// Check if the task exists and get the old values
@@ -756,11 +837,15 @@ func (t *Task) Update() (err error) {
 		ot.Reminders[i] = r.Reminder
 	}
 
+	// Keep a copy of the old values to pass them to the event listeners once the update is done
+	oldTask := ot
+	doer := t.doer
+
 	// When a repeating task is marked as done, we update all deadlines and reminders and set it as undone
 	updateDone(&ot, t)
 
 	// Update the assignees
-	if err := ot.updateTaskAssignees(s, t.Assignees); err != nil {
+	if err := ot.updateTaskAssignees(s, t.Assignees, doer); err != nil {
 		_ = s.Rollback()
 		return err
 	}
@@ -791,10 +876,13 @@ func (t *Task) Update() (err error) {
 		"hex_color",
 		"done_at",
 		"percent_done",
+		"estimate",
+		"points",
 		"list_id",
 		"bucket_id",
 		"position",
 		"repeat_from_current_date",
+		"repeat_rule",
 		"is_favorite",
 	}
 
@@ -820,6 +908,12 @@ func (t *Task) Update() (err error) {
 
 		t.Index = latestTask.Index + 1
 		colsToUpdate = append(colsToUpdate, "index")
+
+		// Custom fields are defined per list, the values of the old list don't apply anymore
+		if _, err = s.Where("task_id = ?", t.ID).Delete(&TaskCustomFieldValue{}); err != nil {
+			_ = s.Rollback()
+			return err
+		}
 	}
 
 	// Check the bucket limit
@@ -896,6 +990,14 @@ func (t *Task) Update() (err error) {
 	if t.PercentDone == 0 {
 		ot.PercentDone = 0
 	}
+	// Estimate
+	if t.Estimate == 0 {
+		ot.Estimate = 0
+	}
+	// Points
+	if t.Points == 0 {
+		ot.Points = 0
+	}
 	// Position
 	if t.Position == 0 {
 		ot.Position = 0
@@ -904,11 +1006,17 @@ func (t *Task) Update() (err error) {
 	if !t.RepeatFromCurrentDate {
 		ot.RepeatFromCurrentDate = false
 	}
+	// Repeat rule
+	if t.RepeatRule == "" {
+		ot.RepeatRule = ""
+	}
 	// Is Favorite
 	if !t.IsFavorite {
 		ot.IsFavorite = false
 	}
 
+	customFields := t.CustomFields
+
 	_, err = s.ID(t.ID).
 		Cols(colsToUpdate...).
 		Update(ot)
@@ -918,105 +1026,196 @@ func (t *Task) Update() (err error) {
 		return err
 	}
 
+	t.CustomFields = customFields
+	if err = setTaskCustomFieldValues(s, t); err != nil {
+		_ = s.Rollback()
+		return err
+	}
+
 	err = updateListLastUpdatedS(s, &List{ID: t.ListID})
 	if err != nil {
 		_ = s.Rollback()
 		return err
 	}
-	return s.Commit()
+	if err = s.Commit(); err != nil {
+		return err
+	}
+
+	t.doer = doer
+	events.Dispatch(&TaskUpdatedEvent{OldTask: &oldTask, Task: t, Doer: doer})
+
+	return saveMentions(t, nil, t.Description, doer)
 }
 
 // This helper function updates the reminders, doneAt, start and end dates of the *old* task
 // and saves the new values in the newTask object.
 // We make a few assumtions here:
-//   1. Everything in oldTask is the truth - we figure out if we update anything at all if oldTask.RepeatAfter has a value > 0
+//   1. Everything in oldTask is the truth - we figure out if we update anything at all if oldTask.RepeatRule is set or oldTask.RepeatAfter has a value > 0
 //   2. Because of 1., this functions should not be used to update values other than Done in the same go
 func updateDone(oldTask *Task, newTask *Task) {
-	if !oldTask.Done && newTask.Done && oldTask.RepeatAfter > 0 {
-
-		repeatDuration := time.Duration(oldTask.RepeatAfter) * time.Second
-
+	if !oldTask.Done && newTask.Done {
 		// Current time in an extra variable to base all calculations on the same time
 		now := time.Now()
 
-		// assuming we'll merge the new task over the old task
-		if !oldTask.DueDate.IsZero() {
-			if oldTask.RepeatFromCurrentDate {
-				newTask.DueDate = now.Add(repeatDuration)
-			} else {
-				// Always add one instance of the repeating interval to catch cases where a due date is already in the future
-				// but not the repeating interval
-				newTask.DueDate = oldTask.DueDate.Add(repeatDuration)
-				// Add the repeating interval until the new due date is in the future
-				for !newTask.DueDate.After(now) {
-					newTask.DueDate = newTask.DueDate.Add(repeatDuration)
+		switch {
+		case oldTask.RepeatRule != "":
+			addRepeatRuleToTask(oldTask, newTask, now)
+		case oldTask.RepeatAfter > 0:
+			addRepeatIntervalToTask(oldTask, newTask, now)
+		}
+	}
+
+	// Update the "done at" timestamp
+	if !oldTask.Done && newTask.Done {
+		newTask.DoneAt = time.Now()
+	}
+	// When unmarking a task as done, reset the timestamp
+	if oldTask.Done && !newTask.Done {
+		newTask.DoneAt = time.Time{}
+	}
+}
+
+// Moves all dates of a task by its repeat interval and marks it as undone.
+func addRepeatIntervalToTask(oldTask *Task, newTask *Task, now time.Time) {
+	repeatDuration := time.Duration(oldTask.RepeatAfter) * time.Second
+
+	// assuming we'll merge the new task over the old task
+	if !oldTask.DueDate.IsZero() {
+		if oldTask.RepeatFromCurrentDate {
+			newTask.DueDate = now.Add(repeatDuration)
+		} else {
+			// Always add one instance of the repeating interval to catch cases where a due date is already in the future
+			// but not the repeating interval
+			newTask.DueDate = oldTask.DueDate.Add(repeatDuration)
+			// Add the repeating interval until the new due date is in the future
+			for !newTask.DueDate.After(now) {
+				newTask.DueDate = newTask.DueDate.Add(repeatDuration)
+			}
+		}
+	}
+
+	newTask.Reminders = oldTask.Reminders
+	// When repeating from the current date, all reminders should keep their difference to each other.
+	// To make this easier, we sort them first because we can then rely on the fact the first is the smallest
+	if len(oldTask.Reminders) > 0 {
+		if oldTask.RepeatFromCurrentDate {
+			sort.Slice(oldTask.Reminders, func(i, j int) bool {
+				return oldTask.Reminders[i].Unix() < oldTask.Reminders[j].Unix()
+			})
+			first := oldTask.Reminders[0]
+			for in, r := range oldTask.Reminders {
+				diff := r.Sub(first)
+				newTask.Reminders[in] = now.Add(repeatDuration + diff)
+			}
+		} else {
+			for in, r := range oldTask.Reminders {
+				newTask.Reminders[in] = r.Add(repeatDuration)
+				for !newTask.Reminders[in].After(now) {
+					newTask.Reminders[in] = newTask.Reminders[in].Add(repeatDuration)
 				}
 			}
 		}
+	}
 
-		newTask.Reminders = oldTask.Reminders
-		// When repeating from the current date, all reminders should keep their difference to each other.
-		// To make this easier, we sort them first because we can then rely on the fact the first is the smallest
-		if len(oldTask.Reminders) > 0 {
+	// If a task has a start and end date, the end date should keep the difference to the start date when setting them as new
+	if oldTask.RepeatFromCurrentDate && !oldTask.StartDate.IsZero() && !oldTask.EndDate.IsZero() {
+		diff := oldTask.EndDate.Sub(oldTask.StartDate)
+		newTask.StartDate = now.Add(repeatDuration)
+		newTask.EndDate = now.Add(repeatDuration + diff)
+	} else {
+		if !oldTask.StartDate.IsZero() {
 			if oldTask.RepeatFromCurrentDate {
-				sort.Slice(oldTask.Reminders, func(i, j int) bool {
-					return oldTask.Reminders[i].Unix() < oldTask.Reminders[j].Unix()
-				})
-				first := oldTask.Reminders[0]
-				for in, r := range oldTask.Reminders {
-					diff := r.Sub(first)
-					newTask.Reminders[in] = now.Add(repeatDuration + diff)
-				}
+				newTask.StartDate = now.Add(repeatDuration)
 			} else {
-				for in, r := range oldTask.Reminders {
-					newTask.Reminders[in] = r.Add(repeatDuration)
-					for !newTask.Reminders[in].After(now) {
-						newTask.Reminders[in] = newTask.Reminders[in].Add(repeatDuration)
-					}
+				newTask.StartDate = oldTask.StartDate.Add(repeatDuration)
+				for !newTask.StartDate.After(now) {
+					newTask.StartDate = newTask.StartDate.Add(repeatDuration)
 				}
 			}
 		}
 
-		// If a task has a start and end date, the end date should keep the difference to the start date when setting them as new
-		if oldTask.RepeatFromCurrentDate && !oldTask.StartDate.IsZero() && !oldTask.EndDate.IsZero() {
-			diff := oldTask.EndDate.Sub(oldTask.StartDate)
-			newTask.StartDate = now.Add(repeatDuration)
-			newTask.EndDate = now.Add(repeatDuration + diff)
-		} else {
-			if !oldTask.StartDate.IsZero() {
-				if oldTask.RepeatFromCurrentDate {
-					newTask.StartDate = now.Add(repeatDuration)
-				} else {
-					newTask.StartDate = oldTask.StartDate.Add(repeatDuration)
-					for !newTask.StartDate.After(now) {
-						newTask.StartDate = newTask.StartDate.Add(repeatDuration)
-					}
+		if !oldTask.EndDate.IsZero() {
+			if oldTask.RepeatFromCurrentDate {
+				newTask.EndDate = now.Add(repeatDuration)
+			} else {
+				newTask.EndDate = oldTask.EndDate.Add(repeatDuration)
+				for !newTask.EndDate.After(now) {
+					newTask.EndDate = newTask.EndDate.Add(repeatDuration)
 				}
 			}
+		}
+	}
 
-			if !oldTask.EndDate.IsZero() {
-				if oldTask.RepeatFromCurrentDate {
-					newTask.EndDate = now.Add(repeatDuration)
-				} else {
-					newTask.EndDate = oldTask.EndDate.Add(repeatDuration)
-					for !newTask.EndDate.After(now) {
-						newTask.EndDate = newTask.EndDate.Add(repeatDuration)
-					}
-				}
-			}
+	newTask.Done = false
+}
+
+// Moves all dates of a task to the next occurrence of its repeat rule and marks it as undone.
+// The first date set out of due date, start date, end date and reminders is used to evaluate the rule, all other
+// dates keep their distance to it. Because the rule is evaluated in the configured timezone, the time of the day
+// does not drift when daylight saving time starts or ends.
+// If the rule does not have another occurrence, the task stays done.
+func addRepeatRuleToTask(oldTask *Task, newTask *Task, now time.Time) {
+	rule, err := parseRepeatRule(oldTask.RepeatRule)
+	if err != nil {
+		log.Errorf("Could not parse repeat rule of task %d: %s", oldTask.ID, err)
+		return
+	}
+
+	base := getRepeatRuleBaseDate(oldTask)
+
+	// The position of the next occurrence in the rule, used to count down the number of remaining occurrences
+	position := 1
+	next := base
+	switch {
+	case base.IsZero():
+		// Without any dates we can only check if the rule has ended
+		if !rule.until.IsZero() && now.After(rule.until) {
+			return
+		}
+	case oldTask.RepeatFromCurrentDate:
+		// Start the rule at the current day but keep the time of the day of the old date
+		b := base.In(config.GetTimeZone())
+		n := now.In(config.GetTimeZone())
+		start := time.Date(n.Year(), n.Month(), n.Day(), b.Hour(), b.Minute(), b.Second(), 0, b.Location())
+		var ok bool
+		next, _, ok = rule.next(start, now)
+		if !ok {
+			return
+		}
+	default:
+		var ok bool
+		next, position, ok = rule.next(base, now)
+		if !ok {
+			return
 		}
+	}
 
-		newTask.Done = false
+	if rule.count > 0 {
+		if position >= rule.count {
+			return
+		}
+		// Only count down if the rule was not changed in the same update
+		if newTask.RepeatRule == oldTask.RepeatRule {
+			newTask.RepeatRule = setRepeatRuleCount(oldTask.RepeatRule, rule.count-position)
+		}
 	}
 
-	// Update the "done at" timestamp
-	if !oldTask.Done && newTask.Done {
-		newTask.DoneAt = time.Now()
+	diff := next.Sub(base)
+	if !oldTask.DueDate.IsZero() {
+		newTask.DueDate = oldTask.DueDate.Add(diff)
 	}
-	// When unmarking a task as done, reset the timestamp
-	if oldTask.Done && !newTask.Done {
-		newTask.DoneAt = time.Time{}
+	if !oldTask.StartDate.IsZero() {
+		newTask.StartDate = oldTask.StartDate.Add(diff)
+	}
+	if !oldTask.EndDate.IsZero() {
+		newTask.EndDate = oldTask.EndDate.Add(diff)
+	}
+	newTask.Reminders = make([]time.Time, 0, len(oldTask.Reminders))
+	for _, r := range oldTask.Reminders {
+		newTask.Reminders = append(newTask.Reminders, r.Add(diff))
 	}
+
+	newTask.Done = false
 }
 
 // Creates or deletes all necessary reminders without unneded db operations.
@@ -1120,6 +1319,12 @@ func (t *Task) updateReminders(s *xorm.Session, reminders []time.Time) (err erro
 // @Router /tasks/{id} [delete]
 func (t *Task) Delete() (err error) {
 
+	// Get the full task to be able to pass it to the event listeners after it was deleted
+	fullTask, err := GetTaskByIDSimple(t.ID)
+	if err != nil {
+		return err
+	}
+
 	if _, err = x.ID(t.ID).Delete(Task{}); err != nil {
 		return err
 	}
@@ -1129,9 +1334,32 @@ func (t *Task) Delete() (err error) {
 		return err
 	}
 
-	metrics.UpdateCount(-1, metrics.TaskCountKey)
+	// Delete mentions
+	if err = deleteMentionsForTask(t.ID); err != nil {
+		return err
+	}
+
+	// Delete revisions
+	if err = deleteRevisionsForTask(t.ID); err != nil {
+		return err
+	}
+
+	// Delete time entries
+	if _, err = x.Where("task_id = ?", t.ID).Delete(&TimeEntry{}); err != nil {
+		return err
+	}
+
+	// Delete custom field values
+	if _, err = x.Where("task_id = ?", t.ID).Delete(&TaskCustomFieldValue{}); err != nil {
+		return err
+	}
+
+	err = updateListLastUpdated(&List{ID: fullTask.ListID})
+	if err != nil {
+		return
+	}
 
-	err = updateListLastUpdated(&List{ID: t.ListID})
+	events.Dispatch(&TaskDeletedEvent{Task: &fullTask, Doer: t.doer})
 	return
 }
 
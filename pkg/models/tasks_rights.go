@@ -22,11 +22,13 @@ import (
 
 // CanDelete checks if the user can delete an task
 func (t *Task) CanDelete(a web.Auth) (bool, error) {
+	t.doer = a
 	return t.canDoTask(a)
 }
 
 // CanUpdate determines if a user has the right to update a list task
 func (t *Task) CanUpdate(a web.Auth) (bool, error) {
+	t.doer = a
 	return t.canDoTask(a)
 }
 
@@ -21,6 +21,7 @@ import (
 	"time"
 
 	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/events"
 	"code.vikunja.io/api/pkg/user"
 	"github.com/stretchr/testify/assert"
 )
@@ -58,7 +59,7 @@ func TestTask_Create(t *testing.T) {
 			"created_by_id": 1,
 			"bucket_id":     1,
 		}, false)
-
+		events.AssertDispatched(t, &TaskCreatedEvent{})
 	})
 	t.Run("empty title", func(t *testing.T) {
 		db.LoadAndAssertFixtures(t)
@@ -94,6 +95,44 @@ func TestTask_Create(t *testing.T) {
 		assert.Error(t, err)
 		assert.True(t, user.IsErrUserDoesNotExist(err))
 	})
+	t.Run("invalid repeat rule", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		task := &Task{
+			Title:      "Lorem",
+			ListID:     1,
+			RepeatRule: "FREQ=DAILY;BYHOUR=9",
+		}
+		err := task.Create(usr)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidRepeatRule(err))
+	})
+	t.Run("estimate and points", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		task := &Task{
+			Title:    "Lorem",
+			ListID:   1,
+			Estimate: 5400,
+			Points:   2.5,
+		}
+		err := task.Create(usr)
+		assert.NoError(t, err)
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"id":       task.ID,
+			"estimate": 5400,
+			"points":   2.5,
+		}, false)
+	})
+	t.Run("negative points", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		task := &Task{
+			Title:  "Lorem",
+			ListID: 1,
+			Points: -1,
+		}
+		err := task.Create(usr)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidTaskEstimate(err))
+	})
 	t.Run("full bucket", func(t *testing.T) {
 		db.LoadAndAssertFixtures(t)
 		task := &Task{
@@ -125,6 +164,7 @@ func TestTask_Update(t *testing.T) {
 			"description": "Lorem Ipsum Dolor",
 			"list_id":     1,
 		}, false)
+		events.AssertDispatched(t, &TaskUpdatedEvent{})
 	})
 	t.Run("nonexistant task", func(t *testing.T) {
 		db.LoadAndAssertFixtures(t)
@@ -177,6 +217,7 @@ func TestTask_Delete(t *testing.T) {
 		db.AssertMissing(t, "tasks", map[string]interface{}{
 			"id": 1,
 		})
+		events.AssertDispatched(t, &TaskDeletedEvent{})
 	})
 }
 
@@ -392,6 +433,102 @@ func TestUpdateDone(t *testing.T) {
 			})
 		})
 	})
+	t.Run("repeat rule", func(t *testing.T) {
+		now := time.Date(2020, time.October, 19, 12, 0, 0, 0, time.UTC)
+		t.Run("normal", func(t *testing.T) {
+			oldTask := &Task{
+				Done:        false,
+				RepeatAfter: 8600,
+				RepeatRule:  "FREQ=MONTHLY;BYMONTHDAY=15",
+				DueDate:     time.Date(2100, time.January, 15, 9, 0, 0, 0, time.UTC),
+			}
+			newTask := &Task{
+				Done: true,
+			}
+			updateDone(oldTask, newTask)
+			assert.False(t, newTask.Done)
+			assert.Equal(t, time.Date(2100, time.February, 15, 9, 0, 0, 0, time.UTC), newTask.DueDate.UTC())
+		})
+		t.Run("overdue", func(t *testing.T) {
+			oldTask := &Task{
+				RepeatRule: "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR",
+				DueDate:    time.Date(2020, time.October, 1, 9, 0, 0, 0, time.UTC),
+			}
+			newTask := &Task{
+				Done: true,
+			}
+			addRepeatRuleToTask(oldTask, newTask, now)
+			assert.False(t, newTask.Done)
+			assert.Equal(t, time.Date(2020, time.October, 20, 9, 0, 0, 0, time.UTC), newTask.DueDate.UTC())
+		})
+		t.Run("other dates keep their distance", func(t *testing.T) {
+			oldTask := &Task{
+				RepeatRule: "FREQ=DAILY",
+				DueDate:    time.Date(2020, time.October, 19, 9, 0, 0, 0, time.UTC),
+				StartDate:  time.Date(2020, time.October, 19, 8, 0, 0, 0, time.UTC),
+				EndDate:    time.Date(2020, time.October, 19, 10, 0, 0, 0, time.UTC),
+				Reminders: []time.Time{
+					time.Date(2020, time.October, 19, 7, 0, 0, 0, time.UTC),
+				},
+			}
+			newTask := &Task{
+				Done: true,
+			}
+			addRepeatRuleToTask(oldTask, newTask, now)
+			assert.Equal(t, time.Date(2020, time.October, 20, 9, 0, 0, 0, time.UTC), newTask.DueDate.UTC())
+			assert.Equal(t, time.Date(2020, time.October, 20, 8, 0, 0, 0, time.UTC), newTask.StartDate.UTC())
+			assert.Equal(t, time.Date(2020, time.October, 20, 10, 0, 0, 0, time.UTC), newTask.EndDate.UTC())
+			assert.Equal(t, []time.Time{time.Date(2020, time.October, 20, 7, 0, 0, 0, time.UTC)}, []time.Time{newTask.Reminders[0].UTC()})
+		})
+		t.Run("repeat from current date", func(t *testing.T) {
+			oldTask := &Task{
+				RepeatRule:            "FREQ=DAILY;INTERVAL=3",
+				RepeatFromCurrentDate: true,
+				DueDate:               time.Date(2020, time.October, 1, 9, 0, 0, 0, time.UTC),
+			}
+			newTask := &Task{
+				Done: true,
+			}
+			addRepeatRuleToTask(oldTask, newTask, now)
+			assert.Equal(t, time.Date(2020, time.October, 22, 9, 0, 0, 0, time.UTC), newTask.DueDate.UTC())
+		})
+		t.Run("count down", func(t *testing.T) {
+			oldTask := &Task{
+				RepeatRule: "FREQ=DAILY;COUNT=3",
+				DueDate:    time.Date(2020, time.October, 19, 9, 0, 0, 0, time.UTC),
+			}
+			newTask := &Task{
+				Done:       true,
+				RepeatRule: "FREQ=DAILY;COUNT=3",
+			}
+			addRepeatRuleToTask(oldTask, newTask, now)
+			assert.False(t, newTask.Done)
+			assert.Equal(t, "FREQ=DAILY;COUNT=2", newTask.RepeatRule)
+		})
+		t.Run("last occurrence", func(t *testing.T) {
+			oldTask := &Task{
+				RepeatRule: "FREQ=DAILY;COUNT=1",
+				DueDate:    time.Date(2020, time.October, 19, 9, 0, 0, 0, time.UTC),
+			}
+			newTask := &Task{
+				Done: true,
+			}
+			addRepeatRuleToTask(oldTask, newTask, now)
+			assert.True(t, newTask.Done)
+			assert.True(t, newTask.DueDate.IsZero())
+		})
+		t.Run("until reached", func(t *testing.T) {
+			oldTask := &Task{
+				RepeatRule: "FREQ=DAILY;UNTIL=20201019T235959Z",
+				DueDate:    time.Date(2020, time.October, 19, 9, 0, 0, 0, time.UTC),
+			}
+			newTask := &Task{
+				Done: true,
+			}
+			addRepeatRuleToTask(oldTask, newTask, now)
+			assert.True(t, newTask.Done)
+		})
+	})
 }
 
 func TestTask_ReadOne(t *testing.T) {
@@ -17,6 +17,7 @@
 package models
 
 import (
+	"code.vikunja.io/api/pkg/events"
 	user2 "code.vikunja.io/api/pkg/user"
 	"code.vikunja.io/web"
 )
@@ -38,7 +39,7 @@ import (
 func (tm *TeamMember) Create(a web.Auth) (err error) {
 
 	// Check if the team extst
-	_, err = GetTeamByID(tm.TeamID)
+	team, err := GetTeamByID(tm.TeamID)
 	if err != nil {
 		return
 	}
@@ -62,6 +63,15 @@ func (tm *TeamMember) Create(a web.Auth) (err error) {
 
 	// Insert the user
 	_, err = x.Insert(tm)
+	if err != nil {
+		return
+	}
+
+	events.Dispatch(&TeamMemberAddedEvent{
+		Team:   team,
+		Member: user,
+		Doer:   a,
+	})
 	return
 }
 
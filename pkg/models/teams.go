@@ -19,7 +19,7 @@ package models
 import (
 	"time"
 
-	"code.vikunja.io/api/pkg/metrics"
+	"code.vikunja.io/api/pkg/events"
 	"code.vikunja.io/api/pkg/user"
 	"code.vikunja.io/web"
 	"xorm.io/builder"
@@ -271,7 +271,7 @@ func (t *Team) Create(a web.Auth) (err error) {
 		return err
 	}
 
-	metrics.UpdateCount(1, metrics.TeamCountKey)
+	events.Dispatch(&TeamCreatedEvent{Team: t})
 	return
 }
 
@@ -312,7 +312,7 @@ func (t *Team) Delete() (err error) {
 		return
 	}
 
-	metrics.UpdateCount(-1, metrics.TeamCountKey)
+	events.Dispatch(&TeamDeletedEvent{Team: t})
 	return
 }
 
@@ -0,0 +1,556 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"sort"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// TimeEntry holds a span of time a user spent working on a task
+type TimeEntry struct {
+	// The unique, numeric id of this time entry.
+	ID int64 `xorm:"int(11) autoincr not null unique pk" json:"id" param:"timeentry"`
+	// The task this time entry belongs to.
+	TaskID int64 `xorm:"int(11) not null INDEX" json:"task_id" param:"task"`
+	UserID int64 `xorm:"int(11) not null INDEX" json:"-"`
+	// The user who tracked this time.
+	User *user.User `xorm:"-" json:"user"`
+
+	// When the user started working on the task.
+	Start time.Time `xorm:"DATETIME not null INDEX 'start_time'" json:"start"`
+	// When the user stopped working on the task. This is null as long as the timer of this entry is running.
+	End time.Time `xorm:"DATETIME null INDEX 'end_time'" json:"end"`
+	// The tracked time in seconds. If an entry is created without an end, the end is calculated from the start and this.
+	Duration int64 `xorm:"bigint not null default 0" json:"duration"`
+	// What the user did in this time.
+	Note string `xorm:"text null" json:"note"`
+
+	// A timestamp when this time entry was created. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+	// A timestamp when this time entry was last updated. You cannot change this value.
+	Updated time.Time `xorm:"updated not null" json:"updated"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TableName holds the table name for the time entries table
+func (te *TimeEntry) TableName() string {
+	return "time_entries"
+}
+
+// TaskTimeTracked holds how much time was tracked on a task and its list
+type TaskTimeTracked struct {
+	// The time tracked on this task by all users in seconds.
+	Total int64 `json:"total"`
+	// The time tracked on all tasks of the list this task belongs to in seconds.
+	ListTotal int64 `json:"list_total"`
+	// The time tracked on this task per user.
+	Users []*UserTimeTracked `json:"users"`
+}
+
+// UserTimeTracked holds how much time a single user tracked
+type UserTimeTracked struct {
+	User *user.User `json:"user"`
+	// The tracked time in seconds.
+	Duration int64 `json:"duration"`
+}
+
+// Makes sure the start, end and duration of a finished time entry fit together.
+func (te *TimeEntry) setTimes(now time.Time) error {
+	if te.Duration < 0 {
+		return ErrInvalidTimeEntry{Reason: "the duration cannot be negative"}
+	}
+
+	duration := time.Duration(te.Duration) * time.Second
+
+	switch {
+	case !te.End.IsZero():
+		if te.Start.IsZero() {
+			if te.Duration == 0 {
+				return ErrInvalidTimeEntry{Reason: "an entry with an end needs a start or a duration"}
+			}
+			te.Start = te.End.Add(-duration)
+		}
+	case te.Duration > 0:
+		if te.Start.IsZero() {
+			te.Start = now.Add(-duration)
+		}
+		te.End = te.Start.Add(duration)
+	default:
+		return ErrInvalidTimeEntry{Reason: "an entry needs an end or a duration"}
+	}
+
+	if te.End.Before(te.Start) {
+		return ErrInvalidTimeEntry{Reason: "the end cannot be before the start"}
+	}
+
+	te.Duration = int64(te.End.Sub(te.Start).Seconds())
+	return nil
+}
+
+func getTimeEntryByID(id int64) (te *TimeEntry, err error) {
+	te = &TimeEntry{}
+	exists, err := x.Where("id = ?", id).Get(te)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrTimeEntryDoesNotExist{ID: id}
+	}
+	return
+}
+
+// Returns all users by their id with their email addresses obfuscated
+func getUsersForTimeEntries(userIDs []int64) (users map[int64]*user.User, err error) {
+	users = make(map[int64]*user.User, len(userIDs))
+	if len(userIDs) == 0 {
+		return
+	}
+
+	err = x.In("id", userIDs).Find(&users)
+	if err != nil {
+		return
+	}
+
+	for _, u := range users {
+		u.Email = ""
+	}
+	return
+}
+
+// Create creates a new time entry
+// @Summary Create a new time entry
+// @Description Adds time the current user spent on a task. The entry needs either an end or a duration, the start defaults to the end minus the duration. To track the time while working on a task, use the timer instead. The user needs to have write access to the task.
+// @tags time tracking
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param taskID path int true "Task ID"
+// @Param entry body models.TimeEntry true "The time entry object"
+// @Success 200 {object} models.TimeEntry "The created time entry object."
+// @Failure 400 {object} web.HTTPError "Invalid time entry object provided."
+// @Failure 403 {object} web.HTTPError "The user does not have write access to the task."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{taskID}/time-entries [put]
+func (te *TimeEntry) Create(a web.Auth) (err error) {
+	if err = te.setTimes(time.Now()); err != nil {
+		return
+	}
+
+	te.ID = 0
+	te.UserID = a.GetID()
+	if _, err = x.Insert(te); err != nil {
+		return
+	}
+
+	te.User, err = user.GetUserByID(te.UserID)
+	return
+}
+
+// Update updates a time entry
+// @Summary Update a time entry
+// @Description Updates the start, end, duration and note of a time entry. The entry of a running timer stays running as long as neither an end nor a duration is set. Only the user who tracked the time and list admins can do this.
+// @tags time tracking
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param taskID path int true "Task ID"
+// @Param entryID path int true "Time entry ID"
+// @Param entry body models.TimeEntry true "The time entry object"
+// @Success 200 {object} models.TimeEntry "The updated time entry object."
+// @Failure 400 {object} web.HTTPError "Invalid time entry object provided."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the time entry."
+// @Failure 404 {object} web.HTTPError "The time entry does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{taskID}/time-entries/{entryID} [post]
+func (te *TimeEntry) Update() (err error) {
+	old, err := getTimeEntryByID(te.ID)
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	cols := []string{"start_time", "end_time", "duration", "note"}
+
+	if old.End.IsZero() && te.End.IsZero() && te.Duration == 0 {
+		// The timer of this entry is still running
+		if te.Start.IsZero() {
+			te.Start = old.Start
+		}
+		if te.Start.After(now) {
+			return ErrInvalidTimeEntry{Reason: "a running entry cannot start in the future"}
+		}
+		cols = []string{"start_time", "note"}
+	} else if err = te.setTimes(now); err != nil {
+		return
+	}
+
+	if _, err = x.ID(te.ID).Cols(cols...).Update(te); err != nil {
+		return
+	}
+
+	te.TaskID = old.TaskID
+	te.UserID = old.UserID
+	te.Created = old.Created
+	te.User, err = user.GetUserByID(te.UserID)
+	return
+}
+
+// Delete removes a time entry
+// @Summary Remove a time entry
+// @Description Removes a time entry. Only the user who tracked the time and list admins can do this.
+// @tags time tracking
+// @Produce json
+// @Security JWTKeyAuth
+// @Param taskID path int true "Task ID"
+// @Param entryID path int true "Time entry ID"
+// @Success 200 {object} models.Message "The time entry was successfully deleted."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the time entry."
+// @Failure 404 {object} web.HTTPError "The time entry does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{taskID}/time-entries/{entryID} [delete]
+func (te *TimeEntry) Delete() (err error) {
+	deleted, err := x.Where("id = ? AND task_id = ?", te.ID, te.TaskID).Delete(&TimeEntry{})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrTimeEntryDoesNotExist{ID: te.ID, TaskID: te.TaskID}
+	}
+	return
+}
+
+// ReadOne returns a single time entry
+// @Summary Get one time entry
+// @Description Returns a single time entry of a task. The user needs to have read access to the task.
+// @tags time tracking
+// @Produce json
+// @Security JWTKeyAuth
+// @Param taskID path int true "Task ID"
+// @Param entryID path int true "Time entry ID"
+// @Success 200 {object} models.TimeEntry "The time entry."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task."
+// @Failure 404 {object} web.HTTPError "The time entry does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{taskID}/time-entries/{entryID} [get]
+func (te *TimeEntry) ReadOne() (err error) {
+	exists, err := x.Where("id = ? AND task_id = ?", te.ID, te.TaskID).Get(te)
+	if err != nil {
+		return
+	}
+	if !exists {
+		return ErrTimeEntryDoesNotExist{ID: te.ID, TaskID: te.TaskID}
+	}
+
+	users, err := getUsersForTimeEntries([]int64{te.UserID})
+	if err != nil {
+		return
+	}
+	te.User = users[te.UserID]
+	return
+}
+
+// ReadAll returns all time entries of a task
+// @Summary Get all time entries of a task
+// @Description Returns all time entries of a task, the latest first. The user needs to have read access to the task.
+// @tags time tracking
+// @Produce json
+// @Security JWTKeyAuth
+// @Param taskID path int true "Task ID"
+// @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
+// @Param per_page query int false "The maximum number of items per page. Note this parameter is limited by the configured maximum of items per page."
+// @Param s query string false "Search time entries by their note."
+// @Success 200 {array} models.TimeEntry "The time entries."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{taskID}/time-entries [get]
+func (te *TimeEntry) ReadAll(a web.Auth, search string, page int, perPage int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	canRead, _, err := te.CanRead(a)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if !canRead {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	limit, start := getLimitFromPageIndex(page, perPage)
+
+	cond := builder.And(
+		builder.Eq{"task_id": te.TaskID},
+		builder.Like{"note", "%" + search + "%"},
+	)
+
+	entries := []*TimeEntry{}
+	query := x.Where(cond).OrderBy("start_time desc, id desc")
+	if limit > 0 {
+		query = query.Limit(limit, start)
+	}
+	err = query.Find(&entries)
+	if err != nil {
+		return
+	}
+
+	userIDs := make([]int64, 0, len(entries))
+	for _, e := range entries {
+		userIDs = append(userIDs, e.UserID)
+	}
+	users, err := getUsersForTimeEntries(userIDs)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		e.User = users[e.UserID]
+	}
+
+	numberOfTotalItems, err = x.Where(cond).Count(&TimeEntry{})
+	return entries, len(entries), numberOfTotalItems, err
+}
+
+func getRunningTimeEntry(s *xorm.Session, userID int64) (te *TimeEntry, err error) {
+	te = &TimeEntry{}
+	exists, err := s.
+		Where("user_id = ?", userID).
+		And(builder.IsNull{"end_time"}).
+		Get(te)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrNoRunningTimer{UserID: userID}
+	}
+	return
+}
+
+func stopRunningTimeEntry(s *xorm.Session, userID int64, now time.Time) (te *TimeEntry, err error) {
+	te, err = getRunningTimeEntry(s, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	te.End = now
+	if te.End.Before(te.Start) {
+		te.End = te.Start
+	}
+	te.Duration = int64(te.End.Sub(te.Start).Seconds())
+
+	_, err = s.ID(te.ID).Cols("end_time", "duration").Update(te)
+	return
+}
+
+// GetRunningTimeEntry returns the time entry of the running timer of a user.
+func GetRunningTimeEntry(a web.Auth) (te *TimeEntry, err error) {
+	if _, is := a.(*LinkSharing); is {
+		return nil, ErrTimeTrackingNotAvailableForLinkShare{LinkShareID: a.GetID()}
+	}
+
+	s := x.NewSession()
+	defer s.Close()
+
+	te, err = getRunningTimeEntry(s, a.GetID())
+	if err != nil {
+		return nil, err
+	}
+
+	te.User, err = user.GetUserByID(te.UserID)
+	return
+}
+
+// StartTimeEntryTimer starts a timer for the user on a task. Because a user can only work on one task at a time,
+// a timer which is already running is stopped first.
+func StartTimeEntryTimer(a web.Auth, taskID int64, note string) (te *TimeEntry, err error) {
+	if _, is := a.(*LinkSharing); is {
+		return nil, ErrTimeTrackingNotAvailableForLinkShare{LinkShareID: a.GetID()}
+	}
+
+	canWrite, err := (&Task{ID: taskID}).CanWrite(a)
+	if err != nil {
+		return nil, err
+	}
+	if !canWrite {
+		return nil, ErrGenericForbidden{}
+	}
+
+	now := time.Now()
+
+	s := x.NewSession()
+	defer s.Close()
+	if err = s.Begin(); err != nil {
+		return nil, err
+	}
+
+	// Lock the user until the new timer is saved so concurrent requests can't start two timers at the same time
+	_, err = s.Where("id = ?", a.GetID()).ForUpdate().Get(&user.User{})
+	if err != nil {
+		_ = s.Rollback()
+		return nil, err
+	}
+
+	_, err = stopRunningTimeEntry(s, a.GetID(), now)
+	if err != nil && !IsErrNoRunningTimer(err) {
+		_ = s.Rollback()
+		return nil, err
+	}
+
+	te = &TimeEntry{
+		TaskID: taskID,
+		UserID: a.GetID(),
+		Start:  now,
+		Note:   note,
+	}
+	if _, err = s.Insert(te); err != nil {
+		_ = s.Rollback()
+		return nil, err
+	}
+
+	if err = s.Commit(); err != nil {
+		return nil, err
+	}
+
+	te.User, err = user.GetUserByID(te.UserID)
+	return
+}
+
+// StopTimeEntryTimer stops the running timer of a user and returns its finished time entry.
+func StopTimeEntryTimer(a web.Auth) (te *TimeEntry, err error) {
+	if _, is := a.(*LinkSharing); is {
+		return nil, ErrTimeTrackingNotAvailableForLinkShare{LinkShareID: a.GetID()}
+	}
+
+	s := x.NewSession()
+	defer s.Close()
+	if err = s.Begin(); err != nil {
+		return nil, err
+	}
+
+	te, err = stopRunningTimeEntry(s, a.GetID(), time.Now())
+	if err != nil {
+		_ = s.Rollback()
+		return nil, err
+	}
+
+	if err = s.Commit(); err != nil {
+		return nil, err
+	}
+
+	te.User, err = user.GetUserByID(te.UserID)
+	return
+}
+
+// Adds the time tracked on each task, per user and on the list of each task to the tasks.
+// Running timers are not counted until they are stopped.
+func addTimeTrackedToTasks(taskMap map[int64]*Task, listIDs []int64) (err error) {
+	if !config.ServiceEnableTimeTracking.GetBool() || len(listIDs) == 0 {
+		return nil
+	}
+
+	type listTimeTracked struct {
+		ListID   int64
+		Duration int64
+	}
+
+	listSums := []*listTimeTracked{}
+	err = x.
+		Select("tasks.list_id, SUM(time_entries.duration) AS duration").
+		Table("time_entries").
+		Join("INNER", "tasks", "tasks.id = time_entries.task_id").
+		In("tasks.list_id", listIDs).
+		GroupBy("tasks.list_id").
+		Find(&listSums)
+	if err != nil || len(listSums) == 0 {
+		return
+	}
+
+	listTotals := make(map[int64]int64, len(listSums))
+	for _, l := range listSums {
+		listTotals[l.ListID] = l.Duration
+	}
+
+	type taskUserTimeTracked struct {
+		TaskID   int64
+		UserID   int64
+		Duration int64
+	}
+
+	taskIDs := make([]int64, 0, len(taskMap))
+	for id := range taskMap {
+		taskIDs = append(taskIDs, id)
+	}
+
+	taskUserSums := []*taskUserTimeTracked{}
+	err = x.
+		Select("task_id, user_id, SUM(duration) AS duration").
+		Table("time_entries").
+		In("task_id", taskIDs).
+		GroupBy("task_id, user_id").
+		Find(&taskUserSums)
+	if err != nil {
+		return
+	}
+
+	taskUserTotals := make(map[int64]map[int64]int64)
+	userIDs := make([]int64, 0, len(taskUserSums))
+	for _, e := range taskUserSums {
+		if _, has := taskUserTotals[e.TaskID]; !has {
+			taskUserTotals[e.TaskID] = make(map[int64]int64)
+		}
+		taskUserTotals[e.TaskID][e.UserID] = e.Duration
+		userIDs = append(userIDs, e.UserID)
+	}
+
+	users, err := getUsersForTimeEntries(userIDs)
+	if err != nil {
+		return
+	}
+
+	for _, t := range taskMap {
+		if _, has := listTotals[t.ListID]; !has {
+			continue
+		}
+
+		t.TimeTracked = &TaskTimeTracked{
+			ListTotal: listTotals[t.ListID],
+			Users:     []*UserTimeTracked{},
+		}
+		for userID, duration := range taskUserTotals[t.ID] {
+			t.TimeTracked.Total += duration
+			u, exists := users[userID]
+			if !exists {
+				continue
+			}
+			t.TimeTracked.Users = append(t.TimeTracked.Users, &UserTimeTracked{
+				User:     u,
+				Duration: duration,
+			})
+		}
+		sort.Slice(t.TimeTracked.Users, func(i, j int) bool {
+			if t.TimeTracked.Users[i].Duration == t.TimeTracked.Users[j].Duration {
+				return t.TimeTracked.Users[i].User.ID < t.TimeTracked.Users[j].User.ID
+			}
+			return t.TimeTracked.Users[i].Duration > t.TimeTracked.Users[j].Duration
+		})
+	}
+
+	return
+}
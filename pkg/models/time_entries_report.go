@@ -0,0 +1,278 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+	"xorm.io/builder"
+)
+
+// TimeReportOptions holds everything to filter the time entries in a report by
+type TimeReportOptions struct {
+	// Only entries which started at or after this time are included.
+	From time.Time
+	// Only entries which started before this time are included.
+	To time.Time
+	// If set, only entries on tasks of this list are included.
+	ListID int64
+	// If set, only entries of this user are included.
+	UserID int64
+}
+
+// TimeReportEntry is a single finished time entry in a time report
+type TimeReportEntry struct {
+	ID             int64      `json:"id"`
+	TaskID         int64      `json:"task_id"`
+	TaskIdentifier string     `json:"task_identifier"`
+	TaskTitle      string     `json:"task_title"`
+	ListID         int64      `json:"list_id"`
+	ListTitle      string     `json:"list_title"`
+	User           *user.User `json:"user"`
+	Start          time.Time  `json:"start"`
+	End            time.Time  `json:"end"`
+	// The tracked time in seconds.
+	Duration int64  `json:"duration"`
+	Note     string `json:"note"`
+}
+
+// ListTimeTracked holds how much time was tracked on a list
+type ListTimeTracked struct {
+	ListID    int64  `json:"list_id"`
+	ListTitle string `json:"list_title"`
+	// The tracked time in seconds.
+	Duration int64 `json:"duration"`
+}
+
+// TimeReport holds all time entries matching a filter with their totals
+type TimeReport struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+	// The time tracked in all entries of the report in seconds.
+	Total int64 `json:"total"`
+	// The time tracked per user.
+	Users []*UserTimeTracked `json:"users"`
+	// The time tracked per list.
+	Lists []*ListTimeTracked `json:"lists"`
+	// All entries of the report, the oldest first.
+	Entries []*TimeReportEntry `json:"entries"`
+}
+
+// GetTimeReport returns all finished time entries on lists the user has access to, filtered by the options.
+// Running timers are not part of a report until they are stopped.
+func GetTimeReport(a web.Auth, opts *TimeReportOptions) (report *TimeReport, err error) {
+	if _, is := a.(*LinkSharing); is {
+		return nil, ErrTimeTrackingNotAvailableForLinkShare{LinkShareID: a.GetID()}
+	}
+
+	report = &TimeReport{
+		From:    opts.From,
+		To:      opts.To,
+		Users:   []*UserTimeTracked{},
+		Lists:   []*ListTimeTracked{},
+		Entries: []*TimeReportEntry{},
+	}
+
+	var lists []*List
+	if opts.ListID != 0 {
+		l := &List{ID: opts.ListID}
+		canRead, _, err := l.CanRead(a)
+		if err != nil {
+			return nil, err
+		}
+		if !canRead {
+			return nil, ErrGenericForbidden{}
+		}
+		if err := l.GetSimpleByID(); err != nil {
+			return nil, err
+		}
+		lists = []*List{l}
+	} else {
+		lists, _, _, err = getRawListsForUser(&listOptions{
+			user:       &user.User{ID: a.GetID()},
+			page:       -1,
+			isArchived: true,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(lists) == 0 {
+		return report, nil
+	}
+
+	listMap := make(map[int64]*List, len(lists))
+	listIDs := make([]int64, 0, len(lists))
+	for _, l := range lists {
+		listMap[l.ID] = l
+		listIDs = append(listIDs, l.ID)
+	}
+
+	cond := builder.And(
+		builder.In("tasks.list_id", listIDs),
+		builder.NotNull{"time_entries.end_time"},
+	)
+	if !opts.From.IsZero() {
+		cond = builder.And(cond, builder.Gte{"time_entries.start_time": opts.From})
+	}
+	if !opts.To.IsZero() {
+		cond = builder.And(cond, builder.Lt{"time_entries.start_time": opts.To})
+	}
+	if opts.UserID != 0 {
+		cond = builder.And(cond, builder.Eq{"time_entries.user_id": opts.UserID})
+	}
+
+	entries := []*TimeEntry{}
+	err = x.
+		Select("time_entries.*").
+		Join("INNER", "tasks", "tasks.id = time_entries.task_id").
+		Where(cond).
+		OrderBy("time_entries.start_time asc, time_entries.id asc").
+		Find(&entries)
+	if err != nil || len(entries) == 0 {
+		return report, err
+	}
+
+	taskIDs := make([]int64, 0, len(entries))
+	userIDs := make([]int64, 0, len(entries))
+	for _, e := range entries {
+		taskIDs = append(taskIDs, e.TaskID)
+		userIDs = append(userIDs, e.UserID)
+	}
+
+	tasks := make(map[int64]*Task, len(taskIDs))
+	err = x.In("id", taskIDs).Find(&tasks)
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := getUsersForTimeEntries(userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	userTotals := make(map[int64]*UserTimeTracked)
+	listTotals := make(map[int64]*ListTimeTracked)
+	for _, e := range entries {
+		task := tasks[e.TaskID]
+		list := listMap[task.ListID]
+		task.setIdentifier(list)
+
+		report.Entries = append(report.Entries, &TimeReportEntry{
+			ID:             e.ID,
+			TaskID:         task.ID,
+			TaskIdentifier: task.Identifier,
+			TaskTitle:      task.Title,
+			ListID:         list.ID,
+			ListTitle:      list.Title,
+			User:           users[e.UserID],
+			Start:          e.Start,
+			End:            e.End,
+			Duration:       e.Duration,
+			Note:           e.Note,
+		})
+
+		report.Total += e.Duration
+
+		if u, exists := users[e.UserID]; exists {
+			if _, has := userTotals[u.ID]; !has {
+				userTotals[u.ID] = &UserTimeTracked{User: u}
+				report.Users = append(report.Users, userTotals[u.ID])
+			}
+			userTotals[u.ID].Duration += e.Duration
+		}
+
+		if _, has := listTotals[list.ID]; !has {
+			listTotals[list.ID] = &ListTimeTracked{ListID: list.ID, ListTitle: list.Title}
+			report.Lists = append(report.Lists, listTotals[list.ID])
+		}
+		listTotals[list.ID].Duration += e.Duration
+	}
+
+	sort.SliceStable(report.Users, func(i, j int) bool {
+		return report.Users[i].Duration > report.Users[j].Duration
+	})
+	sort.SliceStable(report.Lists, func(i, j int) bool {
+		return report.Lists[i].Duration > report.Lists[j].Duration
+	})
+
+	return report, nil
+}
+
+// Spreadsheet applications run cells starting with one of these characters as a formula.
+// Prefixing them with a ' makes sure they are only shown as text.
+func escapeCSVCell(value string) string {
+	if value != "" && strings.ContainsAny(value[:1], "=+-@\t\r") {
+		return "'" + value
+	}
+	return value
+}
+
+// WriteCSV writes all entries of a time report as csv, one entry per line.
+// All times are in the configured timezone.
+func (r *TimeReport) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+
+	err := writer.Write([]string{
+		"start",
+		"end",
+		"duration_seconds",
+		"duration_hours",
+		"user",
+		"list",
+		"task_identifier",
+		"task",
+		"note",
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, e := range r.Entries {
+		username := ""
+		if e.User != nil {
+			username = e.User.Username
+		}
+
+		err = writer.Write([]string{
+			e.Start.In(config.GetTimeZone()).Format(time.RFC3339),
+			e.End.In(config.GetTimeZone()).Format(time.RFC3339),
+			strconv.FormatInt(e.Duration, 10),
+			strconv.FormatFloat(float64(e.Duration)/3600, 'f', 2, 64),
+			escapeCSVCell(username),
+			escapeCSVCell(e.ListTitle),
+			escapeCSVCell(e.TaskIdentifier),
+			escapeCSVCell(e.TaskTitle),
+			escapeCSVCell(e.Note),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,294 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeEntry_setTimes(t *testing.T) {
+	now := time.Date(2020, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	t.Run("start and end", func(t *testing.T) {
+		te := &TimeEntry{Start: now.Add(-time.Hour), End: now}
+		err := te.setTimes(now)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3600), te.Duration)
+	})
+	t.Run("end and duration", func(t *testing.T) {
+		te := &TimeEntry{End: now, Duration: 1800}
+		err := te.setTimes(now)
+		assert.NoError(t, err)
+		assert.Equal(t, now.Add(-30*time.Minute), te.Start)
+	})
+	t.Run("only duration", func(t *testing.T) {
+		te := &TimeEntry{Duration: 900}
+		err := te.setTimes(now)
+		assert.NoError(t, err)
+		assert.Equal(t, now.Add(-15*time.Minute), te.Start)
+		assert.Equal(t, now, te.End)
+	})
+	t.Run("start and duration", func(t *testing.T) {
+		te := &TimeEntry{Start: now, Duration: 60}
+		err := te.setTimes(now)
+		assert.NoError(t, err)
+		assert.Equal(t, now.Add(time.Minute), te.End)
+	})
+	t.Run("end before start", func(t *testing.T) {
+		te := &TimeEntry{Start: now, End: now.Add(-time.Hour)}
+		err := te.setTimes(now)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidTimeEntry(err))
+	})
+	t.Run("negative duration", func(t *testing.T) {
+		te := &TimeEntry{Duration: -5}
+		err := te.setTimes(now)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidTimeEntry(err))
+	})
+	t.Run("neither end nor duration", func(t *testing.T) {
+		te := &TimeEntry{Start: now}
+		err := te.setTimes(now)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidTimeEntry(err))
+	})
+}
+
+func TestTimeEntry_Create(t *testing.T) {
+	u := &user.User{ID: 3}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		te := &TimeEntry{
+			TaskID:   13,
+			Duration: 600,
+			Note:     "review",
+		}
+		can, err := te.CanCreate(u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+		err = te.Create(u)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(600), te.Duration)
+		assert.Equal(t, int64(3), te.User.ID)
+		db.AssertExists(t, "time_entries", map[string]interface{}{
+			"id":       te.ID,
+			"task_id":  13,
+			"user_id":  3,
+			"duration": 600,
+			"note":     "review",
+		}, false)
+	})
+	t.Run("invalid times", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		te := &TimeEntry{TaskID: 13}
+		err := te.Create(u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidTimeEntry(err))
+	})
+	t.Run("no write access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		te := &TimeEntry{TaskID: 1, Duration: 600}
+		can, err := te.CanCreate(u)
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("link share", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		te := &TimeEntry{TaskID: 1, Duration: 600}
+		_, err := te.CanCreate(&LinkSharing{ID: 2})
+		assert.Error(t, err)
+		assert.True(t, IsErrTimeTrackingNotAvailableForLinkShare(err))
+	})
+}
+
+func TestTimeEntry_Rights(t *testing.T) {
+	t.Run("owner can update", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		te := &TimeEntry{ID: 1, TaskID: 13}
+		can, err := te.CanUpdate(&user.User{ID: 3})
+		assert.NoError(t, err)
+		assert.True(t, can)
+	})
+	t.Run("wrong task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		te := &TimeEntry{ID: 1, TaskID: 1}
+		_, err := te.CanDelete(&user.User{ID: 1})
+		assert.Error(t, err)
+		assert.True(t, IsErrTimeEntryDoesNotExist(err))
+	})
+	t.Run("nonexisting", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		te := &TimeEntry{ID: 9999, TaskID: 13}
+		_, err := te.CanDelete(&user.User{ID: 3})
+		assert.Error(t, err)
+		assert.True(t, IsErrTimeEntryDoesNotExist(err))
+	})
+	t.Run("no access to the list", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		te := &TimeEntry{ID: 1, TaskID: 13}
+		can, err := te.CanUpdate(&user.User{ID: 13})
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+}
+
+func TestTimeEntryTimer(t *testing.T) {
+	t.Run("start stops the running timer", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		u := &user.User{ID: 3}
+		te, err := StartTimeEntryTimer(u, 13, "coding")
+		assert.NoError(t, err)
+		assert.NotEqual(t, int64(3), te.ID)
+		assert.True(t, te.End.IsZero())
+
+		running, err := GetRunningTimeEntry(u)
+		assert.NoError(t, err)
+		assert.Equal(t, te.ID, running.ID)
+		assert.Equal(t, "coding", running.Note)
+
+		stopped, err := getTimeEntryByID(3)
+		assert.NoError(t, err)
+		assert.False(t, stopped.End.IsZero())
+		assert.True(t, stopped.Duration > 0)
+	})
+	t.Run("stop", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		u := &user.User{ID: 3}
+		te, err := StopTimeEntryTimer(u)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), te.ID)
+		assert.False(t, te.End.IsZero())
+
+		_, err = GetRunningTimeEntry(u)
+		assert.Error(t, err)
+		assert.True(t, IsErrNoRunningTimer(err))
+	})
+	t.Run("stop without a running timer", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		_, err := StopTimeEntryTimer(&user.User{ID: 1})
+		assert.Error(t, err)
+		assert.True(t, IsErrNoRunningTimer(err))
+	})
+	t.Run("no write access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		_, err := StartTimeEntryTimer(&user.User{ID: 3}, 1, "")
+		assert.Error(t, err)
+		assert.True(t, IsErrGenericForbidden(err))
+	})
+}
+
+func TestAddTimeTrackedToTasks(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+
+	taskMap := map[int64]*Task{
+		1:  {ID: 1, ListID: 1},
+		13: {ID: 13, ListID: 2},
+	}
+	err := addTimeTrackedToTasks(taskMap, []int64{1, 2})
+	assert.NoError(t, err)
+
+	assert.Nil(t, taskMap[1].TimeTracked)
+	assert.NotNil(t, taskMap[13].TimeTracked)
+	assert.Equal(t, int64(5400), taskMap[13].TimeTracked.Total)
+	assert.Equal(t, int64(5400), taskMap[13].TimeTracked.ListTotal)
+	assert.Len(t, taskMap[13].TimeTracked.Users, 2)
+	assert.Equal(t, int64(3), taskMap[13].TimeTracked.Users[0].User.ID)
+	assert.Equal(t, int64(3600), taskMap[13].TimeTracked.Users[0].Duration)
+	assert.Equal(t, int64(1), taskMap[13].TimeTracked.Users[1].User.ID)
+	assert.Equal(t, int64(1800), taskMap[13].TimeTracked.Users[1].Duration)
+	assert.Equal(t, "", taskMap[13].TimeTracked.Users[1].User.Email)
+}
+
+func TestGetTimeReport(t *testing.T) {
+	u := &user.User{ID: 3}
+
+	t.Run("all lists", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		report, err := GetTimeReport(u, &TimeReportOptions{})
+		assert.NoError(t, err)
+		assert.Len(t, report.Entries, 2)
+		assert.Equal(t, int64(1), report.Entries[0].ID)
+		assert.Equal(t, "test2-1", report.Entries[0].TaskIdentifier)
+		assert.Equal(t, "Test2", report.Entries[0].ListTitle)
+		assert.Equal(t, int64(5400), report.Total)
+		assert.Len(t, report.Users, 2)
+		assert.Equal(t, int64(3600), report.Users[0].Duration)
+		assert.Len(t, report.Lists, 1)
+		assert.Equal(t, int64(5400), report.Lists[0].Duration)
+	})
+	t.Run("date range", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		report, err := GetTimeReport(u, &TimeReportOptions{
+			From: time.Date(2018, 12, 2, 0, 0, 0, 0, time.UTC),
+			To:   time.Date(2018, 12, 3, 0, 0, 0, 0, time.UTC),
+		})
+		assert.NoError(t, err)
+		assert.Len(t, report.Entries, 1)
+		assert.Equal(t, int64(2), report.Entries[0].ID)
+		assert.Equal(t, int64(1800), report.Total)
+	})
+	t.Run("user", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		report, err := GetTimeReport(u, &TimeReportOptions{ListID: 2, UserID: 3})
+		assert.NoError(t, err)
+		assert.Len(t, report.Entries, 1)
+		assert.Equal(t, int64(3600), report.Total)
+	})
+	t.Run("list without access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		_, err := GetTimeReport(&user.User{ID: 13}, &TimeReportOptions{ListID: 2})
+		assert.Error(t, err)
+		assert.True(t, IsErrGenericForbidden(err))
+	})
+	t.Run("link share", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		_, err := GetTimeReport(&LinkSharing{ID: 1}, &TimeReportOptions{})
+		assert.Error(t, err)
+		assert.True(t, IsErrTimeTrackingNotAvailableForLinkShare(err))
+	})
+	t.Run("csv", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		report, err := GetTimeReport(u, &TimeReportOptions{})
+		assert.NoError(t, err)
+
+		buf := &bytes.Buffer{}
+		err = report.WriteCSV(buf)
+		assert.NoError(t, err)
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		assert.Len(t, lines, 3)
+		assert.True(t, strings.HasPrefix(lines[0], "start,end,duration_seconds"))
+		assert.Contains(t, lines[1], ",3600,1.00,user3,Test2,test2-1,task #13 basic other list,planning")
+	})
+}
+
+func TestEscapeCSVCell(t *testing.T) {
+	assert.Equal(t, "", escapeCSVCell(""))
+	assert.Equal(t, "planning", escapeCSVCell("planning"))
+	assert.Equal(t, "a=b", escapeCSVCell("a=b"))
+	assert.Equal(t, "'=HYPERLINK(\"https://example.com\")", escapeCSVCell("=HYPERLINK(\"https://example.com\")"))
+	assert.Equal(t, "'+1", escapeCSVCell("+1"))
+	assert.Equal(t, "'-1", escapeCSVCell("-1"))
+	assert.Equal(t, "'@SUM(A1)", escapeCSVCell("@SUM(A1)"))
+}
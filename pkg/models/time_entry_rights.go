@@ -0,0 +1,73 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import "code.vikunja.io/web"
+
+// CanRead checks if a user can read a time entry
+func (te *TimeEntry) CanRead(a web.Auth) (bool, int, error) {
+	t := Task{ID: te.TaskID}
+	return t.CanRead(a)
+}
+
+// CanCreate checks if a user can track time on a task
+func (te *TimeEntry) CanCreate(a web.Auth) (bool, error) {
+	if _, is := a.(*LinkSharing); is {
+		return false, ErrTimeTrackingNotAvailableForLinkShare{LinkShareID: a.GetID()}
+	}
+
+	t := Task{ID: te.TaskID}
+	return t.CanWrite(a)
+}
+
+// CanUpdate checks if a user can update a time entry
+func (te *TimeEntry) CanUpdate(a web.Auth) (bool, error) {
+	return te.canDoTimeEntry(a)
+}
+
+// CanDelete checks if a user can delete a time entry
+func (te *TimeEntry) CanDelete(a web.Auth) (bool, error) {
+	return te.canDoTimeEntry(a)
+}
+
+// Only the user who tracked the time can change their entries, as long as they still have write access to the task.
+// List admins can change the entries of everyone, for example to correct them before billing.
+func (te *TimeEntry) canDoTimeEntry(a web.Auth) (bool, error) {
+	if _, is := a.(*LinkSharing); is {
+		return false, ErrTimeTrackingNotAvailableForLinkShare{LinkShareID: a.GetID()}
+	}
+
+	entry, err := getTimeEntryByID(te.ID)
+	if err != nil {
+		return false, err
+	}
+	if entry.TaskID != te.TaskID {
+		return false, ErrTimeEntryDoesNotExist{ID: te.ID, TaskID: te.TaskID}
+	}
+
+	t := &Task{ID: te.TaskID}
+	if entry.UserID == a.GetID() {
+		return t.CanWrite(a)
+	}
+
+	task, err := GetTaskByIDSimple(te.TaskID)
+	if err != nil {
+		return false, err
+	}
+	l := &List{ID: task.ListID}
+	return l.IsAdmin(a)
+}
@@ -59,6 +59,21 @@ func SetupTests() {
 		"users_namespace",
 		"buckets",
 		"saved_filters",
+		"webhooks",
+		"webhook_deliveries",
+		"notifications",
+		"mentions",
+		"activities",
+		"task_revisions",
+		"api_tokens",
+		"sessions",
+		"totp",
+		"totp_recovery_codes",
+		"webauthn_credentials",
+		"time_entries",
+		"custom_fields",
+		"task_custom_field_values",
+		"list_templates",
 	)
 	if err != nil {
 		log.Fatal(err)
@@ -0,0 +1,536 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/files"
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/user"
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// DeleteUser deletes a user and cleans up everything which only belongs to them.
+// All namespaces, lists, teams, labels, saved filters, webhooks and list templates owned by the user are transferred
+// to transferTo. If transferTo is nil, they are deleted instead.
+// Tasks, comments and everything else the user created in lists of other users stays where it is. It belongs to
+// transferTo afterwards or has no creator anymore if the data is not transferred.
+// Transferring data is only meant for admins using the cli, users deleting their own account can't push it onto
+// someone else.
+func DeleteUser(u *user.User, transferTo *user.User) (err error) {
+	if transferTo != nil && transferTo.ID == u.ID {
+		return user.ErrCannotTransferToSameUser{UserID: u.ID}
+	}
+
+	s := x.NewSession()
+	defer s.Close()
+	if err = s.Begin(); err != nil {
+		return
+	}
+
+	var deletedEvents []events.Event
+	if transferTo != nil {
+		err = transferOwnedData(s, u, transferTo)
+	} else {
+		deletedEvents, err = deleteOwnedData(s, u)
+	}
+	if err != nil {
+		_ = s.Rollback()
+		return
+	}
+
+	err = deleteUserReferences(s, u, transferTo)
+	if err != nil {
+		_ = s.Rollback()
+		return
+	}
+
+	fileIDs, err := deleteUserFiles(s, u, transferTo)
+	if err != nil {
+		_ = s.Rollback()
+		return
+	}
+
+	err = user.DeleteUser(s, u)
+	if err != nil {
+		_ = s.Rollback()
+		return
+	}
+
+	if err = s.Commit(); err != nil {
+		return
+	}
+
+	// Files are not part of the transaction, so they are only removed once everything else is gone.
+	// The user does not exist anymore at this point, a file which could not be removed is not a reason to fail.
+	for _, fileID := range fileIDs {
+		f := &files.File{ID: fileID}
+		err = f.Delete()
+		if err != nil && !files.IsErrFileDoesNotExist(err) {
+			log.Errorf("Could not delete file %d of deleted user %d: %s", fileID, u.ID, err)
+		}
+	}
+
+	for _, e := range deletedEvents {
+		events.Dispatch(e)
+	}
+	events.Dispatch(&user.DeletedEvent{User: u})
+	return nil
+}
+
+// CheckUserDataTransfer checks if a user who deletes their own account can transfer their data to another user.
+// This is only possible if the other user already has admin access to all namespaces, lists and teams of the user,
+// nobody should end up with data they never had access to.
+func CheckUserDataTransfer(u *user.User, transferTo *user.User) (err error) {
+	if transferTo.ID == u.ID {
+		return user.ErrCannotTransferToSameUser{UserID: u.ID}
+	}
+
+	namespaces := []*Namespace{}
+	err = x.Where("owner_id = ?", u.ID).Find(&namespaces)
+	if err != nil {
+		return
+	}
+	for _, n := range namespaces {
+		is, err := n.IsAdmin(transferTo)
+		if err != nil {
+			return err
+		}
+		if !is {
+			return user.ErrCannotTransferToUser{UserID: u.ID, TransferToID: transferTo.ID}
+		}
+	}
+
+	lists := []*List{}
+	err = x.Where("owner_id = ?", u.ID).Find(&lists)
+	if err != nil {
+		return
+	}
+	for _, l := range lists {
+		is, err := l.IsAdmin(transferTo)
+		if err != nil {
+			return err
+		}
+		if !is {
+			return user.ErrCannotTransferToUser{UserID: u.ID, TransferToID: transferTo.ID}
+		}
+	}
+
+	teams := []*Team{}
+	err = x.Where("created_by_id = ?", u.ID).Find(&teams)
+	if err != nil {
+		return
+	}
+	for _, t := range teams {
+		is, err := t.IsAdmin(transferTo)
+		if err != nil {
+			return err
+		}
+		if !is {
+			return user.ErrCannotTransferToUser{UserID: u.ID, TransferToID: transferTo.ID}
+		}
+	}
+
+	return nil
+}
+
+func transferOwnedData(s *xorm.Session, u *user.User, transferTo *user.User) (err error) {
+	_, err = s.
+		Where("owner_id = ?", u.ID).
+		Cols("owner_id").
+		NoAutoTime().
+		Update(&Namespace{OwnerID: transferTo.ID})
+	if err != nil {
+		return
+	}
+
+	_, err = s.
+		Where("owner_id = ?", u.ID).
+		Cols("owner_id").
+		NoAutoTime().
+		Update(&List{OwnerID: transferTo.ID})
+	if err != nil {
+		return
+	}
+
+	_, err = s.
+		Where("owner_id = ?", u.ID).
+		Cols("owner_id").
+		NoAutoTime().
+		Update(&SavedFilter{OwnerID: transferTo.ID})
+	if err != nil {
+		return
+	}
+
+	_, err = s.
+		Where("owner_id = ?", u.ID).
+		Cols("owner_id").
+		NoAutoTime().
+		Update(&ListTemplate{OwnerID: transferTo.ID})
+	if err != nil {
+		return
+	}
+
+	_, err = s.
+		Where("created_by_id = ?", u.ID).
+		Cols("created_by_id").
+		NoAutoTime().
+		Update(&Label{CreatedByID: transferTo.ID})
+	if err != nil {
+		return
+	}
+
+	_, err = s.
+		Where("created_by_id = ?", u.ID).
+		Cols("created_by_id").
+		NoAutoTime().
+		Update(&Webhook{CreatedByID: transferTo.ID})
+	if err != nil {
+		return
+	}
+
+	teams := []*Team{}
+	err = s.Where("created_by_id = ?", u.ID).Find(&teams)
+	if err != nil {
+		return
+	}
+
+	for _, t := range teams {
+		_, err = s.
+			Where("id = ?", t.ID).
+			Cols("created_by_id").
+			NoAutoTime().
+			Update(&Team{CreatedByID: transferTo.ID})
+		if err != nil {
+			return
+		}
+
+		// The new owner of a team needs to be able to manage it
+		err = ensureTeamAdmin(s, t.ID, transferTo)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+func ensureTeamAdmin(s *xorm.Session, teamID int64, u *user.User) (err error) {
+	member := &TeamMember{}
+	exists, err := s.Where("team_id = ? AND user_id = ?", teamID, u.ID).Get(member)
+	if err != nil {
+		return
+	}
+
+	if !exists {
+		_, err = s.Insert(&TeamMember{TeamID: teamID, UserID: u.ID, Admin: true})
+		return
+	}
+
+	if member.Admin {
+		return
+	}
+
+	_, err = s.
+		Where("id = ?", member.ID).
+		Cols("admin").
+		Update(&TeamMember{Admin: true})
+	return
+}
+
+// Deletes everything the user owns and returns the events to dispatch once the deletion went through.
+func deleteOwnedData(s *xorm.Session, u *user.User) (deletedEvents []events.Event, err error) {
+	namespaces := []*Namespace{}
+	err = s.Where("owner_id = ?", u.ID).Find(&namespaces)
+	if err != nil {
+		return
+	}
+	namespaceIDs := make([]int64, 0, len(namespaces))
+	for _, n := range namespaces {
+		namespaceIDs = append(namespaceIDs, n.ID)
+		deletedEvents = append(deletedEvents, &NamespaceDeletedEvent{Namespace: n})
+	}
+
+	// Lists in namespaces of the user are deleted with them, even if someone else created them
+	lists := []*List{}
+	err = s.
+		Where(builder.Or(
+			builder.Eq{"owner_id": u.ID},
+			builder.In("namespace_id", namespaceIDs),
+		)).
+		Find(&lists)
+	if err != nil {
+		return
+	}
+	listIDs := make([]int64, 0, len(lists))
+	for _, l := range lists {
+		listIDs = append(listIDs, l.ID)
+		deletedEvents = append(deletedEvents, &ListDeletedEvent{List: l})
+	}
+
+	if len(namespaceIDs) > 0 {
+		_, err = s.In("id", namespaceIDs).Delete(&Namespace{})
+		if err != nil {
+			return
+		}
+	}
+
+	err = deleteLists(s, listIDs)
+	if err != nil {
+		return
+	}
+
+	err = deleteWebhooksForListsAndNamespaces(s, nil, namespaceIDs)
+	if err != nil {
+		return
+	}
+
+	teams := []*Team{}
+	err = s.Where("created_by_id = ?", u.ID).Find(&teams)
+	if err != nil {
+		return
+	}
+	if len(teams) > 0 {
+		teamIDs := make([]int64, 0, len(teams))
+		for _, t := range teams {
+			teamIDs = append(teamIDs, t.ID)
+			deletedEvents = append(deletedEvents, &TeamDeletedEvent{Team: t})
+		}
+		_, err = s.In("team_id", teamIDs).Delete(&TeamMember{})
+		if err != nil {
+			return
+		}
+		_, err = s.In("team_id", teamIDs).Delete(&TeamNamespace{})
+		if err != nil {
+			return
+		}
+		_, err = s.In("team_id", teamIDs).Delete(&TeamList{})
+		if err != nil {
+			return
+		}
+		_, err = s.In("id", teamIDs).Delete(&Team{})
+		if err != nil {
+			return
+		}
+	}
+
+	labels := []*Label{}
+	err = s.Where("created_by_id = ?", u.ID).Find(&labels)
+	if err != nil {
+		return
+	}
+	if len(labels) > 0 {
+		labelIDs := make([]int64, 0, len(labels))
+		for _, l := range labels {
+			labelIDs = append(labelIDs, l.ID)
+		}
+		_, err = s.In("label_id", labelIDs).Delete(&LabelTask{})
+		if err != nil {
+			return
+		}
+		_, err = s.In("id", labelIDs).Delete(&Label{})
+		if err != nil {
+			return
+		}
+	}
+
+	// Webhooks the user created for lists of other users would still send data to wherever the user told them to
+	_, err = s.
+		In("webhook_id", builder.Select("id").From("webhooks").Where(builder.Eq{"created_by_id": u.ID})).
+		Delete(&WebhookDelivery{})
+	if err != nil {
+		return
+	}
+	_, err = s.Where("created_by_id = ?", u.ID).Delete(&Webhook{})
+	if err != nil {
+		return
+	}
+
+	_, err = s.Where("owner_id = ?", u.ID).Delete(&SavedFilter{})
+	if err != nil {
+		return
+	}
+
+	_, err = s.Where("owner_id = ?", u.ID).Delete(&ListTemplate{})
+	return
+}
+
+// Removes everything referencing the user which does not make sense without them and hands over everything
+// else they created to transferTo. If there is no one to transfer it to, it is kept without a creator.
+func deleteUserReferences(s *xorm.Session, u *user.User, transferTo *user.User) (err error) {
+	_, err = s.Where("user_id = ?", u.ID).Delete(&TaskAssginee{})
+	if err != nil {
+		return
+	}
+
+	_, err = s.Where("user_id = ?", u.ID).Delete(&TeamMember{})
+	if err != nil {
+		return
+	}
+
+	_, err = s.Where("user_id = ?", u.ID).Delete(&ListUser{})
+	if err != nil {
+		return
+	}
+
+	_, err = s.Where("user_id = ?", u.ID).Delete(&NamespaceUser{})
+	if err != nil {
+		return
+	}
+
+	_, err = s.Where("shared_by_id = ?", u.ID).Delete(&LinkSharing{})
+	if err != nil {
+		return
+	}
+
+	_, err = s.Where("owner_id = ?", u.ID).Delete(&APIToken{})
+	if err != nil {
+		return
+	}
+
+	_, err = s.Where("user_id = ?", u.ID).Delete(&Notification{})
+	if err != nil {
+		return
+	}
+
+	_, err = s.Where("user_id = ?", u.ID).Delete(&Mention{})
+	if err != nil {
+		return
+	}
+
+	_, err = s.Where("user_id = ?", u.ID).Delete(&TimeEntry{})
+	if err != nil {
+		return
+	}
+
+	// Remove the user from all user custom fields they were chosen in
+	_, err = s.
+		In("field_id", builder.Select("id").From("custom_fields").Where(builder.Eq{"type": CustomFieldTypeUser})).
+		And("value_number = ?", u.ID).
+		Delete(&TaskCustomFieldValue{})
+	if err != nil {
+		return
+	}
+
+	var newCreatorID int64
+	if transferTo != nil {
+		newCreatorID = transferTo.ID
+	}
+
+	_, err = s.
+		Where("created_by_id = ?", u.ID).
+		Cols("created_by_id").
+		NoAutoTime().
+		Update(&Task{CreatedByID: newCreatorID})
+	if err != nil {
+		return
+	}
+
+	_, err = s.
+		Where("author_id = ?", u.ID).
+		Cols("author_id").
+		NoAutoTime().
+		Update(&TaskComment{AuthorID: newCreatorID})
+	if err != nil {
+		return
+	}
+
+	_, err = s.
+		Where("created_by_id = ?", u.ID).
+		Cols("created_by_id").
+		NoAutoTime().
+		Update(&Bucket{CreatedByID: newCreatorID})
+	if err != nil {
+		return
+	}
+
+	_, err = s.
+		Where("created_by_id = ?", u.ID).
+		Cols("created_by_id").
+		NoAutoTime().
+		Update(&TaskRelation{CreatedByID: newCreatorID})
+	if err != nil {
+		return
+	}
+
+	_, err = s.
+		Where("created_by_id = ?", u.ID).
+		Cols("created_by_id").
+		NoAutoTime().
+		Update(&TaskRevision{CreatedByID: newCreatorID})
+	if err != nil {
+		return
+	}
+
+	_, err = s.
+		Where("created_by_id = ?", u.ID).
+		Cols("created_by_id").
+		NoAutoTime().
+		Update(&Mention{CreatedByID: newCreatorID})
+	if err != nil {
+		return
+	}
+
+	_, err = s.
+		Where("doer_id = ?", u.ID).
+		Cols("doer_id").
+		NoAutoTime().
+		Update(&Activity{DoerID: newCreatorID})
+	return
+}
+
+// Removes the attachments the user uploaded and returns the ids of all files which need to be deleted together with
+// the avatar and the data export of the user. If the data of the user is transferred to another user, the attachments
+// are kept and belong to that user afterwards.
+func deleteUserFiles(s *xorm.Session, u *user.User, transferTo *user.User) (fileIDs []int64, err error) {
+	for _, fileID := range []int64{u.AvatarFileID, u.ExportFileID} {
+		if fileID != 0 {
+			fileIDs = append(fileIDs, fileID)
+		}
+	}
+
+	if transferTo != nil {
+		_, err = s.
+			Where("created_by_id = ?", u.ID).
+			Cols("created_by_id").
+			Update(&TaskAttachment{CreatedByID: transferTo.ID})
+		if err != nil {
+			return
+		}
+
+		_, err = s.
+			Where("created_by_id = ?", u.ID).
+			Cols("created_by_id").
+			NoAutoTime().
+			Update(&files.File{CreatedByID: transferTo.ID})
+		return
+	}
+
+	attachments := []*TaskAttachment{}
+	err = s.Where("created_by_id = ?", u.ID).Find(&attachments)
+	if err != nil {
+		return
+	}
+
+	for _, a := range attachments {
+		fileIDs = append(fileIDs, a.FileID)
+	}
+
+	_, err = s.Where("created_by_id = ?", u.ID).Delete(&TaskAttachment{})
+	return
+}
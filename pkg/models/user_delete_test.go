@@ -0,0 +1,243 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/files"
+	"code.vikunja.io/api/pkg/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeleteUser(t *testing.T) {
+	t.Run("transfer data", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		files.InitTestFileFixtures(t)
+		u := &user.User{ID: 1}
+		transferTo := &user.User{ID: 2}
+
+		err := DeleteUser(u, transferTo)
+		assert.NoError(t, err)
+
+		db.AssertMissing(t, "users", map[string]interface{}{
+			"id": 1,
+		})
+		db.AssertExists(t, "namespaces", map[string]interface{}{
+			"id":       1,
+			"owner_id": 2,
+		}, false)
+		db.AssertExists(t, "list", map[string]interface{}{
+			"id":       1,
+			"owner_id": 2,
+		}, false)
+		db.AssertExists(t, "saved_filters", map[string]interface{}{
+			"id":       1,
+			"owner_id": 2,
+		}, false)
+		db.AssertExists(t, "labels", map[string]interface{}{
+			"id":            1,
+			"created_by_id": 2,
+		}, false)
+		db.AssertExists(t, "teams", map[string]interface{}{
+			"id":            1,
+			"created_by_id": 2,
+		}, false)
+		// user 2 was already a member of team 1 but not an admin
+		db.AssertExists(t, "team_members", map[string]interface{}{
+			"team_id": 1,
+			"user_id": 2,
+			"admin":   true,
+		}, false)
+		// and was no member of team 2 before
+		db.AssertExists(t, "team_members", map[string]interface{}{
+			"team_id": 2,
+			"user_id": 2,
+			"admin":   true,
+		}, false)
+		db.AssertExists(t, "task_attachments", map[string]interface{}{
+			"id":            1,
+			"created_by_id": 2,
+		}, false)
+		db.AssertMissing(t, "team_members", map[string]interface{}{
+			"user_id": 1,
+		})
+		db.AssertMissing(t, "task_assignees", map[string]interface{}{
+			"user_id": 1,
+		})
+		db.AssertMissing(t, "link_sharing", map[string]interface{}{
+			"shared_by_id": 1,
+		})
+		db.AssertMissing(t, "sessions", map[string]interface{}{
+			"user_id": 1,
+		})
+		// Things the user created in lists of other users belong to the new owner
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"id":            32,
+			"created_by_id": 2,
+		}, false)
+		db.AssertExists(t, "task_comments", map[string]interface{}{
+			"id":        15,
+			"author_id": 2,
+		}, false)
+		db.AssertExists(t, "buckets", map[string]interface{}{
+			"id":            21,
+			"created_by_id": 2,
+		}, false)
+		db.AssertExists(t, "webhooks", map[string]interface{}{
+			"id":            1,
+			"created_by_id": 2,
+		}, false)
+		db.AssertMissing(t, "activities", map[string]interface{}{
+			"doer_id": 1,
+		})
+	})
+	t.Run("transfer to the same user", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		u := &user.User{ID: 1}
+
+		err := DeleteUser(u, u)
+		assert.Error(t, err)
+		assert.True(t, user.IsErrCannotTransferToSameUser(err))
+		db.AssertExists(t, "users", map[string]interface{}{
+			"id": 1,
+		}, false)
+	})
+	t.Run("delete data", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		files.InitTestFileFixtures(t)
+		u := &user.User{ID: 1}
+
+		err := DeleteUser(u, nil)
+		assert.NoError(t, err)
+
+		db.AssertMissing(t, "users", map[string]interface{}{
+			"id": 1,
+		})
+		db.AssertMissing(t, "namespaces", map[string]interface{}{
+			"owner_id": 1,
+		})
+		db.AssertMissing(t, "list", map[string]interface{}{
+			"owner_id": 1,
+		})
+		// Lists of other users in a namespace of the deleted user are deleted with the namespace
+		db.AssertMissing(t, "list", map[string]interface{}{
+			"id": 2,
+		})
+		db.AssertMissing(t, "saved_filters", map[string]interface{}{
+			"owner_id": 1,
+		})
+		db.AssertMissing(t, "labels", map[string]interface{}{
+			"created_by_id": 1,
+		})
+		db.AssertMissing(t, "teams", map[string]interface{}{
+			"created_by_id": 1,
+		})
+		db.AssertMissing(t, "task_attachments", map[string]interface{}{
+			"created_by_id": 1,
+		})
+		db.AssertMissing(t, "files", map[string]interface{}{
+			"id": 1,
+		})
+		db.AssertMissing(t, "link_sharing", map[string]interface{}{
+			"shared_by_id": 1,
+		})
+		db.AssertMissing(t, "webhooks", map[string]interface{}{
+			"created_by_id": 1,
+		})
+		// Things the user created in lists of other users are kept without a creator
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"id":            32,
+			"created_by_id": 0,
+		}, false)
+		db.AssertExists(t, "task_comments", map[string]interface{}{
+			"id":        15,
+			"author_id": 0,
+		}, false)
+		db.AssertExists(t, "buckets", map[string]interface{}{
+			"id":            21,
+			"created_by_id": 0,
+		}, false)
+		db.AssertMissing(t, "activities", map[string]interface{}{
+			"doer_id": 1,
+		})
+		db.AssertMissing(t, "task_revisions", map[string]interface{}{
+			"created_by_id": 1,
+		})
+	})
+	t.Run("other users keep their data", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		files.InitTestFileFixtures(t)
+
+		err := DeleteUser(&user.User{ID: 1}, nil)
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "list", map[string]interface{}{
+			"id":       3,
+			"owner_id": 3,
+		}, false)
+		db.AssertExists(t, "users", map[string]interface{}{
+			"id": 2,
+		}, false)
+	})
+}
+
+func TestCheckUserDataTransfer(t *testing.T) {
+	t.Run("transfer to a user without admin access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+
+		err := CheckUserDataTransfer(&user.User{ID: 1}, &user.User{ID: 2})
+		assert.Error(t, err)
+		assert.True(t, user.IsErrCannotTransferToUser(err))
+	})
+	t.Run("transfer to the same user", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+
+		err := CheckUserDataTransfer(&user.User{ID: 1}, &user.User{ID: 1})
+		assert.Error(t, err)
+		assert.True(t, user.IsErrCannotTransferToSameUser(err))
+	})
+	t.Run("transfer to a user with admin access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+
+		namespaces := []*Namespace{}
+		err := x.Where("owner_id = ?", 1).Find(&namespaces)
+		assert.NoError(t, err)
+		for _, n := range namespaces {
+			_, err = x.Insert(&NamespaceUser{UserID: 2, NamespaceID: n.ID, Right: RightAdmin})
+			assert.NoError(t, err)
+		}
+		lists := []*List{}
+		err = x.Where("owner_id = ?", 1).Find(&lists)
+		assert.NoError(t, err)
+		for _, l := range lists {
+			_, err = x.Insert(&ListUser{UserID: 2, ListID: l.ID, Right: RightAdmin})
+			assert.NoError(t, err)
+		}
+		teams := []*Team{}
+		err = x.Where("created_by_id = ?", 1).Find(&teams)
+		assert.NoError(t, err)
+		for _, team := range teams {
+			_, err = x.Insert(&TeamMember{UserID: 2, TeamID: team.ID, Admin: true})
+			assert.NoError(t, err)
+		}
+
+		err = CheckUserDataTransfer(&user.User{ID: 1}, &user.User{ID: 2})
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,382 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/api/pkg/utils"
+	"code.vikunja.io/api/pkg/version"
+	"code.vikunja.io/web"
+)
+
+// The time to wait before the first retry of a failed webhook delivery. Every following retry waits twice as long as the previous one.
+var webhookRetryBaseDelay = 10 * time.Second
+
+// Only this many bytes of the response of the receiver are stored in the delivery log.
+const webhookMaxResponseBodyLength = 1024
+
+// WebhookDelivery holds the result of a single attempt to deliver a webhook
+type WebhookDelivery struct {
+	// The unique, numeric id of this delivery attempt.
+	ID int64 `xorm:"int(11) autoincr not null unique pk" json:"id"`
+	// The webhook this delivery belongs to.
+	WebhookID int64 `xorm:"int(11) not null INDEX" json:"webhook_id" param:"webhook"`
+	// All attempts to deliver the same event share the same delivery uid. It is sent to the receiver in the X-Vikunja-Delivery header.
+	DeliveryUID string `xorm:"varchar(40) not null INDEX" json:"delivery_uid"`
+	// The name of the event which triggered the webhook.
+	EventName string `xorm:"varchar(250) not null" json:"event_name"`
+	// Which attempt this was. Starts with 1.
+	Attempt int `xorm:"int(11) not null" json:"attempt"`
+	// The json payload sent to the receiver.
+	Payload string `xorm:"longtext not null" json:"payload"`
+	// The http status code the receiver responded with. 0 if there was no response at all.
+	StatusCode int `xorm:"int(11) not null default 0" json:"status_code"`
+	// The first 1024 bytes of the response body.
+	ResponseBody string `xorm:"longtext null" json:"response_body"`
+	// If the request could not be sent at all, this contains the reason.
+	Error string `xorm:"text null" json:"error"`
+	// Whether the receiver responded with a 2xx status code.
+	Success bool `xorm:"not null default false" json:"success"`
+
+	// A timestamp when this delivery was attempted.
+	Created time.Time `xorm:"created not null" json:"created"`
+
+	// Used to check the rights for the list or namespace the delivery log was requested with.
+	ListID      int64 `xorm:"-" json:"-" param:"list"`
+	NamespaceID int64 `xorm:"-" json:"-" param:"namespace"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TableName returns the table name for webhook deliveries
+func (wd *WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// ReadAll returns the delivery log of a webhook
+// @Summary Get the delivery log of a webhook
+// @Description Returns all delivery attempts of a webhook, newest first. The user needs to be admin of the list the webhook belongs to.
+// @tags webhooks
+// @Produce json
+// @Security JWTKeyAuth
+// @Param list path int true "List ID"
+// @Param webhook path int true "Webhook ID"
+// @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
+// @Param per_page query int false "The maximum number of items per page. Note this parameter is limited by the configured maximum of items per page."
+// @Success 200 {array} models.WebhookDelivery "The delivery attempts"
+// @Failure 403 {object} web.HTTPError "The user does not have admin access to the list"
+// @Failure 404 {object} web.HTTPError "The webhook does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /lists/{list}/webhooks/{webhook}/deliveries [get]
+func (wd *WebhookDelivery) ReadAll(a web.Auth, search string, page int, perPage int) (result interface{}, resultCount int, totalItems int64, err error) {
+	w := &Webhook{ID: wd.WebhookID, ListID: wd.ListID, NamespaceID: wd.NamespaceID}
+	can, err := w.canDoExistingWebhook(a)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if !can {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	limit, start := getLimitFromPageIndex(page, perPage)
+
+	deliveries := []*WebhookDelivery{}
+	query := x.
+		Where("webhook_id = ?", wd.WebhookID).
+		OrderBy("id desc")
+	if limit > 0 {
+		query = query.Limit(limit, start)
+	}
+	err = query.Find(&deliveries)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	totalItems, err = x.
+		Where("webhook_id = ?", wd.WebhookID).
+		Count(&WebhookDelivery{})
+	return deliveries, len(deliveries), totalItems, err
+}
+
+// webhookPayload is the body of every webhook request
+type webhookPayload struct {
+	EventName string      `json:"event_name"`
+	Time      time.Time   `json:"time"`
+	Data      interface{} `json:"data"`
+}
+
+// webhookEventData is the data of an event sent to webhook receivers. It only contains what receivers need to know,
+// all users are reduced to their id and username.
+type webhookEventData struct {
+	Task *Task `json:"task,omitempty"`
+	// The task with the values it had before it was updated. Only set for task.updated.
+	OldTask  *Task        `json:"old_task,omitempty"`
+	Comment  *TaskComment `json:"comment,omitempty"`
+	Assignee *user.User   `json:"assignee,omitempty"`
+	List     *List        `json:"list,omitempty"`
+	User     *user.User   `json:"user,omitempty"`
+	Team     *Team        `json:"team,omitempty"`
+	// The user who triggered the event. Empty if it was done through a link share.
+	Doer *user.User `json:"doer,omitempty"`
+}
+
+func newWebhookTask(t *Task) *Task {
+	if t == nil {
+		return nil
+	}
+	task := newRealtimeTask(t)
+	if t.Attachments != nil {
+		task.Attachments = make([]*TaskAttachment, 0, len(t.Attachments))
+		for _, a := range t.Attachments {
+			attachment := *a
+			attachment.CreatedBy = newRealtimeUser(a.CreatedBy)
+			task.Attachments = append(task.Attachments, &attachment)
+		}
+	}
+	return task
+}
+
+func newWebhookList(l *List) *List {
+	if l == nil {
+		return nil
+	}
+	list := *l
+	list.Owner = newRealtimeUser(l.Owner)
+	return &list
+}
+
+// Builds the data sent to webhook receivers for an event
+func newWebhookEventData(event events.Event) *webhookEventData {
+	var doer web.Auth
+	data := &webhookEventData{}
+
+	switch e := event.(type) {
+	case *TaskCreatedEvent:
+		data.Task, doer = newWebhookTask(e.Task), e.Doer
+	case *TaskUpdatedEvent:
+		data.Task, doer = newWebhookTask(e.Task), e.Doer
+		data.OldTask = newWebhookTask(e.OldTask)
+	case *TaskDeletedEvent:
+		data.Task, doer = newWebhookTask(e.Task), e.Doer
+	case *TaskAssigneeCreatedEvent:
+		data.Task, doer = newWebhookTask(e.Task), e.Doer
+		data.Assignee = newRealtimeUser(e.Assignee)
+	case *TaskCommentCreatedEvent:
+		data.Task, doer = newWebhookTask(e.Task), e.Doer
+		if e.Comment != nil {
+			comment := *e.Comment
+			comment.Author = newRealtimeUser(e.Comment.Author)
+			data.Comment = &comment
+		}
+	case *ListSharedWithUserEvent:
+		doer = e.Doer
+		data.List = newWebhookList(e.List)
+		data.User = newRealtimeUser(e.User)
+	case *ListSharedWithTeamEvent:
+		doer = e.Doer
+		data.List = newWebhookList(e.List)
+		if e.Team != nil {
+			data.Team = &Team{
+				ID:          e.Team.ID,
+				Name:        e.Team.Name,
+				Description: e.Team.Description,
+				CreatedBy:   newRealtimeUser(e.Team.CreatedBy),
+				Created:     e.Team.Created,
+				Updated:     e.Team.Updated,
+			}
+		}
+	}
+
+	data.Doer = newNotificationDoer(doer)
+	return data
+}
+
+// Returns an http client which refuses to connect to non-public addresses, unless that is explicitly allowed.
+// The check happens after the host name of the target was resolved so a public host name pointing to an internal
+// address can't be used to get around it.
+func newWebhookHTTPClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: time.Duration(config.WebhooksTimeoutSeconds.GetInt64()) * time.Second,
+	}
+	if !config.WebhooksAllowNonPublicTargets.GetBool() {
+		dialer.Control = func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || isNonPublicIP(ip) {
+				return fmt.Errorf("webhook target %s is not a public address", host)
+			}
+			return nil
+		}
+	}
+
+	return &http.Client{
+		Timeout: time.Duration(config.WebhooksTimeoutSeconds.GetInt64()) * time.Second,
+		Transport: &http.Transport{
+			DialContext: dialer.DialContext,
+		},
+	}
+}
+
+// Calculates the signature of a payload. Receivers can verify a request came from Vikunja by calculating
+// the HMAC-SHA256 of the request body with the secret of the webhook and comparing it to the one in the
+// X-Vikunja-Signature header.
+func signWebhookPayload(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Sends the payload once and returns the result of that attempt.
+func (w *Webhook) sendPayload(client *http.Client, deliveryUID, eventName string, payload []byte) (delivery *WebhookDelivery) {
+	delivery = &WebhookDelivery{
+		WebhookID:   w.ID,
+		DeliveryUID: deliveryUID,
+		EventName:   eventName,
+		Payload:     string(payload),
+	}
+
+	req, err := http.NewRequest(http.MethodPost, w.TargetURL, bytes.NewReader(payload))
+	if err != nil {
+		delivery.Error = err.Error()
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Vikunja/"+version.Version)
+	req.Header.Set("X-Vikunja-Event", eventName)
+	req.Header.Set("X-Vikunja-Delivery", deliveryUID)
+	req.Header.Set("X-Vikunja-Signature", signWebhookPayload(w.Secret, payload))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		delivery.Error = err.Error()
+		return
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, webhookMaxResponseBodyLength))
+	if err != nil {
+		delivery.Error = err.Error()
+	}
+
+	delivery.StatusCode = resp.StatusCode
+	delivery.ResponseBody = string(body)
+	delivery.Success = resp.StatusCode >= 200 && resp.StatusCode < 300
+	return
+}
+
+// Delivers an event to the webhook's target url. If the delivery fails, it is retried with an exponential backoff
+// until the configured maximum number of retries is reached. Every attempt is saved in the delivery log.
+// This blocks until the webhook was delivered or all retries failed and should therefore be called in a goroutine.
+func (w *Webhook) deliver(event events.Event) (delivered bool, err error) {
+	eventName := event.Name()
+	payload, err := json.Marshal(&webhookPayload{
+		EventName: eventName,
+		Time:      time.Now(),
+		Data:      newWebhookEventData(event),
+	})
+	if err != nil {
+		return false, err
+	}
+
+	client := newWebhookHTTPClient()
+	deliveryUID := utils.MakeRandomString(40)
+	maxAttempts := config.WebhooksMaxRetries.GetInt() + 1
+
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if attempt > 1 {
+			time.Sleep(webhookRetryBaseDelay * time.Duration(1<<uint(attempt-2)))
+		}
+
+		delivery := w.sendPayload(client, deliveryUID, eventName, payload)
+		delivery.Attempt = attempt
+		if _, err := x.Insert(delivery); err != nil {
+			return false, err
+		}
+
+		if delivery.Success {
+			return true, nil
+		}
+
+		log.Debugf("[Webhooks] Delivery %s of webhook %d failed (attempt %d of %d), status: %d, error: %s", deliveryUID, w.ID, attempt, maxAttempts, delivery.StatusCode, delivery.Error)
+	}
+
+	return false, nil
+}
+
+// Returns all webhooks which want to be notified about an event on the list.
+// This includes the webhooks of the list itself and those of the namespace the list is in.
+func getWebhooksForListEvent(listID int64, eventName string) (webhooks []*Webhook, err error) {
+	l := &List{ID: listID}
+	if err := l.GetSimpleByID(); err != nil {
+		return nil, err
+	}
+
+	all := []*Webhook{}
+	err = x.
+		Where("list_id = ? OR namespace_id = ?", l.ID, l.NamespaceID).
+		Find(&all)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, w := range all {
+		if w.listensFor(eventName) {
+			webhooks = append(webhooks, w)
+		}
+	}
+	return
+}
+
+// Returns the id of the list an event happened in
+func getListIDForWebhookEvent(event interface{}) int64 {
+	switch e := event.(type) {
+	case *TaskCreatedEvent:
+		return e.Task.ListID
+	case *TaskUpdatedEvent:
+		return e.Task.ListID
+	case *TaskDeletedEvent:
+		return e.Task.ListID
+	case *TaskAssigneeCreatedEvent:
+		return e.Task.ListID
+	case *TaskCommentCreatedEvent:
+		return e.Task.ListID
+	case *ListSharedWithUserEvent:
+		return e.List.ID
+	case *ListSharedWithTeamEvent:
+		return e.List.ID
+	}
+	return 0
+}
@@ -0,0 +1,384 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/api/pkg/utils"
+	"code.vikunja.io/web"
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// Webhook represents a webhook which is called every time one of its events happens on its list
+// or on any list in its namespace.
+type Webhook struct {
+	// The unique, numeric id of this webhook.
+	ID int64 `xorm:"int(11) autoincr not null unique pk" json:"id" param:"webhook"`
+	// The url Vikunja will send a POST request to every time one of the webhook's events happens.
+	TargetURL string `xorm:"varchar(500) not null" json:"target_url" valid:"required,runelength(1|500)" minLength:"1" maxLength:"500"`
+	// The events this webhook is triggered for. Check the /webhooks/events endpoint for a list of all available events.
+	Events []string `xorm:"JSON not null" json:"events"`
+	// The secret used to sign all requests to the target url with HMAC-SHA256. If you don't provide one, Vikunja will
+	// generate one for you. The secret is only returned when creating the webhook.
+	Secret string `xorm:"varchar(250) not null" json:"secret,omitempty" valid:"runelength(0|250)" maxLength:"250"`
+	// The list this webhook belongs to. Either this or the namespace id is set, never both.
+	ListID int64 `xorm:"int(11) null INDEX" json:"list_id" param:"list"`
+	// The namespace this webhook belongs to. A namespace webhook is triggered for events of all lists in that namespace.
+	NamespaceID int64 `xorm:"int(11) null INDEX" json:"namespace_id" param:"namespace"`
+
+	CreatedByID int64 `xorm:"int(11) not null" json:"-"`
+	// The user who created this webhook.
+	CreatedBy *user.User `xorm:"-" json:"created_by" valid:"-"`
+
+	// A timestamp when this webhook was created. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+	// A timestamp when this webhook was last updated. You cannot change this value.
+	Updated time.Time `xorm:"updated not null" json:"updated"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TableName returns the table name for webhooks
+func (w *Webhook) TableName() string {
+	return "webhooks"
+}
+
+// All events a webhook can listen for.
+var availableWebhookEvents = map[string]bool{
+	(&TaskCreatedEvent{}).Name():         true,
+	(&TaskUpdatedEvent{}).Name():         true,
+	(&TaskDeletedEvent{}).Name():         true,
+	(&TaskAssigneeCreatedEvent{}).Name(): true,
+	(&TaskCommentCreatedEvent{}).Name():  true,
+	(&ListSharedWithUserEvent{}).Name():  true,
+	(&ListSharedWithTeamEvent{}).Name():  true,
+}
+
+// GetAvailableWebhookEvents returns the names of all events a webhook can be triggered for.
+func GetAvailableWebhookEvents() (eventNames []string) {
+	eventNames = make([]string, 0, len(availableWebhookEvents))
+	for name := range availableWebhookEvents {
+		eventNames = append(eventNames, name)
+	}
+	sort.Strings(eventNames)
+	return
+}
+
+// Address ranges which are not reachable from the public internet. Webhooks must not be able to send requests
+// to services in the network Vikunja runs in.
+var nonPublicIPNets = func() (nets []*net.IPNet) {
+	for _, cidr := range []string{
+		"0.0.0.0/8",
+		"10.0.0.0/8",
+		"100.64.0.0/10",
+		"127.0.0.0/8",
+		"169.254.0.0/16",
+		"172.16.0.0/12",
+		"192.168.0.0/16",
+		"::1/128",
+		"fc00::/7",
+		"fe80::/10",
+	} {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets = append(nets, n)
+	}
+	return
+}()
+
+func isNonPublicIP(ip net.IP) bool {
+	if ip.IsUnspecified() || ip.IsMulticast() {
+		return true
+	}
+	for _, n := range nonPublicIPNets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Checks whether a host name is obviously not public. Host names resolving to a non-public address are rejected
+// when the webhook is delivered.
+func isNonPublicHost(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && isNonPublicIP(ip)
+}
+
+func (w *Webhook) validate() error {
+	u, err := url.Parse(w.TargetURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return ErrWebhookTargetURLInvalid{TargetURL: w.TargetURL}
+	}
+	if !config.WebhooksAllowNonPublicTargets.GetBool() && isNonPublicHost(u.Hostname()) {
+		return ErrWebhookTargetURLInvalid{TargetURL: w.TargetURL}
+	}
+
+	if len(w.Events) == 0 {
+		return ErrWebhookEventInvalid{}
+	}
+	for _, e := range w.Events {
+		if !availableWebhookEvents[e] {
+			return ErrWebhookEventInvalid{EventName: e}
+		}
+	}
+
+	return nil
+}
+
+func getWebhookByID(id int64) (w *Webhook, err error) {
+	w = &Webhook{}
+	exists, err := x.Where("id = ?", id).Get(w)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrWebhookDoesNotExist{WebhookID: id}
+	}
+	return
+}
+
+// Returns whether the webhook wants to be triggered for an event
+func (w *Webhook) listensFor(eventName string) bool {
+	for _, e := range w.Events {
+		if e == eventName {
+			return true
+		}
+	}
+	return false
+}
+
+// Create creates a new webhook
+// @Summary Create a webhook for a list
+// @Description Creates a new webhook for a list. The user needs to be admin of the list to do this.
+// @tags webhooks
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param list path int true "List ID"
+// @Param webhook body models.Webhook true "The webhook you want to create."
+// @Success 200 {object} models.Webhook "The created webhook object. This is the only time the secret is returned."
+// @Failure 400 {object} web.HTTPError "Invalid webhook object provided."
+// @Failure 403 {object} web.HTTPError "The user does not have admin access to the list"
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /lists/{list}/webhooks [put]
+func (w *Webhook) Create(a web.Auth) (err error) {
+	if err := w.validate(); err != nil {
+		return err
+	}
+
+	w.ID = 0
+	// A webhook can belong to either a list or a namespace, never to both
+	if w.ListID != 0 {
+		w.NamespaceID = 0
+	}
+
+	if w.Secret == "" {
+		w.Secret = utils.MakeRandomString(40)
+	}
+
+	w.CreatedBy, err = user.GetFromAuth(a)
+	if err != nil {
+		return err
+	}
+	w.CreatedByID = w.CreatedBy.ID
+
+	_, err = x.Insert(w)
+	return
+}
+
+// ReadAll returns all webhooks of a list or namespace
+// @Summary Get all webhooks of a list
+// @Description Returns all webhooks of a list. The secrets are not returned. The user needs to be admin of the list.
+// @tags webhooks
+// @Produce json
+// @Security JWTKeyAuth
+// @Param list path int true "List ID"
+// @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
+// @Param per_page query int false "The maximum number of items per page. Note this parameter is limited by the configured maximum of items per page."
+// @Success 200 {array} models.Webhook "The webhooks"
+// @Failure 403 {object} web.HTTPError "The user does not have admin access to the list"
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /lists/{list}/webhooks [get]
+func (w *Webhook) ReadAll(a web.Auth, search string, page int, perPage int) (result interface{}, resultCount int, totalItems int64, err error) {
+	can, err := w.canDoWebhook(a)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if !can {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	cond := "namespace_id = ?"
+	id := w.NamespaceID
+	if w.ListID != 0 {
+		cond = "list_id = ?"
+		id = w.ListID
+	}
+
+	limit, start := getLimitFromPageIndex(page, perPage)
+
+	webhooks := []*Webhook{}
+	query := x.Where(cond, id).OrderBy("id asc")
+	if limit > 0 {
+		query = query.Limit(limit, start)
+	}
+	err = query.Find(&webhooks)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	userIDs := make([]int64, 0, len(webhooks))
+	for _, wh := range webhooks {
+		userIDs = append(userIDs, wh.CreatedByID)
+	}
+
+	users := make(map[int64]*user.User)
+	if len(userIDs) > 0 {
+		err = x.In("id", userIDs).Find(&users)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+	}
+
+	for _, wh := range webhooks {
+		wh.CreatedBy = users[wh.CreatedByID]
+		// The secret is only shown once when creating the webhook
+		wh.Secret = ""
+	}
+
+	totalItems, err = x.Where(cond, id).Count(&Webhook{})
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	return webhooks, len(webhooks), totalItems, nil
+}
+
+// Update updates a webhook
+// @Summary Update a webhook of a list
+// @Description Updates the target url, events or secret of a webhook. The secret is only changed if a new one is provided.
+// @tags webhooks
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param list path int true "List ID"
+// @Param webhook path int true "Webhook ID"
+// @Param webhook body models.Webhook true "The webhook with updated values."
+// @Success 200 {object} models.Webhook "The updated webhook object."
+// @Failure 400 {object} web.HTTPError "Invalid webhook object provided."
+// @Failure 403 {object} web.HTTPError "The user does not have admin access to the list"
+// @Failure 404 {object} web.HTTPError "The webhook does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /lists/{list}/webhooks/{webhook} [post]
+func (w *Webhook) Update() (err error) {
+	if err := w.validate(); err != nil {
+		return err
+	}
+
+	colsToUpdate := []string{
+		"target_url",
+		"events",
+	}
+	if w.Secret != "" {
+		colsToUpdate = append(colsToUpdate, "secret")
+	}
+
+	_, err = x.
+		Where("id = ?", w.ID).
+		Cols(colsToUpdate...).
+		Update(w)
+	if err != nil {
+		return err
+	}
+
+	updated, err := getWebhookByID(w.ID)
+	if err != nil {
+		return err
+	}
+	*w = *updated
+	w.Secret = ""
+	w.CreatedBy, err = user.GetUserByID(w.CreatedByID)
+	return
+}
+
+// Delete removes a webhook
+// @Summary Delete a webhook of a list
+// @Description Deletes a webhook and its delivery log.
+// @tags webhooks
+// @Produce json
+// @Security JWTKeyAuth
+// @Param list path int true "List ID"
+// @Param webhook path int true "Webhook ID"
+// @Success 200 {object} models.Message "The webhook was successfully deleted."
+// @Failure 403 {object} web.HTTPError "The user does not have admin access to the list"
+// @Failure 404 {object} web.HTTPError "The webhook does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /lists/{list}/webhooks/{webhook} [delete]
+func (w *Webhook) Delete() (err error) {
+	_, err = x.Where("webhook_id = ?", w.ID).Delete(&WebhookDelivery{})
+	if err != nil {
+		return err
+	}
+	_, err = x.Where("id = ?", w.ID).Delete(&Webhook{})
+	return
+}
+
+// Deletes all webhooks and their deliveries of the given lists and namespaces.
+// Used when lists or namespaces get deleted.
+func deleteWebhooksForListsAndNamespaces(s *xorm.Session, listIDs []int64, namespaceIDs []int64) (err error) {
+	webhooks := []*Webhook{}
+	err = s.
+		Where(builder.Or(
+			builder.In("list_id", listIDs),
+			builder.In("namespace_id", namespaceIDs),
+		)).
+		Find(&webhooks)
+	if err != nil {
+		return err
+	}
+	if len(webhooks) == 0 {
+		return nil
+	}
+
+	webhookIDs := make([]int64, 0, len(webhooks))
+	for _, wh := range webhooks {
+		webhookIDs = append(webhookIDs, wh.ID)
+	}
+
+	_, err = s.In("webhook_id", webhookIDs).Delete(&WebhookDelivery{})
+	if err != nil {
+		return err
+	}
+	_, err = s.In("id", webhookIDs).Delete(&Webhook{})
+	return
+}
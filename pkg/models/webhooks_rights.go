@@ -0,0 +1,72 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"code.vikunja.io/web"
+)
+
+// CanCreate checks if a user can create a webhook on a list or namespace
+func (w *Webhook) CanCreate(a web.Auth) (bool, error) {
+	return w.canDoWebhook(a)
+}
+
+// CanUpdate checks if a user can update a webhook
+func (w *Webhook) CanUpdate(a web.Auth) (bool, error) {
+	return w.canDoExistingWebhook(a)
+}
+
+// CanDelete checks if a user can delete a webhook
+func (w *Webhook) CanDelete(a web.Auth) (bool, error) {
+	return w.canDoExistingWebhook(a)
+}
+
+// Checks if the webhook exists and belongs to the list or namespace it was requested with
+// before checking if the user is admin of it.
+func (w *Webhook) canDoExistingWebhook(a web.Auth) (bool, error) {
+	ow, err := getWebhookByID(w.ID)
+	if err != nil {
+		return false, err
+	}
+
+	if (w.ListID != 0 && ow.ListID != w.ListID) ||
+		(w.NamespaceID != 0 && ow.NamespaceID != w.NamespaceID) {
+		return false, ErrWebhookDoesNotExist{WebhookID: w.ID}
+	}
+
+	return ow.canDoWebhook(a)
+}
+
+// Only list or namespace admins can manage webhooks.
+func (w *Webhook) canDoWebhook(a web.Auth) (bool, error) {
+	// Link shares can't manage webhooks
+	if _, is := a.(*LinkSharing); is {
+		return false, nil
+	}
+
+	if w.ListID != 0 {
+		l := &List{ID: w.ListID}
+		return l.IsAdmin(a)
+	}
+
+	if w.NamespaceID != 0 {
+		n := &Namespace{ID: w.NamespaceID}
+		return n.IsAdmin(a)
+	}
+
+	return false, nil
+}
@@ -0,0 +1,431 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhook_Create(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		w := &Webhook{
+			ListID:    1,
+			TargetURL: "https://example.com/new",
+			Events:    []string{"task.created"},
+		}
+		can, err := w.CanCreate(u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+		err = w.Create(u)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, w.Secret)
+		assert.Equal(t, int64(1), w.CreatedByID)
+		db.AssertExists(t, "webhooks", map[string]interface{}{
+			"id":            w.ID,
+			"target_url":    "https://example.com/new",
+			"list_id":       1,
+			"created_by_id": 1,
+		}, false)
+	})
+	t.Run("namespace", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		w := &Webhook{
+			NamespaceID: 1,
+			TargetURL:   "https://example.com/new",
+			Events:      []string{"task.created"},
+			Secret:      "mysecret",
+		}
+		can, err := w.CanCreate(u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+		err = w.Create(u)
+		assert.NoError(t, err)
+		assert.Equal(t, "mysecret", w.Secret)
+		db.AssertExists(t, "webhooks", map[string]interface{}{
+			"id":           w.ID,
+			"namespace_id": 1,
+			"secret":       "mysecret",
+		}, false)
+	})
+	t.Run("invalid target url", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		w := &Webhook{
+			ListID:    1,
+			TargetURL: "ftp://example.com",
+			Events:    []string{"task.created"},
+		}
+		err := w.Create(u)
+		assert.Error(t, err)
+		assert.True(t, IsErrWebhookTargetURLInvalid(err))
+	})
+	t.Run("non-public target url", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		for _, target := range []string{
+			"http://localhost:8080/hook",
+			"http://127.0.0.1/hook",
+			"http://10.0.0.1/hook",
+			"http://192.168.1.10/hook",
+			"http://169.254.169.254/latest/meta-data",
+			"http://[::1]/hook",
+		} {
+			w := &Webhook{
+				ListID:    1,
+				TargetURL: target,
+				Events:    []string{"task.created"},
+			}
+			err := w.Create(u)
+			assert.Error(t, err, target)
+			assert.True(t, IsErrWebhookTargetURLInvalid(err), target)
+		}
+	})
+	t.Run("invalid event", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		w := &Webhook{
+			ListID:    1,
+			TargetURL: "https://example.com/new",
+			Events:    []string{"task.created", "user.created"},
+		}
+		err := w.Create(u)
+		assert.Error(t, err)
+		assert.True(t, IsErrWebhookEventInvalid(err))
+	})
+	t.Run("no events", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		w := &Webhook{
+			ListID:    1,
+			TargetURL: "https://example.com/new",
+		}
+		err := w.Create(u)
+		assert.Error(t, err)
+		assert.True(t, IsErrWebhookEventInvalid(err))
+	})
+	t.Run("no admin", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		w := &Webhook{ListID: 3}
+		can, err := w.CanCreate(u)
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("link share", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		w := &Webhook{ListID: 1}
+		can, err := w.CanCreate(&LinkSharing{ID: 3, ListID: 1, Right: RightAdmin})
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+}
+
+func TestWebhook_ReadAll(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("list", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		w := &Webhook{ListID: 1}
+		result, _, total, err := w.ReadAll(u, "", 0, 50)
+		assert.NoError(t, err)
+		webhooks := result.([]*Webhook)
+		assert.Len(t, webhooks, 1)
+		assert.Equal(t, int64(1), total)
+		assert.Equal(t, int64(1), webhooks[0].ID)
+		assert.Empty(t, webhooks[0].Secret)
+		assert.Equal(t, int64(1), webhooks[0].CreatedBy.ID)
+	})
+	t.Run("namespace", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		w := &Webhook{NamespaceID: 1}
+		result, _, _, err := w.ReadAll(u, "", 0, 50)
+		assert.NoError(t, err)
+		webhooks := result.([]*Webhook)
+		assert.Len(t, webhooks, 1)
+		assert.Equal(t, int64(2), webhooks[0].ID)
+	})
+	t.Run("no admin", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		w := &Webhook{ListID: 3}
+		_, _, _, err := w.ReadAll(u, "", 0, 50)
+		assert.Error(t, err)
+		assert.True(t, IsErrGenericForbidden(err))
+	})
+}
+
+func TestWebhook_Update(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		w := &Webhook{
+			ID:        1,
+			ListID:    1,
+			TargetURL: "https://example.com/updated",
+			Events:    []string{"task.deleted"},
+		}
+		can, err := w.CanUpdate(u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+		err = w.Update()
+		assert.NoError(t, err)
+		assert.Empty(t, w.Secret)
+		db.AssertExists(t, "webhooks", map[string]interface{}{
+			"id":         1,
+			"target_url": "https://example.com/updated",
+			// The secret should not be changed if none was provided
+			"secret": "secret1",
+		}, false)
+	})
+	t.Run("wrong list", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		w := &Webhook{
+			ID:     1,
+			ListID: 2,
+		}
+		_, err := w.CanUpdate(u)
+		assert.Error(t, err)
+		assert.True(t, IsErrWebhookDoesNotExist(err))
+	})
+	t.Run("nonexisting", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		w := &Webhook{
+			ID:     9999,
+			ListID: 1,
+		}
+		_, err := w.CanUpdate(u)
+		assert.Error(t, err)
+		assert.True(t, IsErrWebhookDoesNotExist(err))
+	})
+}
+
+func TestWebhook_Delete(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		w := &Webhook{ID: 1, ListID: 1}
+		can, err := w.CanDelete(&user.User{ID: 1})
+		assert.NoError(t, err)
+		assert.True(t, can)
+		err = w.Delete()
+		assert.NoError(t, err)
+		db.AssertMissing(t, "webhooks", map[string]interface{}{
+			"id": 1,
+		})
+		db.AssertMissing(t, "webhook_deliveries", map[string]interface{}{
+			"webhook_id": 1,
+		})
+	})
+	t.Run("no admin", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		w := &Webhook{ID: 3, ListID: 3}
+		can, err := w.CanDelete(&user.User{ID: 1})
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+}
+
+func TestWebhook_deliver(t *testing.T) {
+	webhookRetryBaseDelay = time.Millisecond
+	// The test receivers listen on 127.0.0.1
+	config.WebhooksAllowNonPublicTargets.Set(true)
+	defer config.WebhooksAllowNonPublicTargets.Set(false)
+
+	t.Run("signed payload", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+
+		var (
+			receivedBody      []byte
+			receivedSignature string
+			receivedEvent     string
+		)
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			receivedBody, _ = ioutil.ReadAll(r.Body)
+			receivedSignature = r.Header.Get("X-Vikunja-Signature")
+			receivedEvent = r.Header.Get("X-Vikunja-Event")
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer receiver.Close()
+
+		wh := &Webhook{ID: 1, TargetURL: receiver.URL, Secret: "secret1"}
+		delivered, err := wh.deliver(&TaskCreatedEvent{Task: &Task{ID: 1, Title: "test"}})
+		assert.NoError(t, err)
+		assert.True(t, delivered)
+
+		mac := hmac.New(sha256.New, []byte("secret1"))
+		_, _ = mac.Write(receivedBody)
+		assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), receivedSignature)
+		assert.Equal(t, "task.created", receivedEvent)
+
+		payload := map[string]interface{}{}
+		err = json.Unmarshal(receivedBody, &payload)
+		assert.NoError(t, err)
+		assert.Equal(t, "task.created", payload["event_name"])
+		assert.Equal(t, "test", payload["data"].(map[string]interface{})["task"].(map[string]interface{})["title"])
+
+		db.AssertExists(t, "webhook_deliveries", map[string]interface{}{
+			"webhook_id":  1,
+			"event_name":  "task.created",
+			"attempt":     1,
+			"status_code": 200,
+			"success":     true,
+		}, false)
+	})
+	t.Run("retries", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+
+		var requests int32
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Fail the first two requests
+			if atomic.AddInt32(&requests, 1) <= 2 {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer receiver.Close()
+
+		wh := &Webhook{ID: 1, TargetURL: receiver.URL, Secret: "secret1"}
+		delivered, err := wh.deliver(&TaskCreatedEvent{Task: &Task{ID: 1}})
+		assert.NoError(t, err)
+		assert.True(t, delivered)
+		assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+
+		db.AssertExists(t, "webhook_deliveries", map[string]interface{}{
+			"webhook_id":  1,
+			"attempt":     2,
+			"status_code": 500,
+			"success":     false,
+		}, false)
+		db.AssertExists(t, "webhook_deliveries", map[string]interface{}{
+			"webhook_id":  1,
+			"attempt":     3,
+			"status_code": 204,
+			"success":     true,
+		}, false)
+	})
+	t.Run("gives up after max retries", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		config.WebhooksMaxRetries.Set(2)
+		defer config.WebhooksMaxRetries.Set(5)
+
+		var requests int32
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&requests, 1)
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer receiver.Close()
+
+		wh := &Webhook{ID: 1, TargetURL: receiver.URL, Secret: "secret1"}
+		delivered, err := wh.deliver(&TaskCreatedEvent{Task: &Task{ID: 1}})
+		assert.NoError(t, err)
+		assert.False(t, delivered)
+		assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+	})
+	t.Run("only public user data", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+
+		var receivedBody []byte
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			receivedBody, _ = ioutil.ReadAll(r.Body)
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer receiver.Close()
+
+		wh := &Webhook{ID: 1, TargetURL: receiver.URL, Secret: "secret1"}
+		delivered, err := wh.deliver(&TaskAssigneeCreatedEvent{
+			Task: &Task{
+				ID:        1,
+				CreatedBy: &user.User{ID: 1, Username: "user1", Email: "user1@example.com"},
+			},
+			Assignee: &user.User{ID: 2, Username: "user2", Email: "user2@example.com"},
+			Doer:     &LinkSharing{ID: 1, Hash: "test"},
+		})
+		assert.NoError(t, err)
+		assert.True(t, delivered)
+
+		payload := map[string]interface{}{}
+		err = json.Unmarshal(receivedBody, &payload)
+		assert.NoError(t, err)
+		data := payload["data"].(map[string]interface{})
+		assert.Equal(t, "user2", data["assignee"].(map[string]interface{})["username"])
+		assert.Nil(t, data["doer"])
+		assert.NotContains(t, string(receivedBody), "@example.com")
+		assert.NotContains(t, string(receivedBody), "hash")
+
+		delivered, err = wh.deliver(&ListSharedWithUserEvent{
+			List: &List{ID: 1, Owner: &user.User{ID: 1, Username: "user1", Email: "user1@example.com"}},
+			User: &user.User{ID: 2, Username: "user2", Email: "user2@example.com"},
+			Doer: &user.User{ID: 1},
+		})
+		assert.NoError(t, err)
+		assert.True(t, delivered)
+		assert.NotContains(t, string(receivedBody), "@example.com")
+		assert.Contains(t, string(receivedBody), `"doer":{"id":1,"username":"user1"`)
+	})
+	t.Run("refuses non-public targets", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		config.WebhooksAllowNonPublicTargets.Set(false)
+		defer config.WebhooksAllowNonPublicTargets.Set(true)
+		config.WebhooksMaxRetries.Set(0)
+		defer config.WebhooksMaxRetries.Set(5)
+
+		var requests int32
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&requests, 1)
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer receiver.Close()
+
+		wh := &Webhook{ID: 1, TargetURL: receiver.URL, Secret: "secret1"}
+		delivered, err := wh.deliver(&TaskCreatedEvent{Task: &Task{ID: 1}})
+		assert.NoError(t, err)
+		assert.False(t, delivered)
+		assert.Equal(t, int32(0), atomic.LoadInt32(&requests))
+	})
+}
+
+func TestGetWebhooksForListEvent(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+
+	webhooks, err := getWebhooksForListEvent(1, "task.created")
+	assert.NoError(t, err)
+	// One for the list itself and one for its namespace
+	assert.Len(t, webhooks, 2)
+
+	webhooks, err = getWebhooksForListEvent(1, "task.updated")
+	assert.NoError(t, err)
+	assert.Len(t, webhooks, 1)
+	assert.Equal(t, int64(1), webhooks[0].ID)
+
+	// List 2 is in namespace 1 as well
+	webhooks, err = getWebhooksForListEvent(2, "task.created")
+	assert.NoError(t, err)
+	assert.Len(t, webhooks, 1)
+	assert.Equal(t, int64(2), webhooks[0].ID)
+}
@@ -0,0 +1,177 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package ldap
+
+import (
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/user"
+	"github.com/go-ldap/ldap/v3"
+)
+
+// Issuer is the issuer of all users authenticated through ldap
+const Issuer = `ldap`
+
+// The parts of an ldap connection Vikunja uses
+type connection interface {
+	Bind(username, password string) error
+	Search(searchRequest *ldap.SearchRequest) (*ldap.SearchResult, error)
+	Close()
+}
+
+func dialLdap() (connection, error) {
+	host := config.AuthLdapHost.GetString()
+	address := fmt.Sprintf("%s:%d", host, config.AuthLdapPort.GetInt())
+
+	if config.AuthLdapUseTLS.GetBool() {
+		return ldap.DialTLS("tcp", address, &tls.Config{
+			ServerName:         host,
+			InsecureSkipVerify: !config.AuthLdapVerifyTLS.GetBool(), //nolint:gosec
+		})
+	}
+
+	return ldap.Dial("tcp", address)
+}
+
+// Overwritten in tests to use a fake ldap server
+var dial = dialLdap
+
+// InitLDAP makes user.CheckUserCredentials authenticate users against the configured ldap server if ldap is enabled.
+func InitLDAP() {
+	if !config.AuthLdapEnabled.GetBool() {
+		return
+	}
+
+	if config.AuthLdapHost.GetString() == "" {
+		log.Fatal("LDAP is enabled but no ldap host is configured.")
+	}
+
+	user.SetPasswordAuthenticator(&Authenticator{})
+	log.Debugf("[LDAP] Authenticating users against %s", config.AuthLdapHost.GetString())
+}
+
+// Authenticator authenticates users against an ldap server
+type Authenticator struct{}
+
+// Issuer returns the issuer of all ldap users
+func (a *Authenticator) Issuer() string {
+	return Issuer
+}
+
+// AuthenticateUser searches the user with the username in ldap and checks the password by binding as that user.
+func (a *Authenticator) AuthenticateUser(username, password string) (eu *user.ExternalUser, err error) {
+	// An empty password would result in an unauthenticated bind, which most servers accept
+	if username == "" || password == "" {
+		return nil, user.ErrWrongUsernameOrPassword{}
+	}
+
+	conn, err := dial()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if config.AuthLdapBindDN.GetString() != "" {
+		err = conn.Bind(config.AuthLdapBindDN.GetString(), config.AuthLdapBindPassword.GetString())
+		if err != nil {
+			return nil, fmt.Errorf("could not bind as %s: %w", config.AuthLdapBindDN.GetString(), err)
+		}
+	}
+
+	usernameAttribute := config.AuthLdapAttributeUsername.GetString()
+	emailAttribute := config.AuthLdapAttributeEmail.GetString()
+	displaynameAttribute := config.AuthLdapAttributeDisplayname.GetString()
+	idAttribute := config.AuthLdapAttributeID.GetString()
+
+	filter := strings.ReplaceAll(config.AuthLdapUserFilter.GetString(), "{username}", ldap.EscapeFilter(username))
+	result, err := conn.Search(ldap.NewSearchRequest(
+		config.AuthLdapBaseDN.GetString(),
+		ldap.ScopeWholeSubtree,
+		ldap.NeverDerefAliases,
+		2, // Only one user may match, we request two to find out if there are more
+		0,
+		false,
+		filter,
+		[]string{usernameAttribute, emailAttribute, displaynameAttribute, idAttribute},
+		nil,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result.Entries) != 1 {
+		if len(result.Entries) > 1 {
+			log.Errorf("[LDAP] Found %d users for username %s, the user filter must only match one user", len(result.Entries), username)
+		}
+		return nil, user.ErrWrongUsernameOrPassword{}
+	}
+
+	entry := result.Entries[0]
+	err = conn.Bind(entry.DN, password)
+	if err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, user.ErrWrongUsernameOrPassword{}
+		}
+		return nil, err
+	}
+
+	// The dn changes when the user is renamed or moved, so we can't use it to recognize the user
+	subject := getSubject(entry, idAttribute)
+	if subject == "" {
+		log.Errorf("[LDAP] User %s does not have the id attribute %s, they can't log in", entry.DN, idAttribute)
+		return nil, user.ErrWrongUsernameOrPassword{}
+	}
+
+	eu = &user.ExternalUser{
+		Issuer:            Issuer,
+		Subject:           subject,
+		PreferredUsername: entry.GetAttributeValue(usernameAttribute),
+		Email:             entry.GetAttributeValue(emailAttribute),
+		Name:              entry.GetAttributeValue(displaynameAttribute),
+	}
+	if eu.PreferredUsername == "" {
+		eu.PreferredUsername = username
+	}
+
+	return eu, nil
+}
+
+// Returns the value of the id attribute of a user. Binary values like the objectGUID of Active Directory are hex encoded.
+func getSubject(entry *ldap.Entry, idAttribute string) string {
+	raw := entry.GetRawAttributeValue(idAttribute)
+	if len(raw) == 0 {
+		return ""
+	}
+
+	if !utf8.Valid(raw) {
+		return hex.EncodeToString(raw)
+	}
+	for _, r := range string(raw) {
+		if !unicode.IsPrint(r) {
+			return hex.EncodeToString(raw)
+		}
+	}
+
+	return string(raw)
+}
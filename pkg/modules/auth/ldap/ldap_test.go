@@ -0,0 +1,150 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package ldap
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/user"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+// A fake ldap server with a service account and a few users
+type fakeConnection struct {
+	// All users by their dn with their attributes
+	users map[string]map[string][]string
+	// All passwords by dn
+	passwords map[string]string
+	// The filter of the last search
+	lastFilter string
+	closed     bool
+}
+
+func (f *fakeConnection) Bind(username, password string) error {
+	if pw, has := f.passwords[username]; has && pw == password {
+		return nil
+	}
+	return ldap.NewError(ldap.LDAPResultInvalidCredentials, nil)
+}
+
+func (f *fakeConnection) Search(req *ldap.SearchRequest) (*ldap.SearchResult, error) {
+	f.lastFilter = req.Filter
+	result := &ldap.SearchResult{}
+	for dn, attributes := range f.users {
+		if req.Filter == "(&(objectclass=inetOrgPerson)(uid="+attributes["uid"][0]+"))" {
+			result.Entries = append(result.Entries, ldap.NewEntry(dn, attributes))
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeConnection) Close() {
+	f.closed = true
+}
+
+func setupFakeLdap() *fakeConnection {
+	config.InitDefaultConfig()
+	config.AuthLdapEnabled.Set(true)
+	config.AuthLdapHost.Set("ldap.example.com")
+	config.AuthLdapBaseDN.Set("ou=users,dc=example,dc=com")
+	config.AuthLdapBindDN.Set("cn=vikunja,dc=example,dc=com")
+	config.AuthLdapBindPassword.Set("service")
+
+	f := &fakeConnection{
+		users: map[string]map[string][]string{
+			"uid=jane,ou=users,dc=example,dc=com": {
+				"uid":         {"jane"},
+				"mail":        {"jane@example.com"},
+				"displayName": {"Jane Doe"},
+				"entryUUID":   {"5d8a5f5c-1e0b-4c47-9a5e-4f3c3a0c7b1e"},
+			},
+		},
+		passwords: map[string]string{
+			"cn=vikunja,dc=example,dc=com":        "service",
+			"uid=jane,ou=users,dc=example,dc=com": "secret",
+		},
+	}
+	dial = func() (connection, error) {
+		return f, nil
+	}
+	return f
+}
+
+func TestAuthenticator_AuthenticateUser(t *testing.T) {
+	a := &Authenticator{}
+
+	t.Run("normal", func(t *testing.T) {
+		f := setupFakeLdap()
+		eu, err := a.AuthenticateUser("jane", "secret")
+		assert.NoError(t, err)
+		assert.Equal(t, Issuer, eu.Issuer)
+		assert.Equal(t, "5d8a5f5c-1e0b-4c47-9a5e-4f3c3a0c7b1e", eu.Subject)
+		assert.Equal(t, "jane", eu.PreferredUsername)
+		assert.Equal(t, "jane@example.com", eu.Email)
+		assert.Equal(t, "Jane Doe", eu.Name)
+		assert.True(t, f.closed)
+	})
+	t.Run("wrong password", func(t *testing.T) {
+		setupFakeLdap()
+		_, err := a.AuthenticateUser("jane", "wrong")
+		assert.Error(t, err)
+		assert.True(t, user.IsErrWrongUsernameOrPassword(err))
+	})
+	t.Run("empty password", func(t *testing.T) {
+		setupFakeLdap()
+		_, err := a.AuthenticateUser("jane", "")
+		assert.Error(t, err)
+		assert.True(t, user.IsErrWrongUsernameOrPassword(err))
+	})
+	t.Run("nonexisting user", func(t *testing.T) {
+		setupFakeLdap()
+		_, err := a.AuthenticateUser("john", "secret")
+		assert.Error(t, err)
+		assert.True(t, user.IsErrWrongUsernameOrPassword(err))
+	})
+	t.Run("username is escaped", func(t *testing.T) {
+		f := setupFakeLdap()
+		_, err := a.AuthenticateUser("*)(uid=*", "secret")
+		assert.Error(t, err)
+		assert.True(t, user.IsErrWrongUsernameOrPassword(err))
+		assert.Equal(t, `(&(objectclass=inetOrgPerson)(uid=\2a\29\28uid=\2a))`, f.lastFilter)
+	})
+	t.Run("binary id attribute", func(t *testing.T) {
+		f := setupFakeLdap()
+		config.AuthLdapAttributeID.Set("objectGUID")
+		f.users["uid=jane,ou=users,dc=example,dc=com"]["objectGUID"] = []string{string([]byte{0x5d, 0x8a, 0x00, 0xff})}
+		eu, err := a.AuthenticateUser("jane", "secret")
+		assert.NoError(t, err)
+		assert.Equal(t, "5d8a00ff", eu.Subject)
+	})
+	t.Run("without id attribute", func(t *testing.T) {
+		setupFakeLdap()
+		config.AuthLdapAttributeID.Set("objectGUID")
+		_, err := a.AuthenticateUser("jane", "secret")
+		assert.Error(t, err)
+		assert.True(t, user.IsErrWrongUsernameOrPassword(err))
+	})
+	t.Run("wrong service account", func(t *testing.T) {
+		setupFakeLdap()
+		config.AuthLdapBindPassword.Set("wrong")
+		_, err := a.AuthenticateUser("jane", "secret")
+		assert.Error(t, err)
+		assert.False(t, user.IsErrWrongUsernameOrPassword(err))
+	})
+}
@@ -0,0 +1,103 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package openid
+
+import (
+	"fmt"
+	"net/http"
+
+	"code.vikunja.io/web"
+)
+
+// ErrOpenIDProviderDoesNotExist represents an error where an openid provider does not exist
+type ErrOpenIDProviderDoesNotExist struct {
+	Provider string
+}
+
+// IsErrOpenIDProviderDoesNotExist checks if an error is ErrOpenIDProviderDoesNotExist.
+func IsErrOpenIDProviderDoesNotExist(err error) bool {
+	_, ok := err.(ErrOpenIDProviderDoesNotExist)
+	return ok
+}
+
+func (err ErrOpenIDProviderDoesNotExist) Error() string {
+	return fmt.Sprintf("OpenID provider does not exist [Provider: %s]", err.Provider)
+}
+
+// ErrCodeOpenIDProviderDoesNotExist holds the unique world-error code of this error
+const ErrCodeOpenIDProviderDoesNotExist = 15001
+
+// HTTPError holds the http error description
+func (err ErrOpenIDProviderDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeOpenIDProviderDoesNotExist,
+		Message:  "This openid provider does not exist.",
+	}
+}
+
+// ErrOpenIDInvalidState represents an error where the state of an openid callback is invalid or expired
+type ErrOpenIDInvalidState struct{}
+
+// IsErrOpenIDInvalidState checks if an error is ErrOpenIDInvalidState.
+func IsErrOpenIDInvalidState(err error) bool {
+	_, ok := err.(ErrOpenIDInvalidState)
+	return ok
+}
+
+func (err ErrOpenIDInvalidState) Error() string {
+	return "OpenID state is invalid"
+}
+
+// ErrCodeOpenIDInvalidState holds the unique world-error code of this error
+const ErrCodeOpenIDInvalidState = 15002
+
+// HTTPError holds the http error description
+func (err ErrOpenIDInvalidState) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeOpenIDInvalidState,
+		Message:  "The openid state is invalid or expired. Please try to log in again.",
+	}
+}
+
+// ErrOpenIDAuthenticationFailed represents an error where the provider did not authenticate a user
+type ErrOpenIDAuthenticationFailed struct {
+	Provider string
+}
+
+// IsErrOpenIDAuthenticationFailed checks if an error is ErrOpenIDAuthenticationFailed.
+func IsErrOpenIDAuthenticationFailed(err error) bool {
+	_, ok := err.(ErrOpenIDAuthenticationFailed)
+	return ok
+}
+
+func (err ErrOpenIDAuthenticationFailed) Error() string {
+	return fmt.Sprintf("OpenID authentication failed [Provider: %s]", err.Provider)
+}
+
+// ErrCodeOpenIDAuthenticationFailed holds the unique world-error code of this error
+const ErrCodeOpenIDAuthenticationFailed = 15003
+
+// HTTPError holds the http error description
+func (err ErrOpenIDAuthenticationFailed) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusPreconditionFailed,
+		Code:     ErrCodeOpenIDAuthenticationFailed,
+		Message:  "The authentication with the openid provider failed.",
+	}
+}
@@ -0,0 +1,483 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package openid
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/api/pkg/utils"
+	"github.com/dgrijalva/jwt-go"
+)
+
+// The time a user has to authenticate at the provider before the state expires
+const stateLifetime = 10 * time.Minute
+
+const stateType = `openid_state`
+
+// Provider is an openid connect provider users can log in with
+type Provider struct {
+	// The name of the provider as shown to users.
+	Name string `json:"name"`
+	// The key of the provider, used in all urls.
+	Key string `json:"key"`
+
+	AuthURL      string `json:"-"`
+	ClientID     string `json:"-"`
+	ClientSecret string `json:"-"`
+}
+
+// Callback holds everything a client receives from the provider after the user authenticated
+type Callback struct {
+	// The authorization code the provider added to the redirect url.
+	Code string `query:"code" json:"code"`
+	// The state the provider added to the redirect url.
+	State string `query:"state" json:"state"`
+}
+
+// The parts of the discovery document at /.well-known/openid-configuration Vikunja needs
+type discoveryDocument struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	IDToken     string `json:"id_token"`
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type jsonWebKeySet struct {
+	Keys []*jsonWebKey `json:"keys"`
+}
+
+var (
+	discoveryCache      = make(map[string]*discoveryDocument)
+	discoveryCacheMutex sync.Mutex
+)
+
+// Config values of lists are either map[string]interface{} or map[interface{}]interface{}, depending on where
+// they came from.
+func getConfigValue(raw interface{}, key string) string {
+	switch m := raw.(type) {
+	case map[string]interface{}:
+		if v, has := m[key]; has && v != nil {
+			return fmt.Sprintf("%v", v)
+		}
+	case map[interface{}]interface{}:
+		if v, has := m[key]; has && v != nil {
+			return fmt.Sprintf("%v", v)
+		}
+	}
+	return ""
+}
+
+// GetAllProviders returns all configured openid connect providers
+func GetAllProviders() (providers []*Provider, err error) {
+	if !config.AuthOpenIDEnabled.GetBool() {
+		return
+	}
+
+	rawProviders, is := config.AuthOpenIDProviders.Get().([]interface{})
+	if !is {
+		return
+	}
+
+	for _, raw := range rawProviders {
+		p := &Provider{
+			Name:         getConfigValue(raw, "name"),
+			AuthURL:      strings.TrimSuffix(getConfigValue(raw, "authurl"), "/"),
+			ClientID:     getConfigValue(raw, "clientid"),
+			ClientSecret: getConfigValue(raw, "clientsecret"),
+		}
+		if p.Name == "" || p.AuthURL == "" || p.ClientID == "" {
+			return nil, fmt.Errorf("openid provider %q needs a name, an auth url and a client id", p.Name)
+		}
+		p.Key = strings.ToLower(p.Name)
+		providers = append(providers, p)
+	}
+
+	return
+}
+
+// GetProvider returns the provider with the given key
+func GetProvider(key string) (*Provider, error) {
+	providers, err := GetAllProviders()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range providers {
+		if p.Key == key {
+			return p, nil
+		}
+	}
+
+	return nil, ErrOpenIDProviderDoesNotExist{Provider: key}
+}
+
+// RedirectURL returns the url the provider should redirect the user to after authenticating
+func (p *Provider) RedirectURL() string {
+	redirectURL := config.AuthOpenIDRedirectURL.GetString()
+	if redirectURL == "" {
+		redirectURL = config.ServiceFrontendurl.GetString() + "auth/openid/"
+	}
+	return redirectURL + p.Key
+}
+
+func doRequest(req *http.Request) (resp *http.Response, err error) {
+	hc := http.Client{Timeout: 30 * time.Second}
+	resp, err = hc.Do(req)
+	if err != nil {
+		return
+	}
+
+	if resp.StatusCode > 399 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("got http status %d from %s", resp.StatusCode, req.URL.String())
+	}
+
+	return
+}
+
+func getJSON(u string, v interface{}) error {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := doRequest(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// Gets the discovery document of the provider. It is only requested once per provider and then cached.
+func (p *Provider) getDiscoveryDocument() (doc *discoveryDocument, err error) {
+	discoveryCacheMutex.Lock()
+	defer discoveryCacheMutex.Unlock()
+
+	if doc, has := discoveryCache[p.AuthURL]; has {
+		return doc, nil
+	}
+
+	doc = &discoveryDocument{}
+	err = getJSON(p.AuthURL+"/.well-known/openid-configuration", doc)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSuffix(doc.Issuer, "/") != p.AuthURL {
+		return nil, fmt.Errorf("issuer %s of openid provider %s does not match its auth url %s", doc.Issuer, p.Name, p.AuthURL)
+	}
+
+	discoveryCache[p.AuthURL] = doc
+	return doc, nil
+}
+
+// LoginCookieName is the name of the cookie which binds a login at a provider to the browser which started it
+const LoginCookieName = "vikunja_openid_login"
+
+// Creates a new random state and nonce for a login and a signed token holding both of them.
+// Only the state and the nonce are sent to the provider, the signed token is stored in an http only cookie in the
+// browser of the user. This way, Vikunja doesn't need to store anything until the user comes back from the provider
+// and a callback with a code and state obtained by someone else is rejected because it doesn't match the cookie.
+func (p *Provider) newLogin() (login, state, nonce string, err error) {
+	state = utils.MakeRandomString(32)
+	nonce = utils.MakeRandomString(32)
+
+	t := jwt.New(jwt.SigningMethodHS256)
+	claims := t.Claims.(jwt.MapClaims)
+	claims["type"] = stateType
+	claims["provider"] = p.Key
+	claims["state"] = state
+	claims["nonce"] = nonce
+	claims["exp"] = time.Now().Add(stateLifetime).Unix()
+
+	login, err = t.SignedString([]byte(config.ServiceJWTSecret.GetString()))
+	return
+}
+
+// Checks the login token from the cookie was issued by this instance for this provider and the state of the callback
+// is the one which was sent to the provider for it. Returns the nonce of the login.
+func (p *Provider) checkState(login, state string) (nonce string, err error) {
+	if login == "" || state == "" {
+		return "", ErrOpenIDInvalidState{}
+	}
+
+	t, err := jwt.Parse(login, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method %v", t.Header["alg"])
+		}
+		return []byte(config.ServiceJWTSecret.GetString()), nil
+	})
+	if err != nil || !t.Valid {
+		return "", ErrOpenIDInvalidState{}
+	}
+
+	claims := t.Claims.(jwt.MapClaims)
+	typ, _ := claims["type"].(string)
+	provider, _ := claims["provider"].(string)
+	loginState, _ := claims["state"].(string)
+	nonce, _ = claims["nonce"].(string)
+	if typ != stateType || provider != p.Key || nonce == "" ||
+		subtle.ConstantTimeCompare([]byte(loginState), []byte(state)) != 1 {
+		return "", ErrOpenIDInvalidState{}
+	}
+
+	return nonce, nil
+}
+
+// NewLoginCookie returns the cookie which binds a login to the browser of the user.
+func NewLoginCookie(login string, secure bool) *http.Cookie {
+	return &http.Cookie{
+		Name:     LoginCookieName,
+		Value:    login,
+		Path:     "/",
+		MaxAge:   int(stateLifetime.Seconds()),
+		Secure:   secure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// ClearLoginCookie returns a cookie which removes the login cookie from the browser.
+// Every login can only be used once.
+func ClearLoginCookie(secure bool) *http.Cookie {
+	c := NewLoginCookie("", secure)
+	c.MaxAge = -1
+	return c
+}
+
+// GetAuthURL returns the url at the provider the user needs to be redirected to for logging in and the login token
+// which needs to be stored in the login cookie.
+func (p *Provider) GetAuthURL() (authURL, login string, err error) {
+	doc, err := p.getDiscoveryDocument()
+	if err != nil {
+		return "", "", err
+	}
+
+	login, state, nonce, err := p.newLogin()
+	if err != nil {
+		return "", "", err
+	}
+
+	params := url.Values{
+		"response_type": []string{"code"},
+		"client_id":     []string{p.ClientID},
+		"redirect_uri":  []string{p.RedirectURL()},
+		"scope":         []string{"openid email profile"},
+		"state":         []string{state},
+		"nonce":         []string{nonce},
+	}
+
+	separator := "?"
+	if strings.Contains(doc.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+
+	return doc.AuthorizationEndpoint + separator + params.Encode(), login, nil
+}
+
+// Exchanges the authorization code for the tokens at the token endpoint of the provider
+func (p *Provider) exchangeCode(doc *discoveryDocument, code string) (token *tokenResponse, err error) {
+	form := url.Values{
+		"grant_type":   []string{"authorization_code"},
+		"code":         []string{code},
+		"redirect_uri": []string{p.RedirectURL()},
+	}
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, doc.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(p.ClientID), url.QueryEscape(p.ClientSecret))
+
+	resp, err := doRequest(req)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	token = &tokenResponse{}
+	err = json.NewDecoder(resp.Body).Decode(token)
+	return
+}
+
+func (k *jsonWebKey) rsaPublicKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}, nil
+}
+
+// Returns the rsa key with the given id from the key set of the provider
+func getSigningKey(doc *discoveryDocument, kid string) (*rsa.PublicKey, error) {
+	keys := &jsonWebKeySet{}
+	err := getJSON(doc.JWKSURI, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, k := range keys.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		if kid == "" || k.Kid == kid {
+			return k.rsaPublicKey()
+		}
+	}
+
+	return nil, fmt.Errorf("no rsa key with id %q found", kid)
+}
+
+func hasAudience(claims jwt.MapClaims, clientID string) bool {
+	switch aud := claims["aud"].(type) {
+	case string:
+		return aud == clientID
+	case []interface{}:
+		for _, a := range aud {
+			if s, is := a.(string); is && s == clientID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Verifies the signature and all claims of the id token and returns its claims
+func (p *Provider) verifyIDToken(doc *discoveryDocument, rawIDToken, nonce string) (claims jwt.MapClaims, err error) {
+	t, err := jwt.Parse(rawIDToken, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, fmt.Errorf("unexpected signing method %v", t.Header["alg"])
+		}
+		kid, _ := t.Header["kid"].(string)
+		return getSigningKey(doc, kid)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !t.Valid {
+		return nil, fmt.Errorf("id token is invalid")
+	}
+
+	claims = t.Claims.(jwt.MapClaims)
+
+	// jwt-go only checks the expiry if it is present, but it is required for id tokens
+	if _, has := claims["exp"]; !has {
+		return nil, fmt.Errorf("id token has no expiry")
+	}
+	if !claims.VerifyIssuer(doc.Issuer, true) {
+		return nil, fmt.Errorf("id token was issued by %v, expected %s", claims["iss"], doc.Issuer)
+	}
+	if !hasAudience(claims, p.ClientID) {
+		return nil, fmt.Errorf("id token was not issued for client %s", p.ClientID)
+	}
+	if tokenNonce, _ := claims["nonce"].(string); tokenNonce != nonce {
+		return nil, fmt.Errorf("id token nonce does not match")
+	}
+	if sub, _ := claims["sub"].(string); sub == "" {
+		return nil, fmt.Errorf("id token has no subject")
+	}
+
+	return claims, nil
+}
+
+// Authenticate exchanges the code from the callback for an id token and returns the user it was issued for.
+// The login is the value of the login cookie of the browser which sent the callback.
+func (p *Provider) Authenticate(cb *Callback, login string) (eu *user.ExternalUser, err error) {
+	nonce, err := p.checkState(login, cb.State)
+	if err != nil {
+		return nil, err
+	}
+
+	if cb.Code == "" {
+		return nil, ErrOpenIDAuthenticationFailed{Provider: p.Key}
+	}
+
+	doc, err := p.getDiscoveryDocument()
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := p.exchangeCode(doc, cb.Code)
+	if err != nil {
+		log.Debugf("[OpenID] Could not exchange code at provider %s: %s", p.Key, err)
+		return nil, ErrOpenIDAuthenticationFailed{Provider: p.Key}
+	}
+
+	claims, err := p.verifyIDToken(doc, token.IDToken, nonce)
+	if err != nil {
+		log.Debugf("[OpenID] Invalid id token from provider %s: %s", p.Key, err)
+		return nil, ErrOpenIDAuthenticationFailed{Provider: p.Key}
+	}
+
+	eu = &user.ExternalUser{
+		Issuer:  doc.Issuer,
+		Subject: claims["sub"].(string),
+	}
+	eu.PreferredUsername, _ = claims["preferred_username"].(string)
+	eu.Name, _ = claims["name"].(string)
+
+	// Only use email addresses the provider verified
+	email, _ := claims["email"].(string)
+	if verified, has := claims["email_verified"].(bool); !has || verified {
+		eu.Email = email
+	}
+
+	if eu.PreferredUsername == "" {
+		eu.PreferredUsername = strings.Split(email, "@")[0]
+	}
+
+	return eu, nil
+}
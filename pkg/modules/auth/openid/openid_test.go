@@ -0,0 +1,329 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package openid
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+)
+
+// A minimal openid connect issuer which issues id tokens for every request with the right code
+type mockIssuer struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	code   string
+	nonce  string
+	// Claims which override the default claims of the id token. A nil value removes the claim.
+	claims jwt.MapClaims
+	// If set, id tokens are signed with this key instead of the one in the key set.
+	signingKey *rsa.PrivateKey
+}
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	m := &mockIssuer{key: key, code: "valid-code"}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(&discoveryDocument{
+			Issuer:                m.server.URL,
+			AuthorizationEndpoint: m.server.URL + "/auth",
+			TokenEndpoint:         m.server.URL + "/token",
+			JWKSURI:               m.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(&jsonWebKeySet{Keys: []*jsonWebKey{
+			{
+				Kty: "RSA",
+				Kid: "test",
+				N:   base64.RawURLEncoding.EncodeToString(m.key.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(m.key.E)).Bytes()),
+			},
+		}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		clientID, clientSecret, _ := r.BasicAuth()
+		if r.FormValue("code") != m.code || clientID != "vikunja" || clientSecret != "secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		claims := jwt.MapClaims{
+			"iss":                m.server.URL,
+			"aud":                "vikunja",
+			"sub":                "12345",
+			"exp":                time.Now().Add(time.Hour).Unix(),
+			"iat":                time.Now().Unix(),
+			"nonce":              m.nonce,
+			"email":              "oidc@example.com",
+			"email_verified":     true,
+			"preferred_username": "oidcuser",
+		}
+		for k, v := range m.claims {
+			if v == nil {
+				delete(claims, k)
+				continue
+			}
+			claims[k] = v
+		}
+
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = "test"
+		signingKey := m.key
+		if m.signingKey != nil {
+			signingKey = m.signingKey
+		}
+		idToken, err := token.SignedString(signingKey)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(&tokenResponse{
+			AccessToken: "access",
+			TokenType:   "Bearer",
+			IDToken:     idToken,
+		})
+	})
+
+	m.server = httptest.NewServer(mux)
+	return m
+}
+
+func setupProviders(issuerURL string) {
+	config.InitDefaultConfig()
+	config.ServiceJWTSecret.Set("secret")
+	config.ServiceFrontendurl.Set("https://vikunja.example.com/")
+	config.AuthOpenIDEnabled.Set(true)
+	config.AuthOpenIDProviders.Set([]interface{}{
+		map[string]interface{}{
+			"name":         "Test",
+			"authurl":      issuerURL,
+			"clientid":     "vikunja",
+			"clientsecret": "secret",
+		},
+		map[interface{}]interface{}{
+			"name":         "Other",
+			"authurl":      issuerURL,
+			"clientid":     "other",
+			"clientsecret": "secret",
+		},
+	})
+}
+
+// Gets the auth url like a client would and returns the state from it and the login for the cookie.
+// The mock issuer will use the nonce from it.
+func startLogin(t *testing.T, p *Provider, m *mockIssuer) (state, login string) {
+	authURL, login, err := p.GetAuthURL()
+	assert.NoError(t, err)
+	u, err := url.Parse(authURL)
+	assert.NoError(t, err)
+	m.nonce = u.Query().Get("nonce")
+	return u.Query().Get("state"), login
+}
+
+func TestGetProvider(t *testing.T) {
+	setupProviders("https://issuer.example.com/")
+
+	t.Run("normal", func(t *testing.T) {
+		p, err := GetProvider("test")
+		assert.NoError(t, err)
+		assert.Equal(t, "Test", p.Name)
+		assert.Equal(t, "https://issuer.example.com", p.AuthURL)
+		assert.Equal(t, "https://vikunja.example.com/auth/openid/test", p.RedirectURL())
+	})
+	t.Run("all", func(t *testing.T) {
+		providers, err := GetAllProviders()
+		assert.NoError(t, err)
+		assert.Len(t, providers, 2)
+		assert.Equal(t, "other", providers[1].Key)
+	})
+	t.Run("nonexisting", func(t *testing.T) {
+		_, err := GetProvider("nonexisting")
+		assert.Error(t, err)
+		assert.True(t, IsErrOpenIDProviderDoesNotExist(err))
+	})
+	t.Run("disabled", func(t *testing.T) {
+		config.AuthOpenIDEnabled.Set(false)
+		defer config.AuthOpenIDEnabled.Set(true)
+		providers, err := GetAllProviders()
+		assert.NoError(t, err)
+		assert.Len(t, providers, 0)
+	})
+}
+
+func TestProvider_GetAuthURL(t *testing.T) {
+	m := newMockIssuer(t)
+	defer m.server.Close()
+	setupProviders(m.server.URL)
+
+	p, err := GetProvider("test")
+	assert.NoError(t, err)
+	authURL, login, err := p.GetAuthURL()
+	assert.NoError(t, err)
+
+	u, err := url.Parse(authURL)
+	assert.NoError(t, err)
+	assert.Equal(t, m.server.URL+"/auth", u.Scheme+"://"+u.Host+u.Path)
+	assert.Equal(t, "code", u.Query().Get("response_type"))
+	assert.Equal(t, "vikunja", u.Query().Get("client_id"))
+	assert.Equal(t, "https://vikunja.example.com/auth/openid/test", u.Query().Get("redirect_uri"))
+	assert.Contains(t, u.Query().Get("scope"), "openid")
+	assert.NotEmpty(t, u.Query().Get("nonce"))
+
+	assert.NotEmpty(t, u.Query().Get("state"))
+	assert.NotContains(t, login, u.Query().Get("state"))
+
+	nonce, err := p.checkState(login, u.Query().Get("state"))
+	assert.NoError(t, err)
+	assert.Equal(t, u.Query().Get("nonce"), nonce)
+}
+
+func TestProvider_Authenticate(t *testing.T) {
+	m := newMockIssuer(t)
+	defer m.server.Close()
+	setupProviders(m.server.URL)
+
+	p, err := GetProvider("test")
+	assert.NoError(t, err)
+
+	t.Run("normal", func(t *testing.T) {
+		m.claims = nil
+		state, login := startLogin(t, p, m)
+		eu, err := p.Authenticate(&Callback{Code: "valid-code", State: state}, login)
+		assert.NoError(t, err)
+		assert.Equal(t, m.server.URL, eu.Issuer)
+		assert.Equal(t, "12345", eu.Subject)
+		assert.Equal(t, "oidcuser", eu.PreferredUsername)
+		assert.Equal(t, "oidc@example.com", eu.Email)
+	})
+	t.Run("audience as list", func(t *testing.T) {
+		m.claims = jwt.MapClaims{"aud": []string{"something", "vikunja"}}
+		state, login := startLogin(t, p, m)
+		_, err := p.Authenticate(&Callback{Code: "valid-code", State: state}, login)
+		assert.NoError(t, err)
+	})
+	t.Run("unverified email", func(t *testing.T) {
+		m.claims = jwt.MapClaims{"email_verified": false, "preferred_username": nil}
+		state, login := startLogin(t, p, m)
+		eu, err := p.Authenticate(&Callback{Code: "valid-code", State: state}, login)
+		assert.NoError(t, err)
+		assert.Empty(t, eu.Email)
+		assert.Equal(t, "oidc", eu.PreferredUsername)
+	})
+	t.Run("invalid state", func(t *testing.T) {
+		m.claims = nil
+		_, login := startLogin(t, p, m)
+		_, err := p.Authenticate(&Callback{Code: "valid-code", State: "invalid"}, login)
+		assert.Error(t, err)
+		assert.True(t, IsErrOpenIDInvalidState(err))
+	})
+	t.Run("without login cookie", func(t *testing.T) {
+		m.claims = nil
+		state, _ := startLogin(t, p, m)
+		_, err := p.Authenticate(&Callback{Code: "valid-code", State: state}, "")
+		assert.Error(t, err)
+		assert.True(t, IsErrOpenIDInvalidState(err))
+	})
+	t.Run("state of another login", func(t *testing.T) {
+		m.claims = nil
+		// The state and code of an attacker's login don't work with the login cookie of the victim
+		_, login := startLogin(t, p, m)
+		state, _ := startLogin(t, p, m)
+		_, err := p.Authenticate(&Callback{Code: "valid-code", State: state}, login)
+		assert.Error(t, err)
+		assert.True(t, IsErrOpenIDInvalidState(err))
+	})
+	t.Run("state of another provider", func(t *testing.T) {
+		m.claims = nil
+		other, err := GetProvider("other")
+		assert.NoError(t, err)
+		state, login := startLogin(t, other, m)
+		_, err = p.Authenticate(&Callback{Code: "valid-code", State: state}, login)
+		assert.Error(t, err)
+		assert.True(t, IsErrOpenIDInvalidState(err))
+	})
+	t.Run("invalid code", func(t *testing.T) {
+		m.claims = nil
+		state, login := startLogin(t, p, m)
+		_, err := p.Authenticate(&Callback{Code: "invalid-code", State: state}, login)
+		assert.Error(t, err)
+		assert.True(t, IsErrOpenIDAuthenticationFailed(err))
+	})
+	t.Run("wrong nonce", func(t *testing.T) {
+		m.claims = jwt.MapClaims{"nonce": "wrong"}
+		state, login := startLogin(t, p, m)
+		_, err := p.Authenticate(&Callback{Code: "valid-code", State: state}, login)
+		assert.Error(t, err)
+		assert.True(t, IsErrOpenIDAuthenticationFailed(err))
+	})
+	t.Run("wrong audience", func(t *testing.T) {
+		m.claims = jwt.MapClaims{"aud": "someone-else"}
+		state, login := startLogin(t, p, m)
+		_, err := p.Authenticate(&Callback{Code: "valid-code", State: state}, login)
+		assert.Error(t, err)
+		assert.True(t, IsErrOpenIDAuthenticationFailed(err))
+	})
+	t.Run("wrong issuer", func(t *testing.T) {
+		m.claims = jwt.MapClaims{"iss": "https://evil.example.com"}
+		state, login := startLogin(t, p, m)
+		_, err := p.Authenticate(&Callback{Code: "valid-code", State: state}, login)
+		assert.Error(t, err)
+		assert.True(t, IsErrOpenIDAuthenticationFailed(err))
+	})
+	t.Run("expired", func(t *testing.T) {
+		m.claims = jwt.MapClaims{"exp": time.Now().Add(-time.Minute).Unix()}
+		state, login := startLogin(t, p, m)
+		_, err := p.Authenticate(&Callback{Code: "valid-code", State: state}, login)
+		assert.Error(t, err)
+		assert.True(t, IsErrOpenIDAuthenticationFailed(err))
+	})
+	t.Run("no subject", func(t *testing.T) {
+		m.claims = jwt.MapClaims{"sub": nil}
+		state, login := startLogin(t, p, m)
+		_, err := p.Authenticate(&Callback{Code: "valid-code", State: state}, login)
+		assert.Error(t, err)
+		assert.True(t, IsErrOpenIDAuthenticationFailed(err))
+	})
+	t.Run("signed with another key", func(t *testing.T) {
+		m.claims = nil
+		other, err := rsa.GenerateKey(rand.Reader, 2048)
+		assert.NoError(t, err)
+		m.signingKey = other
+		defer func() { m.signingKey = nil }()
+
+		state, login := startLogin(t, p, m)
+		_, err = p.Authenticate(&Callback{Code: "valid-code", State: state}, login)
+		assert.Error(t, err)
+		assert.True(t, IsErrOpenIDAuthenticationFailed(err))
+	})
+}
@@ -19,21 +19,16 @@ package dump
 import (
 	"archive/zip"
 	"fmt"
-	"io"
 	"os"
-	"strconv"
 
 	"code.vikunja.io/api/pkg/db"
 	"code.vikunja.io/api/pkg/files"
 	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/utils"
 	"code.vikunja.io/api/pkg/version"
 	"github.com/spf13/viper"
 )
 
-// Change to deflate to gain better compression
-// see http://golang.org/pkg/archive/zip/#pkg-constants
-const compressionUsed = zip.Deflate
-
 // Dump creates a zip file with all vikunja files at filename
 func Dump(filename string) error {
 	dumpFile, err := os.Create(filename)
@@ -47,7 +42,7 @@ func Dump(filename string) error {
 
 	// Config
 	log.Info("Start dumping config file...")
-	err = writeFileToZip(viper.ConfigFileUsed(), dumpWriter)
+	err = utils.WriteFileToZip(viper.ConfigFileUsed(), dumpWriter)
 	if err != nil {
 		return fmt.Errorf("error saving config file: %s", err)
 	}
@@ -55,7 +50,7 @@ func Dump(filename string) error {
 
 	// Version
 	log.Info("Start dumping version file...")
-	err = writeBytesToZip("VERSION", []byte(version.Version), dumpWriter)
+	err = utils.WriteBytesToZip("VERSION", []byte(version.Version), dumpWriter)
 	if err != nil {
 		return fmt.Errorf("error saving version: %s", err)
 	}
@@ -68,7 +63,7 @@ func Dump(filename string) error {
 		return fmt.Errorf("error saving database data: %s", err)
 	}
 	for t, d := range data {
-		err = writeBytesToZip("database/"+t+".json", d, dumpWriter)
+		err = utils.WriteBytesToZip("database/"+t+".json", d, dumpWriter)
 		if err != nil {
 			return fmt.Errorf("error writing database table %s: %s", t, err)
 		}
@@ -81,20 +76,9 @@ func Dump(filename string) error {
 	if err != nil {
 		return fmt.Errorf("error saving file: %s", err)
 	}
-	for fid, file := range allFiles {
-		header := &zip.FileHeader{
-			Name:   "files/" + strconv.FormatInt(fid, 10),
-			Method: compressionUsed,
-		}
-		w, err := dumpWriter.CreateHeader(header)
-		if err != nil {
-			return err
-		}
-		_, err = io.Copy(w, file)
-		if err != nil {
-			return fmt.Errorf("error writing file %d: %s", fid, err)
-		}
-		_ = file.Close()
+	err = utils.WriteFilesToZip(allFiles, dumpWriter)
+	if err != nil {
+		return err
 	}
 	log.Infof("Dumped files")
 
@@ -102,46 +86,3 @@ func Dump(filename string) error {
 	log.Infof("Dump file saved at %s", filename)
 	return nil
 }
-
-func writeFileToZip(filename string, writer *zip.Writer) error {
-	// #nosec
-	fileToZip, err := os.Open(filename)
-	if err != nil {
-		return err
-	}
-	defer fileToZip.Close()
-
-	// Get the file information
-	info, err := fileToZip.Stat()
-	if err != nil {
-		return err
-	}
-
-	header, err := zip.FileInfoHeader(info)
-	if err != nil {
-		return err
-	}
-
-	header.Name = info.Name()
-	header.Method = compressionUsed
-
-	w, err := writer.CreateHeader(header)
-	if err != nil {
-		return err
-	}
-	_, err = io.Copy(w, fileToZip)
-	return err
-}
-
-func writeBytesToZip(filename string, data []byte, writer *zip.Writer) (err error) {
-	header := &zip.FileHeader{
-		Name:   filename,
-		Method: compressionUsed,
-	}
-	w, err := writer.CreateHeader(header)
-	if err != nil {
-		return err
-	}
-	_, err = w.Write(data)
-	return
-}
@@ -20,6 +20,7 @@ import (
 	"bytes"
 	"io/ioutil"
 
+	"code.vikunja.io/api/pkg/files"
 	"code.vikunja.io/api/pkg/log"
 	"code.vikunja.io/api/pkg/models"
 	"code.vikunja.io/api/pkg/user"
@@ -29,12 +30,83 @@ import (
 // (Namespaces, tasks, etc. Even attachments and relations.)
 func InsertFromStructure(str []*models.NamespaceWithLists, user *user.User) (err error) {
 
+	// Related tasks of the other migrators point to the same task objects as the tasks in the lists, so we need to
+	// point them to the copies we're inserting instead.
+	convertedTasks := make(map[*models.Task]*models.Task)
+	fullStructure := make([]*models.NamespaceWithListsAndTasks, 0, len(str))
+	for _, n := range str {
+		namespace := &models.NamespaceWithListsAndTasks{
+			Namespace: n.Namespace,
+			Lists:     make([]*models.ListWithTasksAndBuckets, 0, len(n.Lists)),
+		}
+		for _, l := range n.Lists {
+			list := &models.ListWithTasksAndBuckets{
+				List:  *l,
+				Tasks: make([]*models.TaskWithComments, 0, len(l.Tasks)),
+			}
+			for _, t := range l.Tasks {
+				task := &models.TaskWithComments{Task: *t}
+				convertedTasks[t] = &task.Task
+				list.Tasks = append(list.Tasks, task)
+			}
+			namespace.Lists = append(namespace.Lists, list)
+		}
+		fullStructure = append(fullStructure, namespace)
+	}
+
+	for _, n := range fullStructure {
+		for _, l := range n.Lists {
+			for _, t := range l.Tasks {
+				relatedTasks := make(models.RelatedTaskMap, len(t.RelatedTasks))
+				for kind, tasks := range t.RelatedTasks {
+					for _, rt := range tasks {
+						if converted, exists := convertedTasks[rt]; exists {
+							rt = converted
+						}
+						relatedTasks[kind] = append(relatedTasks[kind], rt)
+					}
+				}
+				t.RelatedTasks = relatedTasks
+			}
+		}
+	}
+
+	return InsertFromExportStructure(fullStructure, nil, user)
+}
+
+// ExportFiles gives access to the files stored in a Vikunja data export.
+type ExportFiles interface {
+	// Read returns the content of the file which had the given id in the exporting instance.
+	// exists is false if the export does not contain the file.
+	Read(id int64) (content []byte, exists bool, err error)
+}
+
+// InsertFromExportStructure takes the data of a Vikunja data export and a user and creates everything for this user.
+// In addition to InsertFromStructure, this also creates kanban buckets, custom fields, comments and list backgrounds.
+// All ids in the structure are only used to map tasks, buckets and relations to each other, everything is created new.
+// The content of attachments and backgrounds is read from exportFiles when they are created, every file only once.
+// exportFiles may be nil if the structure already contains the content of all files.
+//nolint:gocyclo
+func InsertFromExportStructure(str []*models.NamespaceWithListsAndTasks, exportFiles ExportFiles, user *user.User) (err error) {
+
 	log.Debugf("[creating structure] Creating %d namespaces", len(str))
 
 	labels := make(map[string]*models.Label)
 
+	// The tasks we created, mapped by the id they had in the structure. Used to create relations afterwards.
+	tasksByOldID := make(map[int64]*models.Task)
+	createdTasks := make(map[*models.Task]bool)
+	allTasks := []*models.Task{}
+
+	// The files we already created from the export, mapped by the id they had in the export.
+	// Attachments pointing to the same file in the export are copied from the file we already created.
+	createdFiles := make(map[int64]*files.File)
+
 	// Create all namespaces
 	for _, n := range str {
+		// Lists can't be created in archived namespaces, so we archive it after creating everything else.
+		isArchived := n.IsArchived
+		n.IsArchived = false
 		err = n.Create(user)
 		if err != nil {
 			return
@@ -45,64 +117,127 @@ func InsertFromStructure(str []*models.NamespaceWithLists, user *user.User) (err
 
 		// Create all lists
 		for _, l := range n.Lists {
-			// The tasks slice is going to be reset during the creation of the list so we rescue it here to be able
-			// to still loop over the tasks aftere the list was created.
-			tasks := l.Tasks
-
 			l.NamespaceID = n.ID
 			err = l.Create(user)
+			if models.IsErrListIdentifierIsNotUnique(err) {
+				l.Identifier = ""
+				err = l.Create(user)
+			}
 			if err != nil {
 				return
 			}
 
 			log.Debugf("[creating structure] Created list %d", l.ID)
-			log.Debugf("[creating structure] Creating %d tasks", len(tasks))
 
-			// Create all tasks
-			for _, t := range tasks {
-				t.ListID = l.ID
-				err = t.Create(user)
+			if exportFiles != nil && l.BackgroundFileID != 0 {
+				content, exists, err := exportFiles.Read(l.BackgroundFileID)
 				if err != nil {
-					return
+					return err
 				}
+				if exists {
+					l.BackgroundInformation = bytes.NewBuffer(content)
+				}
+			}
 
-				log.Debugf("[creating structure] Created task %d", t.ID)
-				if len(t.RelatedTasks) > 0 {
-					log.Debugf("[creating structure] Creating %d related task kinds", len(t.RelatedTasks))
+			if b, has := l.BackgroundInformation.(*bytes.Buffer); has && b.Len() > 0 {
+				file, err := files.Create(b, "", uint64(b.Len()), user)
+				if err != nil {
+					return err
+				}
+				err = models.SetListBackground(l.ID, file)
+				if err != nil {
+					return err
 				}
+				log.Debugf("[creating structure] Created background %d for list %d", file.ID, l.ID)
+			}
 
-				// Create all relation for each task
-				for kind, tasks := range t.RelatedTasks {
+			// Create all buckets
+			// The old bucket id is the key, the new one the value
+			bucketMap := make(map[int64]int64, len(l.Buckets))
+			var defaultBucket *models.Bucket
+			if len(l.Buckets) > 0 {
+				// The list already got a default bucket when it was created, it's removed once all tasks are in the
+				// buckets from the structure.
+				bs, _, _, err := (&models.Bucket{ListID: l.ID}).ReadAll(user, "", 0, 0)
+				if err != nil {
+					return err
+				}
+				defaultBucket = bs.([]*models.Bucket)[0]
 
-					if len(tasks) > 0 {
-						log.Debugf("[creating structure] Creating %d related tasks for kind %v", len(tasks), kind)
-					}
+				log.Debugf("[creating structure] Creating %d buckets", len(l.Buckets))
+			}
+			bucketLimits := make(map[*models.Bucket]int64)
+			for _, b := range l.Buckets {
+				oldID := b.ID
+				b.ID = 0
+				b.ListID = l.ID
+				// The limit is only set after all tasks are created to not run into it while creating them
+				if b.Limit > 0 {
+					bucketLimits[b] = b.Limit
+					b.Limit = 0
+				}
+				err = b.Create(user)
+				if err != nil {
+					return
+				}
+				bucketMap[oldID] = b.ID
+				log.Debugf("[creating structure] Created bucket %d", b.ID)
+			}
 
-					for _, rt := range tasks {
-						// First create the related tasks if they do not exist
-						if rt.ID == 0 {
-							rt.ListID = t.ListID
-							err = rt.Create(user)
-							if err != nil {
-								return
-							}
-							log.Debugf("[creating structure] Created related task %d", rt.ID)
-						}
+			// Create all custom fields
+			if len(l.CustomFields) > 0 {
+				log.Debugf("[creating structure] Creating %d custom fields", len(l.CustomFields))
+			}
+			userCustomFields := make(map[string]bool)
+			for _, cf := range l.CustomFields {
+				cf.ListID = l.ID
+				err = cf.Create(user)
+				if err != nil {
+					return
+				}
+				if cf.Type == models.CustomFieldTypeUser {
+					userCustomFields[cf.Name] = true
+				}
+				log.Debugf("[creating structure] Created custom field %d", cf.ID)
+			}
 
-						// Then create the relation
-						taskRel := &models.TaskRelation{
-							TaskID:       t.ID,
-							OtherTaskID:  rt.ID,
-							RelationKind: kind,
-						}
-						err = taskRel.Create(user)
-						if err != nil {
-							return
-						}
+			log.Debugf("[creating structure] Creating %d tasks", len(l.Tasks))
 
-						log.Debugf("[creating structure] Created task relation between task %d and %d", t.ID, rt.ID)
+			// Create all tasks
+			for _, t := range l.Tasks {
+				oldID := t.ID
+				t.ListID = l.ID
+				t.BucketID = bucketMap[t.BucketID]
+				// Assignees might not exist or not have access to the new list, the same goes for users in custom fields
+				t.Assignees = nil
+				for name := range userCustomFields {
+					delete(t.CustomFields, name)
+				}
+				err = t.Create(user)
+				if err != nil {
+					return
+				}
+
+				if oldID != 0 {
+					tasksByOldID[oldID] = &t.Task
+				}
+				createdTasks[&t.Task] = true
+				allTasks = append(allTasks, &t.Task)
 
+				log.Debugf("[creating structure] Created task %d", t.ID)
+
+				// Create all comments for each task
+				if len(t.Comments) > 0 {
+					log.Debugf("[creating structure] Creating %d comments", len(t.Comments))
+				}
+				for _, c := range t.Comments {
+					c.ID = 0
+					c.TaskID = t.ID
+					err = c.Create(user)
+					if err != nil {
+						return
 					}
+					log.Debugf("[creating structure] Created new comment %d", c.ID)
 				}
 
 				// Create all attachments for each task
@@ -110,11 +245,20 @@ func InsertFromStructure(str []*models.NamespaceWithLists, user *user.User) (err
 					log.Debugf("[creating structure] Creating %d attachments", len(t.Attachments))
 				}
 				for _, a := range t.Attachments {
+					if a.File != nil && exportFiles != nil {
+						err = createAttachmentFromExport(a, t.ID, exportFiles, createdFiles, user)
+						if err != nil {
+							return
+						}
+						continue
+					}
+
 					// Check if we have a file to create
-					if len(a.File.FileContent) > 0 {
+					if a.File != nil && len(a.File.FileContent) > 0 {
+						a.ID = 0
 						a.TaskID = t.ID
 						fr := ioutil.NopCloser(bytes.NewReader(a.File.FileContent))
-						err = a.NewAttachment(fr, a.File.Name, a.File.Size, user)
+						err = a.NewAttachment(fr, a.File.Name, uint64(len(a.File.FileContent)), user)
 						if err != nil {
 							return
 						}
@@ -130,6 +274,7 @@ func InsertFromStructure(str []*models.NamespaceWithLists, user *user.User) (err
 					var exists bool
 					lb, exists = labels[label.Title+label.HexColor]
 					if !exists {
+						label.ID = 0
 						err = label.Create(user)
 						if err != nil {
 							return err
@@ -150,6 +295,84 @@ func InsertFromStructure(str []*models.NamespaceWithLists, user *user.User) (err
 					log.Debugf("[creating structure] Associated task %d with label %d", t.ID, lb.ID)
 				}
 			}
+
+			for b, limit := range bucketLimits {
+				b.Limit = limit
+				err = b.Update()
+				if err != nil {
+					return
+				}
+			}
+
+			if defaultBucket != nil {
+				err = defaultBucket.Delete()
+				if err != nil {
+					return
+				}
+			}
+		}
+
+		if isArchived {
+			n.IsArchived = true
+			err = n.Update()
+			if err != nil {
+				return
+			}
+		}
+	}
+
+	// Create all relations once all tasks exist, since they can point to tasks in other lists
+	for _, t := range allTasks {
+		if len(t.RelatedTasks) > 0 {
+			log.Debugf("[creating structure] Creating %d related task kinds", len(t.RelatedTasks))
+		}
+
+		for kind, tasks := range t.RelatedTasks {
+
+			if len(tasks) > 0 {
+				log.Debugf("[creating structure] Creating %d related tasks for kind %v", len(tasks), kind)
+			}
+
+			for _, rt := range tasks {
+				otherTask := rt
+				switch {
+				case createdTasks[rt]:
+					// Already created as part of a list
+				case rt.ID == 0:
+					// Create the related task if it does not exist
+					rt.ListID = t.ListID
+					err = rt.Create(user)
+					if err != nil {
+						return
+					}
+					createdTasks[rt] = true
+					log.Debugf("[creating structure] Created related task %d", rt.ID)
+				default:
+					var exists bool
+					otherTask, exists = tasksByOldID[rt.ID]
+					if !exists {
+						log.Debugf("[creating structure] Not creating relation between task %d and old task %d because it was not part of the structure", t.ID, rt.ID)
+						continue
+					}
+				}
+
+				// Then create the relation
+				taskRel := &models.TaskRelation{
+					TaskID:       t.ID,
+					OtherTaskID:  otherTask.ID,
+					RelationKind: kind,
+				}
+				err = taskRel.Create(user)
+				// Relations are created in both directions, so the one from the other side might already exist
+				if models.IsErrRelationAlreadyExists(err) {
+					continue
+				}
+				if err != nil {
+					return
+				}
+
+				log.Debugf("[creating structure] Created task relation between task %d and %d", t.ID, otherTask.ID)
+			}
 		}
 	}
 
@@ -157,3 +380,43 @@ func InsertFromStructure(str []*models.NamespaceWithLists, user *user.User) (err
 
 	return nil
 }
+
+// Creates an attachment with the content of its file in the export. If another attachment with the same file was
+// already created, the content is copied from the file created for it instead of reading it from the export again.
+func createAttachmentFromExport(a *models.TaskAttachment, taskID int64, exportFiles ExportFiles, createdFiles map[int64]*files.File, user *user.User) (err error) {
+	oldFileID := a.File.ID
+	a.ID = 0
+	a.TaskID = taskID
+
+	if created, exists := createdFiles[oldFileID]; exists {
+		f := &files.File{ID: created.ID}
+		err = f.LoadFileByID()
+		if err != nil {
+			return err
+		}
+		defer f.File.Close()
+		err = a.NewAttachment(f.File, a.File.Name, created.Size, user)
+		if err != nil {
+			return err
+		}
+		log.Debugf("[creating structure] Created new attachment %d from the already created file %d", a.ID, created.ID)
+		return nil
+	}
+
+	content, exists, err := exportFiles.Read(oldFileID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		log.Debugf("[creating structure] Not creating attachment because its file %d is not part of the export", oldFileID)
+		return nil
+	}
+
+	err = a.NewAttachment(ioutil.NopCloser(bytes.NewReader(content)), a.File.Name, uint64(len(content)), user)
+	if err != nil {
+		return err
+	}
+	createdFiles[oldFileID] = a.File
+	log.Debugf("[creating structure] Created new attachment %d", a.ID)
+	return nil
+}
@@ -84,6 +84,10 @@ func (mw *MigrationWeb) Migrate(c echo.Context) error {
 func (mw *MigrationWeb) Status(c echo.Context) error {
 	ms := mw.MigrationStruct()
 
+	return status(ms, c)
+}
+
+func status(ms migration.MigratorName, c echo.Context) error {
 	user, err := user2.GetCurrentUser(c)
 	if err != nil {
 		return handler.HandleHTTPError(err, c)
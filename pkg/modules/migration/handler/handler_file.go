@@ -0,0 +1,80 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package handler
+
+import (
+	"net/http"
+
+	"code.vikunja.io/api/pkg/models"
+	"code.vikunja.io/api/pkg/modules/migration"
+	user2 "code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web/handler"
+	"github.com/labstack/echo/v4"
+)
+
+// FileMigratorWeb holds the web handler for migrators which import an uploaded file
+type FileMigratorWeb struct {
+	MigrationStruct func() migration.FileMigrator
+}
+
+// RegisterRoutes registers all routes for migration
+func (fw *FileMigratorWeb) RegisterRoutes(g *echo.Group) {
+	ms := fw.MigrationStruct()
+	g.GET("/"+ms.Name()+"/status", fw.Status)
+	g.PUT("/"+ms.Name()+"/migrate", fw.Migrate)
+}
+
+// Migrate imports the file uploaded as "import"
+func (fw *FileMigratorWeb) Migrate(c echo.Context) error {
+	ms := fw.MigrationStruct()
+
+	// Get the user from context
+	user, err := user2.GetCurrentUser(c)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	file, err := c.FormFile("import")
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "No or invalid file provided: "+err.Error())
+	}
+	src, err := file.Open()
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+	defer src.Close()
+
+	// Do the migration
+	err = ms.Migrate(user, src, file.Size)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	err = migration.SetMigrationStatus(ms, user)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	return c.JSON(http.StatusOK, models.Message{Message: "Everything was migrated successfully."})
+}
+
+// Status returns whether or not a user has already done this migration
+func (fw *FileMigratorWeb) Status(c echo.Context) error {
+	ms := fw.MigrationStruct()
+
+	return status(ms, c)
+}
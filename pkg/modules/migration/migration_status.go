@@ -36,7 +36,7 @@ func (s *Status) TableName() string {
 }
 
 // SetMigrationStatus sets the migration status for a user
-func SetMigrationStatus(m Migrator, u *user.User) (err error) {
+func SetMigrationStatus(m MigratorName, u *user.User) (err error) {
 	status := &Status{
 		UserID:       u.ID,
 		MigratorName: m.Name(),
@@ -46,7 +46,7 @@ func SetMigrationStatus(m Migrator, u *user.User) (err error) {
 }
 
 // GetMigrationStatus returns the migration status for a migration and a user
-func GetMigrationStatus(m Migrator, u *user.User) (status *Status, err error) {
+func GetMigrationStatus(m MigratorName, u *user.User) (status *Status, err error) {
 	status = &Status{}
 	_, err = x.Where("user_id = ? and migrator_name = ?", u.ID, m.Name()).Desc("id").Get(status)
 	return
@@ -18,11 +18,21 @@
 package migration
 
 import (
+	"io"
+
 	"code.vikunja.io/api/pkg/user"
 )
 
+// MigratorName is the interface shared among all migrators to get their name
+type MigratorName interface {
+	// Name holds the name of the migration.
+	// This is used to show the name to users and to keep track of users who already migrated.
+	Name() string
+}
+
 // Migrator is the basic migrator interface which is shared among all migrators
 type Migrator interface {
+	MigratorName
 	// Migrate is the interface used to migrate a user's tasks from another platform to vikunja.
 	// The user object is the user who's tasks will be migrated.
 	Migrate(user *user.User) error
@@ -30,7 +40,12 @@ type Migrator interface {
 	// The use case for this are Oauth flows, where the server token should remain hidden and not
 	// known to the frontend.
 	AuthURL() string
-	// Title holds the name of the migration.
-	// This is used to show the name to users and to keep track of users who already migrated.
-	Name() string
+}
+
+// FileMigrator is the interface for migrators which import a file uploaded by the user instead of talking to an api.
+type FileMigrator interface {
+	MigratorName
+	// Migrate is the interface used to migrate a user's tasks from a file to vikunja.
+	// The user object is the user who's tasks will be migrated.
+	Migrate(user *user.User, file io.ReaderAt, size int64) error
 }
@@ -0,0 +1,254 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package vikunjafile
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/files"
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/models"
+	"code.vikunja.io/api/pkg/modules/migration"
+	"code.vikunja.io/api/pkg/user"
+	"github.com/c2h5oh/datasize"
+)
+
+const logPrefix = "[Vikunja File Import] "
+
+// The maximum size of the json files in an import. Attachments and backgrounds are limited by the configured
+// maximum file size instead.
+const maxJSONFileSize = 100 * datasize.MB
+
+// The maximum size of all attachments and backgrounds in an import together.
+const maxImportFilesSize = 5 * datasize.GB
+
+// FileMigrator imports a Vikunja data export
+type FileMigrator struct{}
+
+// Name is used to get the name of the vikunja-file migration - we're using the docs here to annotate the status route.
+// @Summary Get migration status
+// @Description Returns if the current user already did the migation or not. This is useful to show a confirmation message in the frontend if the user is trying to do the same migration again.
+// @tags migration
+// @Produce json
+// @Security JWTKeyAuth
+// @Success 200 {object} migration.Status "The migration status"
+// @Failure 500 {object} models.Message "Internal server error"
+// @Router /migration/vikunja-file/status [get]
+func (v *FileMigrator) Name() string {
+	return "vikunja-file"
+}
+
+// Migrate imports all namespaces, lists, tasks, buckets, comments, saved filters and files from a Vikunja data export
+// @Summary Import all lists, tasks etc. from a Vikunja data export
+// @Description Imports all namespaces, lists, tasks, buckets, comments, saved filters and files from a Vikunja data export. The file needs to be uploaded as multipart form data in the field "import".
+// @tags migration
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Security JWTKeyAuth
+// @Param import formData string true "The Vikunja data export zip file."
+// @Success 200 {object} models.Message "A message telling you everything was migrated successfully."
+// @Failure 500 {object} models.Message "Internal server error"
+// @Router /migration/vikunja-file/migrate [put]
+func (v *FileMigrator) Migrate(user *user.User, file io.ReaderAt, size int64) error {
+	r, err := zip.NewReader(file, size)
+	if err != nil {
+		return fmt.Errorf("could not open import file: %s", err)
+	}
+
+	log.Debugf(logPrefix+"Importing a zip file containing %d files", len(r.File))
+
+	var maxFileSize datasize.ByteSize
+	err = maxFileSize.UnmarshalText([]byte(config.FilesMaxSize.GetString()))
+	if err != nil {
+		return err
+	}
+
+	var dataFile, filterFile *zip.File
+	storedFiles := make(map[int64]*zip.File)
+	for _, f := range r.File {
+		if strings.HasPrefix(f.Name, "files/") {
+			id, err := strconv.ParseInt(strings.TrimPrefix(f.Name, "files/"), 10, 64)
+			if err != nil {
+				return fmt.Errorf("could not convert file id: %s", err)
+			}
+			storedFiles[id] = f
+			continue
+		}
+		switch f.Name {
+		case "data.json":
+			dataFile = f
+		case "filters.json":
+			filterFile = f
+		}
+	}
+
+	if dataFile == nil {
+		return fmt.Errorf("import file does not contain a data.json, it is not a Vikunja data export")
+	}
+
+	data := []*models.NamespaceWithListsAndTasks{}
+	err = readJSONFromZip(dataFile, &data)
+	if err != nil {
+		return fmt.Errorf("could not read data: %s", err)
+	}
+
+	log.Debugf(logPrefix+"Read data for %d namespaces", len(data))
+
+	exportFiles := &exportFiles{
+		files:       storedFiles,
+		maxFileSize: maxFileSize.Bytes(),
+	}
+
+	// Check the sizes of all files before creating anything. They are only read once they are needed and checked
+	// again while reading them, because the sizes in the zip file can't be trusted.
+	err = exportFiles.checkSizes(data)
+	if err != nil {
+		return err
+	}
+
+	err = migration.InsertFromExportStructure(data, exportFiles, user)
+	if err != nil {
+		return fmt.Errorf("could not insert data: %s", err)
+	}
+
+	if filterFile == nil {
+		log.Debugf(logPrefix + "Import file does not contain saved filters, not importing any")
+		return nil
+	}
+
+	filters := []*models.SavedFilter{}
+	err = readJSONFromZip(filterFile, &filters)
+	if err != nil {
+		return fmt.Errorf("could not read saved filters: %s", err)
+	}
+
+	for _, f := range filters {
+		f.ID = 0
+		err = f.Create(user)
+		if err != nil {
+			return fmt.Errorf("could not create saved filter: %s", err)
+		}
+	}
+
+	log.Debugf(logPrefix+"Imported %d saved filters", len(filters))
+
+	return nil
+}
+
+// Gives access to the files stored in an import. Every file is only read once it is needed and the size of all
+// files read from the import together is limited.
+type exportFiles struct {
+	files       map[int64]*zip.File
+	maxFileSize uint64
+	read        uint64
+}
+
+// Checks the sizes of all files the data points to, as they are noted in the zip file. Files which are referenced
+// multiple times only count once since they are only read once.
+func (e *exportFiles) checkSizes(data []*models.NamespaceWithListsAndTasks) error {
+	ids := make(map[int64]bool)
+	for _, n := range data {
+		for _, l := range n.Lists {
+			if l.BackgroundFileID != 0 {
+				ids[l.BackgroundFileID] = true
+			}
+			for _, t := range l.Tasks {
+				for _, a := range t.Attachments {
+					if a.File != nil {
+						ids[a.File.ID] = true
+					}
+				}
+			}
+		}
+	}
+
+	var total uint64
+	for id := range ids {
+		f, exists := e.files[id]
+		if !exists {
+			continue
+		}
+		if f.UncompressedSize64 > e.maxFileSize {
+			return fmt.Errorf("could not read file %d: %s", id, files.ErrFileIsTooLarge{Size: f.UncompressedSize64})
+		}
+		total += f.UncompressedSize64
+		if total > maxImportFilesSize.Bytes() {
+			return fmt.Errorf("the files in the import are larger than %s", maxImportFilesSize.HR())
+		}
+	}
+
+	return nil
+}
+
+// Read reads a file from the import.
+func (e *exportFiles) Read(id int64) (content []byte, exists bool, err error) {
+	f, exists := e.files[id]
+	if !exists {
+		log.Debugf(logPrefix+"File %d is not part of the import", id)
+		return nil, false, nil
+	}
+
+	content, err = readFileFromZip(f, e.maxFileSize)
+	if err != nil {
+		return nil, true, fmt.Errorf("could not read file %d: %s", id, err)
+	}
+
+	e.read += uint64(len(content))
+	if e.read > maxImportFilesSize.Bytes() {
+		return nil, true, fmt.Errorf("the files in the import are larger than %s", maxImportFilesSize.HR())
+	}
+
+	return content, true, nil
+}
+
+// Reads a file from the import, but not more than maxSize bytes of it.
+func readFileFromZip(f *zip.File, maxSize uint64) (content []byte, err error) {
+	if f.UncompressedSize64 > maxSize {
+		return nil, files.ErrFileIsTooLarge{Size: f.UncompressedSize64}
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	// The size in the header of the zip file can't be trusted
+	content, err = ioutil.ReadAll(io.LimitReader(rc, int64(maxSize)+1))
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(content)) > maxSize {
+		return nil, files.ErrFileIsTooLarge{Size: uint64(len(content))}
+	}
+	return content, nil
+}
+
+func readJSONFromZip(f *zip.File, v interface{}) error {
+	content, err := readFileFromZip(f, maxJSONFileSize.Bytes())
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(content, v)
+}
@@ -0,0 +1,176 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package vikunjafile
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/api/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+const testData = `[
+  {
+    "id": 42,
+    "title": "Imported Namespace",
+    "lists": [
+      {
+        "id": 1337,
+        "title": "Imported List",
+        "background_file_id": 7,
+        "buckets": [
+          {"id": 100, "title": "Imported Bucket 1", "list_id": 1337},
+          {"id": 101, "title": "Imported Bucket 2", "list_id": 1337, "limit": 1}
+        ],
+        "tasks": [
+          {
+            "id": 500,
+            "title": "Imported Task 1",
+            "bucket_id": 101,
+            "related_tasks": {
+              "subtask": [{"id": 501, "title": "Imported Task 2"}],
+              "related": [{"id": 9999, "title": "Not exported"}]
+            },
+            "labels": [{"id": 1, "title": "Imported Label", "hex_color": "ff00ff"}],
+            "attachments": [{"id": 1, "task_id": 500, "file": {"id": 8, "name": "test.txt", "size": 4000}}],
+            "comments": [{"id": 1, "comment": "Imported Comment"}]
+          },
+          {
+            "id": 501,
+            "title": "Imported Task 2",
+            "bucket_id": 101,
+            "related_tasks": {
+              "parenttask": [{"id": 500, "title": "Imported Task 1"}]
+            },
+            "attachments": [{"id": 2, "task_id": 501, "file": {"id": 8, "name": "copy.txt", "size": 4000}}]
+          }
+        ]
+      }
+    ]
+  }
+]`
+
+const testFilters = `[{"id": 1, "title": "Imported Filter", "filters": {"filter_by": ["done"], "filter_value": ["false"]}}]`
+
+func createTestExport(t *testing.T, withData bool) *bytes.Reader {
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	if withData {
+		assert.NoError(t, utils.WriteBytesToZip("data.json", []byte(testData), w))
+	}
+	assert.NoError(t, utils.WriteBytesToZip("filters.json", []byte(testFilters), w))
+	assert.NoError(t, utils.WriteBytesToZip("files/7", []byte("background"), w))
+	assert.NoError(t, utils.WriteBytesToZip("files/8", []byte{1, 2, 3, 4}, w))
+	assert.NoError(t, w.Close())
+	return bytes.NewReader(buf.Bytes())
+}
+
+func TestFileMigrator_Migrate(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+
+		export := createTestExport(t, true)
+		m := &FileMigrator{}
+		err := m.Migrate(u, export, export.Size())
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "namespaces", map[string]interface{}{
+			"title":    "Imported Namespace",
+			"owner_id": u.ID,
+		}, false)
+		db.AssertExists(t, "list", map[string]interface{}{
+			"title":    "Imported List",
+			"owner_id": u.ID,
+		}, false)
+		db.AssertExists(t, "buckets", map[string]interface{}{
+			"title": "Imported Bucket 1",
+		}, false)
+		db.AssertExists(t, "buckets", map[string]interface{}{
+			"title": "Imported Bucket 2",
+			"limit": 1,
+		}, false)
+		// The default bucket created with the list is removed
+		db.AssertMissing(t, "buckets", map[string]interface{}{
+			"title": "New Bucket",
+		})
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"title": "Imported Task 1",
+		}, false)
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"title": "Imported Task 2",
+		}, false)
+		// The relation to the task which was not exported is skipped
+		db.AssertMissing(t, "tasks", map[string]interface{}{
+			"title": "Not exported",
+		})
+		db.AssertExists(t, "task_comments", map[string]interface{}{
+			"comment":   "Imported Comment",
+			"author_id": u.ID,
+		}, false)
+		db.AssertExists(t, "labels", map[string]interface{}{
+			"title":         "Imported Label",
+			"created_by_id": u.ID,
+		}, false)
+		// The size is taken from the content, not from the export
+		db.AssertExists(t, "files", map[string]interface{}{
+			"name":          "test.txt",
+			"size":          4,
+			"created_by_id": u.ID,
+		}, false)
+		// Another attachment with the same file in the export gets its own copy of the file
+		db.AssertExists(t, "files", map[string]interface{}{
+			"name":          "copy.txt",
+			"size":          4,
+			"created_by_id": u.ID,
+		}, false)
+		db.AssertExists(t, "saved_filters", map[string]interface{}{
+			"title":    "Imported Filter",
+			"owner_id": u.ID,
+		}, false)
+	})
+	t.Run("file too large", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		config.FilesMaxSize.Set("5B")
+		defer config.FilesMaxSize.Set("20MB")
+
+		export := createTestExport(t, true)
+		m := &FileMigrator{}
+		err := m.Migrate(u, export, export.Size())
+		assert.Error(t, err)
+		db.AssertMissing(t, "namespaces", map[string]interface{}{
+			"title": "Imported Namespace",
+		})
+	})
+	t.Run("not a vikunja export", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+
+		export := createTestExport(t, false)
+		m := &FileMigrator{}
+		err := m.Migrate(u, export, export.Size())
+		assert.Error(t, err)
+		db.AssertMissing(t, "saved_filters", map[string]interface{}{
+			"title": "Imported Filter",
+		})
+	})
+}
@@ -0,0 +1,105 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package realtime
+
+import (
+	"sync"
+
+	"code.vikunja.io/api/pkg/log"
+)
+
+// How many messages are buffered for every subscriber before new ones get dropped
+const subscriptionBufferSize = 100
+
+// memoryBroker distributes messages to subscribers in this instance only
+type memoryBroker struct {
+	subscribers map[string]map[*memorySubscription]bool
+	mutex       sync.RWMutex
+}
+
+func newMemoryBroker() *memoryBroker {
+	return &memoryBroker{
+		subscribers: make(map[string]map[*memorySubscription]bool),
+	}
+}
+
+// Publish sends a message to all subscribers of its topic
+func (b *memoryBroker) Publish(msg *Message) error {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	for sub := range b.subscribers[msg.Topic] {
+		// A slow subscriber should never block everyone else
+		select {
+		case sub.messages <- msg:
+		default:
+			log.Debugf("[Realtime] Dropped message on topic %s because the subscriber is too slow", msg.Topic)
+		}
+	}
+
+	return nil
+}
+
+// Subscribe creates a new subscription for the given topics
+func (b *memoryBroker) Subscribe(topics []string) (Subscription, error) {
+	sub := &memorySubscription{
+		broker:   b,
+		topics:   topics,
+		messages: make(chan *Message, subscriptionBufferSize),
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for _, t := range topics {
+		if _, exists := b.subscribers[t]; !exists {
+			b.subscribers[t] = make(map[*memorySubscription]bool)
+		}
+		b.subscribers[t][sub] = true
+	}
+
+	return sub, nil
+}
+
+type memorySubscription struct {
+	broker    *memoryBroker
+	topics    []string
+	messages  chan *Message
+	closeOnce sync.Once
+}
+
+// Messages returns the channel where all messages for this subscription arrive
+func (s *memorySubscription) Messages() <-chan *Message {
+	return s.messages
+}
+
+// Close removes the subscription from the broker
+func (s *memorySubscription) Close() error {
+	s.closeOnce.Do(func() {
+		s.broker.mutex.Lock()
+		defer s.broker.mutex.Unlock()
+
+		for _, t := range s.topics {
+			delete(s.broker.subscribers[t], s)
+			if len(s.broker.subscribers[t]) == 0 {
+				delete(s.broker.subscribers, t)
+			}
+		}
+		close(s.messages)
+	})
+	return nil
+}
@@ -0,0 +1,147 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package realtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"code.vikunja.io/api/pkg/config"
+)
+
+// All kinds of topics a client can subscribe to
+const (
+	TopicKindList = `list`
+	TopicKindTask = `task`
+	TopicKindUser = `user`
+)
+
+// Message is a single realtime message sent to everyone subscribed to its topic
+type Message struct {
+	// The topic this message was published on, for example "list.1"
+	Topic string `json:"topic"`
+	// The name of the event which caused this message, for example "task.updated"
+	Event string          `json:"event"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// Broker distributes messages to all subscribers of their topic
+type Broker interface {
+	Publish(msg *Message) (err error)
+	Subscribe(topics []string) (sub Subscription, err error)
+}
+
+// Subscription receives all messages published on the topics it was created with
+type Subscription interface {
+	Messages() <-chan *Message
+	Close() (err error)
+}
+
+var (
+	broker Broker
+
+	stopped  = make(chan struct{})
+	stopOnce sync.Once
+)
+
+// InitBroker initializes the realtime message broker.
+// If redis is enabled, messages are distributed to all api instances connected to it, otherwise only to
+// subscribers connected to this instance.
+func InitBroker() {
+	if config.RedisEnabled.GetBool() {
+		broker = newRedisBroker()
+		return
+	}
+	broker = newMemoryBroker()
+}
+
+// Publish sends data to everyone subscribed to a topic
+func Publish(topic, event string, data interface{}) error {
+	if broker == nil {
+		return nil
+	}
+
+	d, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	return broker.Publish(&Message{
+		Topic: topic,
+		Event: event,
+		Data:  d,
+	})
+}
+
+// Subscribe creates a new subscription for all messages on the given topics.
+// The subscription needs to be closed when it is not used anymore.
+func Subscribe(topics ...string) (Subscription, error) {
+	if broker == nil {
+		InitBroker()
+	}
+	return broker.Subscribe(topics)
+}
+
+// Stop tells all open streams to finish. Call this before shutting down the web server, otherwise it would wait
+// for all clients to disconnect.
+func Stop() {
+	stopOnce.Do(func() {
+		close(stopped)
+	})
+}
+
+// Stopped returns a channel which is closed once Stop was called
+func Stopped() <-chan struct{} {
+	return stopped
+}
+
+func topic(kind string, id int64) string {
+	return kind + "." + strconv.FormatInt(id, 10)
+}
+
+// ListTopic returns the topic for everything happening in a list
+func ListTopic(listID int64) string {
+	return topic(TopicKindList, listID)
+}
+
+// TaskTopic returns the topic for everything happening to a task
+func TaskTopic(taskID int64) string {
+	return topic(TopicKindTask, taskID)
+}
+
+// UserTopic returns the topic for the personal feed of a user
+func UserTopic(userID int64) string {
+	return topic(TopicKindUser, userID)
+}
+
+// ParseTopic returns the kind and id of a topic
+func ParseTopic(t string) (kind string, id int64, err error) {
+	parts := strings.SplitN(t, ".", 2)
+	if len(parts) != 2 {
+		return "", 0, fmt.Errorf("invalid topic %s", t)
+	}
+
+	id, err = strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid topic %s: %s", t, err)
+	}
+
+	return parts[0], id, nil
+}
@@ -0,0 +1,86 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package realtime
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryBroker(t *testing.T) {
+	t.Run("publish to subscribers", func(t *testing.T) {
+		b := newMemoryBroker()
+		sub, err := b.Subscribe([]string{ListTopic(1), TaskTopic(2)})
+		assert.NoError(t, err)
+		other, err := b.Subscribe([]string{ListTopic(3)})
+		assert.NoError(t, err)
+
+		err = b.Publish(&Message{Topic: ListTopic(1), Event: "task.updated"})
+		assert.NoError(t, err)
+		err = b.Publish(&Message{Topic: TaskTopic(2), Event: "task.comment.created"})
+		assert.NoError(t, err)
+
+		msg := <-sub.Messages()
+		assert.Equal(t, "list.1", msg.Topic)
+		assert.Equal(t, "task.updated", msg.Event)
+		msg = <-sub.Messages()
+		assert.Equal(t, "task.2", msg.Topic)
+		assert.Len(t, other.Messages(), 0)
+	})
+	t.Run("closed subscription", func(t *testing.T) {
+		b := newMemoryBroker()
+		sub, err := b.Subscribe([]string{ListTopic(1)})
+		assert.NoError(t, err)
+
+		assert.NoError(t, sub.Close())
+		// Closing twice should not panic
+		assert.NoError(t, sub.Close())
+		assert.Len(t, b.subscribers, 0)
+
+		err = b.Publish(&Message{Topic: ListTopic(1)})
+		assert.NoError(t, err)
+		_, ok := <-sub.Messages()
+		assert.False(t, ok)
+	})
+	t.Run("slow subscriber", func(t *testing.T) {
+		b := newMemoryBroker()
+		sub, err := b.Subscribe([]string{ListTopic(1)})
+		assert.NoError(t, err)
+
+		for i := 0; i < subscriptionBufferSize+10; i++ {
+			err = b.Publish(&Message{Topic: ListTopic(1)})
+			assert.NoError(t, err)
+		}
+		assert.Len(t, sub.Messages(), subscriptionBufferSize)
+	})
+}
+
+func TestParseTopic(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		kind, id, err := ParseTopic(UserTopic(42))
+		assert.NoError(t, err)
+		assert.Equal(t, TopicKindUser, kind)
+		assert.Equal(t, int64(42), id)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		_, _, err := ParseTopic("list")
+		assert.Error(t, err)
+		_, _, err = ParseTopic("list.abc")
+		assert.Error(t, err)
+	})
+}
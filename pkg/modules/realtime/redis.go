@@ -0,0 +1,80 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package realtime
+
+import (
+	"encoding/json"
+	"sync"
+
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/red"
+	"github.com/go-redis/redis/v7"
+)
+
+// All realtime messages are published on redis channels with this prefix
+const redisChannelPrefix = `vikunja.realtime.`
+
+// redisBroker publishes all messages to redis. Every instance listens on all realtime channels with a single
+// redis connection and passes the messages on to its own subscribers.
+type redisBroker struct {
+	client     *redis.Client
+	local      *memoryBroker
+	listenOnce sync.Once
+}
+
+func newRedisBroker() *redisBroker {
+	red.InitRedis()
+
+	return &redisBroker{
+		client: red.GetRedis(),
+		local:  newMemoryBroker(),
+	}
+}
+
+// Publish sends a message to all instances connected to redis
+func (b *redisBroker) Publish(msg *Message) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	return b.client.Publish(redisChannelPrefix+msg.Topic, payload).Err()
+}
+
+// Subscribe creates a new subscription for the given topics
+func (b *redisBroker) Subscribe(topics []string) (Subscription, error) {
+	// Only instances which actually have subscribers need to listen for messages
+	b.listenOnce.Do(b.listen)
+	return b.local.Subscribe(topics)
+}
+
+func (b *redisBroker) listen() {
+	pubsub := b.client.PSubscribe(redisChannelPrefix + "*")
+
+	go func() {
+		for m := range pubsub.Channel() {
+			msg := &Message{}
+			if err := json.Unmarshal([]byte(m.Payload), msg); err != nil {
+				log.Errorf("[Realtime] Could not decode message from redis channel %s: %s", m.Channel, err)
+				continue
+			}
+			_ = b.local.Publish(msg)
+		}
+	}()
+
+	log.Debugf("[Realtime] Listening for realtime messages on redis")
+}
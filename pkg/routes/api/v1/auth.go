@@ -36,7 +36,8 @@ const (
 )
 
 // NewUserJWTAuthtoken generates and signes a new jwt token for a user. This is a global function to be able to call it from integration tests.
-func NewUserJWTAuthtoken(user *user.User) (token string, err error) {
+// The token belongs to the session with the given id, tokens which are not part of a session use 0.
+func NewUserJWTAuthtoken(user *user.User, sessionID int64) (token string, err error) {
 	t := jwt.New(jwt.SigningMethodHS256)
 
 	// Set claims
@@ -45,12 +46,39 @@ func NewUserJWTAuthtoken(user *user.User) (token string, err error) {
 	claims["id"] = user.ID
 	claims["username"] = user.Username
 	claims["email"] = user.Email
-	claims["exp"] = time.Now().Add(time.Hour * 72).Unix()
+	claims["exp"] = time.Now().Add(time.Duration(config.ServiceJWTTTL.GetInt64()) * time.Second).Unix()
+	if sessionID > 0 {
+		claims["sid"] = sessionID
+	}
 
 	// Generate encoded token and send it as response.
 	return t.SignedString([]byte(config.ServiceJWTSecret.GetString()))
 }
 
+// GetSessionIDFromClaims returns the id of the session a user token belongs to or 0 if it has none
+func GetSessionIDFromClaims(claims jwt.MapClaims) int64 {
+	sid, is := claims["sid"].(float64)
+	if !is {
+		return 0
+	}
+	return int64(sid)
+}
+
+// Creates a new session for a user who just logged in and returns the first access and refresh token for it
+func newUserSessionToken(c echo.Context, u *user.User) (t *Token, err error) {
+	s, refreshToken, err := user.CreateSession(u, c.Request().UserAgent(), c.RealIP())
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := NewUserJWTAuthtoken(u, s.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Token{Token: token, RefreshToken: refreshToken}, nil
+}
+
 // NewLinkShareJWTAuthtoken creates a new jwt token from a link share
 func NewLinkShareJWTAuthtoken(share *models.LinkSharing) (token string, err error) {
 	t := jwt.New(jwt.SigningMethodHS256)
@@ -63,7 +91,12 @@ func NewLinkShareJWTAuthtoken(share *models.LinkSharing) (token string, err erro
 	claims["list_id"] = share.ListID
 	claims["right"] = share.Right
 	claims["sharedByID"] = share.SharedByID
-	claims["exp"] = time.Now().Add(time.Hour * 72).Unix()
+	exp := time.Now().Add(time.Hour * 72)
+	// The token should not be valid longer than the share itself
+	if !share.ExpiresAt.IsZero() && share.ExpiresAt.Before(exp) {
+		exp = share.ExpiresAt
+	}
+	claims["exp"] = exp.Unix()
 
 	// Generate encoded token and send it as response.
 	return t.SignedString([]byte(config.ServiceJWTSecret.GetString()))
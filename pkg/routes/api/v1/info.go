@@ -20,9 +20,12 @@ import (
 	"net/http"
 
 	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/modules/auth/openid"
 	"code.vikunja.io/api/pkg/modules/migration/todoist"
+	vikunjafile "code.vikunja.io/api/pkg/modules/migration/vikunja-file"
 	"code.vikunja.io/api/pkg/modules/migration/wunderlist"
 	"code.vikunja.io/api/pkg/version"
+	"code.vikunja.io/web/handler"
 	"github.com/labstack/echo/v4"
 )
 
@@ -39,6 +42,23 @@ type vikunjaInfos struct {
 	TotpEnabled                bool      `json:"totp_enabled"`
 	Legal                      legalInfo `json:"legal"`
 	CaldavEnabled              bool      `json:"caldav_enabled"`
+	WebhooksEnabled            bool      `json:"webhooks_enabled"`
+	TimeTrackingEnabled        bool      `json:"time_tracking_enabled"`
+	Auth                       authInfo  `json:"auth"`
+}
+
+type authInfo struct {
+	OpenIDConnect openIDAuthInfo   `json:"openid_connect"`
+	WebAuthn      webAuthnAuthInfo `json:"webauthn"`
+}
+
+type webAuthnAuthInfo struct {
+	Enabled bool `json:"enabled"`
+}
+
+type openIDAuthInfo struct {
+	Enabled   bool               `json:"enabled"`
+	Providers []*openid.Provider `json:"providers"`
 }
 
 type legalInfo struct {
@@ -64,12 +84,16 @@ func Info(c echo.Context) error {
 		TaskAttachmentsEnabled: config.ServiceEnableTaskAttachments.GetBool(),
 		TotpEnabled:            config.ServiceEnableTotp.GetBool(),
 		CaldavEnabled:          config.ServiceEnableCaldav.GetBool(),
+		WebhooksEnabled:        config.WebhooksEnabled.GetBool(),
+		TimeTrackingEnabled:    config.ServiceEnableTimeTracking.GetBool(),
 		Legal: legalInfo{
 			ImprintURL:       config.LegalImprintURL.GetString(),
 			PrivacyPolicyURL: config.LegalPrivacyURL.GetString(),
 		},
 	}
 
+	info.Auth.WebAuthn.Enabled = config.AuthWebAuthnEnabled.GetBool()
+
 	// Migrators
 	if config.MigrationWunderlistEnable.GetBool() {
 		m := &wunderlist.Migration{}
@@ -79,6 +103,8 @@ func Info(c echo.Context) error {
 		m := &todoist.Migration{}
 		info.AvailableMigrators = append(info.AvailableMigrators, m.Name())
 	}
+	vikunjaFileMigrator := &vikunjafile.FileMigrator{}
+	info.AvailableMigrators = append(info.AvailableMigrators, vikunjaFileMigrator.Name())
 
 	if config.BackgroundsEnabled.GetBool() {
 		if config.BackgroundsUploadEnabled.GetBool() {
@@ -89,5 +115,14 @@ func Info(c echo.Context) error {
 		}
 	}
 
+	if config.AuthOpenIDEnabled.GetBool() {
+		providers, err := openid.GetAllProviders()
+		if err != nil {
+			return handler.HandleHTTPError(err, c)
+		}
+		info.Auth.OpenIDConnect.Enabled = true
+		info.Auth.OpenIDConnect.Providers = providers
+	}
+
 	return c.JSON(http.StatusOK, info)
 }
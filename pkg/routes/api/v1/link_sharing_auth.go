@@ -31,24 +31,44 @@ type LinkShareToken struct {
 	ListID int64 `json:"list_id"`
 }
 
+// LinkShareAuth holds the password to authenticate with a password protected link share
+type LinkShareAuth struct {
+	// The password of the link share. Only needed if the share is password protected.
+	Password string `json:"password"`
+}
+
 // AuthenticateLinkShare gives a jwt auth token for valid share hashes
 // @Summary Get an auth token for a share
-// @Description Get a jwt auth token for a shared list from a share hash.
+// @Description Get a jwt auth token for a shared list from a share hash. Password protected shares need the password.
 // @tags sharing
 // @Accept json
 // @Produce json
 // @Param share path string true "The share hash"
+// @Param password body v1.LinkShareAuth false "The password for password protected link shares."
 // @Success 200 {object} v1.Token "The valid jwt auth token."
 // @Failure 400 {object} web.HTTPError "Invalid link share object provided."
+// @Failure 403 {object} web.HTTPError "The link share needs a password, the provided one is wrong or the share can't be used anymore."
+// @Failure 429 {object} web.HTTPError "Too many wrong passwords."
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /shares/{share}/auth [post]
 func AuthenticateLinkShare(c echo.Context) error {
+	sa := &LinkShareAuth{}
+	if err := c.Bind(sa); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid model provided.")
+	}
+
 	hash := c.Param("share")
 	share, err := models.GetLinkShareByHash(hash)
 	if err != nil {
 		return handler.HandleHTTPError(err, c)
 	}
 
+	err = models.AuthenticateLinkShare(share, sa.Password)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+	share.Password = ""
+
 	t, err := NewLinkShareJWTAuthtoken(share)
 	if err != nil {
 		return handler.HandleHTTPError(err, c)
@@ -29,18 +29,22 @@ import (
 // Token represents an authentification token
 type Token struct {
 	Token string `json:"token"`
+	// The token to get a new access token once this one expired. Only returned when a new session was created.
+	RefreshToken string `json:"refresh_token,omitempty"`
+	// How many totp recovery codes the user has left. Only returned when logging in with totp.
+	TOTPRecoveryCodesLeft *int64 `json:"totp_recovery_codes_left,omitempty"`
 }
 
 // Login is the login handler
 // @Summary Login
-// @Description Logs a user in. Returns a JWT-Token to authenticate further requests.
+// @Description Logs a user in. Returns a short-lived JWT-Token to authenticate further requests and a refresh token to get a new one once it expired. If the user has totp or security keys enabled, either a totp passcode or the response of a security key (see /login/webauthn/begin) is needed.
 // @tags user
 // @Accept json
 // @Produce json
 // @Param credentials body user.Login true "The login credentials"
 // @Success 200 {object} v1.Token
 // @Failure 400 {object} models.Message "Invalid user password model."
-// @Failure 412 {object} models.Message "Invalid totp passcode."
+// @Failure 412 {object} models.Message "Invalid totp passcode, recovery code or security key response."
 // @Failure 403 {object} models.Message "Invalid username or password."
 // @Router /login [post]
 func Login(c echo.Context) error {
@@ -60,23 +64,46 @@ func Login(c echo.Context) error {
 		return handler.HandleHTTPError(err, c)
 	}
 
-	if totpEnabled {
-		_, err = user2.ValidateTOTPPasscode(&user2.TOTPPasscode{
+	webAuthnEnabled, err := user2.WebAuthnEnabledForUser(user)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	var recoveryCodesLeft *int64
+	switch {
+	case webAuthnEnabled && len(u.WebAuthn) > 0:
+		// A security key can be used instead of the totp passcode
+		err = user2.ValidateWebAuthnLogin(user, u.WebAuthn, false)
+		if err != nil {
+			return handler.HandleHTTPError(err, c)
+		}
+	case webAuthnEnabled && (!totpEnabled || u.TOTPPasscode == ""):
+		return handler.HandleHTTPError(user2.ErrSecondFactorRequired{UserID: user.ID}, c)
+	case totpEnabled:
+		// A recovery code can be used instead of the totp passcode
+		_, err = user2.ValidateTOTPPasscodeOrRecoveryCode(&user2.TOTPPasscode{
 			User:     user,
 			Passcode: u.TOTPPasscode,
 		})
 		if err != nil {
 			return handler.HandleHTTPError(err, c)
 		}
+
+		left, err := user2.GetTOTPRecoveryCodesLeft(user)
+		if err != nil {
+			return handler.HandleHTTPError(err, c)
+		}
+		recoveryCodesLeft = &left
 	}
 
-	// Create token
-	t, err := NewUserJWTAuthtoken(user)
+	// Create a session and a token for it
+	t, err := newUserSessionToken(c, user)
 	if err != nil {
-		return err
+		return handler.HandleHTTPError(err, c)
 	}
+	t.TOTPRecoveryCodesLeft = recoveryCodesLeft
 
-	return c.JSON(http.StatusOK, Token{Token: t})
+	return c.JSON(http.StatusOK, t)
 }
 
 // RenewToken gives a new token to every user with a valid token
@@ -95,9 +122,7 @@ func RenewToken(c echo.Context) (err error) {
 	claims := jwtinf.Claims.(jwt.MapClaims)
 	typ := int(claims["type"].(float64))
 	if typ == AuthTypeLinkShare {
-		share := &models.LinkSharing{}
-		share.ID = int64(claims["id"].(float64))
-		err := share.ReadOne()
+		share, err := models.GetLinkShareFromClaims(claims)
 		if err != nil {
 			return handler.HandleHTTPError(err, c)
 		}
@@ -113,8 +138,8 @@ func RenewToken(c echo.Context) (err error) {
 		return handler.HandleHTTPError(err, c)
 	}
 
-	// Create token
-	t, err := NewUserJWTAuthtoken(user)
+	// Create token, it belongs to the same session as the one it was renewed with
+	t, err := NewUserJWTAuthtoken(user, GetSessionIDFromClaims(claims))
 	if err != nil {
 		return err
 	}
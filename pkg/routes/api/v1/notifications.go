@@ -0,0 +1,56 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package v1
+
+import (
+	"net/http"
+
+	"code.vikunja.io/api/pkg/models"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web/handler"
+	"github.com/labstack/echo/v4"
+)
+
+// MarkAllNotificationsAsRead marks all notifications of the current user as read
+// @Summary Mark all notifications as read
+// @Description Marks all unread notifications of the current user as read.
+// @tags notifications
+// @Produce json
+// @Security JWTKeyAuth
+// @Success 200 {object} models.Message "All notifications were marked as read."
+// @Failure 403 {object} web.HTTPError "Link shares cannot have notifications."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /notifications [post]
+func MarkAllNotificationsAsRead(c echo.Context) error {
+	auth, err := GetAuthFromClaims(c)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	// Link shares cannot have notifications
+	u, err := user.GetFromAuth(auth)
+	if err != nil {
+		return handler.HandleHTTPError(models.ErrGenericForbidden{}, c)
+	}
+
+	err = models.MarkAllNotificationsAsRead(u)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	return c.JSON(http.StatusOK, models.Message{Message: "All notifications were marked as read."})
+}
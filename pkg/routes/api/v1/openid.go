@@ -0,0 +1,106 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package v1
+
+import (
+	"net/http"
+
+	"code.vikunja.io/api/pkg/models"
+	"code.vikunja.io/api/pkg/modules/auth/openid"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web/handler"
+	"github.com/labstack/echo/v4"
+)
+
+// RedirectToOpenIDProvider redirects the user to the login page of an openid provider
+// @Summary Log in with an openid provider
+// @Description Redirects to the login page of the openid provider. After the user logged in, the provider redirects back to the frontend which then needs to call the callback endpoint with the code and state it got. The login is bound to the browser with an http only cookie, the callback therefore needs to be called from the same browser with credentials.
+// @tags auth
+// @Param provider path string true "The key of the openid provider"
+// @Success 302 "Redirect to the provider."
+// @Failure 404 {object} models.Message "The provider does not exist."
+// @Failure 500 {object} models.Message "Internal server error"
+// @Router /auth/openid/{provider} [get]
+func RedirectToOpenIDProvider(c echo.Context) error {
+	provider, err := openid.GetProvider(c.Param("provider"))
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	authURL, login, err := provider.GetAuthURL()
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	c.SetCookie(openid.NewLoginCookie(login, c.Scheme() == "https"))
+	return c.Redirect(http.StatusFound, authURL)
+}
+
+// HandleOpenIDCallback logs a user in with the code an openid provider issued
+// @Summary Authenticate with an openid provider
+// @Description Exchanges the code and state from the provider redirect for a Vikunja jwt token. If this is the first time the user logs in, a new user is created. The login cookie set when redirecting to the provider needs to be sent along, it can only be used once.
+// @tags auth
+// @Accept json
+// @Produce json
+// @Param provider path string true "The key of the openid provider"
+// @Param callback body openid.Callback true "The code and state the provider added to the redirect url"
+// @Success 200 {object} v1.Token
+// @Failure 400 {object} models.Message "The state is invalid or expired."
+// @Failure 404 {object} models.Message "The provider does not exist."
+// @Failure 412 {object} models.Message "The authentication with the provider failed."
+// @Failure 500 {object} models.Message "Internal server error"
+// @Router /auth/openid/{provider}/callback [post]
+func HandleOpenIDCallback(c echo.Context) error {
+	cb := &openid.Callback{}
+	if err := c.Bind(cb); err != nil {
+		return c.JSON(http.StatusBadRequest, models.Message{Message: "Bad data"})
+	}
+
+	provider, err := openid.GetProvider(c.Param("provider"))
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	var login string
+	if cookie, err := c.Cookie(openid.LoginCookieName); err == nil {
+		login = cookie.Value
+	}
+	c.SetCookie(openid.ClearLoginCookie(c.Scheme() == "https"))
+
+	eu, err := provider.Authenticate(cb, login)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	// New users get their namespace through the user created event
+	u, _, err := user.GetOrCreateExternalUser(eu)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	if !u.IsActive {
+		return handler.HandleHTTPError(user.ErrEmailNotConfirmed{UserID: u.ID}, c)
+	}
+
+	// Create a session and a token for it
+	t, err := newUserSessionToken(c, u)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	return c.JSON(http.StatusOK, t)
+}
@@ -0,0 +1,169 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/models"
+	"code.vikunja.io/api/pkg/modules/realtime"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web/handler"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo/v4"
+)
+
+// Proxies and load balancers tend to close connections which did not send anything for a while
+const realtimeKeepAliveInterval = 30 * time.Second
+
+func parseIDsFromQuery(c echo.Context, name string) (ids []int64, err error) {
+	for _, raw := range c.QueryParams()[name] {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return
+}
+
+// Streams stay open for a long time, the user may have logged out, changed their password or deleted their account
+// since it was opened. Link shares may have been deleted or expired.
+func checkRealtimeAuth(c echo.Context) error {
+	jwtinf := c.Get("user").(*jwt.Token)
+	claims := jwtinf.Claims.(jwt.MapClaims)
+	if err := claims.Valid(); err != nil {
+		return err
+	}
+
+	typ, _ := claims["type"].(float64)
+	switch int(typ) {
+	case AuthTypeUser:
+		userID, _ := claims["id"].(float64)
+		return user.CheckSession(GetSessionIDFromClaims(claims), int64(userID), c.RealIP())
+	case AuthTypeLinkShare:
+		_, err := models.GetLinkShareFromClaims(claims)
+		return err
+	}
+	return nil
+}
+
+// SubscribeToEvents streams realtime updates as server-sent events
+// @Summary Subscribe to realtime updates
+// @Description Opens a stream of server-sent events with everything that happens to the subscribed lists and tasks. Every event contains the changed task, bucket, comment, label, attachment or relation.
+// @Description Subscribing to your feed gets you your new notifications as they happen. Because browsers can't set headers for server-sent events, the jwt can also be passed as `token` query parameter.
+// @tags realtime
+// @Produce text/event-stream
+// @Security JWTKeyAuth
+// @Param list query int false "The id of a list to get all updates for. Can be passed multiple times."
+// @Param task query int false "The id of a task to get all updates for. Can be passed multiple times."
+// @Param feed query bool false "If true, you get all notifications for the current user as they happen."
+// @Param token query string false "The jwt, if you can't pass it as header."
+// @Success 200 {string} string "The event stream."
+// @Failure 400 {object} web.HTTPError "Nothing to subscribe to or invalid ids provided."
+// @Failure 403 {object} web.HTTPError "The user does not have access to one of the lists or tasks, or tried to subscribe to the feed with a link share."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /events [get]
+func SubscribeToEvents(c echo.Context) error {
+	listIDs, err := parseIDsFromQuery(c, "list")
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid list id provided.")
+	}
+	taskIDs, err := parseIDsFromQuery(c, "task")
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid task id provided.")
+	}
+	feed := c.QueryParam("feed") == "true"
+
+	if len(listIDs) == 0 && len(taskIDs) == 0 && !feed {
+		return echo.NewHTTPError(http.StatusBadRequest, "You need to subscribe to at least one list, task or your feed.")
+	}
+
+	auth, err := GetAuthFromClaims(c)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	topics, err := models.GetRealtimeTopics(auth, listIDs, taskIDs, feed)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	sub, err := realtime.Subscribe(topics...)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+	defer sub.Close()
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set("Cache-Control", "no-cache")
+	res.Header().Set("Connection", "keep-alive")
+	// Prevents nginx from buffering the stream
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+	res.Flush()
+
+	keepAlive := time.NewTicker(realtimeKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-c.Request().Context().Done():
+			return nil
+		case <-realtime.Stopped():
+			return nil
+		case <-keepAlive.C:
+			if err := checkRealtimeAuth(c); err != nil {
+				log.Debugf("[Realtime] Closing stream because its token is not valid anymore: %s", err)
+				return nil
+			}
+			if _, err := fmt.Fprint(res, ": keep-alive\n\n"); err != nil {
+				return nil
+			}
+			res.Flush()
+		case msg, ok := <-sub.Messages():
+			if !ok {
+				return nil
+			}
+
+			canRead, err := models.CanReadRealtimeTopic(auth, msg.Topic)
+			if err != nil {
+				log.Errorf("[Realtime] Could not check access to topic %s: %s", msg.Topic, err)
+				continue
+			}
+			if !canRead {
+				continue
+			}
+
+			payload, err := json.Marshal(msg)
+			if err != nil {
+				log.Errorf("[Realtime] Could not encode message: %s", err)
+				continue
+			}
+			if _, err := fmt.Fprintf(res, "event: %s\ndata: %s\n\n", msg.Event, payload); err != nil {
+				return nil
+			}
+			res.Flush()
+		}
+	}
+}
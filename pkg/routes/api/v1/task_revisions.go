@@ -0,0 +1,57 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package v1
+
+import (
+	"net/http"
+
+	"code.vikunja.io/api/pkg/models"
+	"code.vikunja.io/web/handler"
+	"github.com/labstack/echo/v4"
+)
+
+// RestoreTaskRevision sets the title and description of a task back to the ones of a revision
+// @Summary Restore a task revision
+// @Description Sets the title and description of a task to the ones saved in a revision. This is saved as a new revision, nothing gets lost.
+// @tags task
+// @Produce json
+// @Security JWTKeyAuth
+// @Param listtask path int true "Task ID"
+// @Param revision path int true "Revision ID"
+// @Success 200 {object} models.Task "The updated task."
+// @Failure 403 {object} web.HTTPError "The user does not have write access to the task."
+// @Failure 404 {object} web.HTTPError "The task or revision does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{listtask}/revisions/{revision}/restore [post]
+func RestoreTaskRevision(c echo.Context) error {
+	revision := &models.TaskRevision{}
+	if err := c.Bind(revision); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "No task or revision ID provided.")
+	}
+
+	auth, err := GetAuthFromClaims(c)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	task, err := revision.Restore(auth)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	return c.JSON(http.StatusOK, task)
+}
@@ -0,0 +1,218 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package v1
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/models"
+	"code.vikunja.io/web/handler"
+	"github.com/labstack/echo/v4"
+)
+
+type timerStart struct {
+	// An optional note for the new time entry.
+	Note string `json:"note"`
+}
+
+// StartTimeEntryTimer starts a timer for the current user on a task
+// @Summary Start a timer on a task
+// @Description Starts tracking the time the current user spends on a task. A user can only have one running timer at a time, if there is already one running it is stopped first. The user needs to have write access to the task.
+// @tags time tracking
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param taskID path int true "Task ID"
+// @Param timer body v1.timerStart false "An optional note for the time entry"
+// @Success 200 {object} models.TimeEntry "The time entry of the new running timer."
+// @Failure 403 {object} web.HTTPError "The user does not have write access to the task."
+// @Failure 412 {object} web.HTTPError "Link shares cannot track time."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{taskID}/timer/start [post]
+func StartTimeEntryTimer(c echo.Context) error {
+	taskID, err := strconv.ParseInt(c.Param("task"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid task id.")
+	}
+
+	start := &timerStart{}
+	if c.Request().ContentLength > 0 {
+		if err := c.Bind(start); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "No or invalid model provided.")
+		}
+	}
+
+	auth, err := GetAuthFromClaims(c)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	te, err := models.StartTimeEntryTimer(auth, taskID, start.Note)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	return c.JSON(http.StatusOK, te)
+}
+
+// GetRunningTimeEntry returns the running timer of the current user
+// @Summary Get the running timer
+// @Description Returns the time entry of the timer the current user is currently running.
+// @tags time tracking
+// @Produce json
+// @Security JWTKeyAuth
+// @Success 200 {object} models.TimeEntry "The time entry of the running timer."
+// @Failure 404 {object} web.HTTPError "The user has no running timer."
+// @Failure 412 {object} web.HTTPError "Link shares cannot track time."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /time-tracking/timer [get]
+func GetRunningTimeEntry(c echo.Context) error {
+	auth, err := GetAuthFromClaims(c)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	te, err := models.GetRunningTimeEntry(auth)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	return c.JSON(http.StatusOK, te)
+}
+
+// StopTimeEntryTimer stops the running timer of the current user
+// @Summary Stop the running timer
+// @Description Stops the running timer of the current user and returns the finished time entry.
+// @tags time tracking
+// @Produce json
+// @Security JWTKeyAuth
+// @Success 200 {object} models.TimeEntry "The finished time entry."
+// @Failure 404 {object} web.HTTPError "The user has no running timer."
+// @Failure 412 {object} web.HTTPError "Link shares cannot track time."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /time-tracking/timer/stop [post]
+func StopTimeEntryTimer(c echo.Context) error {
+	auth, err := GetAuthFromClaims(c)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	te, err := models.StopTimeEntryTimer(auth)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	return c.JSON(http.StatusOK, te)
+}
+
+// Parses a date of the report filter. Dates without a time are interpreted in the configured timezone,
+// if endOfDay is set they include the whole day.
+func parseTimeReportDate(value string, endOfDay bool) (t time.Time, err error) {
+	if value == "" {
+		return
+	}
+
+	t, err = time.Parse(time.RFC3339, value)
+	if err == nil {
+		return
+	}
+
+	t, err = time.ParseInLocation("2006-01-02", value, config.GetTimeZone())
+	if err != nil {
+		return
+	}
+
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return
+}
+
+// GetTimeReport returns a report of the time tracked on all lists the current user has access to
+// @Summary Get a time report
+// @Description Returns all finished time entries on lists the current user has access to which started in the given date range, together with the time tracked in total, per user and per list. Running timers are not included until they are stopped.
+// @tags time tracking
+// @Produce json
+// @Produce text/csv
+// @Security JWTKeyAuth
+// @Param from query string false "Only include entries which started at or after this date. Either a date like 2020-10-01 or a full RFC 3339 timestamp."
+// @Param to query string false "Only include entries which started before this date. A date like 2020-10-31 includes the whole day."
+// @Param list_id query int false "Only include entries on tasks of this list."
+// @Param user_id query int false "Only include entries of this user."
+// @Param format query string false "Either json (default) or csv."
+// @Success 200 {object} models.TimeReport "The time report."
+// @Failure 400 {object} web.HTTPError "Invalid filter or format provided."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the list."
+// @Failure 412 {object} web.HTTPError "Link shares cannot get time reports."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /time-tracking/report [get]
+func GetTimeReport(c echo.Context) error {
+	format := c.QueryParam("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "csv" {
+		return handler.HandleHTTPError(models.ErrInvalidTimeReportFormat{Format: format}, c)
+	}
+
+	opts := &models.TimeReportOptions{}
+	var err error
+
+	opts.From, err = parseTimeReportDate(c.QueryParam("from"), false)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid from date.")
+	}
+	opts.To, err = parseTimeReportDate(c.QueryParam("to"), true)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid to date.")
+	}
+
+	if listID := c.QueryParam("list_id"); listID != "" {
+		opts.ListID, err = strconv.ParseInt(listID, 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid list id.")
+		}
+	}
+	if userID := c.QueryParam("user_id"); userID != "" {
+		opts.UserID, err = strconv.ParseInt(userID, 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid user id.")
+		}
+	}
+
+	auth, err := GetAuthFromClaims(c)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	report, err := models.GetTimeReport(auth, opts)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	if format == "json" {
+		return c.JSON(http.StatusOK, report)
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+	c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="time-report.csv"`)
+	c.Response().WriteHeader(http.StatusOK)
+	return report.WriteCSV(c.Response())
+}
@@ -0,0 +1,119 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package v1
+
+import (
+	"fmt"
+	"net/http"
+
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/models"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web/handler"
+	"github.com/labstack/echo/v4"
+)
+
+// UserRequestDeletion sends the current user an email to confirm the deletion of their account
+// @Summary Request the deletion of the user's account
+// @Description Sends the current user an email with a token to confirm the deletion of their account. Users with a password in Vikunja can also confirm it with their password directly.
+// @tags user
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Success 200 {object} models.Message
+// @Failure 500 {object} models.Message "Internal server error."
+// @Router /user/deletion/request [post]
+func UserRequestDeletion(c echo.Context) error {
+	u, err := user.GetCurrentUser(c)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	u, err = user.GetUserWithEmail(&user.User{ID: u.ID})
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	err = user.RequestAccountDeletion(u)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	return c.JSON(http.StatusOK, models.Message{Message: "We sent you an email with a link to confirm the deletion of your account."})
+}
+
+// UserConfirmDeletion deletes the account of the current user
+// @Summary Delete the user's account
+// @Description Deletes the account of the current user. It needs to be confirmed with the current password or the token sent by email. All namespaces, lists, teams, labels, saved filters and list templates of the user are deleted with it. They can be transferred to another user with `transfer_to` instead if that user already has admin access to all namespaces, lists and teams of the user.
+// @tags user
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param deletion body user.AccountDeletion true "The confirmation of the deletion."
+// @Success 200 {object} models.Message
+// @Failure 400 {object} web.HTTPError "Something's invalid."
+// @Failure 403 {object} web.HTTPError "The user to transfer the data to does not have admin access to all of it."
+// @Failure 404 {object} web.HTTPError "The user to transfer the data to does not exist."
+// @Failure 412 {object} web.HTTPError "The deletion was not confirmed."
+// @Failure 500 {object} models.Message "Internal server error."
+// @Router /user/deletion/confirm [post]
+func UserConfirmDeletion(c echo.Context) error {
+	deletion := &user.AccountDeletion{}
+	if err := c.Bind(deletion); err != nil {
+		log.Debugf("Invalid model error. Internal error was: %s", err.Error())
+		if he, is := err.(*echo.HTTPError); is {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid model provided. Error was: %s", he.Message))
+		}
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid model provided.")
+	}
+
+	u, err := user.GetCurrentUser(c)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	// The user from the token does not contain the password or the deletion token
+	u, err = user.GetUserByID(u.ID)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	err = user.CheckAccountDeletionConfirmation(u, deletion)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	var transferTo *user.User
+	if deletion.TransferTo != "" {
+		transferTo, err = user.GetUserByUsername(deletion.TransferTo)
+		if err != nil {
+			return handler.HandleHTTPError(err, c)
+		}
+
+		err = models.CheckUserDataTransfer(u, transferTo)
+		if err != nil {
+			return handler.HandleHTTPError(err, c)
+		}
+	}
+
+	err = models.DeleteUser(u, transferTo)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	return c.JSON(http.StatusOK, models.Message{Message: "Your account was deleted successfully."})
+}
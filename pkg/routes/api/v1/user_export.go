@@ -0,0 +1,82 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package v1
+
+import (
+	"net/http"
+
+	"code.vikunja.io/api/pkg/models"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web/handler"
+	"github.com/labstack/echo/v4"
+)
+
+// UserRequestDataExport starts building a data export for the current user
+// @Summary Request a user data export
+// @Description Starts building a zip archive with all namespaces, lists, tasks, comments, buckets, saved filters and attachments the current user has access to. The user gets an email once it is ready to download. Requesting a new export replaces the previous one.
+// @tags user
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Success 200 {object} models.Message
+// @Failure 409 {object} web.HTTPError "A data export is already being created."
+// @Failure 500 {object} models.Message "Internal server error."
+// @Router /user/export/request [post]
+func UserRequestDataExport(c echo.Context) error {
+	u, err := user.GetCurrentUser(c)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	u, err = user.GetUserWithEmail(&user.User{ID: u.ID})
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	err = models.RequestUserDataExport(u)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	return c.JSON(http.StatusOK, models.Message{Message: "Your data export is being created. We will send you an email once it is ready."})
+}
+
+// UserDownloadDataExport returns the data export of the current user
+// @Summary Download a user data export
+// @Description Returns the zip archive of the latest data export of the current user. It can be downloaded for seven days after it was created. The archive can be imported again with the vikunja-file migrator.
+// @tags user
+// @Produce octet-stream
+// @Security JWTKeyAuth
+// @Success 200 {file} blob "The data export as zip archive."
+// @Failure 404 {object} web.HTTPError "There is no data export or it expired."
+// @Failure 500 {object} models.Message "Internal server error."
+// @Router /user/export/download [get]
+func UserDownloadDataExport(c echo.Context) error {
+	u, err := user.GetCurrentUser(c)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	exportFile, err := models.GetUserDataExport(u)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+	defer exportFile.File.Close()
+
+	http.ServeContent(c.Response(), c.Request(), exportFile.Name, exportFile.Created, exportFile.File)
+	return nil
+}
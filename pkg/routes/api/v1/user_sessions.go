@@ -0,0 +1,156 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package v1
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/models"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web/handler"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo/v4"
+)
+
+// RefreshTokenRequest holds the refresh token to exchange for a new access token
+type RefreshTokenRequest struct {
+	// The refresh token returned by the login or the last refresh.
+	RefreshToken string `json:"refresh_token"`
+}
+
+// RefreshToken exchanges a refresh token for a new access token
+// @Summary Refresh an access token
+// @Description Exchanges a refresh token for a new access token and a new refresh token. Every refresh token can only be used once.
+// @tags user
+// @Accept json
+// @Produce json
+// @Param token body v1.RefreshTokenRequest true "The refresh token."
+// @Success 200 {object} v1.Token
+// @Failure 400 {object} web.HTTPError "Something's invalid."
+// @Failure 401 {object} web.HTTPError "The refresh token is invalid or expired."
+// @Failure 500 {object} models.Message "Internal server error."
+// @Router /user/token/refresh [post]
+func RefreshToken(c echo.Context) error {
+	r := &RefreshTokenRequest{}
+	if err := c.Bind(r); err != nil {
+		log.Debugf("Invalid model error. Internal error was: %s", err.Error())
+		if he, is := err.(*echo.HTTPError); is {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid model provided. Error was: %s", he.Message))
+		}
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid model provided.")
+	}
+
+	s, u, refreshToken, err := user.RefreshSession(r.RefreshToken, c.RealIP())
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	t, err := NewUserJWTAuthtoken(u, s.ID)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	return c.JSON(http.StatusOK, Token{Token: t, RefreshToken: refreshToken})
+}
+
+func getCurrentSessionID(c echo.Context) int64 {
+	jwtinf := c.Get("user").(*jwt.Token)
+	return GetSessionIDFromClaims(jwtinf.Claims.(jwt.MapClaims))
+}
+
+// UserListSessions returns all sessions of the current user
+// @Summary Get all sessions of the current user
+// @Description Returns all devices the current user is logged in on, the most recently used first.
+// @tags user
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Success 200 {array} user.Session
+// @Failure 500 {object} models.Message "Internal server error."
+// @Router /user/sessions [get]
+func UserListSessions(c echo.Context) error {
+	u, err := user.GetCurrentUser(c)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	sessions, err := user.GetSessionsForUser(u, getCurrentSessionID(c))
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	return c.JSON(http.StatusOK, sessions)
+}
+
+// UserDeleteSession revokes one session of the current user
+// @Summary Revoke a session
+// @Description Revokes a session of the current user. All access and refresh tokens of it stop working immediately.
+// @tags user
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param session path int true "The id of the session to revoke"
+// @Success 200 {object} models.Message "The session was revoked."
+// @Failure 400 {object} web.HTTPError "Something's invalid."
+// @Failure 404 {object} web.HTTPError "The session does not exist."
+// @Failure 500 {object} models.Message "Internal server error."
+// @Router /user/sessions/{session} [delete]
+func UserDeleteSession(c echo.Context) error {
+	sessionID, err := strconv.ParseInt(c.Param("session"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid session id.")
+	}
+
+	u, err := user.GetCurrentUser(c)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	err = user.DeleteSession(u, sessionID)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	return c.JSON(http.StatusOK, models.Message{Message: "The session was revoked successfully."})
+}
+
+// UserDeleteAllSessions revokes all sessions of the current user
+// @Summary Revoke all sessions
+// @Description Revokes all sessions of the current user, including the one used for this request. This logs the user out on all devices.
+// @tags user
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Success 200 {object} models.Message "All sessions were revoked."
+// @Failure 500 {object} models.Message "Internal server error."
+// @Router /user/sessions [delete]
+func UserDeleteAllSessions(c echo.Context) error {
+	u, err := user.GetCurrentUser(c)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	err = user.DeleteAllSessionsForUser(u.ID)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	return c.JSON(http.StatusOK, models.Message{Message: "All sessions were revoked successfully."})
+}
@@ -96,3 +96,122 @@ func ChangeUserAvatarProvider(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, &models.Message{Message: "Avatar was changed successfully."})
 }
+
+// GetUserDigestSettings returns the current user's digest settings
+// @Summary Return the digest settings
+// @Description Returns how often and at which hour the current user receives the digest email of their due and overdue tasks. The hour is in the timezone of the user which is returned as well. If the user did not set a timezone, this is the timezone of the server.
+// @tags user
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Success 200 {object} user.DigestSettings
+// @Failure 400 {object} web.HTTPError "Something's invalid."
+// @Failure 500 {object} models.Message "Internal server error."
+// @Router /user/settings/digest [get]
+func GetUserDigestSettings(c echo.Context) error {
+
+	u, err := user2.GetCurrentUser(c)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	user, err := user2.GetUserByID(u.ID)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	return c.JSON(http.StatusOK, user.GetDigestSettings())
+}
+
+// ChangeUserDigestSettings changes the user's digest settings
+// @Summary Change the digest settings
+// @Description Changes how often and at which hour the current user receives the digest email. Valid frequencies are `daily`, `weekly` (sent on mondays) or an empty string to disable the digest. The hour and the day of weekly digests are evaluated in the timezone of the settings. Leave it empty to use the timezone of the server.
+// @tags user
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param digest body user.DigestSettings true "The user's digest settings"
+// @Success 200 {object} models.Message
+// @Failure 400 {object} web.HTTPError "Something's invalid."
+// @Failure 412 {object} web.HTTPError "The frequency, hour or timezone is invalid."
+// @Failure 500 {object} models.Message "Internal server error."
+// @Router /user/settings/digest [post]
+func ChangeUserDigestSettings(c echo.Context) error {
+
+	settings := &user2.DigestSettings{}
+	err := c.Bind(settings)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Bad digest settings provided.")
+	}
+
+	u, err := user2.GetCurrentUser(c)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	err = user2.UpdateDigestSettings(u, settings)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	return c.JSON(http.StatusOK, &models.Message{Message: "The digest settings were updated successfully."})
+}
+
+// GetUserEmailNotificationSettings returns which email notifications the current user receives
+// @Summary Return the email notification settings
+// @Description Returns which email notifications the current user receives.
+// @tags user
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Success 200 {object} user.EmailNotificationSettings
+// @Failure 400 {object} web.HTTPError "Something's invalid."
+// @Failure 500 {object} models.Message "Internal server error."
+// @Router /user/settings/notifications [get]
+func GetUserEmailNotificationSettings(c echo.Context) error {
+
+	u, err := user2.GetCurrentUser(c)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	user, err := user2.GetUserByID(u.ID)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	return c.JSON(http.StatusOK, user.GetEmailNotificationSettings())
+}
+
+// ChangeUserEmailNotificationSettings changes which email notifications the user receives
+// @Summary Change the email notification settings
+// @Description Turns the email notifications for task assignments, comments and tasks marked as done on or off.
+// @tags user
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param settings body user.EmailNotificationSettings true "The user's email notification settings"
+// @Success 200 {object} models.Message
+// @Failure 400 {object} web.HTTPError "Something's invalid."
+// @Failure 500 {object} models.Message "Internal server error."
+// @Router /user/settings/notifications [post]
+func ChangeUserEmailNotificationSettings(c echo.Context) error {
+
+	settings := &user2.EmailNotificationSettings{}
+	err := c.Bind(settings)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Bad email notification settings provided.")
+	}
+
+	u, err := user2.GetCurrentUser(c)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	err = user2.UpdateEmailNotificationSettings(u, settings)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	return c.JSON(http.StatusOK, &models.Message{Message: "The email notification settings were updated successfully."})
+}
@@ -57,13 +57,13 @@ func UserTOTPEnroll(c echo.Context) error {
 
 // UserTOTPEnable is the handler to enable totp for a user
 // @Summary Enable a previously enrolled totp setting.
-// @Description Enables a previously enrolled totp setting by providing a totp passcode.
+// @Description Enables a previously enrolled totp setting by providing a totp passcode. Returns the recovery codes for the user. They are only shown once.
 // @tags user
 // @Accept json
 // @Produce json
 // @Param totp body user.TOTPPasscode true "The totp passcode."
 // @Security JWTKeyAuth
-// @Success 200 {object} models.Message "Successfully enabled"
+// @Success 200 {object} user.TOTPRecoveryCodes "Successfully enabled"
 // @Failure 400 {object} web.HTTPError "Something's invalid."
 // @Failure 404 {object} web.HTTPError "User does not exist."
 // @Failure 412 {object} web.HTTPError "TOTP is not enrolled."
@@ -86,12 +86,12 @@ func UserTOTPEnable(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "Invalid model provided.")
 	}
 
-	err = user.EnableTOTP(passcode)
+	codes, err := user.EnableTOTP(passcode)
 	if err != nil {
 		return handler.HandleHTTPError(err, c)
 	}
 
-	return c.JSON(http.StatusOK, models.Message{Message: "TOTP was enabled successfully."})
+	return c.JSON(http.StatusOK, codes)
 }
 
 // UserTOTPDisable disables totp settings for the current user.
@@ -191,5 +191,64 @@ func UserTOTP(c echo.Context) error {
 		return handler.HandleHTTPError(err, c)
 	}
 
+	t.RecoveryCodesLeft, err = user.GetTOTPRecoveryCodesLeft(u)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
 	return c.JSON(http.StatusOK, t)
 }
+
+// UserTOTPRegenerateRecoveryCodes replaces all totp recovery codes of the current user with new ones.
+// @Summary Regenerate totp recovery codes
+// @Description Replaces all totp recovery codes of the current user with new ones. The old ones stop working. The new codes are only shown once.
+// @tags user
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param totp body user.Login true "The current user's password (only password is enough)."
+// @Success 200 {object} user.TOTPRecoveryCodes "The new recovery codes."
+// @Failure 400 {object} web.HTTPError "Something's invalid."
+// @Failure 412 {object} web.HTTPError "TOTP is not enabled."
+// @Failure 500 {object} models.Message "Internal server error."
+// @Router /user/settings/totp/recovery-codes [post]
+func UserTOTPRegenerateRecoveryCodes(c echo.Context) error {
+	login := &user.Login{}
+	if err := c.Bind(login); err != nil {
+		log.Debugf("Invalid model error. Internal error was: %s", err.Error())
+		if he, is := err.(*echo.HTTPError); is {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid model provided. Error was: %s", he.Message))
+		}
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid model provided.")
+	}
+
+	u, err := user.GetCurrentUser(c)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	u, err = user.GetUserByID(u.ID)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	err = user.CheckUserPassword(u, login.Password)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	enabled, err := user.TOTPEnabledForUser(u)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+	if !enabled {
+		return handler.HandleHTTPError(user.ErrTOTPNotEnabled{}, c)
+	}
+
+	codes, err := user.GenerateTOTPRecoveryCodes(u)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	return c.JSON(http.StatusOK, codes)
+}
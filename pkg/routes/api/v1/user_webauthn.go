@@ -0,0 +1,211 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package v1
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/models"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web/handler"
+	"github.com/labstack/echo/v4"
+)
+
+func bindWebAuthnBody(c echo.Context, i interface{}) error {
+	if err := c.Bind(i); err != nil {
+		log.Debugf("Invalid model error. Internal error was: %s", err.Error())
+		if he, is := err.(*echo.HTTPError); is {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid model provided. Error was: %s", he.Message))
+		}
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid model provided.")
+	}
+	return nil
+}
+
+// UserListWebAuthnCredentials returns all security keys of the current user
+// @Summary Get all security keys
+// @Description Returns all webauthn credentials the current user registered.
+// @tags user
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Success 200 {array} user.WebAuthnCredential
+// @Failure 500 {object} models.Message "Internal server error."
+// @Router /user/settings/webauthn [get]
+func UserListWebAuthnCredentials(c echo.Context) error {
+	u, err := user.GetCurrentUser(c)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	credentials, err := user.GetWebAuthnCredentials(u)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	return c.JSON(http.StatusOK, credentials)
+}
+
+// UserBeginWebAuthnRegistration starts registering a new security key
+// @Summary Start registering a security key
+// @Description Returns the options which need to be passed to navigator.credentials.create() in the browser. The registration needs to be finished within five minutes.
+// @tags user
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Success 200 {object} map[string]interface{} "The public key credential creation options."
+// @Failure 500 {object} models.Message "Internal server error."
+// @Router /user/settings/webauthn/register/begin [post]
+func UserBeginWebAuthnRegistration(c echo.Context) error {
+	u, err := user.GetCurrentUser(c)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	options, err := user.BeginWebAuthnRegistration(u)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	return c.JSON(http.StatusOK, options)
+}
+
+// UserFinishWebAuthnRegistration saves a new security key
+// @Summary Finish registering a security key
+// @Description Verifies the response of the authenticator and saves the new security key.
+// @tags user
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param registration body user.WebAuthnRegistration true "The name of the key and the response of the authenticator."
+// @Success 200 {object} user.WebAuthnCredential
+// @Failure 400 {object} web.HTTPError "Something's invalid."
+// @Failure 412 {object} web.HTTPError "The response of the authenticator is invalid or the registration expired."
+// @Failure 500 {object} models.Message "Internal server error."
+// @Router /user/settings/webauthn/register/finish [post]
+func UserFinishWebAuthnRegistration(c echo.Context) error {
+	registration := &user.WebAuthnRegistration{}
+	if err := bindWebAuthnBody(c, registration); err != nil {
+		return err
+	}
+
+	u, err := user.GetCurrentUser(c)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	credential, err := user.FinishWebAuthnRegistration(u, registration)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	return c.JSON(http.StatusOK, credential)
+}
+
+// UserDeleteWebAuthnCredential removes a security key of the current user
+// @Summary Delete a security key
+// @Description Removes a security key of the current user. It can't be used to log in anymore.
+// @tags user
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param credential path int true "The id of the security key"
+// @Success 200 {object} models.Message "The security key was deleted."
+// @Failure 400 {object} web.HTTPError "Something's invalid."
+// @Failure 404 {object} web.HTTPError "The security key does not exist."
+// @Failure 500 {object} models.Message "Internal server error."
+// @Router /user/settings/webauthn/{credential} [delete]
+func UserDeleteWebAuthnCredential(c echo.Context) error {
+	credentialID, err := strconv.ParseInt(c.Param("credential"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid security key id.")
+	}
+
+	u, err := user.GetCurrentUser(c)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	err = user.DeleteWebAuthnCredential(u, credentialID)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	return c.JSON(http.StatusOK, models.Message{Message: "The security key was deleted successfully."})
+}
+
+// BeginWebAuthnLogin starts logging in with a security key
+// @Summary Start a security key login
+// @Description Returns the options which need to be passed to navigator.credentials.get() in the browser. The response can then be used to log in without a password or as second factor in the normal login.
+// @tags user
+// @Accept json
+// @Produce json
+// @Param login body user.WebAuthnLogin true "The username of the user logging in."
+// @Success 200 {object} map[string]interface{} "The public key credential request options."
+// @Failure 400 {object} web.HTTPError "Something's invalid."
+// @Failure 403 {object} web.HTTPError "Invalid username."
+// @Failure 412 {object} web.HTTPError "The user has no security keys."
+// @Failure 500 {object} models.Message "Internal server error."
+// @Router /login/webauthn/begin [post]
+func BeginWebAuthnLogin(c echo.Context) error {
+	login := &user.WebAuthnLogin{}
+	if err := bindWebAuthnBody(c, login); err != nil {
+		return err
+	}
+
+	options, err := user.BeginPasswordlessWebAuthnLogin(login)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	return c.JSON(http.StatusOK, options)
+}
+
+// LoginWithWebAuthn logs a user in with only a security key
+// @Summary Passwordless login
+// @Description Logs a user in with a security key instead of a password. The key needs to verify the user, for example with a pin or biometrics.
+// @tags user
+// @Accept json
+// @Produce json
+// @Param login body user.WebAuthnLogin true "The username and the response of the authenticator."
+// @Success 200 {object} v1.Token
+// @Failure 400 {object} web.HTTPError "Something's invalid."
+// @Failure 403 {object} web.HTTPError "Invalid username."
+// @Failure 412 {object} web.HTTPError "The response of the authenticator is invalid."
+// @Failure 500 {object} models.Message "Internal server error."
+// @Router /login/webauthn [post]
+func LoginWithWebAuthn(c echo.Context) error {
+	login := &user.WebAuthnLogin{}
+	if err := bindWebAuthnBody(c, login); err != nil {
+		return err
+	}
+
+	u, err := user.FinishPasswordlessWebAuthnLogin(login)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	t, err := newUserSessionToken(c, u)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	return c.JSON(http.StatusOK, t)
+}
@@ -0,0 +1,165 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package routes
+
+import (
+	"net/http"
+	"strings"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/models"
+	apiv1 "code.vikunja.io/api/pkg/routes/api/v1"
+	"code.vikunja.io/web/handler"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo/v4"
+)
+
+// apiTokenRouteScope maps all routes starting with a path to the scope group an api token needs to use them.
+type apiTokenRouteScope struct {
+	path  string
+	group string
+	// Admin routes need the admin level of the group for all requests, not only the write level for changes.
+	admin bool
+	// Requests with one of these methods need the admin level, all others the read or write level.
+	adminMethods []string
+	// Only the path itself, not the routes below it
+	exact bool
+}
+
+// The methods which change something
+var apiTokenChangeMethods = []string{http.MethodPut, http.MethodPost, http.MethodPatch, http.MethodDelete}
+
+// The first matching path wins, more specific paths therefore need to come first.
+// Everything not listed here, like all user settings, can't be used with an api token at all.
+var apiTokenRouteScopes = []*apiTokenRouteScope{
+	{path: "/lists/:list/tasks", group: "tasks"},
+	{path: "/lists/:list/shares", group: "lists", admin: true},
+	{path: "/lists/:list/teams", group: "lists", admin: true},
+	{path: "/lists/:list/users", group: "lists", admin: true},
+	{path: "/lists/:list/webhooks", group: "lists", admin: true},
+	{path: "/lists/:list/custom-fields", group: "lists", adminMethods: apiTokenChangeMethods},
+	{path: "/lists/:list", group: "lists", adminMethods: []string{http.MethodDelete}, exact: true},
+	{path: "/lists", group: "lists"},
+	{path: "/backgrounds", group: "lists"},
+	{path: "/templates", group: "lists"},
+	{path: "/webhooks/events", group: "lists"},
+	{path: "/namespaces/:namespace/lists", group: "lists"},
+	{path: "/namespaces/:namespace/teams", group: "namespaces", admin: true},
+	{path: "/namespaces/:namespace/users", group: "namespaces", admin: true},
+	{path: "/namespaces/:namespace/webhooks", group: "namespaces", admin: true},
+	{path: "/namespaces/:namespace", group: "namespaces", adminMethods: []string{http.MethodDelete}, exact: true},
+	{path: "/namespaces", group: "namespaces"},
+	{path: "/tasks", group: "tasks"},
+	{path: "/time-tracking", group: "tasks"},
+	{path: "/labels", group: "labels"},
+	{path: "/teams", group: "teams"},
+	{path: "/filters", group: "filters"},
+	{path: "/notifications", group: "notifications"},
+	{path: "/mentions", group: "notifications"},
+	{path: "/users", group: "users"},
+}
+
+func (s *apiTokenRouteScope) needsAdmin(method string) bool {
+	for _, m := range s.adminMethods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the scope group and level needed for a route. If the route can't be used with an api token,
+// allowed is false.
+func getAPITokenScopeForRoute(method, path string) (group, level string, allowed bool) {
+	path = strings.TrimPrefix(path, "/api/v1")
+
+	// Every token can get the user it belongs to
+	if path == "/user" && method == http.MethodGet {
+		return "", "", true
+	}
+
+	for _, s := range apiTokenRouteScopes {
+		if path != s.path && (s.exact || !strings.HasPrefix(path, s.path+"/")) {
+			continue
+		}
+
+		switch {
+		case s.admin || s.needsAdmin(method):
+			level = models.APITokenScopeLevelAdmin
+		case method == http.MethodGet:
+			level = models.APITokenScopeLevelRead
+		default:
+			level = models.APITokenScopeLevelWrite
+		}
+		return s.group, level, true
+	}
+
+	return "", "", false
+}
+
+func getAPITokenFromRequest(c echo.Context) (token string, is bool) {
+	auth := c.Request().Header.Get(echo.HeaderAuthorization)
+	if !strings.HasPrefix(auth, "Bearer "+models.APITokenPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(auth, "Bearer "), true
+}
+
+// Skips the jwt middleware for requests authenticated with an api token, they are checked in checkAPIToken instead.
+func isAPITokenRequest(c echo.Context) bool {
+	_, is := getAPITokenFromRequest(c)
+	return is
+}
+
+// checkAPIToken authenticates requests with an api token and makes sure the token has the scope needed for the route.
+// Afterwards, the request looks exactly like one authenticated with a jwt to everything else.
+func checkAPIToken(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		token, is := getAPITokenFromRequest(c)
+		if !is {
+			return next(c)
+		}
+
+		t, u, err := models.GetAPITokenAndUser(token)
+		if err != nil {
+			return handler.HandleHTTPError(err, c)
+		}
+
+		group, level, allowed := getAPITokenScopeForRoute(c.Request().Method, c.Path())
+		if !allowed {
+			return handler.HandleHTTPError(models.ErrAPITokenMissingScope{}, c)
+		}
+		if group != "" && !t.HasScope(group, level) {
+			return handler.HandleHTTPError(models.ErrAPITokenMissingScope{Scope: group + ":" + level}, c)
+		}
+
+		// Creating a real jwt for the user makes sure everything reading the claims works the same
+		signed, err := apiv1.NewUserJWTAuthtoken(u, 0)
+		if err != nil {
+			return handler.HandleHTTPError(err, c)
+		}
+		jwtinf, err := jwt.Parse(signed, func(t *jwt.Token) (interface{}, error) {
+			return []byte(config.ServiceJWTSecret.GetString()), nil
+		})
+		if err != nil {
+			return handler.HandleHTTPError(err, c)
+		}
+		c.Set("user", jwtinf)
+
+		return next(c)
+	}
+}
@@ -271,6 +271,7 @@ func (vcls *VikunjaCaldavListStorage) UpdateResource(rpath, content string) (*da
 
 	// At this point, we already have the right task in vcls.task, so we can use that ID directly
 	vTask.ID = vcls.task.ID
+	keepRepeatAfter(vTask, vcls.task)
 
 	// Check the rights
 	canUpdate, err := vTask.CanUpdate(vcls.user)
@@ -18,6 +18,7 @@ package caldav
 
 import (
 	"strconv"
+	"strings"
 	"time"
 
 	"code.vikunja.io/api/pkg/caldav"
@@ -41,13 +42,14 @@ func getCaldavTodosForTasks(list *models.List) string {
 			Description: t.Description,
 			Completed:   t.DoneAt,
 			// Organizer:     &t.CreatedBy, // Disabled until we figure out how this works
-			Priority: t.Priority,
-			Start:    t.StartDate,
-			End:      t.EndDate,
-			Created:  t.Created,
-			Updated:  t.Updated,
-			DueDate:  t.DueDate,
-			Duration: duration,
+			Priority:   t.Priority,
+			Start:      t.StartDate,
+			End:        t.EndDate,
+			Created:    t.Created,
+			Updated:    t.Updated,
+			DueDate:    t.DueDate,
+			Duration:   duration,
+			RepeatRule: getRepeatRuleForTask(t),
 		})
 	}
 
@@ -59,6 +61,47 @@ func getCaldavTodosForTasks(list *models.List) string {
 	return caldav.ParseTodos(caldavConfig, caldavtodos)
 }
 
+// Returns the repeat rule of a task. Tasks which only repeat after a fixed amount of seconds get the equivalent
+// rule to let caldav clients show them as repeating.
+func getRepeatRuleForTask(t *models.Task) string {
+	if t.RepeatRule != "" || t.RepeatAfter <= 0 {
+		return t.RepeatRule
+	}
+
+	intervals := []struct {
+		freq    string
+		seconds int64
+	}{
+		{freq: "WEEKLY", seconds: 7 * 24 * 60 * 60},
+		{freq: "DAILY", seconds: 24 * 60 * 60},
+		{freq: "HOURLY", seconds: 60 * 60},
+		{freq: "MINUTELY", seconds: 60},
+	}
+	for _, i := range intervals {
+		if t.RepeatAfter%i.seconds == 0 {
+			return "FREQ=" + i.freq + ";INTERVAL=" + strconv.FormatInt(t.RepeatAfter/i.seconds, 10)
+		}
+	}
+
+	return ""
+}
+
+// Tasks which repeat after a fixed amount of seconds are sent to clients with the equivalent repeat rule.
+// When a client sends that rule back unchanged, the task keeps repeating after the amount of seconds instead of
+// getting the rule saved.
+func keepRepeatAfter(vTask *models.Task, existing *models.Task) {
+	if existing == nil || existing.RepeatRule != "" || existing.RepeatAfter <= 0 || vTask.RepeatRule == "" {
+		return
+	}
+
+	if strings.TrimPrefix(strings.ToUpper(vTask.RepeatRule), "RRULE:") != getRepeatRuleForTask(existing) {
+		return
+	}
+
+	vTask.RepeatRule = ""
+	vTask.RepeatAfter = existing.RepeatAfter
+}
+
 func parseTaskFromVTODO(content string) (vTask *models.Task, err error) {
 	parsed, err := ical.ParseCalendar(content)
 	if err != nil {
@@ -98,6 +141,16 @@ func parseTaskFromVTODO(content string) (vTask *models.Task, err error) {
 		Updated:     caldavTimeToTimestamp(task["DTSTAMP"]),
 		StartDate:   caldavTimeToTimestamp(task["DTSTART"]),
 		DoneAt:      caldavTimeToTimestamp(task["COMPLETED"]),
+		RepeatRule:  task["RRULE"],
+	}
+
+	// Clients can use parts of repeat rules we can't evaluate. Instead of rejecting the whole task,
+	// it is saved without repeating.
+	if vTask.RepeatRule != "" {
+		if err := models.CheckRepeatRule(vTask.RepeatRule); err != nil {
+			log.Warningf("[CALDAV] Not saving the repeat rule of task %s: %s", vTask.UID, err)
+			vTask.RepeatRule = ""
+		}
 	}
 
 	if task["STATUS"] == "COMPLETED" {
@@ -0,0 +1,82 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package caldav
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func vtodoWithRepeatRule(rule string) string {
+	return `BEGIN:VCALENDAR
+VERSION:2.0
+PRODID:-//Test//EN
+BEGIN:VTODO
+UID:repeating
+SUMMARY:Repeating task
+DUE:20201020T090000Z
+RRULE:` + rule + `
+END:VTODO
+END:VCALENDAR`
+}
+
+func TestParseTaskFromVTODO(t *testing.T) {
+	t.Run("supported repeat rule", func(t *testing.T) {
+		task, err := parseTaskFromVTODO(vtodoWithRepeatRule("FREQ=MONTHLY;BYDAY=1MO"))
+		assert.NoError(t, err)
+		assert.Equal(t, "FREQ=MONTHLY;BYDAY=1MO", task.RepeatRule)
+	})
+	t.Run("unsupported repeat rule", func(t *testing.T) {
+		for _, rule := range []string{
+			"FREQ=DAILY;BYHOUR=9,17",
+			"FREQ=DAILY;BYMINUTE=30",
+			"FREQ=YEARLY;BYWEEKNO=20",
+			"FREQ=YEARLY;BYYEARDAY=100",
+			"FREQ=DAILY;X-UNKNOWN=1",
+		} {
+			task, err := parseTaskFromVTODO(vtodoWithRepeatRule(rule))
+			assert.NoError(t, err, rule)
+			assert.Equal(t, "Repeating task", task.Title, rule)
+			assert.Empty(t, task.RepeatRule, rule)
+		}
+	})
+}
+
+func TestKeepRepeatAfter(t *testing.T) {
+	t.Run("generated rule sent back", func(t *testing.T) {
+		existing := &models.Task{RepeatAfter: 2 * 24 * 60 * 60}
+		vTask := &models.Task{RepeatRule: "FREQ=DAILY;INTERVAL=2"}
+		keepRepeatAfter(vTask, existing)
+		assert.Empty(t, vTask.RepeatRule)
+		assert.Equal(t, int64(2*24*60*60), vTask.RepeatAfter)
+	})
+	t.Run("changed rule", func(t *testing.T) {
+		existing := &models.Task{RepeatAfter: 2 * 24 * 60 * 60}
+		vTask := &models.Task{RepeatRule: "FREQ=WEEKLY;BYDAY=MO"}
+		keepRepeatAfter(vTask, existing)
+		assert.Equal(t, "FREQ=WEEKLY;BYDAY=MO", vTask.RepeatRule)
+		assert.Equal(t, int64(0), vTask.RepeatAfter)
+	})
+	t.Run("task with a repeat rule", func(t *testing.T) {
+		existing := &models.Task{RepeatRule: "FREQ=DAILY;INTERVAL=2"}
+		vTask := &models.Task{RepeatRule: "FREQ=DAILY;INTERVAL=2"}
+		keepRepeatAfter(vTask, existing)
+		assert.Equal(t, "FREQ=DAILY;INTERVAL=2", vTask.RepeatRule)
+	})
+}
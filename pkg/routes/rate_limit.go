@@ -24,8 +24,11 @@ import (
 
 	"code.vikunja.io/api/pkg/config"
 	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/models"
 	"code.vikunja.io/api/pkg/red"
 	apiv1 "code.vikunja.io/api/pkg/routes/api/v1"
+	"code.vikunja.io/web"
+	"code.vikunja.io/web/handler"
 	"github.com/labstack/echo/v4"
 	"github.com/ulule/limiter/v3"
 	"github.com/ulule/limiter/v3/drivers/store/memory"
@@ -44,6 +47,7 @@ func RateLimit(rateLimiter *limiter.Limiter, rateLimitKind string) echo.Middlewa
 				auth, err := apiv1.GetAuthFromClaims(c)
 				if err != nil {
 					log.Errorf("Error getting auth from jwt claims: %v", err)
+					return handler.HandleHTTPError(err, c)
 				}
 				rateLimitKey = "user_" + strconv.FormatInt(auth.GetID(), 10)
 			default:
@@ -75,30 +79,80 @@ func RateLimit(rateLimiter *limiter.Limiter, rateLimitKind string) echo.Middlewa
 	}
 }
 
+func newRateLimitStore() (store limiter.Store) {
+	var err error
+	switch config.RateLimitStore.GetString() {
+	case "memory":
+		store = memory.NewStore()
+	case "redis":
+		if !config.RedisEnabled.GetBool() {
+			log.Fatal("Redis is configured for rate limiting, but not enabled!")
+		}
+		store, err = redis.NewStore(red.GetRedis())
+		if err != nil {
+			log.Fatalf("Error while creating rate limit redis store: %s", err)
+		}
+	default:
+		log.Fatalf("Unknown Rate limit store \"%s\"", config.RateLimitStore.GetString())
+	}
+	return
+}
+
 func setupRateLimit(a *echo.Group, rateLimitKind string) {
 	if config.RateLimitEnabled.GetBool() {
 		rate := limiter.Rate{
 			Period: config.RateLimitPeriod.GetDuration() * time.Second,
 			Limit:  config.RateLimitLimit.GetInt64(),
 		}
-		var store limiter.Store
-		var err error
-		switch config.RateLimitStore.GetString() {
-		case "memory":
-			store = memory.NewStore()
-		case "redis":
-			if !config.RedisEnabled.GetBool() {
-				log.Fatal("Redis is configured for rate limiting, but not enabled!")
-			}
-			store, err = redis.NewStore(red.GetRedis())
+		rateLimiter := limiter.New(newRateLimitStore(), rate)
+		log.Debugf("Rate limit configured with %s and %v requests per %v", config.RateLimitStore.GetString(), rate.Limit, rate.Period)
+		a.Use(RateLimit(rateLimiter, rateLimitKind))
+	}
+}
+
+// Returns true if the error returned from a handler is the one with the error code
+func isHandlerErrorCode(err error, code int) bool {
+	he, is := err.(*echo.HTTPError)
+	if !is {
+		return false
+	}
+	webErr, is := he.Message.(web.HTTPError)
+	return is && webErr.Code == code
+}
+
+// LinkShareAuthRateLimit limits the number of wrong passwords per link share to prevent brute forcing them.
+// Only wrong passwords count against the limit, it is therefore always enabled.
+func LinkShareAuthRateLimit(rateLimiter *limiter.Limiter) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) (err error) {
+			hash := c.Param("share")
+			key := "link_share_auth_" + hash
+
+			limiterCtx, err := rateLimiter.Peek(c.Request().Context(), key)
 			if err != nil {
-				log.Fatalf("Error while creating rate limit redis store: %s", err)
+				log.Errorf("LinkShareAuthRateLimit - rateLimiter.Peek - err: %v, %s on %s", err, key, c.Request().URL)
+				return handler.HandleHTTPError(err, c)
 			}
-		default:
-			log.Fatalf("Unknown Rate limit store \"%s\"", config.RateLimitStore.GetString())
+			if limiterCtx.Reached || limiterCtx.Remaining <= 0 {
+				log.Infof("Too many failed link share authentication attempts for share %s", hash)
+				return handler.HandleHTTPError(models.ErrLinkShareTooManyAuthAttempts{Hash: hash}, c)
+			}
+
+			err = next(c)
+			if isHandlerErrorCode(err, models.ErrCodeLinkSharePasswordInvalid) {
+				if _, lerr := rateLimiter.Get(c.Request().Context(), key); lerr != nil {
+					log.Errorf("LinkShareAuthRateLimit - rateLimiter.Get - err: %v, %s on %s", lerr, key, c.Request().URL)
+				}
+			}
+			return err
 		}
-		rateLimiter := limiter.New(store, rate)
-		log.Debugf("Rate limit configured with %s and %v requests per %v", config.RateLimitStore.GetString(), rate.Limit, rate.Period)
-		a.Use(RateLimit(rateLimiter, rateLimitKind))
 	}
 }
+
+func setupLinkShareAuthRateLimit() echo.MiddlewareFunc {
+	rate := limiter.Rate{
+		Period: config.RateLimitLinkSharePasswordPeriod.GetDuration() * time.Second,
+		Limit:  config.RateLimitLinkSharePasswordLimit.GetInt64(),
+	}
+	return LinkShareAuthRateLimit(limiter.New(newRateLimitStore(), rate))
+}
@@ -60,6 +60,7 @@ import (
 	"code.vikunja.io/api/pkg/modules/migration"
 	migrationHandler "code.vikunja.io/api/pkg/modules/migration/handler"
 	"code.vikunja.io/api/pkg/modules/migration/todoist"
+	vikunjafile "code.vikunja.io/api/pkg/modules/migration/vikunja-file"
 	"code.vikunja.io/api/pkg/modules/migration/wunderlist"
 	apiv1 "code.vikunja.io/api/pkg/routes/api/v1"
 	"code.vikunja.io/api/pkg/routes/caldav"
@@ -206,6 +207,17 @@ func RegisterRoutes(e *echo.Echo) {
 	registerAPIRoutes(a)
 }
 
+// Puts the jwt from the token query parameter into the authorization header if none was passed
+func tokenFromQueryParam(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		token := c.QueryParam("token")
+		if token != "" && c.Request().Header.Get(echo.HeaderAuthorization) == "" {
+			c.Request().Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		}
+		return next(c)
+	}
+}
+
 func registerAPIRoutes(a *echo.Group) {
 
 	// This is the group with no auth
@@ -226,6 +238,19 @@ func registerAPIRoutes(a *echo.Group) {
 	n.POST("/user/password/token", apiv1.UserRequestResetPasswordToken)
 	n.POST("/user/password/reset", apiv1.UserResetPassword)
 	n.POST("/user/confirm", apiv1.UserConfirmEmail)
+	n.POST("/user/token/refresh", apiv1.RefreshToken)
+
+	// OpenID Connect login
+	if config.AuthOpenIDEnabled.GetBool() {
+		n.GET("/auth/openid/:provider", apiv1.RedirectToOpenIDProvider)
+		n.POST("/auth/openid/:provider/callback", apiv1.HandleOpenIDCallback)
+	}
+
+	// Security key login
+	if config.AuthWebAuthnEnabled.GetBool() {
+		n.POST("/login/webauthn/begin", apiv1.BeginWebAuthnLogin)
+		n.POST("/login/webauthn", apiv1.LoginWithWebAuthn)
+	}
 
 	// Info endpoint
 	n.GET("/info", apiv1.Info)
@@ -235,12 +260,23 @@ func registerAPIRoutes(a *echo.Group) {
 
 	// Link share auth
 	if config.ServiceEnableLinkSharing.GetBool() {
-		n.POST("/shares/:share/auth", apiv1.AuthenticateLinkShare)
+		n.POST("/shares/:share/auth", apiv1.AuthenticateLinkShare, setupLinkShareAuthRateLimit())
 	}
 
+	// Realtime updates
+	// This is its own group because browsers can't set headers for server-sent events, the token can therefore
+	// also be passed as query parameter. It is not rate limited because the connection stays open.
+	rt := a.Group("/events", tokenFromQueryParam, middleware.JWT([]byte(config.ServiceJWTSecret.GetString())), checkUserSession)
+	rt.GET("", apiv1.SubscribeToEvents)
+
 	// ===== Routes with Authetication =====
 	// Authetification
-	a.Use(middleware.JWT([]byte(config.ServiceJWTSecret.GetString())))
+	a.Use(middleware.JWTWithConfig(middleware.JWTConfig{
+		SigningKey: []byte(config.ServiceJWTSecret.GetString()),
+		Skipper:    isAPITokenRequest,
+	}))
+	a.Use(checkAPIToken)
+	a.Use(checkUserSession)
 
 	// Rate limit
 	setupRateLimit(a, config.RateLimitKind.GetString())
@@ -257,10 +293,30 @@ func registerAPIRoutes(a *echo.Group) {
 	u.POST("/password", apiv1.UserChangePassword)
 	u.GET("s", apiv1.UserList)
 	u.POST("/token", apiv1.RenewToken)
+	u.GET("/sessions", apiv1.UserListSessions)
+	u.DELETE("/sessions", apiv1.UserDeleteAllSessions)
+	u.DELETE("/sessions/:session", apiv1.UserDeleteSession)
+	u.POST("/deletion/request", apiv1.UserRequestDeletion)
+	u.POST("/deletion/confirm", apiv1.UserConfirmDeletion)
+	u.POST("/export/request", apiv1.UserRequestDataExport)
+	u.GET("/export/download", apiv1.UserDownloadDataExport)
 	u.POST("/settings/email", apiv1.UpdateUserEmail)
 	u.GET("/settings/avatar", apiv1.GetUserAvatarProvider)
 	u.POST("/settings/avatar", apiv1.ChangeUserAvatarProvider)
 	u.PUT("/settings/avatar/upload", apiv1.UploadAvatar)
+	u.GET("/settings/digest", apiv1.GetUserDigestSettings)
+	u.POST("/settings/digest", apiv1.ChangeUserDigestSettings)
+	u.GET("/settings/notifications", apiv1.GetUserEmailNotificationSettings)
+	u.POST("/settings/notifications", apiv1.ChangeUserEmailNotificationSettings)
+
+	apiTokenHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.APIToken{}
+		},
+	}
+	u.GET("/settings/tokens", apiTokenHandler.ReadAllWeb)
+	u.PUT("/settings/tokens", apiTokenHandler.CreateWeb)
+	u.DELETE("/settings/tokens/:token", apiTokenHandler.DeleteWeb)
 
 	if config.ServiceEnableTotp.GetBool() {
 		u.GET("/settings/totp", apiv1.UserTOTP)
@@ -268,6 +324,14 @@ func registerAPIRoutes(a *echo.Group) {
 		u.POST("/settings/totp/enable", apiv1.UserTOTPEnable)
 		u.POST("/settings/totp/disable", apiv1.UserTOTPDisable)
 		u.GET("/settings/totp/qrcode", apiv1.UserTOTPQrCode)
+		u.POST("/settings/totp/recovery-codes", apiv1.UserTOTPRegenerateRecoveryCodes)
+	}
+
+	if config.AuthWebAuthnEnabled.GetBool() {
+		u.GET("/settings/webauthn", apiv1.UserListWebAuthnCredentials)
+		u.POST("/settings/webauthn/register/begin", apiv1.UserBeginWebAuthnRegistration)
+		u.POST("/settings/webauthn/register/finish", apiv1.UserFinishWebAuthnRegistration)
+		u.DELETE("/settings/webauthn/:credential", apiv1.UserDeleteWebAuthnCredential)
 	}
 
 	listHandler := &handler.WebHandler{
@@ -311,6 +375,48 @@ func registerAPIRoutes(a *echo.Group) {
 	a.POST("/lists/:list/buckets/:bucket", kanbanBucketHandler.UpdateWeb)
 	a.DELETE("/lists/:list/buckets/:bucket", kanbanBucketHandler.DeleteWeb)
 
+	listPointsHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.ListPoints{}
+		},
+	}
+	a.GET("/lists/:list/points", listPointsHandler.ReadOneWeb)
+
+	listBurndownHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.ListBurndown{}
+		},
+	}
+	a.GET("/lists/:list/burndown", listBurndownHandler.ReadOneWeb)
+
+	customFieldHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.CustomField{}
+		},
+	}
+	a.GET("/lists/:list/custom-fields", customFieldHandler.ReadAllWeb)
+	a.PUT("/lists/:list/custom-fields", customFieldHandler.CreateWeb)
+	a.POST("/lists/:list/custom-fields/:customfield", customFieldHandler.UpdateWeb)
+	a.DELETE("/lists/:list/custom-fields/:customfield", customFieldHandler.DeleteWeb)
+
+	listTemplateHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.ListTemplate{}
+		},
+	}
+	a.PUT("/lists/:list/template", listTemplateHandler.CreateWeb)
+	a.GET("/templates", listTemplateHandler.ReadAllWeb)
+	a.GET("/templates/:template", listTemplateHandler.ReadOneWeb)
+	a.POST("/templates/:template", listTemplateHandler.UpdateWeb)
+	a.DELETE("/templates/:template", listTemplateHandler.DeleteWeb)
+
+	listFromTemplateHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.ListFromTemplate{}
+		},
+	}
+	a.PUT("/templates/:template/instantiate", listFromTemplateHandler.CreateWeb)
+
 	listDuplicateHandler := &handler.WebHandler{
 		EmptyStruct: func() handler.CObject {
 			return &models.ListDuplicate{}
@@ -401,6 +507,23 @@ func registerAPIRoutes(a *echo.Group) {
 		a.GET("/tasks/:task/comments/:commentid", taskCommentHandler.ReadOneWeb)
 	}
 
+	if config.ServiceEnableTimeTracking.GetBool() {
+		timeEntryHandler := &handler.WebHandler{
+			EmptyStruct: func() handler.CObject {
+				return &models.TimeEntry{}
+			},
+		}
+		a.GET("/tasks/:task/time-entries", timeEntryHandler.ReadAllWeb)
+		a.PUT("/tasks/:task/time-entries", timeEntryHandler.CreateWeb)
+		a.DELETE("/tasks/:task/time-entries/:timeentry", timeEntryHandler.DeleteWeb)
+		a.POST("/tasks/:task/time-entries/:timeentry", timeEntryHandler.UpdateWeb)
+		a.GET("/tasks/:task/time-entries/:timeentry", timeEntryHandler.ReadOneWeb)
+		a.POST("/tasks/:task/timer/start", apiv1.StartTimeEntryTimer)
+		a.GET("/time-tracking/timer", apiv1.GetRunningTimeEntry)
+		a.POST("/time-tracking/timer/stop", apiv1.StopTimeEntryTimer)
+		a.GET("/time-tracking/report", apiv1.GetTimeReport)
+	}
+
 	labelHandler := &handler.WebHandler{
 		EmptyStruct: func() handler.CObject {
 			return &models.Label{}
@@ -494,6 +617,70 @@ func registerAPIRoutes(a *echo.Group) {
 	a.DELETE("/teams/:team/members/:user", teamMemberHandler.DeleteWeb)
 	a.POST("/teams/:team/members/:user/admin", teamMemberHandler.UpdateWeb)
 
+	notificationHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.Notification{}
+		},
+	}
+	a.GET("/notifications", notificationHandler.ReadAllWeb)
+	a.POST("/notifications", apiv1.MarkAllNotificationsAsRead)
+	a.POST("/notifications/:notificationid", notificationHandler.UpdateWeb)
+
+	mentionHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.Mention{}
+		},
+	}
+	a.GET("/mentions", mentionHandler.ReadAllWeb)
+
+	activityHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.Activity{}
+		},
+	}
+	a.GET("/tasks/:listtask/activity", activityHandler.ReadAllWeb)
+	a.GET("/lists/:list/activity", activityHandler.ReadAllWeb)
+
+	taskRevisionHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.TaskRevision{}
+		},
+	}
+	a.GET("/tasks/:listtask/revisions", taskRevisionHandler.ReadAllWeb)
+	a.GET("/tasks/:listtask/revisions/:revision", taskRevisionHandler.ReadOneWeb)
+	a.POST("/tasks/:listtask/revisions/:revision/restore", apiv1.RestoreTaskRevision)
+
+	taskRevisionDiffHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.TaskRevisionDiff{}
+		},
+	}
+	a.GET("/tasks/:listtask/revisions/:revision/diff/:otherrevision", taskRevisionDiffHandler.ReadOneWeb)
+
+	if config.WebhooksEnabled.GetBool() {
+		webhookHandler := &handler.WebHandler{
+			EmptyStruct: func() handler.CObject {
+				return &models.Webhook{}
+			},
+		}
+		webhookDeliveryHandler := &handler.WebHandler{
+			EmptyStruct: func() handler.CObject {
+				return &models.WebhookDelivery{}
+			},
+		}
+		a.GET("/webhooks/events", apiv1.GetAvailableWebhookEvents)
+		a.GET("/lists/:list/webhooks", webhookHandler.ReadAllWeb)
+		a.PUT("/lists/:list/webhooks", webhookHandler.CreateWeb)
+		a.POST("/lists/:list/webhooks/:webhook", webhookHandler.UpdateWeb)
+		a.DELETE("/lists/:list/webhooks/:webhook", webhookHandler.DeleteWeb)
+		a.GET("/lists/:list/webhooks/:webhook/deliveries", webhookDeliveryHandler.ReadAllWeb)
+		a.GET("/namespaces/:namespace/webhooks", webhookHandler.ReadAllWeb)
+		a.PUT("/namespaces/:namespace/webhooks", webhookHandler.CreateWeb)
+		a.POST("/namespaces/:namespace/webhooks/:webhook", webhookHandler.UpdateWeb)
+		a.DELETE("/namespaces/:namespace/webhooks/:webhook", webhookHandler.DeleteWeb)
+		a.GET("/namespaces/:namespace/webhooks/:webhook/deliveries", webhookDeliveryHandler.ReadAllWeb)
+	}
+
 	// Migrations
 	m := a.Group("/migration")
 
@@ -517,6 +704,14 @@ func registerAPIRoutes(a *echo.Group) {
 		todoistMigrationHandler.RegisterRoutes(m)
 	}
 
+	// Vikunja data exports
+	vikunjaFileMigrationHandler := &migrationHandler.FileMigratorWeb{
+		MigrationStruct: func() migration.FileMigrator {
+			return &vikunjafile.FileMigrator{}
+		},
+	}
+	vikunjaFileMigrationHandler.RegisterRoutes(m)
+
 	// List Backgrounds
 	if config.BackgroundsEnabled.GetBool() {
 		a.GET("/lists/:list/background", backgroundHandler.GetListBackground)
@@ -0,0 +1,63 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package routes
+
+import (
+	apiv1 "code.vikunja.io/api/pkg/routes/api/v1"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web/handler"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo/v4"
+)
+
+// checkUserSession makes sure the session a user token belongs to was not revoked in the meantime.
+// Tokens of link shares and api tokens don't belong to a session and are checked elsewhere.
+func checkUserSession(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if isAPITokenRequest(c) {
+			return next(c)
+		}
+
+		jwtinf, is := c.Get("user").(*jwt.Token)
+		if !is {
+			return next(c)
+		}
+		claims := jwtinf.Claims.(jwt.MapClaims)
+		typ, is := claims["type"].(float64)
+		if !is || int(typ) != apiv1.AuthTypeUser {
+			return next(c)
+		}
+
+		userID, is := claims["id"].(float64)
+		if !is {
+			return handler.HandleHTTPError(user.ErrSessionInvalid{}, c)
+		}
+
+		// User tokens without a session were issued before sessions existed and can't be revoked
+		sessionID := apiv1.GetSessionIDFromClaims(claims)
+		if sessionID == 0 {
+			return handler.HandleHTTPError(user.ErrSessionInvalid{}, c)
+		}
+
+		err := user.CheckSession(sessionID, int64(userID), c.RealIP())
+		if err != nil {
+			return handler.HandleHTTPError(err, c)
+		}
+
+		return next(c)
+	}
+}
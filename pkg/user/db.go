@@ -47,5 +47,9 @@ func GetTables() []interface{} {
 	return []interface{}{
 		&User{},
 		&TOTP{},
+		&TOTPRecoveryCode{},
+		&Session{},
+		&WebAuthnCredential{},
+		&WebAuthnChallenge{},
 	}
 }
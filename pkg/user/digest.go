@@ -0,0 +1,94 @@
+// Copyright2018-2020 Vikunja and contriubtors. All rights reserved.
+//
+// This file is part of Vikunja.
+//
+// Vikunja is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Vikunja is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Vikunja.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+)
+
+const (
+	// DigestFrequencyNone means the user does not receive any digest emails
+	DigestFrequencyNone = ""
+	// DigestFrequencyDaily means the user receives a digest email every day
+	DigestFrequencyDaily = "daily"
+	// DigestFrequencyWeekly means the user receives a digest email every monday
+	DigestFrequencyWeekly = "weekly"
+)
+
+// DigestSettings holds the settings of a user for the digest email
+type DigestSettings struct {
+	// How often the user wants to receive a digest of their due and overdue tasks. Can be `daily`, `weekly` or empty to disable the digest.
+	Frequency string `json:"frequency"`
+	// The hour of the day (0-23) at which the digest is sent, in the timezone of the settings.
+	Hour int `json:"hour"`
+	// The timezone of the user as name of the IANA time zone database, like `Europe/Berlin`. The hour and the day
+	// of weekly digests are evaluated in it. If it is empty, the timezone configured on the server is used.
+	Timezone string `json:"timezone"`
+}
+
+// GetTimezone returns the timezone of the user or the configured timezone of the server if the user did not set one.
+func (u *User) GetTimezone() *time.Location {
+	if u.Timezone == "" {
+		return config.GetTimeZone()
+	}
+	loc, err := time.LoadLocation(u.Timezone)
+	if err != nil {
+		return config.GetTimeZone()
+	}
+	return loc
+}
+
+// GetDigestSettings returns the digest settings of a user
+func (u *User) GetDigestSettings() *DigestSettings {
+	return &DigestSettings{
+		Frequency: u.DigestFrequency,
+		Hour:      u.DigestHour,
+		Timezone:  u.GetTimezone().String(),
+	}
+}
+
+// UpdateDigestSettings validates and saves the digest settings of a user.
+func UpdateDigestSettings(user *User, settings *DigestSettings) (err error) {
+	if settings.Frequency != DigestFrequencyNone &&
+		settings.Frequency != DigestFrequencyDaily &&
+		settings.Frequency != DigestFrequencyWeekly {
+		return ErrInvalidDigestFrequency{Frequency: settings.Frequency}
+	}
+
+	if settings.Hour < 0 || settings.Hour > 23 {
+		return ErrInvalidDigestHour{Hour: settings.Hour}
+	}
+
+	if settings.Timezone != "" {
+		if _, err := time.LoadLocation(settings.Timezone); err != nil {
+			return ErrInvalidTimezone{Timezone: settings.Timezone}
+		}
+	}
+
+	user.DigestFrequency = settings.Frequency
+	user.DigestHour = settings.Hour
+	user.Timezone = settings.Timezone
+
+	_, err = x.
+		ID(user.ID).
+		Cols("digest_frequency", "digest_hour", "timezone").
+		Update(user)
+	return
+}
@@ -394,3 +394,432 @@ func (err ErrInvalidAvatarProvider) HTTPError() web.HTTPError {
 		Message:  "Invalid avatar provider setting. See docs for valid types.",
 	}
 }
+
+// ErrInvalidDigestFrequency represents a "InvalidDigestFrequency" kind of error.
+type ErrInvalidDigestFrequency struct {
+	Frequency string
+}
+
+// IsErrInvalidDigestFrequency checks if an error is a ErrInvalidDigestFrequency.
+func IsErrInvalidDigestFrequency(err error) bool {
+	_, ok := err.(ErrInvalidDigestFrequency)
+	return ok
+}
+
+func (err ErrInvalidDigestFrequency) Error() string {
+	return fmt.Sprintf("Invalid digest frequency [Frequency: %s]", err.Frequency)
+}
+
+// ErrCodeInvalidDigestFrequency holds the unique world-error code of this error
+const ErrCodeInvalidDigestFrequency = 1019
+
+// HTTPError holds the http error description
+func (err ErrInvalidDigestFrequency) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusPreconditionFailed,
+		Code:     ErrCodeInvalidDigestFrequency,
+		Message:  "Invalid digest frequency. Valid values are 'daily', 'weekly' or an empty string to disable the digest.",
+	}
+}
+
+// ErrInvalidDigestHour represents a "InvalidDigestHour" kind of error.
+type ErrInvalidDigestHour struct {
+	Hour int
+}
+
+// IsErrInvalidDigestHour checks if an error is a ErrInvalidDigestHour.
+func IsErrInvalidDigestHour(err error) bool {
+	_, ok := err.(ErrInvalidDigestHour)
+	return ok
+}
+
+func (err ErrInvalidDigestHour) Error() string {
+	return fmt.Sprintf("Invalid digest hour [Hour: %d]", err.Hour)
+}
+
+// ErrCodeInvalidDigestHour holds the unique world-error code of this error
+const ErrCodeInvalidDigestHour = 1020
+
+// HTTPError holds the http error description
+func (err ErrInvalidDigestHour) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusPreconditionFailed,
+		Code:     ErrCodeInvalidDigestHour,
+		Message:  "Invalid digest hour. The hour must be between 0 and 23.",
+	}
+}
+
+// ErrAccountIsNotLocal represents a "AccountIsNotLocal" kind of error.
+type ErrAccountIsNotLocal struct {
+	UserID int64
+}
+
+// IsErrAccountIsNotLocal checks if an error is a ErrAccountIsNotLocal.
+func IsErrAccountIsNotLocal(err error) bool {
+	_, ok := err.(ErrAccountIsNotLocal)
+	return ok
+}
+
+func (err ErrAccountIsNotLocal) Error() string {
+	return fmt.Sprintf("Account is not a local account [UserID: %d]", err.UserID)
+}
+
+// ErrCodeAccountIsNotLocal holds the unique world-error code of this error
+const ErrCodeAccountIsNotLocal = 1021
+
+// HTTPError holds the http error description
+func (err ErrAccountIsNotLocal) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusPreconditionFailed,
+		Code:     ErrCodeAccountIsNotLocal,
+		Message:  "This account is managed by an external authentication provider. Its password cannot be used or changed in Vikunja.",
+	}
+}
+
+// ErrSessionDoesNotExist represents a "SessionDoesNotExist" kind of error.
+type ErrSessionDoesNotExist struct {
+	SessionID int64
+}
+
+// IsErrSessionDoesNotExist checks if an error is a ErrSessionDoesNotExist.
+func IsErrSessionDoesNotExist(err error) bool {
+	_, ok := err.(ErrSessionDoesNotExist)
+	return ok
+}
+
+func (err ErrSessionDoesNotExist) Error() string {
+	return fmt.Sprintf("Session does not exist [SessionID: %d]", err.SessionID)
+}
+
+// ErrCodeSessionDoesNotExist holds the unique world-error code of this error
+const ErrCodeSessionDoesNotExist = 1022
+
+// HTTPError holds the http error description
+func (err ErrSessionDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeSessionDoesNotExist,
+		Message:  "This session does not exist.",
+	}
+}
+
+// ErrInvalidRefreshToken represents a "InvalidRefreshToken" kind of error.
+type ErrInvalidRefreshToken struct{}
+
+// IsErrInvalidRefreshToken checks if an error is a ErrInvalidRefreshToken.
+func IsErrInvalidRefreshToken(err error) bool {
+	_, ok := err.(ErrInvalidRefreshToken)
+	return ok
+}
+
+func (err ErrInvalidRefreshToken) Error() string {
+	return "Invalid refresh token"
+}
+
+// ErrCodeInvalidRefreshToken holds the unique world-error code of this error
+const ErrCodeInvalidRefreshToken = 1023
+
+// HTTPError holds the http error description
+func (err ErrInvalidRefreshToken) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusUnauthorized,
+		Code:     ErrCodeInvalidRefreshToken,
+		Message:  "The refresh token is invalid or expired. Please log in again.",
+	}
+}
+
+// ErrSessionInvalid represents a "SessionInvalid" kind of error.
+type ErrSessionInvalid struct{}
+
+// IsErrSessionInvalid checks if an error is a ErrSessionInvalid.
+func IsErrSessionInvalid(err error) bool {
+	_, ok := err.(ErrSessionInvalid)
+	return ok
+}
+
+func (err ErrSessionInvalid) Error() string {
+	return "Session is invalid or was revoked"
+}
+
+// ErrCodeSessionInvalid holds the unique world-error code of this error
+const ErrCodeSessionInvalid = 1024
+
+// HTTPError holds the http error description
+func (err ErrSessionInvalid) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusUnauthorized,
+		Code:     ErrCodeSessionInvalid,
+		Message:  "Your session has expired or was revoked. Please log in again.",
+	}
+}
+
+// ErrNoWebAuthnCredentials represents a "NoWebAuthnCredentials" kind of error.
+type ErrNoWebAuthnCredentials struct {
+	UserID int64
+}
+
+// IsErrNoWebAuthnCredentials checks if an error is a ErrNoWebAuthnCredentials.
+func IsErrNoWebAuthnCredentials(err error) bool {
+	_, ok := err.(ErrNoWebAuthnCredentials)
+	return ok
+}
+
+func (err ErrNoWebAuthnCredentials) Error() string {
+	return fmt.Sprintf("User has no webauthn credentials [UserID: %d]", err.UserID)
+}
+
+// ErrCodeNoWebAuthnCredentials holds the unique world-error code of this error
+const ErrCodeNoWebAuthnCredentials = 1025
+
+// HTTPError holds the http error description
+func (err ErrNoWebAuthnCredentials) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusPreconditionFailed,
+		Code:     ErrCodeNoWebAuthnCredentials,
+		Message:  "This user has no security keys registered.",
+	}
+}
+
+// ErrSecondFactorRequired represents a "SecondFactorRequired" kind of error.
+type ErrSecondFactorRequired struct {
+	UserID int64
+}
+
+// IsErrSecondFactorRequired checks if an error is a ErrSecondFactorRequired.
+func IsErrSecondFactorRequired(err error) bool {
+	_, ok := err.(ErrSecondFactorRequired)
+	return ok
+}
+
+func (err ErrSecondFactorRequired) Error() string {
+	return fmt.Sprintf("User needs a second factor to log in [UserID: %d]", err.UserID)
+}
+
+// ErrCodeSecondFactorRequired holds the unique world-error code of this error
+const ErrCodeSecondFactorRequired = 1026
+
+// HTTPError holds the http error description
+func (err ErrSecondFactorRequired) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusPreconditionFailed,
+		Code:     ErrCodeSecondFactorRequired,
+		Message:  "This account needs a second factor to log in. Please provide a totp passcode or use a security key.",
+	}
+}
+
+// ErrInvalidWebAuthnResponse represents a "InvalidWebAuthnResponse" kind of error.
+type ErrInvalidWebAuthnResponse struct {
+	Reason string
+}
+
+// IsErrInvalidWebAuthnResponse checks if an error is a ErrInvalidWebAuthnResponse.
+func IsErrInvalidWebAuthnResponse(err error) bool {
+	_, ok := err.(ErrInvalidWebAuthnResponse)
+	return ok
+}
+
+func (err ErrInvalidWebAuthnResponse) Error() string {
+	return fmt.Sprintf("Invalid webauthn response [Reason: %s]", err.Reason)
+}
+
+// ErrCodeInvalidWebAuthnResponse holds the unique world-error code of this error
+const ErrCodeInvalidWebAuthnResponse = 1027
+
+// HTTPError holds the http error description
+func (err ErrInvalidWebAuthnResponse) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusPreconditionFailed,
+		Code:     ErrCodeInvalidWebAuthnResponse,
+		Message:  "The security key response is invalid or expired. Please try again.",
+	}
+}
+
+// ErrWebAuthnCredentialDoesNotExist represents a "WebAuthnCredentialDoesNotExist" kind of error.
+type ErrWebAuthnCredentialDoesNotExist struct {
+	CredentialID int64
+}
+
+// IsErrWebAuthnCredentialDoesNotExist checks if an error is a ErrWebAuthnCredentialDoesNotExist.
+func IsErrWebAuthnCredentialDoesNotExist(err error) bool {
+	_, ok := err.(ErrWebAuthnCredentialDoesNotExist)
+	return ok
+}
+
+func (err ErrWebAuthnCredentialDoesNotExist) Error() string {
+	return fmt.Sprintf("WebAuthn credential does not exist [CredentialID: %d]", err.CredentialID)
+}
+
+// ErrCodeWebAuthnCredentialDoesNotExist holds the unique world-error code of this error
+const ErrCodeWebAuthnCredentialDoesNotExist = 1028
+
+// HTTPError holds the http error description
+func (err ErrWebAuthnCredentialDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeWebAuthnCredentialDoesNotExist,
+		Message:  "This security key does not exist.",
+	}
+}
+
+// ErrAccountDeletionNotConfirmed represents a "AccountDeletionNotConfirmed" kind of error.
+type ErrAccountDeletionNotConfirmed struct {
+	UserID int64
+}
+
+// IsErrAccountDeletionNotConfirmed checks if an error is a ErrAccountDeletionNotConfirmed.
+func IsErrAccountDeletionNotConfirmed(err error) bool {
+	_, ok := err.(ErrAccountDeletionNotConfirmed)
+	return ok
+}
+
+func (err ErrAccountDeletionNotConfirmed) Error() string {
+	return fmt.Sprintf("Account deletion was not confirmed [UserID: %d]", err.UserID)
+}
+
+// ErrCodeAccountDeletionNotConfirmed holds the unique world-error code of this error
+const ErrCodeAccountDeletionNotConfirmed = 1029
+
+// HTTPError holds the http error description
+func (err ErrAccountDeletionNotConfirmed) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusPreconditionFailed,
+		Code:     ErrCodeAccountDeletionNotConfirmed,
+		Message:  "Please confirm the deletion of your account with your password or the token sent to you by email.",
+	}
+}
+
+// ErrCannotTransferToSameUser represents a "CannotTransferToSameUser" kind of error.
+type ErrCannotTransferToSameUser struct {
+	UserID int64
+}
+
+// IsErrCannotTransferToSameUser checks if an error is a ErrCannotTransferToSameUser.
+func IsErrCannotTransferToSameUser(err error) bool {
+	_, ok := err.(ErrCannotTransferToSameUser)
+	return ok
+}
+
+func (err ErrCannotTransferToSameUser) Error() string {
+	return fmt.Sprintf("Cannot transfer the data of a deleted user to themselves [UserID: %d]", err.UserID)
+}
+
+// ErrCodeCannotTransferToSameUser holds the unique world-error code of this error
+const ErrCodeCannotTransferToSameUser = 1030
+
+// HTTPError holds the http error description
+func (err ErrCannotTransferToSameUser) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeCannotTransferToSameUser,
+		Message:  "You cannot transfer your data to the account you are deleting.",
+	}
+}
+
+// ErrNoDataExportAvailable represents a "NoDataExportAvailable" kind of error.
+type ErrNoDataExportAvailable struct {
+	UserID int64
+}
+
+// IsErrNoDataExportAvailable checks if an error is a ErrNoDataExportAvailable.
+func IsErrNoDataExportAvailable(err error) bool {
+	_, ok := err.(ErrNoDataExportAvailable)
+	return ok
+}
+
+func (err ErrNoDataExportAvailable) Error() string {
+	return fmt.Sprintf("No data export is available for this user [UserID: %d]", err.UserID)
+}
+
+// ErrCodeNoDataExportAvailable holds the unique world-error code of this error
+const ErrCodeNoDataExportAvailable = 1031
+
+// HTTPError holds the http error description
+func (err ErrNoDataExportAvailable) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeNoDataExportAvailable,
+		Message:  "There is no data export available. It may have expired, please request a new one.",
+	}
+}
+
+// ErrDataExportInProgress represents a "DataExportInProgress" kind of error.
+type ErrDataExportInProgress struct {
+	UserID int64
+}
+
+// IsErrDataExportInProgress checks if an error is a ErrDataExportInProgress.
+func IsErrDataExportInProgress(err error) bool {
+	_, ok := err.(ErrDataExportInProgress)
+	return ok
+}
+
+func (err ErrDataExportInProgress) Error() string {
+	return fmt.Sprintf("A data export is already being created for this user [UserID: %d]", err.UserID)
+}
+
+// ErrCodeDataExportInProgress holds the unique world-error code of this error
+const ErrCodeDataExportInProgress = 1032
+
+// HTTPError holds the http error description
+func (err ErrDataExportInProgress) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusConflict,
+		Code:     ErrCodeDataExportInProgress,
+		Message:  "Your data export is already being created. We will send you an email once it is ready.",
+	}
+}
+
+// ErrInvalidTimezone represents a "InvalidTimezone" kind of error.
+type ErrInvalidTimezone struct {
+	Timezone string
+}
+
+// IsErrInvalidTimezone checks if an error is a ErrInvalidTimezone.
+func IsErrInvalidTimezone(err error) bool {
+	_, ok := err.(ErrInvalidTimezone)
+	return ok
+}
+
+func (err ErrInvalidTimezone) Error() string {
+	return fmt.Sprintf("Invalid timezone [Timezone: %s]", err.Timezone)
+}
+
+// ErrCodeInvalidTimezone holds the unique world-error code of this error
+const ErrCodeInvalidTimezone = 1033
+
+// HTTPError holds the http error description
+func (err ErrInvalidTimezone) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusPreconditionFailed,
+		Code:     ErrCodeInvalidTimezone,
+		Message:  "Invalid timezone. It must be a name of the IANA time zone database like 'Europe/Berlin'.",
+	}
+}
+
+// ErrCannotTransferToUser represents a "CannotTransferToUser" kind of error.
+type ErrCannotTransferToUser struct {
+	UserID       int64
+	TransferToID int64
+}
+
+// IsErrCannotTransferToUser checks if an error is a ErrCannotTransferToUser.
+func IsErrCannotTransferToUser(err error) bool {
+	_, ok := err.(ErrCannotTransferToUser)
+	return ok
+}
+
+func (err ErrCannotTransferToUser) Error() string {
+	return fmt.Sprintf("Cannot transfer the data of a deleted user to a user who is not admin of all of it [UserID: %d, TransferToID: %d]", err.UserID, err.TransferToID)
+}
+
+// ErrCodeCannotTransferToUser holds the unique world-error code of this error
+const ErrCodeCannotTransferToUser = 1034
+
+// HTTPError holds the http error description
+func (err ErrCannotTransferToUser) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusForbidden,
+		Code:     ErrCodeCannotTransferToUser,
+		Message:  "You can only transfer your data to a user who already has admin access to all of your namespaces, lists and teams.",
+	}
+}
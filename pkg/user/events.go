@@ -0,0 +1,38 @@
+// Copyright2018-2020 Vikunja and contriubtors. All rights reserved.
+//
+// This file is part of Vikunja.
+//
+// Vikunja is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Vikunja is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Vikunja.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+// CreatedEvent represents an event where a user has been created
+type CreatedEvent struct {
+	User *User
+}
+
+// Name defines the name for CreatedEvent
+func (c *CreatedEvent) Name() string {
+	return "user.created"
+}
+
+// DeletedEvent represents an event where a user has been deleted
+type DeletedEvent struct {
+	User *User
+}
+
+// Name defines the name for DeletedEvent
+func (d *DeletedEvent) Name() string {
+	return "user.deleted"
+}
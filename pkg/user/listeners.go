@@ -0,0 +1,57 @@
+// Copyright2018-2020 Vikunja and contriubtors. All rights reserved.
+//
+// This file is part of Vikunja.
+//
+// Vikunja is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Vikunja is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Vikunja.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/metrics"
+)
+
+// Registers all event listeners of the user package
+func init() {
+	events.RegisterListener((&CreatedEvent{}).Name(), &IncreaseUserCounter{})
+	events.RegisterListener((&DeletedEvent{}).Name(), &DecreaseUserCounter{})
+}
+
+// IncreaseUserCounter represents a listener
+type IncreaseUserCounter struct{}
+
+// Name defines the name for the IncreaseUserCounter listener
+func (s *IncreaseUserCounter) Name() string {
+	return "user.counter.increase"
+}
+
+// Handle is executed when the event IncreaseUserCounter listens on is fired
+func (s *IncreaseUserCounter) Handle(_ events.Event) (err error) {
+	metrics.UpdateCount(1, metrics.ActiveUsersKey)
+	return nil
+}
+
+// DecreaseUserCounter represents a listener
+type DecreaseUserCounter struct{}
+
+// Name defines the name for the DecreaseUserCounter listener
+func (s *DecreaseUserCounter) Name() string {
+	return "user.counter.decrease"
+}
+
+// Handle is executed when the event DecreaseUserCounter listens on is fired
+func (s *DecreaseUserCounter) Handle(_ events.Event) (err error) {
+	metrics.UpdateCount(-1, metrics.ActiveUsersKey)
+	return nil
+}
@@ -0,0 +1,53 @@
+// Copyright2018-2020 Vikunja and contriubtors. All rights reserved.
+//
+// This file is part of Vikunja.
+//
+// Vikunja is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Vikunja is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Vikunja.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+// EmailNotificationSettings holds which email notifications a user wants to receive
+type EmailNotificationSettings struct {
+	// If enabled, the user gets an email when someone else assigns them to a task.
+	TaskAssigned bool `json:"task_assigned"`
+	// If enabled, the user gets an email when someone else comments on a task they created or are assigned to.
+	TaskComment bool `json:"task_comment"`
+	// If enabled, the user gets an email when someone else marks a task they created or are assigned to as done.
+	TaskDone bool `json:"task_done"`
+}
+
+// GetEmailNotificationSettings returns the email notification settings of a user
+func (u *User) GetEmailNotificationSettings() *EmailNotificationSettings {
+	return &EmailNotificationSettings{
+		TaskAssigned: u.EmailNotifyTaskAssigned,
+		TaskComment:  u.EmailNotifyTaskComment,
+		TaskDone:     u.EmailNotifyTaskDone,
+	}
+}
+
+// UpdateEmailNotificationSettings saves the email notification settings of a user
+func UpdateEmailNotificationSettings(user *User, settings *EmailNotificationSettings) (err error) {
+	user.EmailNotifyTaskAssigned = settings.TaskAssigned
+	user.EmailNotifyTaskComment = settings.TaskComment
+	user.EmailNotifyTaskDone = settings.TaskDone
+
+	_, err = x.
+		ID(user.ID).
+		Cols(
+			"email_notify_task_assigned",
+			"email_notify_task_comment",
+			"email_notify_task_done").
+		Update(user)
+	return
+}
@@ -0,0 +1,194 @@
+// Copyright2018-2020 Vikunja and contriubtors. All rights reserved.
+//
+// This file is part of Vikunja.
+//
+// Vikunja is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Vikunja is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Vikunja.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+)
+
+// Session is a login of a user on one device. Every access token belongs to a session and stops working as soon
+// as the session is deleted.
+type Session struct {
+	// The unique, numeric id of this session.
+	ID     int64 `xorm:"int(11) autoincr not null unique pk" json:"id"`
+	UserID int64 `xorm:"int(11) not null INDEX" json:"-"`
+
+	RefreshTokenHash string `xorm:"varchar(64) not null unique" json:"-"`
+
+	// The user agent of the device this session was created on.
+	DeviceInfo string `xorm:"text null" json:"device_info"`
+	// The ip address this session was last used from.
+	IPAddress string `xorm:"varchar(100) null" json:"ip_address"`
+	// When this session was last used.
+	LastActive time.Time `xorm:"DATETIME not null" json:"last_active"`
+	// Whether this is the session the request was made with.
+	IsCurrent bool `xorm:"-" json:"is_current"`
+
+	// A timestamp when this session was created. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+}
+
+// TableName returns the table name for sessions
+func (s *Session) TableName() string {
+	return "sessions"
+}
+
+func hashRefreshToken(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(h[:])
+}
+
+// Refresh tokens must not be guessable, they therefore use crypto/rand and not the random string util
+func newRefreshToken() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func (s *Session) isExpired() bool {
+	return s.LastActive.Add(time.Duration(config.ServiceSessionTTL.GetInt64()) * time.Second).Before(time.Now())
+}
+
+// CreateSession creates a new session for a user who just logged in and returns the refresh token for it.
+func CreateSession(u *User, deviceInfo, ipAddress string) (s *Session, refreshToken string, err error) {
+	refreshToken, err = newRefreshToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	s = &Session{
+		UserID:           u.ID,
+		RefreshTokenHash: hashRefreshToken(refreshToken),
+		DeviceInfo:       deviceInfo,
+		IPAddress:        ipAddress,
+		LastActive:       time.Now(),
+	}
+	_, err = x.Insert(s)
+	return
+}
+
+// RefreshSession exchanges a refresh token for a new one and returns the session and user it belongs to.
+// Every refresh token can only be used once.
+func RefreshSession(refreshToken, ipAddress string) (s *Session, u *User, newToken string, err error) {
+	if refreshToken == "" {
+		return nil, nil, "", ErrInvalidRefreshToken{}
+	}
+
+	s = &Session{}
+	exists, err := x.Where("refresh_token_hash = ?", hashRefreshToken(refreshToken)).Get(s)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if !exists {
+		return nil, nil, "", ErrInvalidRefreshToken{}
+	}
+
+	if s.isExpired() {
+		_, err = x.Where("id = ?", s.ID).Delete(&Session{})
+		if err != nil {
+			return nil, nil, "", err
+		}
+		return nil, nil, "", ErrInvalidRefreshToken{}
+	}
+
+	u, err = GetUserWithEmail(&User{ID: s.UserID})
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if !u.IsActive {
+		return nil, nil, "", ErrInvalidRefreshToken{}
+	}
+
+	newToken, err = newRefreshToken()
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	s.RefreshTokenHash = hashRefreshToken(newToken)
+	s.IPAddress = ipAddress
+	s.LastActive = time.Now()
+	_, err = x.
+		ID(s.ID).
+		Cols("refresh_token_hash", "ip_address", "last_active").
+		Update(s)
+	return
+}
+
+// CheckSession makes sure a session still exists and belongs to the user. It also saves when the session was last
+// used.
+func CheckSession(sessionID, userID int64, ipAddress string) (err error) {
+	s := &Session{}
+	exists, err := x.Where("id = ? AND user_id = ?", sessionID, userID).Get(s)
+	if err != nil {
+		return err
+	}
+	if !exists || s.isExpired() {
+		return ErrSessionInvalid{}
+	}
+
+	// Only saving it once per minute avoids a database write for every request
+	if time.Since(s.LastActive) > time.Minute || s.IPAddress != ipAddress {
+		s.LastActive = time.Now()
+		s.IPAddress = ipAddress
+		_, err = x.ID(s.ID).Cols("last_active", "ip_address").Update(s)
+	}
+	return
+}
+
+// GetSessionsForUser returns all sessions of a user, the most recently used first
+func GetSessionsForUser(u *User, currentSessionID int64) (sessions []*Session, err error) {
+	sessions = []*Session{}
+	err = x.
+		Where("user_id = ?", u.ID).
+		OrderBy("last_active desc").
+		Find(&sessions)
+	if err != nil {
+		return
+	}
+
+	for _, s := range sessions {
+		s.IsCurrent = s.ID == currentSessionID
+	}
+	return
+}
+
+// DeleteSession revokes a single session of a user
+func DeleteSession(u *User, sessionID int64) (err error) {
+	deleted, err := x.Where("id = ? AND user_id = ?", sessionID, u.ID).Delete(&Session{})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrSessionDoesNotExist{SessionID: sessionID}
+	}
+	return nil
+}
+
+// DeleteAllSessionsForUser revokes all sessions of a user, logging them out everywhere
+func DeleteAllSessionsForUser(userID int64) (err error) {
+	_, err = x.Where("user_id = ?", userID).Delete(&Session{})
+	return
+}
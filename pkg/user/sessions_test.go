@@ -0,0 +1,160 @@
+// Copyright2018-2020 Vikunja and contriubtors. All rights reserved.
+//
+// This file is part of Vikunja.
+//
+// Vikunja is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Vikunja is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Vikunja.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateSession(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s, token, err := CreateSession(&User{ID: 1}, "test device", "127.0.0.3")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, token)
+	assert.NotEqual(t, int64(0), s.ID)
+	db.AssertExists(t, "sessions", map[string]interface{}{
+		"id":                 s.ID,
+		"user_id":            1,
+		"refresh_token_hash": hashRefreshToken(token),
+		"device_info":        "test device",
+		"ip_address":         "127.0.0.3",
+	}, false)
+}
+
+func TestRefreshSession(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s, u, token, err := RefreshSession("refreshtoken01", "127.0.0.5")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), s.ID)
+		assert.Equal(t, int64(1), u.ID)
+		assert.NotEmpty(t, token)
+		db.AssertExists(t, "sessions", map[string]interface{}{
+			"id":                 1,
+			"refresh_token_hash": hashRefreshToken(token),
+			"ip_address":         "127.0.0.5",
+		}, false)
+
+		// Every refresh token can only be used once
+		_, _, _, err = RefreshSession("refreshtoken01", "127.0.0.5")
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidRefreshToken(err))
+	})
+	t.Run("expired", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		_, _, _, err := RefreshSession("refreshtoken03", "127.0.0.1")
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidRefreshToken(err))
+		db.AssertMissing(t, "sessions", map[string]interface{}{
+			"id": 3,
+		})
+	})
+	t.Run("nonexisting", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		_, _, _, err := RefreshSession("nonexisting", "127.0.0.1")
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidRefreshToken(err))
+	})
+	t.Run("empty", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		_, _, _, err := RefreshSession("", "127.0.0.1")
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidRefreshToken(err))
+	})
+}
+
+func TestCheckSession(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		err := CheckSession(1, 1, "127.0.0.1")
+		assert.NoError(t, err)
+	})
+	t.Run("new ip address", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		err := CheckSession(1, 1, "127.0.0.9")
+		assert.NoError(t, err)
+		db.AssertExists(t, "sessions", map[string]interface{}{
+			"id":         1,
+			"ip_address": "127.0.0.9",
+		}, false)
+	})
+	t.Run("session of another user", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		err := CheckSession(4, 1, "127.0.0.1")
+		assert.Error(t, err)
+		assert.True(t, IsErrSessionInvalid(err))
+	})
+	t.Run("expired", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		err := CheckSession(3, 1, "127.0.0.1")
+		assert.Error(t, err)
+		assert.True(t, IsErrSessionInvalid(err))
+	})
+	t.Run("nonexisting", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		err := CheckSession(9999, 1, "127.0.0.1")
+		assert.Error(t, err)
+		assert.True(t, IsErrSessionInvalid(err))
+	})
+}
+
+func TestGetSessionsForUser(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	sessions, err := GetSessionsForUser(&User{ID: 1}, 1)
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 3)
+	assert.Equal(t, int64(2), sessions[0].ID)
+	assert.False(t, sessions[0].IsCurrent)
+	assert.Equal(t, int64(1), sessions[1].ID)
+	assert.True(t, sessions[1].IsCurrent)
+}
+
+func TestDeleteSession(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		err := DeleteSession(&User{ID: 1}, 2)
+		assert.NoError(t, err)
+		db.AssertMissing(t, "sessions", map[string]interface{}{
+			"id": 2,
+		})
+	})
+	t.Run("session of another user", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		err := DeleteSession(&User{ID: 1}, 4)
+		assert.Error(t, err)
+		assert.True(t, IsErrSessionDoesNotExist(err))
+		db.AssertExists(t, "sessions", map[string]interface{}{
+			"id": 4,
+		}, false)
+	})
+}
+
+func TestDeleteAllSessionsForUser(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	err := DeleteAllSessionsForUser(1)
+	assert.NoError(t, err)
+	db.AssertMissing(t, "sessions", map[string]interface{}{
+		"user_id": 1,
+	})
+	db.AssertExists(t, "sessions", map[string]interface{}{
+		"id": 4,
+	}, false)
+}
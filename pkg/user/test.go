@@ -35,7 +35,7 @@ func InitTests() {
 		log.Fatal(err)
 	}
 
-	err = db.InitTestFixtures("users")
+	err = db.InitTestFixtures("users", "sessions", "totp", "totp_recovery_codes", "webauthn_credentials")
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -33,6 +33,8 @@ type TOTP struct {
 	Enabled bool `xorm:"null" json:"enabled"`
 	// The totp url used to be able to enroll the user later
 	URL string `xorm:"text null" json:"url"`
+	// How many unused recovery codes the user has left.
+	RecoveryCodesLeft int64 `xorm:"-" json:"recovery_codes_left"`
 }
 
 // TableName holds the table name for totp secrets
@@ -99,7 +101,8 @@ func EnrollTOTP(user *User) (t *TOTP, err error) {
 }
 
 // EnableTOTP enables totp for a user. The provided passcode is used to verify the user has a working totp setup.
-func EnableTOTP(passcode *TOTPPasscode) (err error) {
+// It returns a set of recovery codes the user can use if they lose their totp device.
+func EnableTOTP(passcode *TOTPPasscode) (codes *TOTPRecoveryCodes, err error) {
 	t, err := ValidateTOTPPasscode(passcode)
 	if err != nil {
 		return
@@ -109,13 +112,21 @@ func EnableTOTP(passcode *TOTPPasscode) (err error) {
 		Where("id = ?", t.ID).
 		Cols("enabled").
 		Update(&TOTP{Enabled: true})
-	return
+	if err != nil {
+		return
+	}
+
+	return GenerateTOTPRecoveryCodes(passcode.User)
 }
 
 // DisableTOTP removes all totp settings for a user.
 func DisableTOTP(user *User) (err error) {
 	_, err = x.Where("user_id = ?", user.ID).Delete(&TOTP{})
-	return
+	if err != nil {
+		return
+	}
+
+	return deleteTOTPRecoveryCodes(user)
 }
 
 // ValidateTOTPPasscode validated totp codes of users.
@@ -132,6 +143,24 @@ func ValidateTOTPPasscode(passcode *TOTPPasscode) (t *TOTP, err error) {
 	return
 }
 
+// ValidateTOTPPasscodeOrRecoveryCode validates the totp passcode of a user logging in. If it is not a valid totp
+// passcode, it is checked against the user's recovery codes. A recovery code can only be used once.
+func ValidateTOTPPasscodeOrRecoveryCode(passcode *TOTPPasscode) (usedRecoveryCode bool, err error) {
+	_, err = ValidateTOTPPasscode(passcode)
+	if err == nil {
+		return false, nil
+	}
+	if !IsErrInvalidTOTPPasscode(err) {
+		return false, err
+	}
+
+	err = useTOTPRecoveryCode(passcode.User, passcode.Passcode)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 // GetTOTPQrCodeForUser returns a qrcode for a user's totp setting
 func GetTOTPQrCodeForUser(user *User) (qrcode image.Image, err error) {
 	t, err := GetTOTPForUser(user)
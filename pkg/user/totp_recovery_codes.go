@@ -0,0 +1,118 @@
+// Copyright2018-2020 Vikunja and contriubtors. All rights reserved.
+//
+// This file is part of Vikunja.
+//
+// Vikunja is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Vikunja is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Vikunja.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"strings"
+)
+
+// The number of recovery codes every user gets when enabling totp
+const totpRecoveryCodeCount = 10
+
+// TOTPRecoveryCode is a single-use code to log in without the totp device. Only a hash of it is stored.
+type TOTPRecoveryCode struct {
+	ID       int64  `xorm:"int(11) autoincr not null unique pk" json:"-"`
+	UserID   int64  `xorm:"int(11) not null INDEX" json:"-"`
+	CodeHash string `xorm:"varchar(64) not null" json:"-"`
+}
+
+// TableName holds the table name for totp recovery codes
+func (t *TOTPRecoveryCode) TableName() string {
+	return "totp_recovery_codes"
+}
+
+// TOTPRecoveryCodes holds newly generated recovery codes. They are only shown once.
+type TOTPRecoveryCodes struct {
+	Codes []string `json:"recovery_codes"`
+}
+
+// Makes sure codes are found regardless of how the user typed them
+func hashTOTPRecoveryCode(code string) string {
+	code = strings.ToUpper(code)
+	code = strings.NewReplacer("-", "", " ", "").Replace(code)
+	h := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(h[:])
+}
+
+// Recovery codes are as good as a password, they therefore use crypto/rand and not the random string util
+func newTOTPRecoveryCode() (string, error) {
+	b := make([]byte, 10)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	code := base32.StdEncoding.EncodeToString(b)
+	return code[:8] + "-" + code[8:], nil
+}
+
+// GenerateTOTPRecoveryCodes replaces all recovery codes of a user with new ones and returns them.
+func GenerateTOTPRecoveryCodes(user *User) (codes *TOTPRecoveryCodes, err error) {
+	codes = &TOTPRecoveryCodes{Codes: make([]string, 0, totpRecoveryCodeCount)}
+	recoveryCodes := make([]*TOTPRecoveryCode, 0, totpRecoveryCodeCount)
+	for i := 0; i < totpRecoveryCodeCount; i++ {
+		code, err := newTOTPRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes.Codes = append(codes.Codes, code)
+		recoveryCodes = append(recoveryCodes, &TOTPRecoveryCode{
+			UserID:   user.ID,
+			CodeHash: hashTOTPRecoveryCode(code),
+		})
+	}
+
+	err = deleteTOTPRecoveryCodes(user)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = x.Insert(&recoveryCodes)
+	return
+}
+
+func deleteTOTPRecoveryCodes(user *User) (err error) {
+	_, err = x.Where("user_id = ?", user.ID).Delete(&TOTPRecoveryCode{})
+	return
+}
+
+// GetTOTPRecoveryCodesLeft returns how many unused recovery codes a user has left
+func GetTOTPRecoveryCodesLeft(user *User) (left int64, err error) {
+	return x.Where("user_id = ?", user.ID).Count(&TOTPRecoveryCode{})
+}
+
+// useTOTPRecoveryCode checks a recovery code and removes it so it can't be used again.
+func useTOTPRecoveryCode(user *User, code string) (err error) {
+	if code == "" {
+		return ErrInvalidTOTPPasscode{Passcode: code}
+	}
+
+	deleted, err := x.
+		Where("user_id = ? AND code_hash = ?", user.ID, hashTOTPRecoveryCode(code)).
+		Delete(&TOTPRecoveryCode{})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrInvalidTOTPPasscode{Passcode: code}
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+// Copyright2018-2020 Vikunja and contriubtors. All rights reserved.
+//
+// This file is part of Vikunja.
+//
+// Vikunja is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Vikunja is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Vikunja.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnableTOTP(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	u := &User{ID: 1, Username: "user1"}
+	tt, err := EnrollTOTP(u)
+	assert.NoError(t, err)
+
+	passcode, err := totp.GenerateCode(tt.Secret, time.Now())
+	assert.NoError(t, err)
+	codes, err := EnableTOTP(&TOTPPasscode{User: u, Passcode: passcode})
+	assert.NoError(t, err)
+	assert.Len(t, codes.Codes, totpRecoveryCodeCount)
+
+	left, err := GetTOTPRecoveryCodesLeft(u)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(totpRecoveryCodeCount), left)
+
+	// Only hashes should be stored
+	db.AssertMissing(t, "totp_recovery_codes", map[string]interface{}{
+		"code_hash": codes.Codes[0],
+	})
+	db.AssertExists(t, "totp_recovery_codes", map[string]interface{}{
+		"user_id":   1,
+		"code_hash": hashTOTPRecoveryCode(codes.Codes[0]),
+	}, false)
+}
+
+func TestDisableTOTP(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	err := DisableTOTP(&User{ID: 10})
+	assert.NoError(t, err)
+	db.AssertMissing(t, "totp", map[string]interface{}{
+		"user_id": 10,
+	})
+	db.AssertMissing(t, "totp_recovery_codes", map[string]interface{}{
+		"user_id": 10,
+	})
+}
+
+func TestGenerateTOTPRecoveryCodes(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	u := &User{ID: 10}
+	codes, err := GenerateTOTPRecoveryCodes(u)
+	assert.NoError(t, err)
+	assert.Len(t, codes.Codes, totpRecoveryCodeCount)
+
+	left, err := GetTOTPRecoveryCodesLeft(u)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(totpRecoveryCodeCount), left)
+
+	// The old codes should not work anymore
+	db.AssertMissing(t, "totp_recovery_codes", map[string]interface{}{
+		"id": 1,
+	})
+	_, err = ValidateTOTPPasscodeOrRecoveryCode(&TOTPPasscode{User: u, Passcode: "RECOVERY-CODE0001"})
+	assert.Error(t, err)
+	assert.True(t, IsErrInvalidTOTPPasscode(err))
+}
+
+func TestValidateTOTPPasscodeOrRecoveryCode(t *testing.T) {
+	u := &User{ID: 10}
+
+	t.Run("totp passcode", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		passcode, err := totp.GenerateCode("JBSWY3DPEHPK3PXP", time.Now())
+		assert.NoError(t, err)
+		usedRecoveryCode, err := ValidateTOTPPasscodeOrRecoveryCode(&TOTPPasscode{User: u, Passcode: passcode})
+		assert.NoError(t, err)
+		assert.False(t, usedRecoveryCode)
+	})
+	t.Run("recovery code", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		usedRecoveryCode, err := ValidateTOTPPasscodeOrRecoveryCode(&TOTPPasscode{User: u, Passcode: "RECOVERY-CODE0001"})
+		assert.NoError(t, err)
+		assert.True(t, usedRecoveryCode)
+
+		left, err := GetTOTPRecoveryCodesLeft(u)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), left)
+
+		// Every code can only be used once
+		_, err = ValidateTOTPPasscodeOrRecoveryCode(&TOTPPasscode{User: u, Passcode: "RECOVERY-CODE0001"})
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidTOTPPasscode(err))
+	})
+	t.Run("recovery code in lowercase without dash", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		usedRecoveryCode, err := ValidateTOTPPasscodeOrRecoveryCode(&TOTPPasscode{User: u, Passcode: "recoverycode0002"})
+		assert.NoError(t, err)
+		assert.True(t, usedRecoveryCode)
+	})
+	t.Run("recovery code of another user", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		_, err := ValidateTOTPPasscodeOrRecoveryCode(&TOTPPasscode{User: &User{ID: 1}, Passcode: "RECOVERY-CODE0001"})
+		assert.Error(t, err)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		_, err := ValidateTOTPPasscodeOrRecoveryCode(&TOTPPasscode{User: u, Passcode: "123456"})
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidTOTPPasscode(err))
+	})
+}
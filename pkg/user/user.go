@@ -18,14 +18,16 @@
 package user
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"reflect"
 	"time"
 
 	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/log"
 	"code.vikunja.io/api/pkg/mail"
-	"code.vikunja.io/api/pkg/metrics"
 	"code.vikunja.io/api/pkg/utils"
 	"code.vikunja.io/web"
 	"github.com/dgrijalva/jwt-go"
@@ -41,6 +43,8 @@ type Login struct {
 	Password string `json:"password"`
 	// The totp passcode of a user. Only needs to be provided when enabled.
 	TOTPPasscode string `json:"totp_passcode"`
+	// The response of a security key to use it as second factor instead of the totp passcode.
+	WebAuthn json.RawMessage `json:"webauthn,omitempty"`
 }
 
 // User holds information about an user
@@ -49,6 +53,8 @@ type User struct {
 	ID int64 `xorm:"int(11) autoincr not null unique pk" json:"id"`
 	// The username of the user. Is always unique.
 	Username string `xorm:"varchar(250) not null unique" json:"username" valid:"length(1|250)" minLength:"1" maxLength:"250"`
+	// The full name of the user, if it is known.
+	Name     string `xorm:"varchar(250) null" json:"name,omitempty"`
 	Password string `xorm:"varchar(250) not null" json:"-"`
 	// The user's email address.
 	Email    string `xorm:"varchar(250) null" json:"email,omitempty" valid:"email,length(0|250)" maxLength:"250"`
@@ -56,10 +62,32 @@ type User struct {
 
 	PasswordResetToken string `xorm:"varchar(450) null" json:"-"`
 	EmailConfirmToken  string `xorm:"varchar(450) null" json:"-"`
+	// Sent to the user by email to confirm the deletion of their account.
+	DeletionConfirmToken string `xorm:"varchar(450) null" json:"-"`
+	// When the deletion confirm token was created, it can only be used for a limited time.
+	DeletionConfirmTokenCreated time.Time `xorm:"DATETIME null" json:"-"`
 
 	AvatarProvider string `xorm:"varchar(255) null" json:"-"`
 	AvatarFileID   int64  `xorn:"null" json:"-"`
 
+	// The id of the file holding the latest data export of this user, if there is one.
+	ExportFileID int64 `xorm:"bigint null" json:"-"`
+
+	DigestFrequency string    `xorm:"varchar(20) null" json:"-"`
+	DigestHour      int       `xorm:"null" json:"-"`
+	DigestLastSent  time.Time `xorm:"DATETIME null" json:"-"`
+	// The timezone the digest hour is in. Empty to use the configured timezone of the server.
+	Timezone string `xorm:"varchar(255) null" json:"-"`
+
+	EmailNotifyTaskAssigned bool `xorm:"not null default true" json:"-"`
+	EmailNotifyTaskComment  bool `xorm:"not null default true" json:"-"`
+	EmailNotifyTaskDone     bool `xorm:"not null default true" json:"-"`
+
+	// The issuer and subject of users who authenticate through an external provider like openid connect.
+	// Both are empty for local users.
+	Issuer  string `xorm:"varchar(500) null" json:"-"`
+	Subject string `xorm:"varchar(500) null" json:"-"`
+
 	// A timestamp when this task was created. You cannot change this value.
 	Created time.Time `xorm:"created not null" json:"created"`
 	// A timestamp when this task was last updated. You cannot change this value.
@@ -88,6 +116,11 @@ func GetFromAuth(a web.Auth) (*User, error) {
 	return u, nil
 }
 
+// IsLocalUser returns true if the user authenticates with a password stored in Vikunja
+func (u *User) IsLocalUser() bool {
+	return u.Issuer == ""
+}
+
 // APIUserPassword represents a user object without timestamps and a json password field.
 type APIUserPassword struct {
 	// The unique, numeric id of this user.
@@ -165,6 +198,18 @@ func CheckUserCredentials(u *Login) (*User, error) {
 		return &User{}, ErrNoUsernamePassword{}
 	}
 
+	// Users managed by an external authenticator like ldap are checked there first
+	if externalPasswordAuthenticator != nil {
+		user, err := checkExternalUserCredentials(u)
+		if err == nil {
+			return user, nil
+		}
+		if !IsErrWrongUsernameOrPassword(err) {
+			// Local users should still be able to log in if the external authenticator is not reachable
+			log.Errorf("Could not check credentials of user %s with the external authenticator: %s", u.Username, err)
+		}
+	}
+
 	// Check if the user exists
 	user, err := GetUserByUsername(u.Username)
 	if err != nil {
@@ -173,6 +218,15 @@ func CheckUserCredentials(u *Login) (*User, error) {
 		return &User{}, ErrWrongUsernameOrPassword{}
 	}
 
+	if !user.IsLocalUser() {
+		// Users of the external authenticator were already checked above, their password was wrong
+		if externalPasswordAuthenticator != nil && user.Issuer == externalPasswordAuthenticator.Issuer() {
+			return &User{}, ErrWrongUsernameOrPassword{}
+		}
+		// Users from other external providers don't have a password, they need to log in through their provider
+		return &User{}, ErrAccountIsNotLocal{UserID: user.ID}
+	}
+
 	// User is invalid if it needs to verify its email address
 	if !user.IsActive {
 		return &User{}, ErrEmailNotConfirmed{UserID: user.ID}
@@ -274,7 +328,7 @@ func CreateUser(user *User) (newUser *User, err error) {
 		newUser.EmailConfirmToken = utils.MakeRandomString(60)
 	}
 
-	newUser.AvatarProvider = "initials"
+	setNewUserDefaults(newUser)
 
 	// Insert it
 	_, err = x.Insert(newUser)
@@ -282,15 +336,14 @@ func CreateUser(user *User) (newUser *User, err error) {
 		return &User{}, err
 	}
 
-	// Update the metrics
-	metrics.UpdateCount(1, metrics.ActiveUsersKey)
-
 	// Get the  full new User
 	newUserOut, err := GetUser(newUser)
 	if err != nil {
 		return &User{}, err
 	}
 
+	events.Dispatch(&CreatedEvent{User: newUserOut})
+
 	// Dont send a mail if we're testing
 	if !config.MailerEnabled.GetBool() {
 		return newUserOut, err
@@ -307,6 +360,16 @@ func CreateUser(user *User) (newUser *User, err error) {
 	return newUserOut, err
 }
 
+// Sets the default settings every new user gets
+func setNewUserDefaults(u *User) {
+	u.AvatarProvider = "initials"
+
+	// Users get all email notifications until they turn them off
+	u.EmailNotifyTaskAssigned = true
+	u.EmailNotifyTaskComment = true
+	u.EmailNotifyTaskDone = true
+}
+
 // HashPassword hashes a password
 func hashPassword(password string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 11)
@@ -373,6 +436,14 @@ func UpdateUser(user *User) (updatedUser *User, err error) {
 		return &User{}, err
 	}
 
+	// A disabled user should not be able to use any of their existing logins
+	if theUser.IsActive && !user.IsActive {
+		err = DeleteAllSessionsForUser(user.ID)
+		if err != nil {
+			return &User{}, err
+		}
+	}
+
 	// Get the newly updated user
 	updatedUser, err = GetUserByID(user.ID)
 	if err != nil {
@@ -395,6 +466,10 @@ func UpdateUserPassword(user *User, newPassword string) (err error) {
 		return err
 	}
 
+	if !theUser.IsLocalUser() {
+		return ErrAccountIsNotLocal{UserID: theUser.ID}
+	}
+
 	// Hash the new password and set it
 	hashed, err := hashPassword(newPassword)
 	if err != nil {
@@ -408,5 +483,6 @@ func UpdateUserPassword(user *User, newPassword string) (err error) {
 		return err
 	}
 
-	return err
+	// Everyone who knew the old password should be logged out
+	return DeleteAllSessionsForUser(theUser.ID)
 }
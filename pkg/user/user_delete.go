@@ -0,0 +1,117 @@
+// Copyright2018-2020 Vikunja and contriubtors. All rights reserved.
+//
+// This file is part of Vikunja.
+//
+// Vikunja is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Vikunja is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Vikunja.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"crypto/subtle"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/mail"
+	"code.vikunja.io/api/pkg/utils"
+	"xorm.io/xorm"
+)
+
+// How long the token to confirm the deletion of an account can be used after it was sent
+const deletionConfirmTokenValidity = 24 * time.Hour
+
+// AccountDeletion is used to confirm the deletion of an account
+type AccountDeletion struct {
+	// The current password of the user. Only users who log in with a password stored in Vikunja can use it.
+	Password string `json:"password"`
+	// The token sent to the user by email after requesting the deletion. It is valid for 24 hours.
+	Token string `json:"token"`
+	// The username of the user who gets all namespaces, lists and teams of the deleted user instead of deleting them.
+	// They need to have admin access to all of them already.
+	TransferTo string `json:"transfer_to"`
+}
+
+// RequestAccountDeletion sends the user an email with a token to confirm the deletion of their account.
+// This is needed for users who don't have a password in Vikunja.
+func RequestAccountDeletion(user *User) (err error) {
+	user.DeletionConfirmToken = utils.MakeRandomString(60)
+	user.DeletionConfirmTokenCreated = time.Now()
+	_, err = x.
+		Where("id = ?", user.ID).
+		Cols("deletion_confirm_token", "deletion_confirm_token_created").
+		Update(user)
+	if err != nil {
+		return
+	}
+
+	// Dont send a mail if we're testing
+	if !config.MailerEnabled.GetBool() {
+		return
+	}
+
+	data := map[string]interface{}{
+		"User": user,
+	}
+
+	mail.SendMailWithTemplate(user.Email, "Confirm the deletion of your account on Vikunja", "delete-account", data)
+	return
+}
+
+// CheckAccountDeletionConfirmation checks if a user confirmed the deletion of their account, either with their
+// password or with the token sent to them by email.
+// The user object needs to contain the hashed password and deletion token from the database.
+func CheckAccountDeletionConfirmation(user *User, deletion *AccountDeletion) (err error) {
+	if deletion.Password != "" && user.IsLocalUser() {
+		return CheckUserPassword(user, deletion.Password)
+	}
+
+	if deletion.Token != "" &&
+		user.DeletionConfirmToken != "" &&
+		time.Since(user.DeletionConfirmTokenCreated) < deletionConfirmTokenValidity &&
+		subtle.ConstantTimeCompare([]byte(deletion.Token), []byte(user.DeletionConfirmToken)) == 1 {
+		return nil
+	}
+
+	return ErrAccountDeletionNotConfirmed{UserID: user.ID}
+}
+
+// DeleteUser removes a user with their sessions, totp and security key settings in the given session.
+// Everything else a user owns lives in other packages and needs to be cleaned up before calling this.
+// Dispatching the DeletedEvent is left to the caller once the session was committed.
+func DeleteUser(s *xorm.Session, user *User) (err error) {
+	_, err = s.Where("user_id = ?", user.ID).Delete(&Session{})
+	if err != nil {
+		return
+	}
+
+	_, err = s.Where("user_id = ?", user.ID).Delete(&TOTP{})
+	if err != nil {
+		return
+	}
+	_, err = s.Where("user_id = ?", user.ID).Delete(&TOTPRecoveryCode{})
+	if err != nil {
+		return
+	}
+
+	_, err = s.Where("user_id = ?", user.ID).Delete(&WebAuthnCredential{})
+	if err != nil {
+		return
+	}
+	_, err = s.Where("user_id = ?", user.ID).Delete(&WebAuthnChallenge{})
+	if err != nil {
+		return
+	}
+
+	_, err = s.Where("id = ?", user.ID).Delete(&User{})
+	return
+}
@@ -0,0 +1,109 @@
+// Copyright2018-2020 Vikunja and contriubtors. All rights reserved.
+//
+// This file is part of Vikunja.
+//
+// Vikunja is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Vikunja is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Vikunja.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckAccountDeletionConfirmation(t *testing.T) {
+	t.Run("password", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		u, err := GetUserByID(1)
+		assert.NoError(t, err)
+		err = CheckAccountDeletionConfirmation(u, &AccountDeletion{Password: "1234"})
+		assert.NoError(t, err)
+	})
+	t.Run("wrong password", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		u, err := GetUserByID(1)
+		assert.NoError(t, err)
+		err = CheckAccountDeletionConfirmation(u, &AccountDeletion{Password: "wrong"})
+		assert.Error(t, err)
+		assert.True(t, IsErrWrongUsernameOrPassword(err))
+	})
+	t.Run("password of an external user", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		u, err := GetUserByID(1)
+		assert.NoError(t, err)
+		u.Issuer = "https://sso.example.com"
+		err = CheckAccountDeletionConfirmation(u, &AccountDeletion{Password: "1234"})
+		assert.Error(t, err)
+		assert.True(t, IsErrAccountDeletionNotConfirmed(err))
+	})
+	t.Run("token", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		u, err := GetUserByID(1)
+		assert.NoError(t, err)
+		err = RequestAccountDeletion(u)
+		assert.NoError(t, err)
+
+		u, err = GetUserByID(1)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, u.DeletionConfirmToken)
+		err = CheckAccountDeletionConfirmation(u, &AccountDeletion{Token: u.DeletionConfirmToken})
+		assert.NoError(t, err)
+	})
+	t.Run("expired token", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		u, err := GetUserByID(1)
+		assert.NoError(t, err)
+		err = RequestAccountDeletion(u)
+		assert.NoError(t, err)
+
+		u, err = GetUserByID(1)
+		assert.NoError(t, err)
+		u.DeletionConfirmTokenCreated = time.Now().Add(-25 * time.Hour)
+		err = CheckAccountDeletionConfirmation(u, &AccountDeletion{Token: u.DeletionConfirmToken})
+		assert.Error(t, err)
+		assert.True(t, IsErrAccountDeletionNotConfirmed(err))
+	})
+	t.Run("token without request", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		u, err := GetUserByID(1)
+		assert.NoError(t, err)
+		err = CheckAccountDeletionConfirmation(u, &AccountDeletion{Token: ""})
+		assert.Error(t, err)
+		assert.True(t, IsErrAccountDeletionNotConfirmed(err))
+	})
+}
+
+func TestDeleteUser(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := x.NewSession()
+	defer s.Close()
+	err := s.Begin()
+	assert.NoError(t, err)
+	err = DeleteUser(s, &User{ID: 10})
+	assert.NoError(t, err)
+	err = s.Commit()
+	assert.NoError(t, err)
+	db.AssertMissing(t, "users", map[string]interface{}{
+		"id": 10,
+	})
+	db.AssertMissing(t, "totp", map[string]interface{}{
+		"user_id": 10,
+	})
+	db.AssertMissing(t, "totp_recovery_codes", map[string]interface{}{
+		"user_id": 10,
+	})
+}
@@ -0,0 +1,159 @@
+// Copyright2018-2020 Vikunja and contriubtors. All rights reserved.
+//
+// This file is part of Vikunja.
+//
+// Vikunja is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Vikunja is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Vikunja.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"strings"
+
+	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/utils"
+)
+
+// ExternalUser holds everything an external authentication provider knows about a user
+type ExternalUser struct {
+	// The issuer is the unique identifier of the provider, for openid connect this is the issuer url.
+	Issuer string
+	// The subject identifies the user at the provider. It never changes for the same user.
+	Subject string
+	// The username the user would like to have in Vikunja. It is only used when the user is created.
+	PreferredUsername string
+	// The user's email address.
+	Email string
+	// The full name of the user.
+	Name string
+}
+
+// PasswordAuthenticator authenticates users with their username and password against an external source like ldap.
+type PasswordAuthenticator interface {
+	// Issuer returns the issuer of all users authenticated by this authenticator.
+	Issuer() string
+	// AuthenticateUser returns the user with these credentials or ErrWrongUsernameOrPassword if they are wrong.
+	AuthenticateUser(username, password string) (*ExternalUser, error)
+}
+
+var externalPasswordAuthenticator PasswordAuthenticator
+
+// SetPasswordAuthenticator sets the external authenticator CheckUserCredentials checks credentials with before
+// it checks them against local users. Passing nil disables it.
+func SetPasswordAuthenticator(a PasswordAuthenticator) {
+	externalPasswordAuthenticator = a
+}
+
+// Checks the credentials with the external authenticator and creates the user if it logs in for the first time
+func checkExternalUserCredentials(u *Login) (*User, error) {
+	eu, err := externalPasswordAuthenticator.AuthenticateUser(u.Username, u.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	user, _, err := GetOrCreateExternalUser(eu)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.IsActive {
+		return nil, ErrEmailNotConfirmed{UserID: user.ID}
+	}
+
+	return user, nil
+}
+
+// GetOrCreateExternalUser returns the user linked to the issuer and subject of an external user.
+// If no user is linked yet, a new one is created. If the preferred username is already taken, a random suffix
+// is added to it.
+// Users are only ever linked by their issuer and subject, never by their username or email address because those
+// might not be verified by the provider.
+func GetOrCreateExternalUser(eu *ExternalUser) (u *User, created bool, err error) {
+	if eu.Issuer == "" || eu.Subject == "" {
+		return nil, false, ErrNoUsernamePassword{}
+	}
+
+	u = &User{}
+	exists, err := x.
+		Where("issuer = ? AND subject = ?", eu.Issuer, eu.Subject).
+		Get(u)
+	if err != nil {
+		return nil, false, err
+	}
+	if exists {
+		// The name is the only thing which is kept in sync with the provider
+		if eu.Name != "" && eu.Name != u.Name {
+			u.Name = eu.Name
+			_, err = x.ID(u.ID).Cols("name").Update(u)
+		}
+		return u, false, err
+	}
+
+	if eu.Email != "" {
+		_, err = GetUser(&User{Email: eu.Email})
+		if err == nil {
+			return nil, false, ErrUserEmailExists{Email: eu.Email}
+		}
+		if !IsErrUserDoesNotExist(err) {
+			return nil, false, err
+		}
+	}
+
+	username, err := getUnusedUsername(eu.PreferredUsername)
+	if err != nil {
+		return nil, false, err
+	}
+
+	u = &User{
+		Username: username,
+		Email:    eu.Email,
+		Name:     eu.Name,
+		// Users from external providers are verified by the provider.
+		IsActive: true,
+		Issuer:   eu.Issuer,
+		Subject:  eu.Subject,
+	}
+	setNewUserDefaults(u)
+
+	_, err = x.Insert(u)
+	if err != nil {
+		return nil, false, err
+	}
+
+	events.Dispatch(&CreatedEvent{User: u})
+
+	return u, true, nil
+}
+
+// Returns the username if it is not used already or the username with a random suffix if it is.
+func getUnusedUsername(username string) (string, error) {
+	username = strings.TrimSpace(username)
+	if username == "" {
+		username = "user"
+	}
+	if len(username) > 240 {
+		username = username[:240]
+	}
+
+	candidate := username
+	for {
+		_, err := GetUserByUsername(candidate)
+		if IsErrUserDoesNotExist(err) {
+			return candidate, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		candidate = username + "-" + strings.ToLower(utils.MakeRandomString(5))
+	}
+}
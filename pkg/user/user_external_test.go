@@ -0,0 +1,181 @@
+// Copyright2018-2020 Vikunja and contriubtors. All rights reserved.
+//
+// This file is part of Vikunja.
+//
+// Vikunja is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Vikunja is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Vikunja.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"strings"
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetOrCreateExternalUser(t *testing.T) {
+	t.Run("new user", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		u, created, err := GetOrCreateExternalUser(&ExternalUser{
+			Issuer:            "https://issuer.example.com",
+			Subject:           "12345",
+			PreferredUsername: "external",
+			Email:             "external@example.com",
+		})
+		assert.NoError(t, err)
+		assert.True(t, created)
+		assert.Equal(t, "external", u.Username)
+		assert.True(t, u.IsActive)
+		assert.False(t, u.IsLocalUser())
+		db.AssertExists(t, "users", map[string]interface{}{
+			"id":       u.ID,
+			"username": "external",
+			"issuer":   "https://issuer.example.com",
+			"subject":  "12345",
+		}, false)
+	})
+	t.Run("existing user", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		eu := &ExternalUser{
+			Issuer:            "https://issuer.example.com",
+			Subject:           "12345",
+			PreferredUsername: "external",
+		}
+		first, _, err := GetOrCreateExternalUser(eu)
+		assert.NoError(t, err)
+
+		// Changing the preferred username at the provider should not create a new user
+		eu.PreferredUsername = "changed"
+		second, created, err := GetOrCreateExternalUser(eu)
+		assert.NoError(t, err)
+		assert.False(t, created)
+		assert.Equal(t, first.ID, second.ID)
+		assert.Equal(t, "external", second.Username)
+	})
+	t.Run("username taken", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		u, created, err := GetOrCreateExternalUser(&ExternalUser{
+			Issuer:            "https://issuer.example.com",
+			Subject:           "12345",
+			PreferredUsername: "user1",
+		})
+		assert.NoError(t, err)
+		assert.True(t, created)
+		assert.NotEqual(t, int64(1), u.ID)
+		assert.True(t, strings.HasPrefix(u.Username, "user1-"))
+	})
+	t.Run("email taken", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		_, _, err := GetOrCreateExternalUser(&ExternalUser{
+			Issuer:            "https://issuer.example.com",
+			Subject:           "12345",
+			PreferredUsername: "external",
+			Email:             "user1@example.com",
+		})
+		assert.Error(t, err)
+		assert.True(t, IsErrUserEmailExists(err))
+	})
+	t.Run("no subject", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		_, _, err := GetOrCreateExternalUser(&ExternalUser{
+			Issuer:            "https://issuer.example.com",
+			PreferredUsername: "external",
+		})
+		assert.Error(t, err)
+	})
+	t.Run("password login", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		_, _, err := GetOrCreateExternalUser(&ExternalUser{
+			Issuer:            "https://issuer.example.com",
+			Subject:           "12345",
+			PreferredUsername: "external",
+		})
+		assert.NoError(t, err)
+
+		_, err = CheckUserCredentials(&Login{Username: "external", Password: "1234"})
+		assert.Error(t, err)
+		assert.True(t, IsErrAccountIsNotLocal(err))
+	})
+}
+
+type fakePasswordAuthenticator struct{}
+
+func (f *fakePasswordAuthenticator) Issuer() string {
+	return "ldap"
+}
+
+func (f *fakePasswordAuthenticator) AuthenticateUser(username, password string) (*ExternalUser, error) {
+	if username != "ldapuser" || password != "secret" {
+		return nil, ErrWrongUsernameOrPassword{}
+	}
+	return &ExternalUser{
+		Issuer:            "ldap",
+		Subject:           "uid=ldapuser,dc=example,dc=com",
+		PreferredUsername: "ldapuser",
+		Email:             "ldapuser@example.com",
+		Name:              "LDAP User",
+	}, nil
+}
+
+func TestCheckUserCredentialsWithPasswordAuthenticator(t *testing.T) {
+	SetPasswordAuthenticator(&fakePasswordAuthenticator{})
+	defer SetPasswordAuthenticator(nil)
+
+	t.Run("external user", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		first, err := CheckUserCredentials(&Login{Username: "ldapuser", Password: "secret"})
+		assert.NoError(t, err)
+		assert.Equal(t, "ldapuser", first.Username)
+		assert.Equal(t, "LDAP User", first.Name)
+		assert.False(t, first.IsLocalUser())
+
+		second, err := CheckUserCredentials(&Login{Username: "ldapuser", Password: "secret"})
+		assert.NoError(t, err)
+		assert.Equal(t, first.ID, second.ID)
+	})
+	t.Run("external user with wrong password", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		_, err := CheckUserCredentials(&Login{Username: "ldapuser", Password: "secret"})
+		assert.NoError(t, err)
+
+		_, err = CheckUserCredentials(&Login{Username: "ldapuser", Password: "wrong"})
+		assert.Error(t, err)
+		assert.True(t, IsErrWrongUsernameOrPassword(err))
+	})
+	t.Run("local user", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		u, err := CheckUserCredentials(&Login{Username: "user1", Password: "1234"})
+		assert.NoError(t, err)
+		assert.True(t, u.IsLocalUser())
+	})
+	t.Run("change password", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		u, err := CheckUserCredentials(&Login{Username: "ldapuser", Password: "secret"})
+		assert.NoError(t, err)
+
+		err = UpdateUserPassword(&User{ID: u.ID}, "12345678")
+		assert.Error(t, err)
+		assert.True(t, IsErrAccountIsNotLocal(err))
+	})
+	t.Run("reset password", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		_, err := CheckUserCredentials(&Login{Username: "ldapuser", Password: "secret"})
+		assert.NoError(t, err)
+
+		err = RequestUserPasswordResetTokenByEmail(&PasswordTokenRequest{Email: "ldapuser@example.com"})
+		assert.Error(t, err)
+		assert.True(t, IsErrAccountIsNotLocal(err))
+	})
+}
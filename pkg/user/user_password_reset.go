@@ -50,6 +50,10 @@ func ResetPassword(reset *PasswordReset) (err error) {
 		return ErrInvalidPasswordResetToken{Token: reset.Token}
 	}
 
+	if !user.IsLocalUser() {
+		return ErrAccountIsNotLocal{UserID: user.ID}
+	}
+
 	// Hash the password
 	user.Password, err = hashPassword(reset.NewPassword)
 	if err != nil {
@@ -62,6 +66,11 @@ func ResetPassword(reset *PasswordReset) (err error) {
 		return
 	}
 
+	err = DeleteAllSessionsForUser(user.ID)
+	if err != nil {
+		return
+	}
+
 	// Dont send a mail if we're testing
 	if !config.MailerEnabled.GetBool() {
 		return
@@ -99,6 +108,10 @@ func RequestUserPasswordResetTokenByEmail(tr *PasswordTokenRequest) (err error)
 
 // RequestUserPasswordResetToken sends a user a password reset email.
 func RequestUserPasswordResetToken(user *User) (err error) {
+	if !user.IsLocalUser() {
+		return ErrAccountIsNotLocal{UserID: user.ID}
+	}
+
 	// Generate a token and save it
 	user.PasswordResetToken = utils.MakeRandomString(400)
 
@@ -20,6 +20,7 @@ package user
 import (
 	"testing"
 
+	"code.vikunja.io/api/pkg/config"
 	"code.vikunja.io/api/pkg/db"
 	"github.com/stretchr/testify/assert"
 )
@@ -205,6 +206,17 @@ func TestUpdateUser(t *testing.T) {
 		assert.Equal(t, "$2a$14$dcadBoMBL9jQoOcZK8Fju.cy0Ptx2oZECkKLnaa8ekRoTFe1w7To.", uuser.Password) // Password should not change
 		assert.Equal(t, "changedname", uuser.Username)
 	})
+	t.Run("disable user", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		u, err := GetUserByID(1)
+		assert.NoError(t, err)
+		u.IsActive = false
+		_, err = UpdateUser(u)
+		assert.NoError(t, err)
+		db.AssertMissing(t, "sessions", map[string]interface{}{
+			"user_id": 1,
+		})
+	})
 	t.Run("nonexistant", func(t *testing.T) {
 		db.LoadAndAssertFixtures(t)
 		_, err := UpdateUser(&User{
@@ -224,6 +236,13 @@ func TestUpdateUserPassword(t *testing.T) {
 		}, "12345",
 		)
 		assert.NoError(t, err)
+		db.AssertMissing(t, "sessions", map[string]interface{}{
+			"user_id": 1,
+		})
+		db.AssertExists(t, "sessions", map[string]interface{}{
+			"id":      4,
+			"user_id": 2,
+		}, false)
 	})
 	t.Run("nonexistant user", func(t *testing.T) {
 		db.LoadAndAssertFixtures(t)
@@ -300,3 +319,92 @@ func TestUserPasswordReset(t *testing.T) {
 		assert.True(t, IsErrInvalidPasswordResetToken(err))
 	})
 }
+
+func TestGetDigestSettings(t *testing.T) {
+	settings := (&User{DigestFrequency: DigestFrequencyWeekly, DigestHour: 7}).GetDigestSettings()
+	assert.Equal(t, DigestFrequencyWeekly, settings.Frequency)
+	assert.Equal(t, 7, settings.Hour)
+	assert.Equal(t, config.GetTimeZone().String(), settings.Timezone)
+
+	settings = (&User{Timezone: "Europe/Berlin"}).GetDigestSettings()
+	assert.Equal(t, "Europe/Berlin", settings.Timezone)
+}
+
+func TestUpdateDigestSettings(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		err := UpdateDigestSettings(&User{ID: 1}, &DigestSettings{
+			Frequency: DigestFrequencyDaily,
+			Hour:      7,
+		})
+		assert.NoError(t, err)
+		db.AssertExists(t, "users", map[string]interface{}{
+			"id":               1,
+			"digest_frequency": DigestFrequencyDaily,
+			"digest_hour":      7,
+		}, false)
+	})
+	t.Run("invalid frequency", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		err := UpdateDigestSettings(&User{ID: 1}, &DigestSettings{
+			Frequency: "hourly",
+		})
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidDigestFrequency(err))
+	})
+	t.Run("invalid hour", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		err := UpdateDigestSettings(&User{ID: 1}, &DigestSettings{
+			Frequency: DigestFrequencyWeekly,
+			Hour:      24,
+		})
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidDigestHour(err))
+	})
+	t.Run("timezone", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		err := UpdateDigestSettings(&User{ID: 1}, &DigestSettings{
+			Frequency: DigestFrequencyDaily,
+			Hour:      7,
+			Timezone:  "Europe/Berlin",
+		})
+		assert.NoError(t, err)
+		db.AssertExists(t, "users", map[string]interface{}{
+			"id":       1,
+			"timezone": "Europe/Berlin",
+		}, false)
+	})
+	t.Run("invalid timezone", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		err := UpdateDigestSettings(&User{ID: 1}, &DigestSettings{
+			Frequency: DigestFrequencyDaily,
+			Timezone:  "Nowhere/Somewhere",
+		})
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidTimezone(err))
+	})
+}
+
+func TestUpdateEmailNotificationSettings(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+
+	u, err := GetUserByID(1)
+	assert.NoError(t, err)
+	settings := u.GetEmailNotificationSettings()
+	assert.True(t, settings.TaskAssigned)
+	assert.True(t, settings.TaskComment)
+	assert.True(t, settings.TaskDone)
+
+	err = UpdateEmailNotificationSettings(u, &EmailNotificationSettings{
+		TaskAssigned: true,
+		TaskComment:  false,
+		TaskDone:     false,
+	})
+	assert.NoError(t, err)
+	db.AssertExists(t, "users", map[string]interface{}{
+		"id":                         1,
+		"email_notify_task_assigned": true,
+		"email_notify_task_comment":  false,
+		"email_notify_task_done":     false,
+	}, false)
+}
@@ -0,0 +1,449 @@
+// Copyright2018-2020 Vikunja and contriubtors. All rights reserved.
+//
+// This file is part of Vikunja.
+//
+// Vikunja is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Vikunja is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Vikunja.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"github.com/duo-labs/webauthn/protocol"
+	"github.com/duo-labs/webauthn/webauthn"
+)
+
+// How long a started registration or login ceremony can be finished
+const webAuthnChallengeTTL = 5 * time.Minute
+
+const (
+	webAuthnCeremonyRegistration = "registration"
+	webAuthnCeremonyLogin        = "login"
+)
+
+// WebAuthnCredential is a security key or passkey a user registered to log in with.
+type WebAuthnCredential struct {
+	// The unique, numeric id of this credential.
+	ID     int64 `xorm:"int(11) autoincr not null unique pk" json:"id" param:"credential"`
+	UserID int64 `xorm:"int(11) not null INDEX" json:"-"`
+	// A name to tell the keys of a user apart.
+	Name string `xorm:"varchar(250) not null" json:"name"`
+
+	// The credential id, public key and aaguid of the authenticator, all base64url encoded.
+	CredentialID    string `xorm:"varchar(500) not null unique" json:"-"`
+	PublicKey       string `xorm:"text not null" json:"-"`
+	AAGUID          string `xorm:"varchar(50) null" json:"-"`
+	AttestationType string `xorm:"varchar(50) null" json:"-"`
+	// The signature counter of the authenticator, used to detect cloned keys.
+	SignCount int64 `xorm:"bigint not null default 0" json:"-"`
+
+	// A timestamp when this key was last used to log in.
+	LastUsed time.Time `xorm:"DATETIME null" json:"last_used"`
+	// A timestamp when this key was registered. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+}
+
+// TableName holds the table name for webauthn credentials
+func (w *WebAuthnCredential) TableName() string {
+	return "webauthn_credentials"
+}
+
+// WebAuthnChallenge holds the state of a started registration or login ceremony until it is finished.
+// Every challenge can only be used once.
+type WebAuthnChallenge struct {
+	ID          int64     `xorm:"int(11) autoincr not null unique pk" json:"-"`
+	UserID      int64     `xorm:"int(11) not null INDEX" json:"-"`
+	Challenge   string    `xorm:"varchar(250) not null unique" json:"-"`
+	Ceremony    string    `xorm:"varchar(20) not null" json:"-"`
+	SessionData string    `xorm:"text not null" json:"-"`
+	Created     time.Time `xorm:"created not null" json:"-"`
+}
+
+// TableName holds the table name for webauthn challenges
+func (w *WebAuthnChallenge) TableName() string {
+	return "webauthn_challenges"
+}
+
+// WebAuthnRegistration is used to finish registering a new security key.
+type WebAuthnRegistration struct {
+	// A name for the new key.
+	Name string `json:"name"`
+	// The response of the authenticator as returned by navigator.credentials.create().
+	Credential json.RawMessage `json:"credential"`
+}
+
+// WebAuthnLogin is used to log in with a security key instead of a password.
+type WebAuthnLogin struct {
+	// The username of the user logging in.
+	Username string `json:"username"`
+	// The response of the authenticator as returned by navigator.credentials.get(). Only needed to finish the login.
+	Credential json.RawMessage `json:"credential,omitempty"`
+}
+
+// webAuthnUser wraps a user with its credentials to satisfy the interface of the webauthn library.
+type webAuthnUser struct {
+	user        *User
+	credentials []*WebAuthnCredential
+}
+
+func (w *webAuthnUser) WebAuthnID() []byte {
+	return []byte(strconv.FormatInt(w.user.ID, 10))
+}
+
+func (w *webAuthnUser) WebAuthnName() string {
+	return w.user.Username
+}
+
+func (w *webAuthnUser) WebAuthnDisplayName() string {
+	if w.user.Name != "" {
+		return w.user.Name
+	}
+	return w.user.Username
+}
+
+func (w *webAuthnUser) WebAuthnIcon() string {
+	return ""
+}
+
+func (w *webAuthnUser) WebAuthnCredentials() []webauthn.Credential {
+	credentials := make([]webauthn.Credential, 0, len(w.credentials))
+	for _, c := range w.credentials {
+		id, err := base64.RawURLEncoding.DecodeString(c.CredentialID)
+		if err != nil {
+			continue
+		}
+		publicKey, err := base64.RawURLEncoding.DecodeString(c.PublicKey)
+		if err != nil {
+			continue
+		}
+		aaguid, _ := base64.RawURLEncoding.DecodeString(c.AAGUID)
+		credentials = append(credentials, webauthn.Credential{
+			ID:              id,
+			PublicKey:       publicKey,
+			AttestationType: c.AttestationType,
+			Authenticator: webauthn.Authenticator{
+				AAGUID:    aaguid,
+				SignCount: uint32(c.SignCount),
+			},
+		})
+	}
+	return credentials
+}
+
+// The relying party id and origin default to the frontend url because that's where the browser talks to the keys
+func getWebAuthn() (*webauthn.WebAuthn, error) {
+	rpID := config.AuthWebAuthnRPID.GetString()
+	rpOrigin := config.AuthWebAuthnRPOrigin.GetString()
+	if rpID == "" || rpOrigin == "" {
+		frontend, err := url.Parse(config.ServiceFrontendurl.GetString())
+		if err != nil {
+			return nil, err
+		}
+		if rpID == "" {
+			rpID = frontend.Hostname()
+		}
+		if rpOrigin == "" {
+			rpOrigin = frontend.Scheme + "://" + frontend.Host
+		}
+	}
+
+	return webauthn.New(&webauthn.Config{
+		RPDisplayName: config.AuthWebAuthnRPDisplayName.GetString(),
+		RPID:          rpID,
+		RPOrigin:      rpOrigin,
+	})
+}
+
+func getWebAuthnUser(user *User) (w *webAuthnUser, err error) {
+	w = &webAuthnUser{user: user}
+	err = x.Where("user_id = ?", user.ID).OrderBy("id asc").Find(&w.credentials)
+	return
+}
+
+// WebAuthnEnabledForUser checks if a user has at least one security key registered.
+func WebAuthnEnabledForUser(user *User) (bool, error) {
+	if !config.AuthWebAuthnEnabled.GetBool() {
+		return false, nil
+	}
+	return x.Where("user_id = ?", user.ID).Exist(&WebAuthnCredential{})
+}
+
+// GetWebAuthnCredentials returns all security keys of a user.
+func GetWebAuthnCredentials(user *User) (credentials []*WebAuthnCredential, err error) {
+	credentials = []*WebAuthnCredential{}
+	err = x.Where("user_id = ?", user.ID).OrderBy("id asc").Find(&credentials)
+	return
+}
+
+// DeleteWebAuthnCredential removes a security key of a user.
+func DeleteWebAuthnCredential(user *User, id int64) (err error) {
+	deleted, err := x.Where("id = ? AND user_id = ?", id, user.ID).Delete(&WebAuthnCredential{})
+	if err != nil {
+		return
+	}
+	if deleted == 0 {
+		return ErrWebAuthnCredentialDoesNotExist{CredentialID: id}
+	}
+	return nil
+}
+
+func saveWebAuthnChallenge(user *User, ceremony string, session *webauthn.SessionData) (err error) {
+	// Challenges of ceremonies which were never finished would pile up otherwise
+	_, err = x.
+		Where("user_id = ? AND created < ?", user.ID, time.Now().Add(-webAuthnChallengeTTL)).
+		Delete(&WebAuthnChallenge{})
+	if err != nil {
+		return
+	}
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		return
+	}
+
+	_, err = x.Insert(&WebAuthnChallenge{
+		UserID:      user.ID,
+		Challenge:   session.Challenge,
+		Ceremony:    ceremony,
+		SessionData: string(data),
+	})
+	return
+}
+
+// consumeWebAuthnChallenge returns the session data of a ceremony and removes it so it can't be used again.
+func consumeWebAuthnChallenge(user *User, ceremony string, challenge string) (session *webauthn.SessionData, err error) {
+	c := &WebAuthnChallenge{}
+	exists, err := x.
+		Where("user_id = ? AND ceremony = ? AND challenge = ?", user.ID, ceremony, challenge).
+		Get(c)
+	if err != nil {
+		return
+	}
+	if !exists {
+		return nil, ErrInvalidWebAuthnResponse{Reason: "unknown challenge"}
+	}
+
+	// Only the request which actually deleted the challenge may use it
+	deleted, err := x.Where("id = ?", c.ID).Delete(&WebAuthnChallenge{})
+	if err != nil {
+		return
+	}
+	if deleted == 0 {
+		return nil, ErrInvalidWebAuthnResponse{Reason: "unknown challenge"}
+	}
+
+	if c.Created.Add(webAuthnChallengeTTL).Before(time.Now()) {
+		return nil, ErrInvalidWebAuthnResponse{Reason: "challenge expired"}
+	}
+
+	session = &webauthn.SessionData{}
+	err = json.Unmarshal([]byte(c.SessionData), session)
+	return
+}
+
+// BeginWebAuthnRegistration starts registering a new security key for a user. The returned options need to be passed
+// to navigator.credentials.create() in the browser.
+func BeginWebAuthnRegistration(user *User) (options *protocol.CredentialCreation, err error) {
+	wa, err := getWebAuthn()
+	if err != nil {
+		return
+	}
+
+	wu, err := getWebAuthnUser(user)
+	if err != nil {
+		return
+	}
+
+	// Don't let the user register the same key twice
+	exclusions := []protocol.CredentialDescriptor{}
+	for _, c := range wu.WebAuthnCredentials() {
+		exclusions = append(exclusions, protocol.CredentialDescriptor{
+			Type:         protocol.PublicKeyCredentialType,
+			CredentialID: c.ID,
+		})
+	}
+
+	options, session, err := wa.BeginRegistration(wu, webauthn.WithExclusions(exclusions))
+	if err != nil {
+		return
+	}
+
+	err = saveWebAuthnChallenge(user, webAuthnCeremonyRegistration, session)
+	return
+}
+
+// FinishWebAuthnRegistration verifies the response of the authenticator and saves the new security key.
+func FinishWebAuthnRegistration(user *User, registration *WebAuthnRegistration) (credential *WebAuthnCredential, err error) {
+	parsed, err := protocol.ParseCredentialCreationResponseBody(bytes.NewReader(registration.Credential))
+	if err != nil {
+		return nil, ErrInvalidWebAuthnResponse{Reason: err.Error()}
+	}
+
+	session, err := consumeWebAuthnChallenge(user, webAuthnCeremonyRegistration, parsed.Response.CollectedClientData.Challenge)
+	if err != nil {
+		return
+	}
+
+	wa, err := getWebAuthn()
+	if err != nil {
+		return
+	}
+
+	wu, err := getWebAuthnUser(user)
+	if err != nil {
+		return
+	}
+
+	c, err := wa.CreateCredential(wu, *session, parsed)
+	if err != nil {
+		return nil, ErrInvalidWebAuthnResponse{Reason: err.Error()}
+	}
+
+	credential = &WebAuthnCredential{
+		UserID:          user.ID,
+		Name:            registration.Name,
+		CredentialID:    base64.RawURLEncoding.EncodeToString(c.ID),
+		PublicKey:       base64.RawURLEncoding.EncodeToString(c.PublicKey),
+		AAGUID:          base64.RawURLEncoding.EncodeToString(c.Authenticator.AAGUID),
+		AttestationType: c.AttestationType,
+		SignCount:       int64(c.Authenticator.SignCount),
+	}
+	if credential.Name == "" {
+		credential.Name = "Security key"
+	}
+
+	_, err = x.Insert(credential)
+	return
+}
+
+// BeginWebAuthnLogin starts a login ceremony with the security keys of a user. The returned options need to be passed
+// to navigator.credentials.get() in the browser.
+func BeginWebAuthnLogin(user *User) (options *protocol.CredentialAssertion, err error) {
+	wu, err := getWebAuthnUser(user)
+	if err != nil {
+		return
+	}
+	if len(wu.credentials) == 0 {
+		return nil, ErrNoWebAuthnCredentials{UserID: user.ID}
+	}
+
+	wa, err := getWebAuthn()
+	if err != nil {
+		return
+	}
+
+	options, session, err := wa.BeginLogin(wu)
+	if err != nil {
+		return
+	}
+
+	err = saveWebAuthnChallenge(user, webAuthnCeremonyLogin, session)
+	return
+}
+
+// ValidateWebAuthnLogin verifies the response of an authenticator during login.
+// If requireUserVerification is set, the authenticator must have verified the user with a pin or biometrics. This is
+// needed when the key is used without a password.
+func ValidateWebAuthnLogin(user *User, response json.RawMessage, requireUserVerification bool) (err error) {
+	parsed, err := protocol.ParseCredentialRequestResponseBody(bytes.NewReader(response))
+	if err != nil {
+		return ErrInvalidWebAuthnResponse{Reason: err.Error()}
+	}
+
+	session, err := consumeWebAuthnChallenge(user, webAuthnCeremonyLogin, parsed.Response.CollectedClientData.Challenge)
+	if err != nil {
+		return
+	}
+
+	if requireUserVerification && !parsed.Response.AuthenticatorData.Flags.UserVerified() {
+		return ErrInvalidWebAuthnResponse{Reason: "user was not verified by the authenticator"}
+	}
+
+	wa, err := getWebAuthn()
+	if err != nil {
+		return
+	}
+
+	wu, err := getWebAuthnUser(user)
+	if err != nil {
+		return
+	}
+
+	c, err := wa.ValidateLogin(wu, *session, parsed)
+	if err != nil {
+		return ErrInvalidWebAuthnResponse{Reason: err.Error()}
+	}
+
+	if c.Authenticator.CloneWarning {
+		return ErrInvalidWebAuthnResponse{Reason: "the signature counter of the key went backwards, it might be cloned"}
+	}
+
+	_, err = x.
+		Where("user_id = ? AND credential_id = ?", user.ID, base64.RawURLEncoding.EncodeToString(c.ID)).
+		Cols("sign_count", "last_used").
+		Update(&WebAuthnCredential{
+			SignCount: int64(c.Authenticator.SignCount),
+			LastUsed:  time.Now(),
+		})
+	return
+}
+
+// getUserForWebAuthnLogin returns the user logging in with a security key instead of a password.
+func getUserForWebAuthnLogin(username string) (user *User, err error) {
+	user, err = GetUserByUsername(username)
+	if err != nil {
+		if IsErrUserDoesNotExist(err) {
+			return nil, ErrWrongUsernameOrPassword{}
+		}
+		return
+	}
+
+	// User is invalid if it needs to verify its email address
+	if !user.IsActive {
+		return nil, ErrEmailNotConfirmed{UserID: user.ID}
+	}
+
+	return
+}
+
+// BeginPasswordlessWebAuthnLogin starts logging in a user with only a security key.
+func BeginPasswordlessWebAuthnLogin(login *WebAuthnLogin) (options *protocol.CredentialAssertion, err error) {
+	user, err := getUserForWebAuthnLogin(login.Username)
+	if err != nil {
+		return
+	}
+
+	return BeginWebAuthnLogin(user)
+}
+
+// FinishPasswordlessWebAuthnLogin checks the response of the security key and returns the user it belongs to.
+func FinishPasswordlessWebAuthnLogin(login *WebAuthnLogin) (user *User, err error) {
+	user, err = getUserForWebAuthnLogin(login.Username)
+	if err != nil {
+		return
+	}
+
+	err = ValidateWebAuthnLogin(user, login.Credential, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return
+}
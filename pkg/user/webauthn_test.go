@@ -0,0 +1,347 @@
+// Copyright2018-2020 Vikunja and contriubtors. All rights reserved.
+//
+// This file is part of Vikunja.
+//
+// Vikunja is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Vikunja is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Vikunja.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/db"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testWebAuthnRPID   = "localhost"
+	testWebAuthnOrigin = "http://localhost"
+
+	flagUserPresent            = 0x01
+	flagUserVerified           = 0x04
+	flagAttestedCredentialData = 0x40
+)
+
+// testAuthenticator is a software authenticator which creates the same responses a security key would.
+type testAuthenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	userHandle   []byte
+	signCount    uint32
+	// Whether the authenticator verified the user with a pin or biometrics
+	userVerified bool
+}
+
+// The key is derived from the seed so the fixture credential of user 11 can be used in tests.
+func newTestAuthenticator(seed string, credentialID string) *testAuthenticator {
+	h := sha256.Sum256([]byte(seed))
+	d := new(big.Int).SetBytes(h[:])
+	d.Mod(d, elliptic.P256().Params().N)
+
+	key := &ecdsa.PrivateKey{D: d}
+	key.PublicKey.Curve = elliptic.P256()
+	key.PublicKey.X, key.PublicKey.Y = elliptic.P256().ScalarBaseMult(d.Bytes())
+
+	return &testAuthenticator{
+		key:          key,
+		credentialID: []byte(credentialID),
+		userVerified: true,
+	}
+}
+
+func newFixtureTestAuthenticator() *testAuthenticator {
+	a := newTestAuthenticator("vikunja webauthn test key", "user11-security-key")
+	a.userHandle = []byte("11")
+	a.signCount = 5
+	return a
+}
+
+func padTo32(b []byte) []byte {
+	return append(make([]byte, 32-len(b)), b...)
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func (a *testAuthenticator) coseKey() []byte {
+	// A cbor map with the key type, algorithm, curve and the coordinates of the public key
+	key := []byte{0xa5, 0x01, 0x02, 0x03, 0x26, 0x20, 0x01, 0x21, 0x58, 0x20}
+	key = append(key, padTo32(a.key.X.Bytes())...)
+	key = append(key, 0x22, 0x58, 0x20)
+	return append(key, padTo32(a.key.Y.Bytes())...)
+}
+
+func (a *testAuthenticator) authenticatorData(attested bool) []byte {
+	rpIDHash := sha256.Sum256([]byte(testWebAuthnRPID))
+	data := append([]byte{}, rpIDHash[:]...)
+
+	flags := byte(flagUserPresent)
+	if a.userVerified {
+		flags |= flagUserVerified
+	}
+	if attested {
+		flags |= flagAttestedCredentialData
+	}
+	data = append(data, flags)
+
+	counter := make([]byte, 4)
+	binary.BigEndian.PutUint32(counter, a.signCount)
+	data = append(data, counter...)
+
+	if attested {
+		// aaguid
+		data = append(data, make([]byte, 16)...)
+		idLength := make([]byte, 2)
+		binary.BigEndian.PutUint16(idLength, uint16(len(a.credentialID)))
+		data = append(data, idLength...)
+		data = append(data, a.credentialID...)
+		data = append(data, a.coseKey()...)
+	}
+
+	return data
+}
+
+func clientDataJSON(t *testing.T, typ string, challenge []byte) []byte {
+	data, err := json.Marshal(map[string]string{
+		"type":      typ,
+		"challenge": b64(challenge),
+		"origin":    testWebAuthnOrigin,
+	})
+	assert.NoError(t, err)
+	return data
+}
+
+// create returns what navigator.credentials.create() would return in a browser
+func (a *testAuthenticator) create(t *testing.T, challenge []byte) json.RawMessage {
+	attestationObject, err := cbor.Marshal(map[string]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[string]interface{}{},
+		"authData": a.authenticatorData(true),
+	})
+	assert.NoError(t, err)
+
+	response, err := json.Marshal(map[string]interface{}{
+		"id":    b64(a.credentialID),
+		"rawId": b64(a.credentialID),
+		"type":  "public-key",
+		"response": map[string]string{
+			"attestationObject": b64(attestationObject),
+			"clientDataJSON":    b64(clientDataJSON(t, "webauthn.create", challenge)),
+		},
+	})
+	assert.NoError(t, err)
+	return response
+}
+
+// get returns what navigator.credentials.get() would return in a browser
+func (a *testAuthenticator) get(t *testing.T, challenge []byte) json.RawMessage {
+	a.signCount++
+	authData := a.authenticatorData(false)
+	clientData := clientDataJSON(t, "webauthn.get", challenge)
+
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	r, s, err := ecdsa.Sign(rand.Reader, a.key, digest[:])
+	assert.NoError(t, err)
+	signature, err := asn1.Marshal(struct{ R, S *big.Int }{r, s})
+	assert.NoError(t, err)
+
+	response, err := json.Marshal(map[string]interface{}{
+		"id":    b64(a.credentialID),
+		"rawId": b64(a.credentialID),
+		"type":  "public-key",
+		"response": map[string]string{
+			"authenticatorData": b64(authData),
+			"clientDataJSON":    b64(clientData),
+			"signature":         b64(signature),
+			"userHandle":        b64(a.userHandle),
+		},
+	})
+	assert.NoError(t, err)
+	return response
+}
+
+func setupWebAuthnTestConfig() {
+	config.AuthWebAuthnRPID.Set(testWebAuthnRPID)
+	config.AuthWebAuthnRPOrigin.Set(testWebAuthnOrigin)
+}
+
+func TestWebAuthnRegistration(t *testing.T) {
+	setupWebAuthnTestConfig()
+	u := &User{ID: 1, Username: "user1"}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		options, err := BeginWebAuthnRegistration(u)
+		assert.NoError(t, err)
+		assert.Equal(t, testWebAuthnRPID, options.Response.RelyingParty.ID)
+
+		a := newTestAuthenticator("user1 key", "user1-key")
+		credential, err := FinishWebAuthnRegistration(u, &WebAuthnRegistration{
+			Name:       "My key",
+			Credential: a.create(t, options.Response.Challenge),
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "My key", credential.Name)
+		db.AssertExists(t, "webauthn_credentials", map[string]interface{}{
+			"user_id":       1,
+			"name":          "My key",
+			"credential_id": b64([]byte("user1-key")),
+		}, false)
+
+		enabled, err := WebAuthnEnabledForUser(u)
+		assert.NoError(t, err)
+		assert.True(t, enabled)
+	})
+	t.Run("challenge can only be used once", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		options, err := BeginWebAuthnRegistration(u)
+		assert.NoError(t, err)
+
+		a := newTestAuthenticator("user1 key", "user1-key")
+		_, err = FinishWebAuthnRegistration(u, &WebAuthnRegistration{Credential: a.create(t, options.Response.Challenge)})
+		assert.NoError(t, err)
+		_, err = FinishWebAuthnRegistration(u, &WebAuthnRegistration{Credential: a.create(t, options.Response.Challenge)})
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidWebAuthnResponse(err))
+	})
+	t.Run("challenge of another user", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		options, err := BeginWebAuthnRegistration(u)
+		assert.NoError(t, err)
+
+		a := newTestAuthenticator("user2 key", "user2-key")
+		_, err = FinishWebAuthnRegistration(&User{ID: 2, Username: "user2"}, &WebAuthnRegistration{
+			Credential: a.create(t, options.Response.Challenge),
+		})
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidWebAuthnResponse(err))
+	})
+	t.Run("invalid response", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		_, err := FinishWebAuthnRegistration(u, &WebAuthnRegistration{Credential: json.RawMessage(`{"id":"foo"}`)})
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidWebAuthnResponse(err))
+	})
+}
+
+func TestWebAuthnLogin(t *testing.T) {
+	setupWebAuthnTestConfig()
+	u := &User{ID: 11, Username: "user11"}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		options, err := BeginWebAuthnLogin(u)
+		assert.NoError(t, err)
+
+		a := newFixtureTestAuthenticator()
+		err = ValidateWebAuthnLogin(u, a.get(t, options.Response.Challenge), false)
+		assert.NoError(t, err)
+		db.AssertExists(t, "webauthn_credentials", map[string]interface{}{
+			"id":         1,
+			"sign_count": 6,
+		}, false)
+	})
+	t.Run("no credentials", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		_, err := BeginWebAuthnLogin(&User{ID: 1, Username: "user1"})
+		assert.Error(t, err)
+		assert.True(t, IsErrNoWebAuthnCredentials(err))
+	})
+	t.Run("wrong key", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		options, err := BeginWebAuthnLogin(u)
+		assert.NoError(t, err)
+
+		a := newTestAuthenticator("some other key", "user11-security-key")
+		a.userHandle = []byte("11")
+		a.signCount = 5
+		err = ValidateWebAuthnLogin(u, a.get(t, options.Response.Challenge), false)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidWebAuthnResponse(err))
+	})
+	t.Run("cloned key", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		options, err := BeginWebAuthnLogin(u)
+		assert.NoError(t, err)
+
+		a := newFixtureTestAuthenticator()
+		a.signCount = 1
+		err = ValidateWebAuthnLogin(u, a.get(t, options.Response.Challenge), false)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidWebAuthnResponse(err))
+	})
+	t.Run("passwordless", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		options, err := BeginPasswordlessWebAuthnLogin(&WebAuthnLogin{Username: "user11"})
+		assert.NoError(t, err)
+
+		a := newFixtureTestAuthenticator()
+		user, err := FinishPasswordlessWebAuthnLogin(&WebAuthnLogin{
+			Username:   "user11",
+			Credential: a.get(t, options.Response.Challenge),
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(11), user.ID)
+	})
+	t.Run("passwordless without user verification", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		options, err := BeginPasswordlessWebAuthnLogin(&WebAuthnLogin{Username: "user11"})
+		assert.NoError(t, err)
+
+		a := newFixtureTestAuthenticator()
+		a.userVerified = false
+		_, err = FinishPasswordlessWebAuthnLogin(&WebAuthnLogin{
+			Username:   "user11",
+			Credential: a.get(t, options.Response.Challenge),
+		})
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidWebAuthnResponse(err))
+	})
+	t.Run("passwordless with nonexisting user", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		_, err := BeginPasswordlessWebAuthnLogin(&WebAuthnLogin{Username: "nonexisting"})
+		assert.Error(t, err)
+		assert.True(t, IsErrWrongUsernameOrPassword(err))
+	})
+}
+
+func TestDeleteWebAuthnCredential(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		err := DeleteWebAuthnCredential(&User{ID: 11}, 1)
+		assert.NoError(t, err)
+		db.AssertMissing(t, "webauthn_credentials", map[string]interface{}{
+			"id": 1,
+		})
+	})
+	t.Run("of another user", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		err := DeleteWebAuthnCredential(&User{ID: 1}, 1)
+		assert.Error(t, err)
+		assert.True(t, IsErrWebAuthnCredentialDoesNotExist(err))
+	})
+}
@@ -0,0 +1,110 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2020 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+// Change to deflate to gain better compression
+// see http://golang.org/pkg/archive/zip/#pkg-constants
+const compressionUsed = zip.Deflate
+
+// WriteBytesToZip writes data as a file called filename into the zip archive.
+func WriteBytesToZip(filename string, data []byte, writer *zip.Writer) (err error) {
+	header := &zip.FileHeader{
+		Name:   filename,
+		Method: compressionUsed,
+	}
+	w, err := writer.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return
+}
+
+// WriteReaderToZip writes everything read from r as a file called filename into the zip archive.
+func WriteReaderToZip(filename string, r io.Reader, writer *zip.Writer) (err error) {
+	header := &zip.FileHeader{
+		Name:   filename,
+		Method: compressionUsed,
+	}
+	w, err := writer.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, r)
+	return
+}
+
+// WriteFileToZip copies the file at filename into the zip archive, using only its base name in the archive.
+func WriteFileToZip(filename string, writer *zip.Writer) error {
+	// #nosec
+	fileToZip, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer fileToZip.Close()
+
+	// Get the file information
+	info, err := fileToZip.Stat()
+	if err != nil {
+		return err
+	}
+
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+
+	header.Name = info.Name()
+	header.Method = compressionUsed
+
+	w, err := writer.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, fileToZip)
+	return err
+}
+
+// WriteFilesToZip writes all files into the "files/" folder of the zip archive, named after their id.
+// Every file is closed after it was written.
+func WriteFilesToZip(files map[int64]io.ReadCloser, writer *zip.Writer) (err error) {
+	for fid, file := range files {
+		header := &zip.FileHeader{
+			Name:   "files/" + strconv.FormatInt(fid, 10),
+			Method: compressionUsed,
+		}
+		w, err := writer.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(w, file)
+		if err != nil {
+			return fmt.Errorf("error writing file %d: %s", fid, err)
+		}
+		_ = file.Close()
+	}
+
+	return nil
+}